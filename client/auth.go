@@ -0,0 +1,30 @@
+package client
+
+import "net/http"
+
+// Authenticator adds credentials to the requests of a NewsClient.
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+type AuthenticatorFunc func(r *http.Request) error
+
+func (f AuthenticatorFunc) Authenticate(r *http.Request) error {
+	return f(r)
+}
+
+// BearerToken authenticates with an access token of an author.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// ApiKey authenticates with an api key.
+func ApiKey(key string) Authenticator {
+	return AuthenticatorFunc(func(r *http.Request) error {
+		r.Header.Set("Authorization", "ApiKey "+key)
+		return nil
+	})
+}
@@ -0,0 +1,43 @@
+package client
+
+// BlocksVersion is the version of the block documents the server accepts.
+const BlocksVersion = 1
+
+const (
+	BlockParagraph    = "paragraph"
+	BlockHeading      = "heading"
+	BlockImage        = "image"
+	BlockQuote        = "quote"
+	BlockLesson       = "lesson"
+	BlockCallToAction = "cta"
+)
+
+// Document is news content as blocks apps render natively.
+type Document struct {
+	Version int     `json:"version"`
+	Blocks  []Block `json:"blocks"`
+}
+
+// Block uses only the fields of its type:
+//
+//	paragraph  Text
+//	heading    Text, Level from 1 to 6
+//	image      Url, optional Alt and Caption
+//	quote      Text, optional Attribution
+//	lesson     LessonID, optional Title
+//	cta        Label, Url
+//
+// Texts may break lines but not have empty ones. Urls are absolute http or
+// https ones.
+type Block struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	Level       int    `json:"level,omitempty"`
+	Url         string `json:"url,omitempty"`
+	Alt         string `json:"alt,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+	Attribution string `json:"attribution,omitempty"`
+	LessonID    int    `json:"lesson_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Label       string `json:"label,omitempty"`
+}
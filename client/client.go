@@ -0,0 +1,138 @@
+// Package client is a Go client of the news API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+)
+
+type NewsClient struct {
+	baseURL    string
+	httpClient *http.Client
+	auth       Authenticator
+	retry      RetryPolicy
+}
+
+// NewNewsClient talks to the API at baseURL, e.g. "http://localhost:8080".
+// It falls back to http.DefaultClient when httpClient is nil and to
+// DefaultRetryPolicy when retry is the zero value. Requests are sent
+// anonymously when auth is nil, which only allows reading.
+func NewNewsClient(baseURL string, httpClient *http.Client, auth Authenticator, retry RetryPolicy) *NewsClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if retry == (RetryPolicy{}) {
+		retry = DefaultRetryPolicy
+	}
+
+	return &NewsClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		auth:       auth,
+		retry:      retry,
+	}
+}
+
+// do sends the request and decodes the data of the response into data,
+// retrying idempotent methods. It returns the headers of the response.
+func (c *NewsClient) do(ctx context.Context, method string, path string, body any, data any) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("can't encode request body: [%w]", err)
+		}
+	}
+
+	attempts := 1
+	if method != http.MethodPost {
+		attempts = max(c.retry.MaxAttempts, 1)
+	}
+
+	for attempt := 1; ; attempt++ {
+		header, err := c.send(ctx, method, path, payload, "application/json", data)
+		if err == nil || attempt == attempts || !retryable(ctx, err) {
+			return header, err
+		}
+
+		timer := time.NewTimer(c.retry.delay(attempt, header))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes a single request, contentType is what payload is when it's set.
+func (c *NewsClient) send(ctx context.Context, method string, path string, payload []byte, contentType string, data any) (http.Header, error) {
+	r, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Accept", "application/json")
+	if payload != nil {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	if c.auth != nil {
+		err = c.auth.Authenticate(r)
+		if err != nil {
+			return nil, fmt.Errorf("can't authenticate request: [%w]", err)
+		}
+	}
+
+	resp, err := c.httpClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		response.Response
+		Data json.RawMessage `json:"data"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&envelope)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// answers that aren't responses, e.g. from a proxy, keep the status
+		return resp.Header, newError(resp.StatusCode, envelope.Response)
+	}
+	if decodeErr != nil {
+		return resp.Header, fmt.Errorf("can't decode response: [%w]", decodeErr)
+	}
+
+	if data != nil && len(envelope.Data) != 0 {
+		err = json.Unmarshal(envelope.Data, data)
+		if err != nil {
+			return resp.Header, fmt.Errorf("can't decode response data: [%w]", err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+// retryable tells failures that may go away from the ones that won't.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.StatusCode)
+	}
+
+	// the API couldn't be reached
+	return true
+}
@@ -0,0 +1,1546 @@
+package client
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/openapi"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/server"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/anton-uvarenko/promova_test/internal/transport"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// newsServiceMock keeps news in memory, authorization is left to the auth
+// middleware.
+type newsServiceMock struct {
+	mu     sync.Mutex
+	news   map[int32]core.News
+	nextId int32
+}
+
+func (m *newsServiceMock) AddNews(ctx context.Context, params core.AddNewsParams) (int32, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextId++
+	m.news[m.nextId] = core.News{
+		ID:            m.nextId,
+		Title:         params.Title,
+		Content:       params.Content,
+		ContentFormat: cmp.Or(params.ContentFormat, "plain"),
+		Blocks:        params.Blocks,
+		Targeting:     params.Targeting,
+		AuthorID:      params.AuthorID,
+		Status:        "draft",
+		UpdatedAt:     pgtype.Timestamp{Time: time.Now(), Valid: true},
+	}
+	return m.nextId, nil
+}
+
+func (m *newsServiceMock) UpdatNews(ctx context.Context, params core.UpdateNewsParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news, ok := m.news[params.ID]
+	if !ok {
+		return pkg.ErrNotFound
+	}
+
+	news.Title = params.Title
+	news.Content = params.Content
+	news.ContentFormat = cmp.Or(params.ContentFormat, "plain")
+	news.Blocks = params.Blocks
+	news.Targeting = params.Targeting
+	news.UpdatedBy = params.UpdatedBy
+	news.UpdatedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	m.news[params.ID] = news
+	return nil
+}
+
+func (m *newsServiceMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news, ok := m.news[id]
+	if !ok {
+		return core.News{}, pkg.ErrNotFound
+	}
+	return news, nil
+}
+
+func (m *newsServiceMock) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var news []core.News
+	for _, id := range ids {
+		n, ok := m.news[id]
+		if ok {
+			news = append(news, n)
+		}
+	}
+	return news, nil
+}
+
+func (m *newsServiceMock) GetAllNews(ctx context.Context) ([]core.News, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	client, targeted := targeting.ClientFromContext(ctx)
+	news := []core.News{}
+	for _, n := range m.news {
+		if targeted && !shownTo(n, client) {
+			continue
+		}
+		news = append(news, n)
+	}
+	return news, nil
+}
+
+func (m *newsServiceMock) VisibleNews(ctx context.Context, news []core.News) []core.News {
+	return news
+}
+
+func shownTo(news core.News, client targeting.Client) bool {
+	if !news.Targeting.Valid {
+		return true
+	}
+	rule, err := targeting.Parse(news.Targeting.String)
+	return err == nil && rule.Matches(client)
+}
+
+func (m *newsServiceMock) GetNewsActivity(ctx context.Context, id int32) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (m *newsServiceMock) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return core.GetNewsStatsRow{Count: int32(len(m.news))}, nil
+}
+
+func (m *newsServiceMock) DeleteNews(ctx context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.news[id]
+	if !ok {
+		return pkg.ErrEntityAlreadyDeleted
+	}
+
+	delete(m.news, id)
+	return nil
+}
+
+func (m *newsServiceMock) PublishNews(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (m *newsServiceMock) PinNews(ctx context.Context, id int32, until pgtype.Timestamp) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news, ok := m.news[id]
+	if !ok {
+		return pkg.ErrNotFound
+	}
+
+	news.PinnedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	news.PinnedUntil = until
+	m.news[id] = news
+	return nil
+}
+
+func (m *newsServiceMock) UnpinNews(ctx context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news, ok := m.news[id]
+	if !ok {
+		return pkg.ErrNotFound
+	}
+
+	news.PinnedAt = pgtype.Timestamp{}
+	news.PinnedUntil = pgtype.Timestamp{}
+	m.news[id] = news
+	return nil
+}
+
+func (m *newsServiceMock) DryRunTargeting(ctx context.Context, id int32, clients []targeting.Client) ([]bool, error) {
+	news, err := m.GetNewsById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	shown := []bool{}
+	for _, client := range clients {
+		shown = append(shown, shownTo(news, client))
+	}
+	return shown, nil
+}
+
+// mediaServiceMock keeps media in memory without files, the url of media is
+// their key.
+type mediaServiceMock struct {
+	mu     sync.Mutex
+	media  map[int32]core.Media
+	nextId int32
+}
+
+func (m *mediaServiceMock) UploadMedia(ctx context.Context, newsId int32, role string, data []byte) (core.Media, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextId++
+	m.media[m.nextId] = core.Media{
+		ID:          m.nextId,
+		NewsID:      newsId,
+		Role:        cmp.Or(role, "inline"),
+		ContentType: http.DetectContentType(data),
+		Size:        int64(len(data)),
+		StorageKey:  "news/" + strconv.Itoa(int(newsId)) + "/" + strconv.Itoa(int(m.nextId)),
+		Variants:    []byte("[]"),
+	}
+	return m.media[m.nextId], nil
+}
+
+func (m *mediaServiceMock) GetNewsMedia(ctx context.Context, newsId int32) ([]core.Media, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var media []core.Media
+	for _, medium := range m.media {
+		if medium.NewsID == newsId {
+			media = append(media, medium)
+		}
+	}
+	return media, nil
+}
+
+func (m *mediaServiceMock) DeleteMedia(ctx context.Context, newsId int32, mediaId int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	medium, ok := m.media[mediaId]
+	if !ok || medium.NewsID != newsId {
+		return pkg.ErrNotFound
+	}
+
+	delete(m.media, mediaId)
+	return nil
+}
+
+func (m *mediaServiceMock) URL(key string) string {
+	return "/media/" + key
+}
+
+// commentServiceMock keeps comments in memory and approves them right away.
+type commentServiceMock struct {
+	mu       sync.Mutex
+	comments map[int32]core.Comment
+	nextId   int32
+}
+
+func (m *commentServiceMock) AddComment(ctx context.Context, newsId int32, parentId int32, content string) (core.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if parentId != 0 && m.comments[parentId].NewsID != newsId {
+		return core.Comment{}, pkg.ErrInvalidPayload
+	}
+
+	author, _ := auth.AuthorFromContext(ctx)
+	m.nextId++
+	m.comments[m.nextId] = core.Comment{
+		ID:        m.nextId,
+		NewsID:    newsId,
+		ParentID:  pgtype.Int4{Int32: parentId, Valid: parentId != 0},
+		AuthorID:  author.ID,
+		Content:   content,
+		Status:    "approved",
+		CreatedAt: pgtype.Timestamp{Time: time.Now().UTC().Truncate(time.Second), Valid: true},
+		UpdatedAt: pgtype.Timestamp{Time: time.Now().UTC().Truncate(time.Second), Valid: true},
+	}
+	return m.comments[m.nextId], nil
+}
+
+func (m *commentServiceMock) GetNewsComments(ctx context.Context, newsId int32, after int32, first int) ([]core.Comment, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comments := []core.Comment{}
+	for _, comment := range m.comments {
+		if comment.NewsID == newsId && comment.ID > after {
+			comments = append(comments, comment)
+		}
+	}
+	slices.SortFunc(comments, func(a, b core.Comment) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if len(comments) > first {
+		return comments[:first], true, nil
+	}
+	return comments, false, nil
+}
+
+func (m *commentServiceMock) UpdateComment(ctx context.Context, newsId int32, commentId int32, content string) (core.Comment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment, ok := m.comments[commentId]
+	if !ok || comment.NewsID != newsId {
+		return core.Comment{}, pkg.ErrNotFound
+	}
+
+	comment.Content = content
+	m.comments[commentId] = comment
+	return comment, nil
+}
+
+func (m *commentServiceMock) DeleteComment(ctx context.Context, newsId int32, commentId int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	comment, ok := m.comments[commentId]
+	if !ok || comment.NewsID != newsId {
+		return pkg.ErrNotFound
+	}
+
+	delete(m.comments, commentId)
+	return nil
+}
+
+func (m *commentServiceMock) GetPendingComments(ctx context.Context, after int32, first int) ([]core.Comment, bool, error) {
+	return []core.Comment{}, false, nil
+}
+
+func (m *commentServiceMock) ApproveComment(ctx context.Context, commentId int32) (core.Comment, error) {
+	return core.Comment{}, pkg.ErrNotFound
+}
+
+func (m *commentServiceMock) RejectComment(ctx context.Context, commentId int32, reason string) (core.Comment, error) {
+	return core.Comment{}, pkg.ErrNotFound
+}
+
+// reactionServiceMock keeps reactions in memory, by news, kind and author.
+type reactionServiceMock struct {
+	mu        sync.Mutex
+	reactions map[int32]map[string]map[int32]bool
+}
+
+func (m *reactionServiceMock) AddReaction(ctx context.Context, newsId int32, kind string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !slices.Contains(service.ReactionKinds, kind) {
+		return pkg.ErrInvalidPayload
+	}
+
+	author, _ := auth.AuthorFromContext(ctx)
+	if m.reactions[newsId] == nil {
+		m.reactions[newsId] = map[string]map[int32]bool{}
+	}
+	if m.reactions[newsId][kind] == nil {
+		m.reactions[newsId][kind] = map[int32]bool{}
+	}
+	m.reactions[newsId][kind][author.ID] = true
+	return nil
+}
+
+func (m *reactionServiceMock) RemoveReaction(ctx context.Context, newsId int32, kind string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	author, _ := auth.AuthorFromContext(ctx)
+	delete(m.reactions[newsId][kind], author.ID)
+	return nil
+}
+
+func (m *reactionServiceMock) GetReactions(ctx context.Context, newsIds []int32) (map[int32]service.NewsReactions, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	author, authenticated := auth.AuthorFromContext(ctx)
+	reactions := map[int32]service.NewsReactions{}
+	for _, newsId := range newsIds {
+		newsReactions := service.NewsReactions{Counts: map[string]int{}}
+		for kind, authors := range m.reactions[newsId] {
+			if len(authors) == 0 {
+				continue
+			}
+			newsReactions.Counts[kind] = len(authors)
+			if authenticated && authors[author.ID] {
+				newsReactions.Mine = append(newsReactions.Mine, kind)
+			}
+		}
+		reactions[newsId] = newsReactions
+	}
+	return reactions, nil
+}
+
+// viewServiceMock counts views of each news once per client and ranks news
+// by the count, whatever the window.
+type viewServiceMock struct {
+	mu          sync.Mutex
+	newsService *newsServiceMock
+	viewers     map[int32]map[string]bool
+}
+
+func (m *viewServiceMock) RecordView(ctx context.Context, newsId int32, client string) error {
+	_, err := m.newsService.GetNewsById(ctx, newsId)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.viewers[newsId] == nil {
+		m.viewers[newsId] = map[string]bool{}
+	}
+	m.viewers[newsId][client] = true
+	return nil
+}
+
+func (m *viewServiceMock) GetPopularNews(ctx context.Context, window string, first int) ([]core.News, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news := []core.News{}
+	for newsId := range m.viewers {
+		v, err := m.newsService.GetNewsById(ctx, newsId)
+		if err == nil {
+			news = append(news, v)
+		}
+	}
+	slices.SortFunc(news, func(a, b core.News) int {
+		return cmp.Or(cmp.Compare(len(m.viewers[b.ID]), len(m.viewers[a.ID])), cmp.Compare(b.ID, a.ID))
+	})
+	if len(news) > first {
+		news = news[:first]
+	}
+	return news, nil
+}
+
+// readStateServiceMock keeps what each author read and bookmarked in
+// memory, as sets of news ids.
+type readStateServiceMock struct {
+	mu          sync.Mutex
+	newsService *newsServiceMock
+	read        map[int32]map[int32]bool
+	bookmarks   map[int32]map[int32]bool
+}
+
+func (m *readStateServiceMock) mark(ctx context.Context, marks map[int32]map[int32]bool, newsId int32, value bool) error {
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return pkg.ErrUnauthorized
+	}
+	_, err := m.newsService.GetNewsById(ctx, newsId)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if marks[author.ID] == nil {
+		marks[author.ID] = map[int32]bool{}
+	}
+	if value {
+		marks[author.ID][newsId] = true
+	} else {
+		delete(marks[author.ID], newsId)
+	}
+	return nil
+}
+
+func (m *readStateServiceMock) MarkRead(ctx context.Context, newsId int32) error {
+	return m.mark(ctx, m.read, newsId, true)
+}
+
+func (m *readStateServiceMock) MarkAllRead(ctx context.Context) error {
+	news, _ := m.newsService.GetAllNews(ctx)
+	for _, v := range news {
+		err := m.mark(ctx, m.read, v.ID, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *readStateServiceMock) GetUnreadCount(ctx context.Context) (int, error) {
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return 0, pkg.ErrUnauthorized
+	}
+	news, _ := m.newsService.GetAllNews(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, v := range news {
+		if !m.read[author.ID][v.ID] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *readStateServiceMock) AddBookmark(ctx context.Context, newsId int32) error {
+	return m.mark(ctx, m.bookmarks, newsId, true)
+}
+
+func (m *readStateServiceMock) RemoveBookmark(ctx context.Context, newsId int32) error {
+	return m.mark(ctx, m.bookmarks, newsId, false)
+}
+
+func (m *readStateServiceMock) GetBookmarks(ctx context.Context, before int32, first int) ([]core.News, bool, error) {
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return nil, false, pkg.ErrUnauthorized
+	}
+	all, _ := m.newsService.GetAllNews(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news := []core.News{}
+	for _, v := range all {
+		if m.bookmarks[author.ID][v.ID] && (before == 0 || v.ID < before) {
+			news = append(news, v)
+		}
+	}
+	slices.SortFunc(news, func(a, b core.News) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+	if len(news) > first {
+		return news[:first], true, nil
+	}
+	return news, false, nil
+}
+
+func (m *readStateServiceMock) GetReadStates(ctx context.Context, newsIds []int32) (map[int32]service.ReadState, error) {
+	states := map[int32]service.ReadState{}
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return states, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range newsIds {
+		states[id] = service.ReadState{IsRead: m.read[author.ID][id], IsBookmarked: m.bookmarks[author.ID][id]}
+	}
+	return states, nil
+}
+
+// GetUpdatedAt never changes, responses aren't cached between requests of
+// the client anyway.
+func (m *readStateServiceMock) GetUpdatedAt(ctx context.Context) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+type apiKeyServiceMock struct{}
+
+func (m *apiKeyServiceMock) Authenticate(ctx context.Context, key string) (auth.Author, error) {
+	if key != "test key" {
+		return auth.Author{}, pkg.ErrUnauthorized
+	}
+	return auth.Author{ID: 2, Role: authz.RoleAuthor, ApiKeyID: 1}, nil
+}
+
+// experimentServiceMock runs experiments in memory, readers get the variant
+// of their id.
+type experimentServiceMock struct {
+	mu          sync.Mutex
+	newsService *newsServiceMock
+	variants    map[int32][]core.NewsVariant
+	running     map[int32]bool
+	winners     map[int32]int32
+	nextId      int32
+}
+
+func (m *experimentServiceMock) AddVariant(ctx context.Context, params core.AddNewsVariantParams) (int32, error) {
+	_, err := m.newsService.GetNewsById(ctx, params.NewsID)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running[params.NewsID] {
+		return 0, pkg.ErrInvalidPayload
+	}
+	m.nextId++
+	m.variants[params.NewsID] = append(m.variants[params.NewsID], core.NewsVariant{
+		ID:      m.nextId,
+		NewsID:  params.NewsID,
+		Title:   params.Title,
+		Content: params.Content,
+		Weight:  params.Weight,
+	})
+	return m.nextId, nil
+}
+
+func (m *experimentServiceMock) DeleteVariant(ctx context.Context, newsId int32, variantId int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running[newsId] {
+		return pkg.ErrInvalidPayload
+	}
+	variants := m.variants[newsId]
+	i := slices.IndexFunc(variants, func(v core.NewsVariant) bool { return v.ID == variantId })
+	if i < 0 {
+		return pkg.ErrNotFound
+	}
+	m.variants[newsId] = slices.Delete(variants, i, i+1)
+	return nil
+}
+
+func (m *experimentServiceMock) GetExperiment(ctx context.Context, newsId int32) (service.Experiment, error) {
+	_, err := m.newsService.GetNewsById(ctx, newsId)
+	if err != nil {
+		return service.Experiment{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return service.Experiment{
+		Running:  m.running[newsId],
+		WinnerID: m.winners[newsId],
+		Variants: append([]core.NewsVariant{}, m.variants[newsId]...),
+	}, nil
+}
+
+func (m *experimentServiceMock) StartExperiment(ctx context.Context, newsId int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running[newsId] {
+		return pkg.ErrEntityAlreadyExists
+	}
+	if len(m.variants[newsId]) < 2 {
+		return pkg.ErrInvalidPayload
+	}
+	m.running[newsId] = true
+	return nil
+}
+
+func (m *experimentServiceMock) StopExperiment(ctx context.Context, newsId int32, winnerId int32) (core.NewsVariant, error) {
+	m.mu.Lock()
+	if !m.running[newsId] {
+		m.mu.Unlock()
+		return core.NewsVariant{}, pkg.ErrNotFound
+	}
+	winner := m.variants[newsId][0]
+	for _, v := range m.variants[newsId] {
+		if v.ID == winnerId || winnerId == 0 && v.Clicks > winner.Clicks {
+			winner = v
+		}
+	}
+	m.mu.Unlock()
+
+	news, _ := m.newsService.GetNewsById(ctx, newsId)
+	err := m.newsService.UpdatNews(ctx, core.UpdateNewsParams{
+		ID:            newsId,
+		Title:         pgtype.Text{String: winner.Title, Valid: true},
+		Content:       pgtype.Text{String: winner.Content, Valid: true},
+		ContentFormat: news.ContentFormat,
+	})
+	if err != nil {
+		return core.NewsVariant{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running[newsId] = false
+	m.winners[newsId] = winner.ID
+	return winner, nil
+}
+
+func (m *experimentServiceMock) count(ctx context.Context, newsId int32, variantId int32, count func(v *core.NewsVariant)) error {
+	if _, ok := auth.AuthorFromContext(ctx); !ok {
+		return pkg.ErrUnauthorized
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, v := range m.variants[newsId] {
+		if v.ID == variantId && m.running[newsId] {
+			count(&m.variants[newsId][i])
+			return nil
+		}
+	}
+	return pkg.ErrNotFound
+}
+
+func (m *experimentServiceMock) RecordExposure(ctx context.Context, newsId int32, variantId int32) error {
+	return m.count(ctx, newsId, variantId, func(v *core.NewsVariant) { v.Exposures++ })
+}
+
+func (m *experimentServiceMock) RecordClick(ctx context.Context, newsId int32, variantId int32) error {
+	return m.count(ctx, newsId, variantId, func(v *core.NewsVariant) { v.Clicks++ })
+}
+
+func (m *experimentServiceMock) ApplyVariants(ctx context.Context, news []core.News) ([]core.News, map[int32]int32, error) {
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return news, nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	applied := make([]core.News, 0, len(news))
+	variantIds := map[int32]int32{}
+	for _, n := range news {
+		variants := m.variants[n.ID]
+		if m.running[n.ID] {
+			variant := variants[int(author.ID)%len(variants)]
+			n.Title = pgtype.Text{String: variant.Title, Valid: true}
+			n.Content = pgtype.Text{String: variant.Content, Valid: true}
+			n.Blocks = nil
+			variantIds[n.ID] = variant.ID
+		}
+		applied = append(applied, n)
+	}
+	return applied, variantIds, nil
+}
+
+// flakyHandler fails the first failures requests with status before letting
+// them through to the router.
+type flakyHandler struct {
+	handler http.Handler
+
+	mu         sync.Mutex
+	failures   int
+	status     int
+	retryAfter string
+	requests   int
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests++
+	fail := h.failures > 0
+	if fail {
+		h.failures--
+	}
+	h.mu.Unlock()
+
+	if !fail {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if h.retryAfter != "" {
+		w.Header().Set("Retry-After", h.retryAfter)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	json.NewEncoder(w).Encode(response.Response{
+		Code:  response.TooManyRequests,
+		Error: pkg.ErrTooManyRequests.Error(),
+	})
+}
+
+func (h *flakyHandler) fail(failures int, status int, retryAfter string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures = failures
+	h.status = status
+	h.retryAfter = retryAfter
+	h.requests = 0
+}
+
+func (h *flakyHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.requests
+}
+
+// featuredServiceMock keeps the ids of featured news in order.
+type featuredServiceMock struct {
+	mu          sync.Mutex
+	newsService *newsServiceMock
+	featured    []int32
+}
+
+func (m *featuredServiceMock) GetFeaturedNews(ctx context.Context) ([]core.News, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	news := []core.News{}
+	for _, id := range m.featured {
+		n, err := m.newsService.GetNewsById(ctx, id)
+		if err == nil {
+			news = append(news, n)
+		}
+	}
+	return news, nil
+}
+
+func (m *featuredServiceMock) FeatureNews(ctx context.Context, id int32, position int) error {
+	_, err := m.newsService.GetNewsById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.featured = slices.DeleteFunc(m.featured, func(featuredId int32) bool { return featuredId == id })
+	if position < 0 || position > len(m.featured) {
+		position = len(m.featured)
+	}
+	m.featured = slices.Insert(m.featured, position, id)
+	return nil
+}
+
+func (m *featuredServiceMock) UnfeatureNews(ctx context.Context, id int32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !slices.Contains(m.featured, id) {
+		return pkg.ErrNotFound
+	}
+	m.featured = slices.DeleteFunc(m.featured, func(featuredId int32) bool { return featuredId == id })
+	return nil
+}
+
+var (
+	newsServiceInstance *newsServiceMock
+	handlerInstance     *flakyHandler
+	apiURL              string
+	authToken           string
+	testRetryPolicy     = RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+)
+
+func TestMain(m *testing.M) {
+	keyset := auth.NewKeyset("test", auth.NewHMACKey("test", []byte("test secret")))
+	authToken, _ = keyset.Sign(auth.Author{ID: 1, Name: "test author", Role: authz.RoleAdmin}, time.Hour)
+
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		panic(err)
+	}
+
+	newsServiceInstance = &newsServiceMock{news: map[int32]core.News{}}
+	apiKeyServiceInstance := &apiKeyServiceMock{}
+	mediaServiceInstance := &mediaServiceMock{media: map[int32]core.Media{}}
+	commentServiceInstance := &commentServiceMock{comments: map[int32]core.Comment{}}
+	reactionServiceInstance := &reactionServiceMock{reactions: map[int32]map[string]map[int32]bool{}}
+	viewServiceInstance := &viewServiceMock{newsService: newsServiceInstance, viewers: map[int32]map[string]bool{}}
+	readStateServiceInstance := &readStateServiceMock{newsService: newsServiceInstance, read: map[int32]map[int32]bool{}, bookmarks: map[int32]map[int32]bool{}}
+	experimentServiceInstance := &experimentServiceMock{newsService: newsServiceInstance, variants: map[int32][]core.NewsVariant{}, running: map[int32]bool{}, winners: map[int32]int32{}}
+	featuredServiceInstance := &featuredServiceMock{newsService: newsServiceInstance}
+	handler := transport.NewHandler(newsServiceInstance, nil, nil, nil, nil, mediaServiceInstance, commentServiceInstance, reactionServiceInstance, viewServiceInstance, readStateServiceInstance, experimentServiceInstance, featuredServiceInstance, nil, "")
+	router, err := server.SetUpRoutes(
+		handler.NewsHandler,
+		handler.NewsEventHandler,
+		handler.SyncHandler,
+		handler.ApiKeyHandler,
+		handler.WebhookHandler,
+		handler.GraphqlHandler,
+		handler.OpenapiHandler,
+		handler.MediaHandler,
+		nil,
+		handler.CommentHandler,
+		handler.ReactionHandler,
+		handler.ViewHandler,
+		handler.ReadStateHandler,
+		handler.ExperimentHandler,
+		auth.Middleware(keyset, apiKeyServiceInstance),
+		auth.OptionalMiddleware(keyset, apiKeyServiceInstance),
+		func(ctx *gin.Context) { ctx.Next() },
+		validator.Middleware(),
+		nil,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	handlerInstance = &flakyHandler{handler: router}
+	apiServer := httptest.NewServer(handlerInstance)
+	defer apiServer.Close()
+	apiURL = apiServer.URL
+
+	m.Run()
+}
+
+func TestNewsClient(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+
+	id, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+	assert.Equal(t, err, nil)
+
+	news, err := newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news, News{ID: id, Title: "some title", Content: "some content", ContentFormat: ContentPlain, AuthorID: 1, Status: "draft"})
+
+	err = newsClient.Update(ctx, id, NewsInput{Title: "other title", Content: "*other* content", ContentFormat: ContentMarkdown})
+	assert.Equal(t, err, nil)
+
+	news, err = newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Title, "other title")
+	assert.Equal(t, news.ContentFormat, ContentMarkdown)
+	assert.Equal(t, news.ContentHTML, "")
+	assert.Equal(t, news.UpdatedBy, 1)
+
+	news, err = newsClient.GetRendered(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Content, "*other* content")
+	assert.Equal(t, news.ContentHTML, "<p><em>other</em> content</p>\n")
+
+	blocks := &Document{Version: BlocksVersion, Blocks: []Block{
+		{Type: BlockHeading, Text: "Title", Level: 1},
+		{Type: BlockLesson, LessonID: 42, Title: "First lesson"},
+	}}
+	err = newsClient.Update(ctx, id, NewsInput{Title: "other title", Blocks: blocks})
+	assert.Equal(t, err, nil)
+
+	news, err = newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Blocks, blocks)
+
+	media, err := newsClient.UploadMedia(ctx, id, MediaCover, "cover.gif", strings.NewReader("GIF89a some image"))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, media, Media{ID: 1, Role: MediaCover, ContentType: "image/gif", Size: 17, URL: "/media/news/" + strconv.Itoa(id) + "/1", Variants: []MediaVariant{}})
+
+	news, err = newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Media, []Media{media})
+
+	err = newsClient.DeleteMedia(ctx, id, media.ID)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.DeleteMedia(ctx, id, media.ID)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	news, err = newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(news.Media), 0)
+
+	err = newsClient.Delete(ctx, id)
+	assert.Equal(t, err, nil)
+
+	_, err = newsClient.Get(ctx, id)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	err = newsClient.Delete(ctx, id)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+	assert.Equal(t, errors.Is(err, ErrEntityAlreadyDeleted), true)
+}
+
+func TestNewsClientErrors(t *testing.T) {
+	testTable := []struct {
+		Name               string
+		Auth               Authenticator
+		Call               func(ctx context.Context, newsClient *NewsClient) error
+		ExpectedErr        error
+		ExpectedStatusCode int
+		ExpectedCode       int
+	}{
+		{
+			Name: "Ok api key",
+			Auth: ApiKey("test key"),
+			Call: func(ctx context.Context, newsClient *NewsClient) error {
+				_, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+				return err
+			},
+		},
+		{
+			Name: "Error invalid payload",
+			Auth: BearerToken(authToken),
+			Call: func(ctx context.Context, newsClient *NewsClient) error {
+				_, err := newsClient.Create(ctx, NewsInput{Title: "f", Content: "some content"})
+				return err
+			},
+			ExpectedErr:        ErrInvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedCode:       response.InvalidPayload,
+		},
+		{
+			Name: "Error anonymous",
+			Call: func(ctx context.Context, newsClient *NewsClient) error {
+				_, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+				return err
+			},
+			ExpectedErr:        ErrUnauthorized,
+			ExpectedStatusCode: http.StatusUnauthorized,
+			ExpectedCode:       response.Unauthorized,
+		},
+		{
+			Name: "Error invalid api key",
+			Auth: ApiKey("other key"),
+			Call: func(ctx context.Context, newsClient *NewsClient) error {
+				return newsClient.Delete(ctx, 1)
+			},
+			ExpectedErr:        ErrUnauthorized,
+			ExpectedStatusCode: http.StatusUnauthorized,
+			ExpectedCode:       response.Unauthorized,
+		},
+		{
+			Name: "Error not found",
+			Auth: BearerToken(authToken),
+			Call: func(ctx context.Context, newsClient *NewsClient) error {
+				return newsClient.Update(ctx, 1000, NewsInput{Title: "some title", Content: "some content"})
+			},
+			ExpectedErr:        ErrNotFound,
+			ExpectedStatusCode: http.StatusNotFound,
+			ExpectedCode:       response.NotFound,
+		},
+		{
+			Name: "Error authenticator",
+			Auth: AuthenticatorFunc(func(r *http.Request) error {
+				return pkg.ErrUnauthorized
+			}),
+			Call: func(ctx context.Context, newsClient *NewsClient) error {
+				return newsClient.Delete(ctx, 1)
+			},
+			ExpectedErr: ErrUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsClient := NewNewsClient(apiURL, nil, testCase.Auth, testRetryPolicy)
+
+			err := testCase.Call(context.Background(), newsClient)
+			if testCase.ExpectedErr == nil {
+				assert.Equal(t, err, nil)
+				return
+			}
+			assert.Equal(t, errors.Is(err, testCase.ExpectedErr), true)
+
+			var apiErr *Error
+			if testCase.ExpectedStatusCode == 0 {
+				assert.Equal(t, errors.As(err, &apiErr), false)
+				return
+			}
+			assert.Equal(t, errors.As(err, &apiErr), true)
+			assert.Equal(t, apiErr.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, apiErr.Code, testCase.ExpectedCode)
+		})
+	}
+}
+
+func TestNewsClientList(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+
+	for i := 0; i < 45; i++ {
+		_, err := newsClient.Create(ctx, NewsInput{Title: "news " + strconv.Itoa(i), Content: "some content"})
+		assert.Equal(t, err, nil)
+	}
+	expected, _ := newsServiceInstance.GetAllNews(ctx)
+	slices.SortFunc(expected, func(a, b core.News) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	handlerInstance.fail(0, 0, "")
+	it := newsClient.List(ctx, 20)
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.News().ID)
+	}
+	assert.Equal(t, it.Err(), nil)
+
+	var expectedIds []int
+	for _, news := range expected {
+		expectedIds = append(expectedIds, int(news.ID))
+	}
+	assert.Equal(t, ids, expectedIds)
+	// 45 news in pages of 20
+	assert.Equal(t, handlerInstance.count(), 3)
+
+	// a failing page ends the iteration with its error
+	handlerInstance.fail(1, http.StatusBadRequest, "")
+	it = newsClient.List(ctx, 20)
+	assert.Equal(t, it.Next(), false)
+	assert.Equal(t, errors.Is(it.Err(), ErrTooManyRequests), true)
+}
+
+func TestNewsClientTargeting(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+
+	iosId, err := newsClient.Create(ctx, NewsInput{Title: "ios news", Content: "some content", Targeting: `platform == "ios" and app_version >= "3.2"`})
+	assert.Equal(t, err, nil)
+	webId, err := newsClient.Create(ctx, NewsInput{Title: "web news", Content: "some content", Targeting: `platform == "web"`})
+	assert.Equal(t, err, nil)
+
+	news, err := newsClient.Get(ctx, iosId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Targeting, `platform == "ios" and app_version >= "3.2"`)
+
+	listed := func(audience Audience) []int {
+		// pages of 1 check the audience is kept for the next pages
+		it := newsClient.ListFor(ctx, audience, 1)
+		var ids []int
+		for it.Next() {
+			if id := it.News().ID; id == iosId || id == webId {
+				ids = append(ids, id)
+			}
+		}
+		assert.Equal(t, it.Err(), nil)
+		return ids
+	}
+
+	handlerInstance.fail(0, 0, "")
+	assert.Equal(t, listed(Audience{Platform: PlatformIOS, AppVersion: "3.10"}), []int{iosId})
+	assert.Equal(t, listed(Audience{Platform: PlatformIOS, AppVersion: "3.1"}), []int(nil))
+	assert.Equal(t, listed(Audience{Platform: PlatformWeb}), []int{webId})
+
+	dryRun, err := newsClient.DryRunTargeting(ctx, iosId, []Audience{
+		{Platform: PlatformIOS, AppVersion: "3.2"},
+		{Platform: PlatformAndroid},
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, dryRun, DryRun{
+		Visible: []Audience{{Platform: PlatformIOS, AppVersion: "3.2"}},
+		Hidden:  []Audience{{Platform: PlatformAndroid}},
+	})
+
+	_, err = newsClient.DryRunTargeting(ctx, iosId, []Audience{{AppVersion: "v3"}})
+	assert.Equal(t, errors.Is(err, ErrInvalidPayload), true)
+}
+
+func TestNewsClientComments(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+
+	id, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+	assert.Equal(t, err, nil)
+
+	comment, err := newsClient.AddComment(ctx, id, 0, "some comment")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, comment.NewsID, id)
+	assert.Equal(t, comment.AuthorID, 1)
+	assert.Equal(t, comment.Status, CommentApproved)
+
+	reply, err := newsClient.AddComment(ctx, id, comment.ID, "some reply")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reply.ParentID, comment.ID)
+
+	_, err = newsClient.AddComment(ctx, id, 100, "some reply")
+	assert.Equal(t, errors.Is(err, ErrInvalidPayload), true)
+
+	reply, err = newsClient.UpdateComment(ctx, id, reply.ID, "other reply")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reply.Content, "other reply")
+
+	handlerInstance.fail(0, 0, "")
+	it := newsClient.Comments(ctx, id, 1)
+	var comments []Comment
+	for it.Next() {
+		comments = append(comments, it.Comment())
+	}
+	assert.Equal(t, it.Err(), nil)
+	assert.Equal(t, comments, []Comment{comment, reply})
+	// a page per comment
+	assert.Equal(t, handlerInstance.count(), 2)
+
+	err = newsClient.DeleteComment(ctx, id, reply.ID)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.DeleteComment(ctx, id, reply.ID)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+}
+
+func TestNewsClientReactions(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+	anonymousClient := NewNewsClient(apiURL, nil, nil, testRetryPolicy)
+
+	id, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+	assert.Equal(t, err, nil)
+
+	err = newsClient.React(ctx, id, ReactionLike)
+	assert.Equal(t, err, nil)
+
+	// reacting twice counts once
+	err = newsClient.React(ctx, id, ReactionLike)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.React(ctx, id, ReactionClap)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.React(ctx, id, "dislike")
+	assert.Equal(t, errors.Is(err, ErrInvalidPayload), true)
+
+	err = anonymousClient.React(ctx, id, ReactionLike)
+	assert.Equal(t, errors.Is(err, ErrUnauthorized), true)
+
+	news, err := newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Reactions, map[string]int{ReactionLike: 1, ReactionClap: 1})
+	slices.Sort(news.MyReactions)
+	assert.Equal(t, news.MyReactions, []string{ReactionClap, ReactionLike})
+
+	news, err = anonymousClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Reactions, map[string]int{ReactionLike: 1, ReactionClap: 1})
+	assert.Equal(t, len(news.MyReactions), 0)
+
+	err = newsClient.Unreact(ctx, id, ReactionClap)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.Unreact(ctx, id, ReactionClap)
+	assert.Equal(t, err, nil)
+
+	news, err = newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Reactions, map[string]int{ReactionLike: 1})
+	assert.Equal(t, news.MyReactions, []string{ReactionLike})
+}
+
+func TestNewsClientViews(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+	anonymousClient := NewNewsClient(apiURL, nil, nil, testRetryPolicy)
+
+	readId, err := newsClient.Create(ctx, NewsInput{Title: "read title", Content: "some content"})
+	assert.Equal(t, err, nil)
+	popularId, err := newsClient.Create(ctx, NewsInput{Title: "popular title", Content: "some content"})
+	assert.Equal(t, err, nil)
+
+	// getting news counts a view, recording one again doesn't count twice
+	_, err = newsClient.Get(ctx, readId)
+	assert.Equal(t, err, nil)
+	err = newsClient.RecordView(ctx, readId)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.RecordView(ctx, popularId)
+	assert.Equal(t, err, nil)
+	err = anonymousClient.RecordView(ctx, popularId)
+	assert.Equal(t, err, nil)
+
+	err = newsClient.RecordView(ctx, 1000)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	popular, err := anonymousClient.Popular(ctx, PopularWeek, 0)
+	assert.Equal(t, err, nil)
+	// other tests view news too
+	var ranked []int
+	for _, news := range popular {
+		if news.ID == readId || news.ID == popularId {
+			ranked = append(ranked, news.ID)
+		}
+	}
+	assert.Equal(t, ranked, []int{popularId, readId})
+
+	popular, err = anonymousClient.Popular(ctx, PopularDay, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(popular), 1)
+
+	_, err = anonymousClient.Popular(ctx, "1y", 0)
+	assert.Equal(t, errors.Is(err, ErrInvalidPayload), true)
+}
+
+func TestNewsClientReadState(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+	anonymousClient := NewNewsClient(apiURL, nil, nil, testRetryPolicy)
+
+	err := newsClient.MarkAllRead(ctx)
+	assert.Equal(t, err, nil)
+	count, err := newsClient.UnreadCount(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 0)
+
+	var ids []int
+	for i := 0; i < 3; i++ {
+		id, err := newsClient.Create(ctx, NewsInput{Title: "bookmarked title", Content: "some content"})
+		assert.Equal(t, err, nil)
+		ids = append(ids, id)
+	}
+
+	err = newsClient.MarkRead(ctx, ids[0])
+	assert.Equal(t, err, nil)
+	count, err = newsClient.UnreadCount(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 2)
+
+	for _, id := range ids {
+		err = newsClient.Bookmark(ctx, id)
+		assert.Equal(t, err, nil)
+	}
+	err = newsClient.Unbookmark(ctx, ids[1])
+	assert.Equal(t, err, nil)
+
+	news, err := newsClient.Get(ctx, ids[0])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.IsRead, true)
+	assert.Equal(t, news.IsBookmarked, true)
+
+	// pages of one, the latest news first
+	var bookmarked []int
+	it := newsClient.Bookmarks(ctx, 1)
+	for it.Next() {
+		bookmarked = append(bookmarked, it.News().ID)
+	}
+	assert.Equal(t, it.Err(), nil)
+	assert.Equal(t, bookmarked, []int{ids[2], ids[0]})
+
+	err = newsClient.MarkRead(ctx, 1000)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	_, err = anonymousClient.UnreadCount(ctx)
+	assert.Equal(t, errors.Is(err, ErrUnauthorized), true)
+}
+
+func TestNewsClientExperiments(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+	anonymousClient := NewNewsClient(apiURL, nil, nil, testRetryPolicy)
+
+	id, err := newsClient.Create(ctx, NewsInput{Title: "tested title", Content: "some content"})
+	assert.Equal(t, err, nil)
+
+	firstId, err := newsClient.AddVariant(ctx, id, VariantInput{Title: "first title", Content: "first content", Weight: 1})
+	assert.Equal(t, err, nil)
+	err = newsClient.StartExperiment(ctx, id)
+	assert.Equal(t, errors.Is(err, ErrInvalidPayload), true)
+	_, err = newsClient.AddVariant(ctx, id, VariantInput{Title: "second title", Content: "second content", Weight: 1})
+	assert.Equal(t, err, nil)
+	_, err = newsClient.AddVariant(ctx, id, VariantInput{Title: "third title", Content: "third content"})
+	assert.Equal(t, errors.Is(err, ErrInvalidPayload), true)
+
+	err = newsClient.StartExperiment(ctx, id)
+	assert.Equal(t, err, nil)
+	err = newsClient.StartExperiment(ctx, id)
+	assert.Equal(t, errors.Is(err, ErrEntityAlreadyExists), true)
+
+	// the reader gets a variant, anonymous ones the news as they are
+	news, err := newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, news.VariantID, 0)
+	assert.NotEqual(t, news.Title, "tested title")
+	news, err = anonymousClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.VariantID, 0)
+	assert.Equal(t, news.Title, "tested title")
+
+	err = newsClient.RecordExposure(ctx, id, firstId)
+	assert.Equal(t, err, nil)
+	err = newsClient.RecordClick(ctx, id, firstId)
+	assert.Equal(t, err, nil)
+	err = anonymousClient.RecordClick(ctx, id, firstId)
+	assert.Equal(t, errors.Is(err, ErrUnauthorized), true)
+
+	experiment, err := newsClient.Experiment(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, experiment.Running, true)
+	assert.Equal(t, len(experiment.Variants), 2)
+	assert.Equal(t, experiment.Variants[0].Exposures, 1)
+	assert.Equal(t, experiment.Variants[0].Clicks, 1)
+
+	winner, err := newsClient.StopExperiment(ctx, id, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, winner.ID, firstId)
+	_, err = newsClient.StopExperiment(ctx, id, 0)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+
+	// the winner is the news now
+	news, err = newsClient.Get(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.VariantID, 0)
+	assert.Equal(t, news.Title, "first title")
+
+	err = newsClient.DeleteVariant(ctx, id, firstId)
+	assert.Equal(t, err, nil)
+	experiment, err = newsClient.Experiment(ctx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, experiment.WinnerID, firstId)
+	assert.Equal(t, len(experiment.Variants), 1)
+}
+
+func TestNewsClientRetries(t *testing.T) {
+	testTable := []struct {
+		Name             string
+		Failures         int
+		Status           int
+		RetryAfter       string
+		Call             func(ctx context.Context, newsClient *NewsClient, id int) error
+		ExpectedErr      error
+		ExpectedRequests int
+	}{
+		{
+			Name:     "Ok get after unavailable",
+			Failures: 2,
+			Status:   http.StatusServiceUnavailable,
+			Call: func(ctx context.Context, newsClient *NewsClient, id int) error {
+				_, err := newsClient.Get(ctx, id)
+				return err
+			},
+			ExpectedRequests: 3,
+		},
+		{
+			Name:       "Ok update after too many requests",
+			Failures:   1,
+			Status:     http.StatusTooManyRequests,
+			RetryAfter: "0",
+			Call: func(ctx context.Context, newsClient *NewsClient, id int) error {
+				return newsClient.Update(ctx, id, NewsInput{Title: "some title", Content: "some content"})
+			},
+			ExpectedRequests: 2,
+		},
+		{
+			Name:     "Error attempts exhausted",
+			Failures: 3,
+			Status:   http.StatusTooManyRequests,
+			Call: func(ctx context.Context, newsClient *NewsClient, id int) error {
+				return newsClient.Delete(ctx, id)
+			},
+			ExpectedErr:      ErrTooManyRequests,
+			ExpectedRequests: 3,
+		},
+		{
+			Name:     "Error create isn't retried",
+			Failures: 1,
+			Status:   http.StatusServiceUnavailable,
+			Call: func(ctx context.Context, newsClient *NewsClient, id int) error {
+				_, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+				return err
+			},
+			ExpectedErr:      ErrTooManyRequests,
+			ExpectedRequests: 1,
+		},
+		{
+			Name:     "Error client errors aren't retried",
+			Failures: 1,
+			Status:   http.StatusBadRequest,
+			Call: func(ctx context.Context, newsClient *NewsClient, id int) error {
+				_, err := newsClient.Get(ctx, id)
+				return err
+			},
+			ExpectedErr:      ErrTooManyRequests,
+			ExpectedRequests: 1,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			ctx := context.Background()
+			newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+			id, err := newsClient.Create(ctx, NewsInput{Title: "some title", Content: "some content"})
+			assert.Equal(t, err, nil)
+
+			handlerInstance.fail(testCase.Failures, testCase.Status, testCase.RetryAfter)
+			defer handlerInstance.fail(0, 0, "")
+
+			err = testCase.Call(ctx, newsClient, id)
+			if testCase.ExpectedErr == nil {
+				assert.Equal(t, err, nil)
+			} else {
+				assert.Equal(t, errors.Is(err, testCase.ExpectedErr), true)
+			}
+			assert.Equal(t, handlerInstance.count(), testCase.ExpectedRequests)
+		})
+	}
+}
+
+func TestNewsClientRetryStopsWithContext(t *testing.T) {
+	newsClient := NewNewsClient(apiURL, nil, nil, RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: time.Hour})
+	handlerInstance.fail(1, http.StatusServiceUnavailable, "")
+	defer handlerInstance.fail(0, 0, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := newsClient.Get(ctx, 1)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert.Equal(t, handlerInstance.count(), 1)
+}
+
+func TestNewsClientFeatured(t *testing.T) {
+	ctx := context.Background()
+	newsClient := NewNewsClient(apiURL, nil, BearerToken(authToken), testRetryPolicy)
+
+	var ids []int
+	for _, title := range []string{"first featured", "second featured", "third featured"} {
+		id, err := newsClient.Create(ctx, NewsInput{Title: title, Content: "some content"})
+		assert.Equal(t, err, nil)
+		ids = append(ids, id)
+	}
+
+	until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	err := newsClient.Pin(ctx, ids[2], until)
+	assert.Equal(t, err, nil)
+
+	// pinned news come first, whatever their id
+	it := newsClient.List(ctx, 2)
+	assert.Equal(t, it.Next(), true)
+	assert.Equal(t, it.News().ID, ids[2])
+	assert.Equal(t, it.News().Pinned, true)
+	assert.Equal(t, it.News().PinnedUntil.Equal(until), true)
+	count := 1
+	for it.Next() {
+		assert.Equal(t, it.News().Pinned, false)
+		count++
+	}
+	assert.Equal(t, it.Err(), nil)
+	assert.Equal(t, count, len(newsServiceInstance.news))
+
+	err = newsClient.Unpin(ctx, ids[2])
+	assert.Equal(t, err, nil)
+	news, err := newsClient.Get(ctx, ids[2])
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Pinned, false)
+
+	err = newsClient.Feature(ctx, ids[0], Last)
+	assert.Equal(t, err, nil)
+	err = newsClient.Feature(ctx, ids[1], Last)
+	assert.Equal(t, err, nil)
+	err = newsClient.Feature(ctx, ids[2], 0)
+	assert.Equal(t, err, nil)
+
+	featured, err := newsClient.Featured(ctx)
+	assert.Equal(t, err, nil)
+	featuredIds := []int{}
+	for _, n := range featured {
+		featuredIds = append(featuredIds, n.ID)
+	}
+	assert.Equal(t, featuredIds, []int{ids[2], ids[0], ids[1]})
+
+	err = newsClient.Unfeature(ctx, ids[0])
+	assert.Equal(t, err, nil)
+	err = newsClient.Unfeature(ctx, ids[0])
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+	err = newsClient.Feature(ctx, 1<<20, Last)
+	assert.Equal(t, errors.Is(err, ErrNotFound), true)
+}
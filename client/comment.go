@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+)
+
+// Comment has ParentID for replies only and ModerationReason for rejected
+// comments only.
+type Comment struct {
+	ID               int       `json:"id"`
+	NewsID           int       `json:"news_id"`
+	ParentID         int       `json:"parent_id"`
+	AuthorID         int       `json:"author_id"`
+	Content          string    `json:"content"`
+	Status           string    `json:"status"`
+	ModerationReason string    `json:"moderation_reason"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type commentInput struct {
+	Content  string `json:"content"`
+	ParentID int    `json:"parent_id,omitempty"`
+}
+
+// AddComment comments published news, replying to the approved comment
+// with parentID unless it's 0. The comment is CommentPending until a
+// moderator approves it, or CommentRejected when it contains blocked words.
+func (c *NewsClient) AddComment(ctx context.Context, newsID int, parentID int, content string) (Comment, error) {
+	var comment Comment
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/comments", commentInput{Content: content, ParentID: parentID}, &comment)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
+
+// UpdateComment changes own comment, which goes through moderation again.
+func (c *NewsClient) UpdateComment(ctx context.Context, newsID int, commentID int, content string) (Comment, error) {
+	var comment Comment
+	_, err := c.do(ctx, http.MethodPut, "/posts/"+strconv.Itoa(newsID)+"/comments/"+strconv.Itoa(commentID), commentInput{Content: content}, &comment)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	return comment, nil
+}
+
+// DeleteComment deletes the comment along with the replies to it.
+func (c *NewsClient) DeleteComment(ctx context.Context, newsID int, commentID int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/comments/"+strconv.Itoa(commentID), nil, nil)
+	return err
+}
+
+// Comments iterates over approved comments of the news, oldest first,
+// requesting pageSize of them at once. It's used the way NewsIterator is.
+func (c *NewsClient) Comments(ctx context.Context, newsID int, pageSize int) *CommentIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &CommentIterator{
+		client: c,
+		ctx:    ctx,
+		path:   "/posts/" + strconv.Itoa(newsID) + "/comments",
+		query:  url.Values{"first": {strconv.Itoa(pageSize)}}.Encode(),
+	}
+}
+
+type CommentIterator struct {
+	client *NewsClient
+	ctx    context.Context
+	path   string
+	// query requests the next page, it's empty after the last one
+	query   string
+	page    []Comment
+	comment Comment
+	err     error
+}
+
+// Next advances to the next comment, it's false once there are no more or
+// requesting a page failed.
+func (it *CommentIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.query == "" {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.comment = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+func (it *CommentIterator) Comment() Comment {
+	return it.comment
+}
+
+func (it *CommentIterator) Err() error {
+	return it.err
+}
+
+func (it *CommentIterator) fetch() {
+	var page []Comment
+	header, err := it.client.do(it.ctx, http.MethodGet, it.path+"?"+it.query, nil, &page)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	it.page = page
+	it.query, it.err = nextQuery(header)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+)
+
+// The errors the API answers with, an Error matches them with errors.Is the
+// same way the errors of the server do.
+var (
+	ErrDbInternal           = pkg.ErrDbInternal
+	ErrEntityAlreadyExists  = pkg.ErrEntityAlreadyExists
+	ErrInvalidPayload       = pkg.ErrInvalidPayload
+	ErrNotFound             = pkg.ErrNotFound
+	ErrInvalidUriParameters = pkg.ErrInvalidUriParameters
+	ErrEntityAlreadyDeleted = pkg.ErrEntityAlreadyDeleted
+	ErrUnauthorized         = pkg.ErrUnauthorized
+	ErrForbidden            = pkg.ErrForbidden
+	ErrTooManyRequests      = pkg.ErrTooManyRequests
+	ErrPayloadTooLarge      = pkg.ErrPayloadTooLarge
+)
+
+var codeErrors = map[int]error{
+	response.CantDecodeRequestBody: ErrInvalidPayload,
+	response.InvalidPayload:        ErrInvalidPayload,
+	response.EntityAlreadyExists:   ErrEntityAlreadyExists,
+	response.InternalError:         ErrDbInternal,
+	response.NotFound:              ErrNotFound,
+	response.Unauthorized:          ErrUnauthorized,
+	response.Forbidden:             ErrForbidden,
+	response.TooManyRequests:       ErrTooManyRequests,
+	response.PayloadTooLarge:       ErrPayloadTooLarge,
+}
+
+// messageErrors are told apart by the message when several share a code,
+// e.g. news that was already deleted is not found as well.
+var messageErrors = []error{
+	ErrEntityAlreadyDeleted,
+	ErrInvalidUriParameters,
+}
+
+// Error is an error answer of the API.
+type Error struct {
+	StatusCode int
+	// Code is the code of the response, 0 when the answer isn't one, e.g. it
+	// comes from a proxy in front of the API
+	Code    int
+	Message string
+	errs    []error
+}
+
+func newError(statusCode int, resp response.Response) *Error {
+	e := &Error{
+		StatusCode: statusCode,
+		Code:       resp.Code,
+		Message:    resp.Error,
+	}
+
+	err, ok := codeErrors[resp.Code]
+	if ok {
+		e.errs = append(e.errs, err)
+	}
+	for _, err := range messageErrors {
+		if strings.HasPrefix(resp.Error, err.Error()) {
+			e.errs = append(e.errs, err)
+		}
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("news api answered with status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("news api answered with status %d: [%s]", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Experiment is the experiment of news with all their variants. StartedAt
+// is nil for news never experimented on, StoppedAt and WinnerID are only
+// set once the experiment stops.
+type Experiment struct {
+	Running   bool       `json:"running"`
+	StartedAt *time.Time `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at"`
+	WinnerID  int        `json:"winner_id"`
+	Variants  []Variant  `json:"variants"`
+}
+
+// Variant is an alternate title and content of news. Exposures and Clicks
+// are counted since the experiment started.
+type Variant struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Weight    int    `json:"weight"`
+	Exposures int    `json:"exposures"`
+	Clicks    int    `json:"clicks"`
+}
+
+// VariantInput is what AddVariant sends. Readers are split between variants
+// in proportion to Weight, from 1 to 1000. Content is written in the
+// content format of the news.
+type VariantInput struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Weight  int    `json:"weight"`
+}
+
+type stopExperimentInput struct {
+	WinnerID int `json:"winner_id,omitempty"`
+}
+
+// AddVariant returns the id of the variant, variants don't change while
+// the experiment runs.
+func (c *NewsClient) AddVariant(ctx context.Context, newsID int, input VariantInput) (int, error) {
+	var data struct {
+		ID int `json:"id"`
+	}
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/variants", input, &data)
+	if err != nil {
+		return 0, err
+	}
+
+	return data.ID, nil
+}
+
+func (c *NewsClient) DeleteVariant(ctx context.Context, newsID int, variantID int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/variants/"+strconv.Itoa(variantID), nil, nil)
+	return err
+}
+
+func (c *NewsClient) Experiment(ctx context.Context, newsID int) (Experiment, error) {
+	var experiment Experiment
+	_, err := c.do(ctx, http.MethodGet, "/posts/"+strconv.Itoa(newsID)+"/experiment", nil, &experiment)
+	if err != nil {
+		return Experiment{}, err
+	}
+
+	return experiment, nil
+}
+
+// StartExperiment needs at least 2 variants, their counts start over.
+func (c *NewsClient) StartExperiment(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/experiment/start", nil, nil)
+	return err
+}
+
+// StopExperiment updates the news with the variant with winnerID, or with
+// the one opened the most for the times it was shown when it's 0, and
+// returns the variant.
+func (c *NewsClient) StopExperiment(ctx context.Context, newsID int, winnerID int) (Variant, error) {
+	var winner Variant
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/experiment/stop", stopExperimentInput{WinnerID: winnerID}, &winner)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	return winner, nil
+}
+
+// RecordExposure counts the variant of News.VariantID shown to the client.
+func (c *NewsClient) RecordExposure(ctx context.Context, newsID int, variantID int) error {
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/variants/"+strconv.Itoa(variantID)+"/exposures", nil, nil)
+	return err
+}
+
+// RecordClick counts the variant of News.VariantID opened by the client.
+func (c *NewsClient) RecordClick(ctx context.Context, newsID int, variantID int) error {
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/variants/"+strconv.Itoa(variantID)+"/clicks", nil, nil)
+	return err
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Last is the position Feature puts news last with.
+const Last = -1
+
+type pinInput struct {
+	PinnedUntil *time.Time `json:"pinned_until,omitempty"`
+}
+
+type featureInput struct {
+	Position *int `json:"position,omitempty"`
+}
+
+// Pin puts published news first in lists until the time given, or until
+// they're unpinned when it's zero. Pinning news again replaces the time.
+func (c *NewsClient) Pin(ctx context.Context, newsID int, until time.Time) error {
+	var input pinInput
+	if !until.IsZero() {
+		input.PinnedUntil = &until
+	}
+
+	_, err := c.do(ctx, http.MethodPut, "/posts/"+strconv.Itoa(newsID)+"/pin", input, nil)
+	return err
+}
+
+// Unpin changes nothing for news that aren't pinned.
+func (c *NewsClient) Unpin(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/pin", nil, nil)
+	return err
+}
+
+// Featured returns the featured news in the order editors put them in.
+func (c *NewsClient) Featured(ctx context.Context) ([]News, error) {
+	var news []News
+	_, err := c.do(ctx, http.MethodGet, "/posts/featured", nil, &news)
+	if err != nil {
+		return nil, err
+	}
+
+	return news, nil
+}
+
+// Feature puts published news at position of the featured news, counting
+// from zero, or last for Last. Featured news are moved. Moving news to the
+// place another editor just took fails with a conflict, trying again works.
+func (c *NewsClient) Feature(ctx context.Context, newsID int, position int) error {
+	var input featureInput
+	if position != Last {
+		input.Position = &position
+	}
+
+	_, err := c.do(ctx, http.MethodPut, "/posts/"+strconv.Itoa(newsID)+"/featured", input, nil)
+	return err
+}
+
+func (c *NewsClient) Unfeature(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/featured", nil, nil)
+	return err
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+const (
+	MediaCover  = "cover"
+	MediaInline = "inline"
+)
+
+// Media has Width, Height and Variants for images only.
+type Media struct {
+	ID          int            `json:"id"`
+	Role        string         `json:"role"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	URL         string         `json:"url"`
+	Variants    []MediaVariant `json:"variants"`
+}
+
+// MediaVariant is a resized copy of an image, Name is "thumbnail" or
+// "medium" and Format is "jpeg" or "webp".
+type MediaVariant struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+// UploadMedia uploads the file read from r as media of the news, role is
+// MediaInline when it's empty. The server tells the type of the file by its
+// content, name is only passed along. Files larger than their type allows
+// fail with ErrPayloadTooLarge.
+func (c *NewsClient) UploadMedia(ctx context.Context, newsID int, role string, name string, r io.Reader) (Media, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if role != "" {
+		err := writer.WriteField("role", role)
+		if err != nil {
+			return Media{}, err
+		}
+	}
+	file, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return Media{}, err
+	}
+	_, err = io.Copy(file, r)
+	if err != nil {
+		return Media{}, fmt.Errorf("can't read file: [%w]", err)
+	}
+	err = writer.Close()
+	if err != nil {
+		return Media{}, err
+	}
+
+	var media Media
+	_, err = c.send(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/media", body.Bytes(), writer.FormDataContentType(), &media)
+	if err != nil {
+		return Media{}, err
+	}
+
+	return media, nil
+}
+
+func (c *NewsClient) DeleteMedia(ctx context.Context, newsID int, mediaID int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/media/"+strconv.Itoa(mediaID), nil, nil)
+	return err
+}
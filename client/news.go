@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPageSize is how many news List requests at once unless told
+// otherwise.
+const defaultPageSize = 20
+
+const (
+	ContentPlain    = "plain"
+	ContentMarkdown = "markdown"
+	ContentHTML     = "html"
+)
+
+// News has ContentHTML only when it's got with GetRendered. Blocks are nil
+// for html content. Media are only there for news got by id. CommentsCount
+// counts approved comments only. Targeting is empty for news listed for
+// everyone.
+type News struct {
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	ContentFormat string    `json:"content_format"`
+	ContentHTML   string    `json:"content_html"`
+	Blocks        *Document `json:"blocks"`
+	AuthorID      int       `json:"author_id"`
+	UpdatedBy     int       `json:"updated_by"`
+	Status        string    `json:"status"`
+	CommentsCount int       `json:"comments_count"`
+	Targeting     string    `json:"targeting"`
+	Media         []Media   `json:"media"`
+
+	// Reactions counts reactions by kind, MyReactions are the kinds the
+	// client reacted with, which is only known to authenticated clients.
+	Reactions   map[string]int `json:"reactions"`
+	MyReactions []string       `json:"my_reactions"`
+
+	// IsRead and IsBookmarked are only known to authenticated clients.
+	IsRead       bool `json:"is_read"`
+	IsBookmarked bool `json:"is_bookmarked"`
+
+	// VariantID is the variant of a running experiment in place of the
+	// title and content, which authenticated clients count exposures and
+	// clicks of.
+	VariantID int `json:"variant_id"`
+
+	// Pinned news come first in lists, PinnedUntil is nil for news pinned
+	// until they're unpinned.
+	Pinned      bool       `json:"pinned"`
+	PinnedUntil *time.Time `json:"pinned_until"`
+}
+
+// NewsInput is what Create and Update send. The title has to be from 3 to
+// 49 characters long and either the content or the blocks have to be set.
+// ContentFormat is ContentPlain when it's empty, or ContentMarkdown for the
+// content the server derives from blocks. News with Targeting, a rule such as
+// platform == "ios" and app_version >= "3.2", are listed only for the
+// audiences it matches, see ListFor.
+type NewsInput struct {
+	Title         string    `json:"title"`
+	Content       string    `json:"content,omitempty"`
+	ContentFormat string    `json:"content_format,omitempty"`
+	Blocks        *Document `json:"blocks,omitempty"`
+	Targeting     string    `json:"targeting,omitempty"`
+}
+
+// Create returns the id of the created news.
+func (c *NewsClient) Create(ctx context.Context, input NewsInput) (int, error) {
+	var data struct {
+		ID int `json:"id"`
+	}
+	_, err := c.do(ctx, http.MethodPost, "/posts", input, &data)
+	if err != nil {
+		return 0, err
+	}
+
+	return data.ID, nil
+}
+
+func (c *NewsClient) Update(ctx context.Context, id int, input NewsInput) error {
+	_, err := c.do(ctx, http.MethodPut, "/posts/"+strconv.Itoa(id), input, nil)
+	return err
+}
+
+func (c *NewsClient) Get(ctx context.Context, id int) (News, error) {
+	var news News
+	_, err := c.do(ctx, http.MethodGet, "/posts/"+strconv.Itoa(id), nil, &news)
+	if err != nil {
+		return News{}, err
+	}
+
+	return news, nil
+}
+
+// GetRendered is Get along with the content rendered by the server to html
+// that's safe to display.
+func (c *NewsClient) GetRendered(ctx context.Context, id int) (News, error) {
+	var news News
+	_, err := c.do(ctx, http.MethodGet, "/posts/"+strconv.Itoa(id)+"?render=html", nil, &news)
+	if err != nil {
+		return News{}, err
+	}
+
+	return news, nil
+}
+
+// Delete fails with ErrEntityAlreadyDeleted for news that are gone, which is
+// also what a retried delete that went through the first time gets.
+func (c *NewsClient) Delete(ctx context.Context, id int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(id), nil, nil)
+	return err
+}
+
+// List iterates over all news by id, requesting pageSize of them at once.
+// Pages are requested as the iteration gets to them, news created meanwhile
+// with greater ids are included.
+func (c *NewsClient) List(ctx context.Context, pageSize int) *NewsIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &NewsIterator{
+		client: c,
+		ctx:    ctx,
+		path:   "/posts",
+		query:  url.Values{"first": {strconv.Itoa(pageSize)}}.Encode(),
+	}
+}
+
+// NewsIterator is used as
+//
+//	it := newsClient.List(ctx, 0)
+//	for it.Next() {
+//		news := it.News()
+//	}
+//	if it.Err() != nil {
+//	}
+type NewsIterator struct {
+	client *NewsClient
+	ctx    context.Context
+	path   string
+	// query requests the next page, it's empty after the last one
+	query string
+	page  []News
+	news  News
+	err   error
+}
+
+// Next advances to the next news, it's false once there are no more or
+// requesting a page failed.
+func (it *NewsIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.query == "" {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.news = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+func (it *NewsIterator) News() News {
+	return it.news
+}
+
+func (it *NewsIterator) Err() error {
+	return it.err
+}
+
+func (it *NewsIterator) fetch() {
+	var page []News
+	header, err := it.client.do(it.ctx, http.MethodGet, it.path+"?"+it.query, nil, &page)
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	it.page = page
+	it.query, it.err = nextQuery(header)
+}
+
+// nextQuery is the query of the next page, it's empty after the last one.
+// The next page is requested relative to the base url, so that the API can
+// be served under a prefix it doesn't know about.
+func nextQuery(header http.Header) (string, error) {
+	next, ok := nextLink(header.Values("Link"))
+	if !ok {
+		return "", nil
+	}
+
+	nextURL, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("can't parse next page link: [%w]", err)
+	}
+
+	return nextURL.RawQuery, nil
+}
+
+// nextLink finds the target of rel="next" in Link headers.
+func nextLink(links []string) (string, bool) {
+	for _, header := range links {
+		for _, link := range strings.Split(header, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if name == "rel" && strings.Trim(value, `"`) == "next" {
+					return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">"), true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
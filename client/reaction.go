@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Kinds of reactions the server accepts, anything else is ErrInvalidPayload.
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionClap  = "clap"
+)
+
+// React reacts to published news with kind. Reacting the same way twice
+// counts once, so it's safe to retry.
+func (c *NewsClient) React(ctx context.Context, newsID int, kind string) error {
+	_, err := c.do(ctx, http.MethodPut, "/posts/"+strconv.Itoa(newsID)+"/reactions/"+kind, nil, nil)
+	return err
+}
+
+// Unreact takes back own reaction of kind, which is fine to do for the
+// reaction that isn't there.
+func (c *NewsClient) Unreact(ctx context.Context, newsID int, kind string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/reactions/"+kind, nil, nil)
+	return err
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// MarkRead marks the news read, drafts are never read.
+func (c *NewsClient) MarkRead(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/read", nil, nil)
+	return err
+}
+
+// MarkAllRead marks everything published so far read, news published later
+// are unread until they're read.
+func (c *NewsClient) MarkAllRead(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/posts/read-all", nil, nil)
+	return err
+}
+
+// UnreadCount counts published news the client hasn't read.
+func (c *NewsClient) UnreadCount(ctx context.Context) (int, error) {
+	var data struct {
+		Count int `json:"count"`
+	}
+	_, err := c.do(ctx, http.MethodGet, "/me/unread-count", nil, &data)
+	if err != nil {
+		return 0, err
+	}
+
+	return data.Count, nil
+}
+
+// Bookmark bookmarks published news, bookmarking them again changes
+// nothing.
+func (c *NewsClient) Bookmark(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodPut, "/posts/"+strconv.Itoa(newsID)+"/bookmark", nil, nil)
+	return err
+}
+
+// Unbookmark removes the bookmark, removing one that isn't there changes
+// nothing.
+func (c *NewsClient) Unbookmark(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodDelete, "/posts/"+strconv.Itoa(newsID)+"/bookmark", nil, nil)
+	return err
+}
+
+// Bookmarks iterates over bookmarked news, the latest news first,
+// requesting pageSize of them at once. It's used the way List is.
+func (c *NewsClient) Bookmarks(ctx context.Context, pageSize int) *NewsIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &NewsIterator{
+		client: c,
+		ctx:    ctx,
+		path:   "/me/bookmarks",
+		query:  url.Values{"first": {strconv.Itoa(pageSize)}}.Encode(),
+	}
+}
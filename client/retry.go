@@ -0,0 +1,62 @@
+package client
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy tells how idempotent calls are retried when the API is
+// unavailable, overloaded or can't be reached. Create is never retried, a
+// retry could create the news twice.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt as well, 1 disables retries
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 100 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+}
+
+// backoff doubles the delay with every attempt. The jitter keeps clients
+// that failed together from retrying together.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxBackoff
+	if attempt < 32 {
+		exponential := p.BaseBackoff << (attempt - 1)
+		if exponential > 0 && exponential < p.MaxBackoff {
+			delay = exponential
+		}
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// delay prefers what the API asked for with Retry-After, as long as it's
+// within MaxBackoff.
+func (p RetryPolicy) delay(attempt int, header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, p.MaxBackoff)
+	}
+
+	return p.backoff(attempt)
+}
+
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Platforms an Audience is on.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+)
+
+// Audience is what a client tells about itself for news targeted at some
+// clients only, every field is optional. AppVersion is up to 4 dot separated
+// numbers, such as 3.2.1.
+type Audience struct {
+	Locale           string `json:"locale,omitempty"`
+	Platform         string `json:"platform,omitempty"`
+	AppVersion       string `json:"app_version,omitempty"`
+	Tier             string `json:"tier,omitempty"`
+	LearningLanguage string `json:"learning_language,omitempty"`
+}
+
+func (a Audience) set(query url.Values) {
+	for key, value := range map[string]string{
+		"locale":            a.Locale,
+		"platform":          a.Platform,
+		"app_version":       a.AppVersion,
+		"tier":              a.Tier,
+		"learning_language": a.LearningLanguage,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+}
+
+// ListFor is List of the news targeted at audience, news without a targeting
+// rule among them.
+func (c *NewsClient) ListFor(ctx context.Context, audience Audience, pageSize int) *NewsIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	query := url.Values{"first": {strconv.Itoa(pageSize)}}
+	audience.set(query)
+
+	return &NewsIterator{
+		client: c,
+		ctx:    ctx,
+		path:   "/posts",
+		query:  query.Encode(),
+	}
+}
+
+// DryRun splits audiences by whether news are listed for them.
+type DryRun struct {
+	Visible []Audience `json:"visible"`
+	Hidden  []Audience `json:"hidden"`
+}
+
+// DryRunTargeting tells which of up to 100 audiences the news are listed for
+// with the rule they have now, without listing anything.
+func (c *NewsClient) DryRunTargeting(ctx context.Context, newsID int, audiences []Audience) (DryRun, error) {
+	body := struct {
+		Contexts []Audience `json:"contexts"`
+	}{audiences}
+
+	var data DryRun
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/targeting/dry-run", body, &data)
+	if err != nil {
+		return DryRun{}, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Windows popular news are ranked over.
+const (
+	PopularDay   = "24h"
+	PopularWeek  = "7d"
+	PopularMonth = "30d"
+)
+
+// RecordView counts a view of the news, for news shown from a cache of the
+// client. Get counts a view already, as does any view within a while of the
+// previous one by the same client.
+func (c *NewsClient) RecordView(ctx context.Context, newsID int) error {
+	_, err := c.do(ctx, http.MethodPost, "/posts/"+strconv.Itoa(newsID)+"/views", nil, nil)
+	return err
+}
+
+// Popular returns up to first published news viewed the most within window,
+// recent views weighing more. The server picks how many when first is 0.
+func (c *NewsClient) Popular(ctx context.Context, window string, first int) ([]News, error) {
+	query := url.Values{"window": {window}}
+	if first > 0 {
+		query.Set("first", strconv.Itoa(first))
+	}
+
+	var news []News
+	_, err := c.do(ctx, http.MethodGet, "/posts/popular?"+query.Encode(), nil, &news)
+	if err != nil {
+		return nil, err
+	}
+
+	return news, nil
+}
@@ -2,34 +2,220 @@ package main
 
 import (
 	"context"
+	"expvar"
+	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/anton-uvarenko/promova_test/internal/cache"
 	"github.com/anton-uvarenko/promova_test/internal/core"
 	"github.com/anton-uvarenko/promova_test/internal/db"
+	"github.com/anton-uvarenko/promova_test/internal/events"
+	"github.com/anton-uvarenko/promova_test/internal/outbox"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/moderation"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/openapi"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/ratelimit"
 	"github.com/anton-uvarenko/promova_test/internal/pkg/server"
 	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/anton-uvarenko/promova_test/internal/storage"
 	"github.com/anton-uvarenko/promova_test/internal/transport"
+	"github.com/anton-uvarenko/promova_test/internal/transport/rpc"
+	"github.com/anton-uvarenko/promova_test/internal/views"
+	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
 )
 
 func main() {
 	godotenv.Load()
-	conn := db.Connect()
-	repo := core.New(conn)
+	pool := db.Connect()
+	repo := core.New(pool)
 
-	appService := service.NewService(repo)
-	handler := transport.NewHandler(appService.NewsService)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	router := server.SetUpRoutes(handler.NewsHandler)
+	newsCache := cache.NewNewsRepo(outbox.NewNewsRepo(pool, repo), 1000, time.Minute)
+	go db.Listen(ctx, pool, cache.NewsChangedChannel, newsCache.HandleNotification, newsCache.Purge)
+	// served along with the runtime stats on the metrics port
+	expvar.Publish("news_cache", expvar.Func(func() any { return newsCache.Stats() }))
+
+	broker := events.NewBroker(repo, 64)
+	err := broker.Start(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go db.Listen(ctx, pool, events.Channel, broker.HandleNotification, broker.Poll)
+
+	maxAttempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil {
+		maxAttempts = 8
+	}
+	dispatcher := outbox.NewDispatcher(repo, &http.Client{Timeout: 10 * time.Second}, maxAttempts)
+	go dispatcher.Run(ctx, 5*time.Second)
+
+	var mediaStorage storage.Storage
+	var mediaFiles http.Handler
+	switch os.Getenv("MEDIA_STORAGE") {
+	case "s3":
+		mediaStorage, err = storage.NewS3(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			Insecure:  os.Getenv("S3_INSECURE") == "true",
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		mediaDir := os.Getenv("MEDIA_DIR")
+		if mediaDir == "" {
+			mediaDir = "media"
+		}
+		local := storage.NewLocal(mediaDir, os.Getenv("MEDIA_BASE_URL"))
+		mediaStorage, mediaFiles = local, local.Handler()
+	}
+
+	commentFilter := moderation.ParseWordList(os.Getenv("COMMENT_BLOCKED_WORDS"))
+
+	viewWindow, err := time.ParseDuration(os.Getenv("VIEW_DEDUP_WINDOW"))
+	if err != nil {
+		viewWindow = 30 * time.Minute
+	}
+	viewFlushInterval, err := time.ParseDuration(os.Getenv("VIEW_FLUSH_INTERVAL"))
+	if err != nil {
+		viewFlushInterval = 10 * time.Second
+	}
+	viewRecorder := views.NewRecorder(repo, viewWindow)
+	viewsFlushed := make(chan struct{})
+	go func() {
+		viewRecorder.Run(ctx, viewFlushInterval)
+		close(viewsFlushed)
+	}()
+
+	appService := service.NewService(newsCache, repo, repo, repo, repo, mediaStorage, repo, commentFilter, repo, repo, viewRecorder, repo, repo, repo, repo)
+	handler := transport.NewHandler(
+		appService.NewsService,
+		broker,
+		appService.ApiKeyService,
+		appService.WebhookService,
+		appService.SyncService,
+		appService.MediaService,
+		appService.CommentService,
+		appService.ReactionService,
+		appService.ViewService,
+		appService.ReadStateService,
+		appService.ExperimentService,
+		appService.FeaturedService,
+		appService.AuthorService,
+		os.Getenv("NEWS_CACHE_CONTROL"),
+	)
+
+	keyset := auth.LoadKeyset()
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		rateLimitStore = ratelimit.NewPostgresStore(repo)
+	}
+	limiter := ratelimit.NewLimiter(
+		rateLimitStore,
+		ratelimit.SystemClock{},
+		ratelimit.PerMinute(120),
+		ratelimit.Route{Method: http.MethodPost, Path: "/posts", Limit: ratelimit.PerMinute(10)},
+		ratelimit.Route{Method: http.MethodPut, Path: "/posts/:id", Limit: ratelimit.PerMinute(30)},
+		ratelimit.Route{Method: http.MethodDelete, Path: "/posts/:id", Limit: ratelimit.PerMinute(30)},
+		ratelimit.Route{Method: http.MethodPost, Path: "/posts/:id/media", Limit: ratelimit.PerMinute(10)},
+		ratelimit.Route{Method: http.MethodPost, Path: "/posts/:id/comments", Limit: ratelimit.PerMinute(5)},
+		ratelimit.Route{Method: http.MethodPut, Path: "/posts/:id/reactions/:kind", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodDelete, Path: "/posts/:id/reactions/:kind", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodPost, Path: "/posts/:id/views", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodPut, Path: "/posts/:id/bookmark", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodDelete, Path: "/posts/:id/bookmark", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodPost, Path: "/posts/:id/variants/:variant_id/exposures", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodPost, Path: "/posts/:id/variants/:variant_id/clicks", Limit: ratelimit.PerMinute(60)},
+		ratelimit.Route{Method: http.MethodPut, Path: "/posts/:id/featured", Limit: ratelimit.PerMinute(30)},
+		ratelimit.Route{Method: http.MethodPost, Path: "/api-keys", Limit: ratelimit.PerHour(20)},
+	)
+
+	validationMiddleware := func(ctx *gin.Context) { ctx.Next() }
+	if os.Getenv("OPENAPI_VALIDATION") == "true" {
+		validator, err := openapi.NewValidator()
+		if err != nil {
+			log.Fatal(err)
+		}
+		validationMiddleware = validator.Middleware()
+	}
+
+	trustedProxies := strings.FieldsFunc(os.Getenv("TRUSTED_PROXIES"), func(r rune) bool {
+		return r == ','
+	})
+	router, err := server.SetUpRoutes(
+		handler.NewsHandler,
+		handler.NewsEventHandler,
+		handler.SyncHandler,
+		handler.ApiKeyHandler,
+		handler.WebhookHandler,
+		handler.GraphqlHandler,
+		handler.OpenapiHandler,
+		handler.MediaHandler,
+		mediaFiles,
+		handler.CommentHandler,
+		handler.ReactionHandler,
+		handler.ViewHandler,
+		handler.ReadStateHandler,
+		handler.ExperimentHandler,
+		auth.Middleware(keyset, appService.ApiKeyService),
+		auth.OptionalMiddleware(keyset, appService.ApiKeyService),
+		ratelimit.Middleware(limiter),
+		validationMiddleware,
+		trustedProxies,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
 	httpServer := server.NewServer(router, "8080")
 
+	grpcServer := server.NewGrpcServer(
+		rpc.NewNewsServer(appService.NewsService),
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(keyset, appService.ApiKeyService)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(keyset, appService.ApiKeyService)),
+	)
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the metrics port isn't meant to be reachable from outside
+	metricsPort := os.Getenv("METRICS_PORT")
+	if metricsPort == "" {
+		metricsPort = "9100"
+	}
+	metricsServer := server.NewServer(expvar.Handler(), metricsPort)
+
 	go httpServer.ListenAndServe()
+	go grpcServer.Serve(grpcListener)
+	go metricsServer.ListenAndServe()
 	finish := make(chan os.Signal, 1)
 	signal.Notify(finish, os.Interrupt, syscall.SIGTERM)
 
 	<-finish
 
-	conn.Close(context.Background())
+	grpcServer.GracefulStop()
+	cancel()
+	// the views counted last are flushed on the way out
+	<-viewsFlushed
+	pool.Close()
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/db"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/blocks"
+	"github.com/joho/godotenv"
+)
+
+// Fills in the blocks of news written before there were any, the service
+// converts news written since. Html news are left without them.
+func main() {
+	batch := flag.Int("batch", 100, "news converted at once")
+	flag.Parse()
+
+	godotenv.Load()
+
+	conn := db.Connect()
+	defer conn.Close()
+	repo := core.New(conn)
+
+	ctx := context.Background()
+	converted := 0
+	for {
+		news, err := repo.GetNewsWithoutBlocks(ctx, int32(*batch))
+		if err != nil {
+			log.Fatal("can't get news: ", err)
+		}
+		if len(news) == 0 {
+			break
+		}
+
+		for _, v := range news {
+			doc, ok := blocks.FromContent(v.ContentFormat, v.Content.String)
+			if !ok {
+				log.Fatalf("can't convert news %d written as %q", v.ID, v.ContentFormat)
+			}
+
+			data, err := json.Marshal(doc)
+			if err != nil {
+				log.Fatal("can't encode blocks: ", err)
+			}
+
+			err = repo.SetNewsBlocks(ctx, core.SetNewsBlocksParams{ID: v.ID, Blocks: data})
+			if err != nil {
+				log.Fatalf("can't set blocks of news %d: %v", v.ID, err)
+			}
+			converted++
+		}
+	}
+
+	fmt.Printf("converted %d news\n", converted)
+}
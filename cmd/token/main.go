@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/db"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	authorId := flag.Int("author", 0, "id of an existing author")
+	name := flag.String("name", "", "name of a new author to create")
+	email := flag.String("email", "", "email of a new author to create")
+	role := flag.String("role", string(authz.RoleAuthor), "role of a new author to create")
+	ttl := flag.Duration("ttl", 24*time.Hour, "token lifetime")
+	flag.Parse()
+
+	godotenv.Load()
+	keyset := auth.LoadKeyset()
+
+	conn := db.Connect()
+	defer conn.Close()
+	repo := core.New(conn)
+
+	if *authorId == 0 {
+		if *name == "" {
+			log.Fatal("either -author or -name is required")
+		}
+		if !authz.Role(*role).Valid() {
+			log.Fatal("unknown role: ", *role)
+		}
+
+		id, err := repo.AddAuthor(context.Background(), core.AddAuthorParams{
+			Name:  *name,
+			Email: pgtype.Text{String: *email, Valid: *email != ""},
+			Role:  *role,
+		})
+		if err != nil {
+			log.Fatal("can't create author: ", err)
+		}
+		*authorId = int(id)
+	}
+
+	author, err := repo.GetAuthorById(context.Background(), int32(*authorId))
+	if err != nil {
+		log.Fatal("can't find author: ", err)
+	}
+
+	token, err := keyset.Sign(auth.Author{ID: author.ID, Name: author.Name, Role: authz.Role(author.Role)}, *ttl)
+	if err != nil {
+		log.Fatal("can't sign token: ", err)
+	}
+
+	fmt.Println(token)
+}
@@ -0,0 +1,201 @@
+package authz
+
+import (
+	"fmt"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+)
+
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleAuthor Role = "author"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+	// RoleService is given to machine clients authenticated with an api key,
+	// what they may do is limited by the key's scopes
+	RoleService Role = "service"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleViewer, RoleAuthor, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+type Action string
+
+const (
+	ActionRead    Action = "news:read"
+	ActionCreate  Action = "news:create"
+	ActionUpdate  Action = "news:update"
+	ActionPublish Action = "news:publish"
+	ActionDelete  Action = "news:delete"
+	// ActionFeature is pinning news and ordering the featured ones
+	ActionFeature Action = "news:feature"
+
+	ActionComment          Action = "comments:create"
+	ActionUpdateComment    Action = "comments:update"
+	ActionDeleteComment    Action = "comments:delete"
+	ActionModerateComments Action = "comments:moderate"
+
+	ActionReact    Action = "reactions:create"
+	ActionBookmark Action = "bookmarks:create"
+
+	ActionManageApiKeys  Action = "api_keys:manage"
+	ActionManageWebhooks Action = "webhooks:manage"
+)
+
+// Scope limits what api keys may do. Published news are public, reading
+// drafts takes news:read or news:write.
+type Scope string
+
+const (
+	ScopeNewsRead   Scope = "news:read"
+	ScopeNewsWrite  Scope = "news:write"
+	ScopeNewsDelete Scope = "news:delete"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeNewsRead, ScopeNewsWrite, ScopeNewsDelete:
+		return true
+	}
+	return false
+}
+
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+)
+
+type Subject struct {
+	ID     int32
+	Role   Role
+	Scopes []Scope
+}
+
+// Resource describes the news item or the comment an action is performed
+// on. It is empty for actions that don't target an existing item, e.g.
+// create. Comments are created on a Resource of their news.
+type Resource struct {
+	AuthorID int32
+	Status   string
+}
+
+type rule func(subject Subject, resource Resource) bool
+
+type policy struct {
+	Role   Role
+	Action Action
+	Allow  rule
+}
+
+func always(Subject, Resource) bool {
+	return true
+}
+
+func ownDraft(subject Subject, resource Resource) bool {
+	return resource.AuthorID == subject.ID && resource.Status == StatusDraft
+}
+
+func own(subject Subject, resource Resource) bool {
+	return resource.AuthorID == subject.ID
+}
+
+func published(_ Subject, resource Resource) bool {
+	return resource.Status == StatusPublished
+}
+
+func scoped(scope Scope) rule {
+	return func(subject Subject, _ Resource) bool {
+		for _, s := range subject.Scopes {
+			if s == scope {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// every allows what all of the rules allow.
+func every(rules ...rule) rule {
+	return func(subject Subject, resource Resource) bool {
+		for _, allow := range rules {
+			if !allow(subject, resource) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// policies is the single place access rules are declared. Anything not
+// listed here is denied.
+var policies = []policy{
+	{Role: RoleViewer, Action: ActionRead, Allow: published},
+	{Role: RoleViewer, Action: ActionComment, Allow: published},
+	{Role: RoleViewer, Action: ActionReact, Allow: published},
+	{Role: RoleViewer, Action: ActionBookmark, Allow: published},
+	{Role: RoleViewer, Action: ActionUpdateComment, Allow: own},
+	{Role: RoleViewer, Action: ActionDeleteComment, Allow: own},
+
+	{Role: RoleAuthor, Action: ActionRead, Allow: published},
+	{Role: RoleAuthor, Action: ActionRead, Allow: ownDraft},
+	{Role: RoleAuthor, Action: ActionCreate, Allow: always},
+	{Role: RoleAuthor, Action: ActionUpdate, Allow: ownDraft},
+	{Role: RoleAuthor, Action: ActionComment, Allow: published},
+	{Role: RoleAuthor, Action: ActionReact, Allow: published},
+	{Role: RoleAuthor, Action: ActionBookmark, Allow: published},
+	{Role: RoleAuthor, Action: ActionUpdateComment, Allow: own},
+	{Role: RoleAuthor, Action: ActionDeleteComment, Allow: own},
+
+	{Role: RoleEditor, Action: ActionRead, Allow: always},
+	{Role: RoleEditor, Action: ActionCreate, Allow: always},
+	{Role: RoleEditor, Action: ActionUpdate, Allow: always},
+	{Role: RoleEditor, Action: ActionPublish, Allow: always},
+	{Role: RoleEditor, Action: ActionFeature, Allow: published},
+	{Role: RoleEditor, Action: ActionComment, Allow: published},
+	{Role: RoleEditor, Action: ActionReact, Allow: published},
+	{Role: RoleEditor, Action: ActionBookmark, Allow: published},
+	{Role: RoleEditor, Action: ActionUpdateComment, Allow: own},
+	{Role: RoleEditor, Action: ActionDeleteComment, Allow: always},
+	{Role: RoleEditor, Action: ActionModerateComments, Allow: always},
+
+	{Role: RoleAdmin, Action: ActionRead, Allow: always},
+	{Role: RoleAdmin, Action: ActionCreate, Allow: always},
+	{Role: RoleAdmin, Action: ActionUpdate, Allow: always},
+	{Role: RoleAdmin, Action: ActionPublish, Allow: always},
+	{Role: RoleAdmin, Action: ActionDelete, Allow: always},
+	{Role: RoleAdmin, Action: ActionFeature, Allow: published},
+	{Role: RoleAdmin, Action: ActionComment, Allow: published},
+	{Role: RoleAdmin, Action: ActionReact, Allow: published},
+	{Role: RoleAdmin, Action: ActionBookmark, Allow: published},
+	{Role: RoleAdmin, Action: ActionUpdateComment, Allow: own},
+	{Role: RoleAdmin, Action: ActionDeleteComment, Allow: always},
+	{Role: RoleAdmin, Action: ActionModerateComments, Allow: always},
+	{Role: RoleAdmin, Action: ActionManageApiKeys, Allow: always},
+	{Role: RoleAdmin, Action: ActionManageWebhooks, Allow: always},
+
+	{Role: RoleService, Action: ActionRead, Allow: published},
+	{Role: RoleService, Action: ActionRead, Allow: scoped(ScopeNewsRead)},
+	{Role: RoleService, Action: ActionRead, Allow: scoped(ScopeNewsWrite)},
+	{Role: RoleService, Action: ActionCreate, Allow: scoped(ScopeNewsWrite)},
+	{Role: RoleService, Action: ActionUpdate, Allow: scoped(ScopeNewsWrite)},
+	{Role: RoleService, Action: ActionPublish, Allow: scoped(ScopeNewsWrite)},
+	{Role: RoleService, Action: ActionFeature, Allow: every(published, scoped(ScopeNewsWrite))},
+	{Role: RoleService, Action: ActionDelete, Allow: scoped(ScopeNewsDelete)},
+}
+
+func Authorize(subject Subject, action Action, resource Resource) error {
+	for _, p := range policies {
+		if p.Role == subject.Role && p.Action == action && p.Allow(subject, resource) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: [%s can't %s]", pkg.ErrForbidden, subject.Role, action)
+}
@@ -0,0 +1,110 @@
+package authz
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestAuthorize(t *testing.T) {
+	ownDraftResource := Resource{AuthorID: 1, Status: StatusDraft}
+	ownPublishedResource := Resource{AuthorID: 1, Status: StatusPublished}
+	othersDraftResource := Resource{AuthorID: 2, Status: StatusDraft}
+
+	viewer := Subject{ID: 1, Role: RoleViewer}
+	author := Subject{ID: 1, Role: RoleAuthor}
+	editor := Subject{ID: 1, Role: RoleEditor}
+	admin := Subject{ID: 1, Role: RoleAdmin}
+	writer := Subject{ID: 1, Role: RoleService, Scopes: []Scope{ScopeNewsRead, ScopeNewsWrite}}
+	reader := Subject{ID: 1, Role: RoleService, Scopes: []Scope{ScopeNewsRead}}
+	deleter := Subject{ID: 1, Role: RoleService, Scopes: []Scope{ScopeNewsDelete}}
+
+	testTable := []struct {
+		Name          string
+		Subject       Subject
+		Action        Action
+		Resource      Resource
+		ExpectedError error
+	}{
+		{Name: "Viewer can read published", Subject: viewer, Action: ActionRead, Resource: ownPublishedResource},
+		{Name: "Viewer can't read draft", Subject: viewer, Action: ActionRead, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Viewer can't create", Subject: viewer, Action: ActionCreate, ExpectedError: pkg.ErrForbidden},
+		{Name: "Viewer can't update", Subject: viewer, Action: ActionUpdate, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Viewer can't publish", Subject: viewer, Action: ActionPublish, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Viewer can't delete", Subject: viewer, Action: ActionDelete, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Author can read published", Subject: author, Action: ActionRead, Resource: Resource{AuthorID: 2, Status: StatusPublished}},
+		{Name: "Author can read own draft", Subject: author, Action: ActionRead, Resource: ownDraftResource},
+		{Name: "Author can't read others draft", Subject: author, Action: ActionRead, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Author can create", Subject: author, Action: ActionCreate},
+		{Name: "Author can update own draft", Subject: author, Action: ActionUpdate, Resource: ownDraftResource},
+		{Name: "Author can't update own published", Subject: author, Action: ActionUpdate, Resource: ownPublishedResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Author can't update others draft", Subject: author, Action: ActionUpdate, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Author can't publish own draft", Subject: author, Action: ActionPublish, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Author can't feature own published", Subject: author, Action: ActionFeature, Resource: ownPublishedResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Author can't delete own draft", Subject: author, Action: ActionDelete, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Editor can read others draft", Subject: editor, Action: ActionRead, Resource: othersDraftResource},
+		{Name: "Editor can create", Subject: editor, Action: ActionCreate},
+		{Name: "Editor can update others draft", Subject: editor, Action: ActionUpdate, Resource: othersDraftResource},
+		{Name: "Editor can publish others draft", Subject: editor, Action: ActionPublish, Resource: othersDraftResource},
+		{Name: "Editor can feature published", Subject: editor, Action: ActionFeature, Resource: ownPublishedResource},
+		{Name: "Editor can't feature draft", Subject: editor, Action: ActionFeature, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Editor can't delete", Subject: editor, Action: ActionDelete, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Admin can read others draft", Subject: admin, Action: ActionRead, Resource: othersDraftResource},
+		{Name: "Admin can create", Subject: admin, Action: ActionCreate},
+		{Name: "Admin can update", Subject: admin, Action: ActionUpdate, Resource: othersDraftResource},
+		{Name: "Admin can publish", Subject: admin, Action: ActionPublish, Resource: othersDraftResource},
+		{Name: "Admin can delete", Subject: admin, Action: ActionDelete, Resource: othersDraftResource},
+		{Name: "Admin can manage api keys", Subject: admin, Action: ActionManageApiKeys},
+		{Name: "Editor can't manage api keys", Subject: editor, Action: ActionManageApiKeys, ExpectedError: pkg.ErrForbidden},
+		{Name: "Admin can manage webhooks", Subject: admin, Action: ActionManageWebhooks},
+		{Name: "Editor can't manage webhooks", Subject: editor, Action: ActionManageWebhooks, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Read scope can read draft", Subject: reader, Action: ActionRead, Resource: othersDraftResource},
+		{Name: "Read scope can't update", Subject: reader, Action: ActionUpdate, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Write scope can read draft", Subject: writer, Action: ActionRead, Resource: othersDraftResource},
+		{Name: "Delete scope can read published", Subject: deleter, Action: ActionRead, Resource: ownPublishedResource},
+		{Name: "Delete scope can't read draft", Subject: deleter, Action: ActionRead, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Write scope can create", Subject: writer, Action: ActionCreate},
+		{Name: "Write scope can update", Subject: writer, Action: ActionUpdate, Resource: othersDraftResource},
+		{Name: "Write scope can publish", Subject: writer, Action: ActionPublish, Resource: othersDraftResource},
+		{Name: "Write scope can feature", Subject: writer, Action: ActionFeature, Resource: ownPublishedResource},
+		{Name: "Write scope can't feature draft", Subject: writer, Action: ActionFeature, Resource: ownDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Read scope can't feature", Subject: reader, Action: ActionFeature, Resource: ownPublishedResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Write scope can't delete", Subject: writer, Action: ActionDelete, Resource: othersDraftResource, ExpectedError: pkg.ErrForbidden},
+		{Name: "Delete scope can delete", Subject: deleter, Action: ActionDelete, Resource: othersDraftResource},
+		{Name: "Delete scope can't create", Subject: deleter, Action: ActionCreate, ExpectedError: pkg.ErrForbidden},
+		{Name: "Service can't manage api keys", Subject: writer, Action: ActionManageApiKeys, ExpectedError: pkg.ErrForbidden},
+		{Name: "Service can't manage webhooks", Subject: writer, Action: ActionManageWebhooks, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Viewer can comment published", Subject: viewer, Action: ActionComment, Resource: Resource{Status: StatusPublished}},
+		{Name: "Viewer can't comment draft", Subject: viewer, Action: ActionComment, Resource: Resource{Status: StatusDraft}, ExpectedError: pkg.ErrForbidden},
+		{Name: "Viewer can update own comment", Subject: viewer, Action: ActionUpdateComment, Resource: Resource{AuthorID: 1}},
+		{Name: "Viewer can't delete others comment", Subject: viewer, Action: ActionDeleteComment, Resource: Resource{AuthorID: 2}, ExpectedError: pkg.ErrForbidden},
+		{Name: "Viewer can't moderate", Subject: viewer, Action: ActionModerateComments, ExpectedError: pkg.ErrForbidden},
+		{Name: "Editor can't update others comment", Subject: editor, Action: ActionUpdateComment, Resource: Resource{AuthorID: 2}, ExpectedError: pkg.ErrForbidden},
+		{Name: "Editor can delete others comment", Subject: editor, Action: ActionDeleteComment, Resource: Resource{AuthorID: 2}},
+		{Name: "Editor can moderate", Subject: editor, Action: ActionModerateComments},
+		{Name: "Service can't comment", Subject: writer, Action: ActionComment, Resource: Resource{Status: StatusPublished}, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Viewer can react to published", Subject: viewer, Action: ActionReact, Resource: Resource{Status: StatusPublished}},
+		{Name: "Author can't react to draft", Subject: author, Action: ActionReact, Resource: Resource{Status: StatusDraft, AuthorID: 1}, ExpectedError: pkg.ErrForbidden},
+		{Name: "Service can't react", Subject: writer, Action: ActionReact, Resource: Resource{Status: StatusPublished}, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Viewer can bookmark published", Subject: viewer, Action: ActionBookmark, Resource: Resource{Status: StatusPublished}},
+		{Name: "Author can't bookmark own draft", Subject: author, Action: ActionBookmark, Resource: Resource{Status: StatusDraft, AuthorID: 1}, ExpectedError: pkg.ErrForbidden},
+
+		{Name: "Unknown role is denied", Subject: Subject{ID: 1, Role: "guest"}, Action: ActionCreate, ExpectedError: pkg.ErrForbidden},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			err := Authorize(testCase.Subject, testCase.Action, testCase.Resource)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+		})
+	}
+}
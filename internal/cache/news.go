@@ -0,0 +1,272 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/lru"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/sync/singleflight"
+)
+
+// NewsChangedChannel is the Postgres channel replicas use to tell each
+// other which news to drop from their caches.
+const NewsChangedChannel = "news_changed"
+
+type newsRepo interface {
+	AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error)
+	DeleteNews(ctx context.Context, id int32) error
+	GetAllNews(ctx context.Context) ([]core.News, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error)
+	GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error)
+	GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error)
+	UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error
+	PublishNews(ctx context.Context, id int32) error
+	PinNews(ctx context.Context, arg core.PinNewsParams) error
+	UnpinNews(ctx context.Context, id int32) error
+	NotifyNewsChanged(ctx context.Context, payload string) error
+}
+
+// NewsRepo is a read-through cache in front of the news queries.
+type NewsRepo struct {
+	newsRepo newsRepo
+	byId     *lru.Cache[int32, core.News]
+	all      *lru.Cache[struct{}, []core.News]
+	group    singleflight.Group
+
+	// generation is bumped on every invalidation, so loads that started
+	// before a write can't put what they read back into the cache
+	mu         sync.Mutex
+	generation uint64
+}
+
+func NewNewsRepo(newsRepo newsRepo, size int, ttl time.Duration) *NewsRepo {
+	return &NewsRepo{
+		newsRepo: newsRepo,
+		byId:     lru.New[int32, core.News](size, ttl),
+		all:      lru.New[struct{}, []core.News](1, ttl),
+	}
+}
+
+func (r *NewsRepo) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	news, ok := r.byId.Get(id)
+	if ok {
+		return news, nil
+	}
+
+	generation := r.currentGeneration()
+	v, err, _ := r.group.Do(fmt.Sprintf("news:%d:%d", id, generation), func() (any, error) {
+		// the load is shared, one caller giving up shouldn't fail the others
+		news, err := r.newsRepo.GetNewsById(context.WithoutCancel(ctx), id)
+		if err != nil {
+			return core.News{}, err
+		}
+
+		r.store(generation, func() { r.byId.Set(id, news) })
+		return news, nil
+	})
+
+	return v.(core.News), err
+}
+
+func (r *NewsRepo) GetAllNews(ctx context.Context) ([]core.News, error) {
+	news, ok := r.all.Get(struct{}{})
+	if ok {
+		return append([]core.News(nil), news...), nil
+	}
+
+	generation := r.currentGeneration()
+	v, err, _ := r.group.Do(fmt.Sprintf("all:%d", generation), func() (any, error) {
+		news, err := r.newsRepo.GetAllNews(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		r.store(generation, func() { r.all.Set(struct{}{}, news) })
+		return news, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]core.News(nil), v.([]core.News)...), nil
+}
+
+// GetNewsByIds serves the cached news and reads the rest with a single
+// query. Batches are rarely repeated, so they aren't shared like single
+// loads are.
+func (r *NewsRepo) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	news := make([]core.News, 0, len(ids))
+	var missing []int32
+	for _, id := range ids {
+		n, ok := r.byId.Get(id)
+		if ok {
+			news = append(news, n)
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return news, nil
+	}
+
+	generation := r.currentGeneration()
+	loaded, err := r.newsRepo.GetNewsByIds(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(generation, func() {
+		for _, n := range loaded {
+			r.byId.Set(n.ID, n)
+		}
+	})
+	return append(news, loaded...), nil
+}
+
+// GetNewsStats isn't cached, it's what clients revalidate against.
+func (r *NewsRepo) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	return r.newsRepo.GetNewsStats(ctx)
+}
+
+// GetNewsActivity isn't cached either, news aren't invalidated with it.
+func (r *NewsRepo) GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error) {
+	return r.newsRepo.GetNewsActivity(ctx, newsID)
+}
+
+func (r *NewsRepo) AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error) {
+	id, err := r.newsRepo.AddNews(ctx, arg)
+	if err != nil {
+		return 0, err
+	}
+
+	r.invalidate(ctx, id)
+	return id, nil
+}
+
+func (r *NewsRepo) UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error {
+	err := r.newsRepo.UpdateNews(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, arg.ID)
+	return nil
+}
+
+func (r *NewsRepo) PublishNews(ctx context.Context, id int32) error {
+	err := r.newsRepo.PublishNews(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, id)
+	return nil
+}
+
+func (r *NewsRepo) PinNews(ctx context.Context, arg core.PinNewsParams) error {
+	err := r.newsRepo.PinNews(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, arg.ID)
+	return nil
+}
+
+func (r *NewsRepo) UnpinNews(ctx context.Context, id int32) error {
+	err := r.newsRepo.UnpinNews(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, id)
+	return nil
+}
+
+func (r *NewsRepo) DeleteNews(ctx context.Context, id int32) error {
+	err := r.newsRepo.DeleteNews(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, id)
+	return nil
+}
+
+func (r *NewsRepo) NotifyNewsChanged(ctx context.Context, payload string) error {
+	return r.newsRepo.NotifyNewsChanged(ctx, payload)
+}
+
+// HandleNotification drops news changed by another replica.
+func (r *NewsRepo) HandleNotification(payload string) {
+	id, err := strconv.ParseInt(payload, 10, 32)
+	if err != nil {
+		r.Purge()
+		return
+	}
+
+	r.evict(int32(id))
+}
+
+func (r *NewsRepo) Purge() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.byId.Purge()
+	r.all.Purge()
+}
+
+func (r *NewsRepo) Stats() lru.Stats {
+	byId := r.byId.Stats()
+	all := r.all.Stats()
+
+	return lru.Stats{
+		Hits:        byId.Hits + all.Hits,
+		Misses:      byId.Misses + all.Misses,
+		Evictions:   byId.Evictions + all.Evictions,
+		Expirations: byId.Expirations + all.Expirations,
+		Size:        byId.Size + all.Size,
+	}
+}
+
+func (r *NewsRepo) currentGeneration() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.generation
+}
+
+func (r *NewsRepo) store(generation uint64, set func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.generation == generation {
+		set()
+	}
+}
+
+func (r *NewsRepo) evict(id int32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.byId.Remove(id)
+	r.all.Remove(struct{}{})
+}
+
+func (r *NewsRepo) invalidate(ctx context.Context, id int32) {
+	r.evict(id)
+
+	err := r.newsRepo.NotifyNewsChanged(ctx, strconv.Itoa(int(id)))
+	if err != nil {
+		// other replicas catch up once their entries expire
+		fmt.Printf("can't notify news change: [%v]\n", err)
+	}
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type NewsRepoMock struct {
+	NewsToReturn       core.News
+	ErrGetNewsToReturn error
+	ErrNotifyToReturn  error
+	GetNewsByIdCalls   atomic.Int32
+	GetAllNewsCalls    atomic.Int32
+	GetNewsByIdsCalls  atomic.Int32
+	Notified           []string
+	// Release, when set, holds reads until it's closed
+	Release chan struct{}
+}
+
+func (m *NewsRepoMock) AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error) {
+	return 2, nil
+}
+
+func (m *NewsRepoMock) DeleteNews(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (m *NewsRepoMock) GetAllNews(ctx context.Context) ([]core.News, error) {
+	m.GetAllNewsCalls.Add(1)
+	return []core.News{m.NewsToReturn}, nil
+}
+
+func (m *NewsRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	m.GetNewsByIdCalls.Add(1)
+	if m.Release != nil {
+		<-m.Release
+	}
+	if m.ErrGetNewsToReturn != nil {
+		return core.News{}, m.ErrGetNewsToReturn
+	}
+	return m.NewsToReturn, nil
+}
+
+func (m *NewsRepoMock) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	m.GetNewsByIdsCalls.Add(1)
+	var news []core.News
+	for _, id := range ids {
+		n := m.NewsToReturn
+		n.ID = id
+		news = append(news, n)
+	}
+	return news, nil
+}
+
+func (m *NewsRepoMock) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	return core.GetNewsStatsRow{Count: 1}, nil
+}
+
+func (m *NewsRepoMock) GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error) {
+	return pgtype.Timestamp{}, nil
+}
+
+func (m *NewsRepoMock) UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error {
+	return nil
+}
+
+func (m *NewsRepoMock) PublishNews(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (m *NewsRepoMock) PinNews(ctx context.Context, arg core.PinNewsParams) error {
+	return nil
+}
+
+func (m *NewsRepoMock) UnpinNews(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (m *NewsRepoMock) NotifyNewsChanged(ctx context.Context, payload string) error {
+	m.Notified = append(m.Notified, payload)
+	return m.ErrNotifyToReturn
+}
+
+func TestGetNewsById(t *testing.T) {
+	repo := &NewsRepoMock{NewsToReturn: core.News{ID: 1, Status: "draft"}}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	for range 3 {
+		news, err := cache.GetNewsById(context.Background(), 1)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news, repo.NewsToReturn)
+	}
+
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(1))
+	assert.Equal(t, cache.Stats().Hits, uint64(2))
+	assert.Equal(t, cache.Stats().Misses, uint64(1))
+}
+
+func TestGetNewsByIdErrorIsNotCached(t *testing.T) {
+	repo := &NewsRepoMock{ErrGetNewsToReturn: errors.New("some unexpected error")}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	_, err := cache.GetNewsById(context.Background(), 1)
+	assert.Equal(t, err, repo.ErrGetNewsToReturn)
+
+	repo.ErrGetNewsToReturn = nil
+	_, err = cache.GetNewsById(context.Background(), 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(2))
+}
+
+func TestGetNewsByIdsReadsMissing(t *testing.T) {
+	repo := &NewsRepoMock{NewsToReturn: core.News{ID: 1}}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	cache.GetNewsById(context.Background(), 1)
+
+	news, err := cache.GetNewsByIds(context.Background(), []int32{1, 2, 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(news), 3)
+	assert.Equal(t, repo.GetNewsByIdsCalls.Load(), int32(1))
+
+	// what the batch read is cached like single loads
+	news, err = cache.GetNewsByIds(context.Background(), []int32{2, 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(news), 2)
+	_, err = cache.GetNewsById(context.Background(), 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.GetNewsByIdsCalls.Load(), int32(1))
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(1))
+}
+
+func TestConcurrentMissesShareLoad(t *testing.T) {
+	repo := &NewsRepoMock{
+		NewsToReturn: core.News{ID: 1},
+		Release:      make(chan struct{}),
+	}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	wg := sync.WaitGroup{}
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.GetNewsById(context.Background(), 1)
+		}()
+	}
+
+	// give the readers a moment to pile up on the same load
+	time.Sleep(50 * time.Millisecond)
+	close(repo.Release)
+	wg.Wait()
+
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(1))
+}
+
+func TestWritesInvalidate(t *testing.T) {
+	testTable := []struct {
+		Name  string
+		Write func(cache *NewsRepo) error
+	}{
+		{
+			Name: "Update",
+			Write: func(cache *NewsRepo) error {
+				return cache.UpdateNews(context.Background(), core.UpdateNewsParams{ID: 1})
+			},
+		},
+		{
+			Name: "Publish",
+			Write: func(cache *NewsRepo) error {
+				return cache.PublishNews(context.Background(), 1)
+			},
+		},
+		{
+			Name: "Delete",
+			Write: func(cache *NewsRepo) error {
+				return cache.DeleteNews(context.Background(), 1)
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &NewsRepoMock{NewsToReturn: core.News{ID: 1}}
+			cache := NewNewsRepo(repo, 10, time.Minute)
+
+			cache.GetNewsById(context.Background(), 1)
+			cache.GetNewsById(context.Background(), 3)
+			cache.GetAllNews(context.Background())
+
+			err := testCase.Write(cache)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, repo.Notified, []string{"1"})
+
+			cache.GetNewsById(context.Background(), 1)
+			cache.GetNewsById(context.Background(), 3)
+			cache.GetAllNews(context.Background())
+
+			// other news stay cached
+			assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(3))
+			assert.Equal(t, repo.GetAllNewsCalls.Load(), int32(2))
+		})
+	}
+}
+
+func TestAddNewsInvalidatesList(t *testing.T) {
+	repo := &NewsRepoMock{NewsToReturn: core.News{ID: 1}}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	cache.GetNewsById(context.Background(), 1)
+	cache.GetAllNews(context.Background())
+
+	_, err := cache.AddNews(context.Background(), core.AddNewsParams{})
+	assert.Equal(t, err, nil)
+
+	cache.GetNewsById(context.Background(), 1)
+	cache.GetAllNews(context.Background())
+
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(1))
+	assert.Equal(t, repo.GetAllNewsCalls.Load(), int32(2))
+}
+
+func TestFailedNotifyKeepsWrite(t *testing.T) {
+	repo := &NewsRepoMock{ErrNotifyToReturn: errors.New("some unexpected error")}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	err := cache.DeleteNews(context.Background(), 1)
+	assert.Equal(t, err, nil)
+}
+
+func TestHandleNotification(t *testing.T) {
+	repo := &NewsRepoMock{NewsToReturn: core.News{ID: 1}}
+	cache := NewNewsRepo(repo, 10, time.Minute)
+
+	cache.GetNewsById(context.Background(), 1)
+	cache.GetNewsById(context.Background(), 3)
+
+	cache.HandleNotification("1")
+	cache.GetNewsById(context.Background(), 1)
+	cache.GetNewsById(context.Background(), 3)
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(3))
+
+	// anything unexpected drops everything
+	cache.HandleNotification("garbage")
+	cache.GetNewsById(context.Background(), 3)
+	assert.Equal(t, repo.GetNewsByIdCalls.Load(), int32(4))
+
+	// notifications aren't forwarded back to the database
+	assert.Equal(t, len(repo.Notified), 0)
+}
@@ -8,10 +8,188 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID         int32
+	Name       string
+	Prefix     string
+	SecretHash []byte
+	Scopes     []string
+	CreatedBy  int32
+	CreatedAt  pgtype.Timestamp
+	ExpiresAt  pgtype.Timestamp
+	LastUsedAt pgtype.Timestamp
+	RevokedAt  pgtype.Timestamp
+}
+
+type Author struct {
+	ID        int32
+	Name      string
+	Email     pgtype.Text
+	CreatedAt pgtype.Timestamp
+	Role      string
+}
+
+type Bookmark struct {
+	AuthorID  int32
+	NewsID    int32
+	CreatedAt pgtype.Timestamp
+}
+
+type Comment struct {
+	ID               int32
+	NewsID           int32
+	ParentID         pgtype.Int4
+	AuthorID         int32
+	Content          string
+	Status           string
+	ModerationReason pgtype.Text
+	ModeratedBy      pgtype.Int4
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+	ModeratedAt      pgtype.Timestamp
+}
+
+type FeaturedNews struct {
+	NewsID     int32
+	Position   string
+	FeaturedAt pgtype.Timestamp
+}
+
+type Media struct {
+	ID          int32
+	NewsID      int32
+	Role        string
+	ContentType string
+	Size        int64
+	Width       pgtype.Int4
+	Height      pgtype.Int4
+	StorageKey  string
+	Variants    []byte
+	UploadedBy  int32
+	CreatedAt   pgtype.Timestamp
+}
+
 type News struct {
+	ID                int32
+	Title             pgtype.Text
+	Content           pgtype.Text
+	CreatedAt         pgtype.Timestamp
+	UpdatedAt         pgtype.Timestamp
+	AuthorID          pgtype.Int4
+	UpdatedBy         pgtype.Int4
+	Status            string
+	PublishedAt       pgtype.Timestamp
+	ContentFormat     string
+	Blocks            []byte
+	CommentsCount     int32
+	Targeting         pgtype.Text
+	PinnedAt          pgtype.Timestamp
+	PinnedUntil       pgtype.Timestamp
+	ApiKeyID          pgtype.Int4
+	UpdatedByApiKeyID pgtype.Int4
+}
+
+type NewsActivity struct {
+	NewsID    int32
+	UpdatedAt pgtype.Timestamp
+}
+
+type NewsEvent struct {
+	ID        int64
+	NewsID    int32
+	Type      string
+	News      []byte
+	CreatedAt pgtype.Timestamp
+}
+
+type NewsExperiment struct {
+	NewsID    int32
+	StartedAt pgtype.Timestamp
+	StoppedAt pgtype.Timestamp
+	WinnerID  pgtype.Int4
+}
+
+type NewsRead struct {
+	AuthorID int32
+	NewsID   int32
+}
+
+type NewsVariant struct {
 	ID        int32
-	Title     pgtype.Text
-	Content   pgtype.Text
+	NewsID    int32
+	Title     string
+	Content   string
+	Weight    int32
+	Exposures int32
+	Clicks    int32
+	CreatedAt pgtype.Timestamp
+}
+
+type NewsVariantReader struct {
+	VariantID int32
+	AuthorID  int32
+	Kind      string
+}
+
+type NewsView struct {
+	NewsID int32
+	Hour   pgtype.Timestamp
+	Views  int32
+}
+
+type Outbox struct {
+	ID           int64
+	Type         string
+	Payload      []byte
+	CreatedAt    pgtype.Timestamp
+	DispatchedAt pgtype.Timestamp
+}
+
+type RateLimit struct {
+	Key       string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt pgtype.Timestamp
+	FullAt    pgtype.Timestamp
+}
+
+type Reaction struct {
+	NewsID    int32
+	AuthorID  int32
+	Kind      string
 	CreatedAt pgtype.Timestamp
+}
+
+type ReactionCount struct {
+	NewsID int32
+	Kind   string
+	Count  int32
+}
+
+type ReadMarker struct {
+	AuthorID  int32
+	ReadUpTo  pgtype.Timestamp
 	UpdatedAt pgtype.Timestamp
 }
+
+type Webhook struct {
+	ID        int32
+	Url       string
+	Secret    string
+	Events    []string
+	CreatedBy int32
+	CreatedAt pgtype.Timestamp
+}
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int32
+	OutboxID       int64
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamp
+	LastError      pgtype.Text
+	ResponseStatus pgtype.Int4
+	CreatedAt      pgtype.Timestamp
+	DeliveredAt    pgtype.Timestamp
+}
@@ -15,11 +15,21 @@ const addNews = `-- name: AddNews :one
 INSERT INTO news (
   title,
   content,
+  content_format,
+  blocks,
+  author_id,
+  targeting,
+  api_key_id,
   created_at,
   updated_at
 ) VALUES (
   $1,
   $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
   NOW(),
   NOW()
 )
@@ -27,12 +37,25 @@ RETURNING id
 `
 
 type AddNewsParams struct {
-	Title   pgtype.Text
-	Content pgtype.Text
+	Title         pgtype.Text
+	Content       pgtype.Text
+	ContentFormat string
+	Blocks        []byte
+	AuthorID      pgtype.Int4
+	Targeting     pgtype.Text
+	ApiKeyID      pgtype.Int4
 }
 
 func (q *Queries) AddNews(ctx context.Context, arg AddNewsParams) (int32, error) {
-	row := q.db.QueryRow(ctx, addNews, arg.Title, arg.Content)
+	row := q.db.QueryRow(ctx, addNews,
+		arg.Title,
+		arg.Content,
+		arg.ContentFormat,
+		arg.Blocks,
+		arg.AuthorID,
+		arg.Targeting,
+		arg.ApiKeyID,
+	)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
@@ -49,7 +72,7 @@ func (q *Queries) DeleteNews(ctx context.Context, id int32) error {
 }
 
 const getAllNews = `-- name: GetAllNews :many
-SELECT id, title, content, created_at, updated_at FROM news
+SELECT id, title, content, created_at, updated_at, author_id, updated_by, status, published_at, content_format, blocks, comments_count, targeting, pinned_at, pinned_until, api_key_id, updated_by_api_key_id FROM news
 `
 
 func (q *Queries) GetAllNews(ctx context.Context) ([]News, error) {
@@ -67,6 +90,18 @@ func (q *Queries) GetAllNews(ctx context.Context) ([]News, error) {
 			&i.Content,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AuthorID,
+			&i.UpdatedBy,
+			&i.Status,
+			&i.PublishedAt,
+			&i.ContentFormat,
+			&i.Blocks,
+			&i.CommentsCount,
+			&i.Targeting,
+			&i.PinnedAt,
+			&i.PinnedUntil,
+			&i.ApiKeyID,
+			&i.UpdatedByApiKeyID,
 		); err != nil {
 			return nil, err
 		}
@@ -78,8 +113,21 @@ func (q *Queries) GetAllNews(ctx context.Context) ([]News, error) {
 	return items, nil
 }
 
+const getNewsActivity = `-- name: GetNewsActivity :one
+SELECT MAX(updated_at)::timestamp AS updated_at FROM news_activity
+WHERE news_id = $1
+`
+
+// is null for news nothing was served along with yet
+func (q *Queries) GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error) {
+	row := q.db.QueryRow(ctx, getNewsActivity, newsID)
+	var updated_at pgtype.Timestamp
+	err := row.Scan(&updated_at)
+	return updated_at, err
+}
+
 const getNewsById = `-- name: GetNewsById :one
-SELECT id, title, content, created_at, updated_at FROM news
+SELECT id, title, content, created_at, updated_at, author_id, updated_by, status, published_at, content_format, blocks, comments_count, targeting, pinned_at, pinned_until, api_key_id, updated_by_api_key_id FROM news
 WHERE id = $1
 `
 
@@ -92,27 +140,234 @@ func (q *Queries) GetNewsById(ctx context.Context, id int32) (News, error) {
 		&i.Content,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AuthorID,
+		&i.UpdatedBy,
+		&i.Status,
+		&i.PublishedAt,
+		&i.ContentFormat,
+		&i.Blocks,
+		&i.CommentsCount,
+		&i.Targeting,
+		&i.PinnedAt,
+		&i.PinnedUntil,
+		&i.ApiKeyID,
+		&i.UpdatedByApiKeyID,
 	)
 	return i, err
 }
 
+const getNewsByIds = `-- name: GetNewsByIds :many
+SELECT id, title, content, created_at, updated_at, author_id, updated_by, status, published_at, content_format, blocks, comments_count, targeting, pinned_at, pinned_until, api_key_id, updated_by_api_key_id FROM news
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) GetNewsByIds(ctx context.Context, ids []int32) ([]News, error) {
+	rows, err := q.db.Query(ctx, getNewsByIds, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []News
+	for rows.Next() {
+		var i News
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AuthorID,
+			&i.UpdatedBy,
+			&i.Status,
+			&i.PublishedAt,
+			&i.ContentFormat,
+			&i.Blocks,
+			&i.CommentsCount,
+			&i.Targeting,
+			&i.PinnedAt,
+			&i.PinnedUntil,
+			&i.ApiKeyID,
+			&i.UpdatedByApiKeyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNewsStats = `-- name: GetNewsStats :one
+SELECT
+  COUNT(*)::int AS count,
+  -- pins running out change the order of the list as well
+  GREATEST(
+    MAX(updated_at),
+    MAX(pinned_until) FILTER (WHERE pinned_until <= NOW()),
+    -- so do media, comments and reactions, served along with news
+    (SELECT MAX(updated_at) FROM news_activity)
+  )::timestamp AS last_updated_at
+FROM news
+`
+
+type GetNewsStatsRow struct {
+	Count         int32
+	LastUpdatedAt pgtype.Timestamp
+}
+
+func (q *Queries) GetNewsStats(ctx context.Context) (GetNewsStatsRow, error) {
+	row := q.db.QueryRow(ctx, getNewsStats)
+	var i GetNewsStatsRow
+	err := row.Scan(&i.Count, &i.LastUpdatedAt)
+	return i, err
+}
+
+const getNewsWithoutBlocks = `-- name: GetNewsWithoutBlocks :many
+SELECT id, content, content_format FROM news
+WHERE blocks IS NULL AND content_format <> 'html'
+ORDER BY id
+LIMIT $1
+`
+
+type GetNewsWithoutBlocksRow struct {
+	ID            int32
+	Content       pgtype.Text
+	ContentFormat string
+}
+
+func (q *Queries) GetNewsWithoutBlocks(ctx context.Context, limit int32) ([]GetNewsWithoutBlocksRow, error) {
+	rows, err := q.db.Query(ctx, getNewsWithoutBlocks, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNewsWithoutBlocksRow
+	for rows.Next() {
+		var i GetNewsWithoutBlocksRow
+		if err := rows.Scan(&i.ID, &i.Content, &i.ContentFormat); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const notifyNewsChanged = `-- name: NotifyNewsChanged :exec
+SELECT pg_notify('news_changed', $1::text)
+`
+
+func (q *Queries) NotifyNewsChanged(ctx context.Context, payload string) error {
+	_, err := q.db.Exec(ctx, notifyNewsChanged, payload)
+	return err
+}
+
+const pinNews = `-- name: PinNews :exec
+UPDATE news
+SET
+  pinned_at = NOW(),
+  pinned_until = $2,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+type PinNewsParams struct {
+	ID          int32
+	PinnedUntil pgtype.Timestamp
+}
+
+func (q *Queries) PinNews(ctx context.Context, arg PinNewsParams) error {
+	_, err := q.db.Exec(ctx, pinNews, arg.ID, arg.PinnedUntil)
+	return err
+}
+
+const publishNews = `-- name: PublishNews :exec
+UPDATE news
+SET
+  status = 'published',
+  published_at = NOW(),
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+func (q *Queries) PublishNews(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, publishNews, id)
+	return err
+}
+
+const setNewsBlocks = `-- name: SetNewsBlocks :exec
+UPDATE news
+SET blocks = $2
+WHERE id = $1 AND blocks IS NULL
+`
+
+type SetNewsBlocksParams struct {
+	ID     int32
+	Blocks []byte
+}
+
+func (q *Queries) SetNewsBlocks(ctx context.Context, arg SetNewsBlocksParams) error {
+	_, err := q.db.Exec(ctx, setNewsBlocks, arg.ID, arg.Blocks)
+	return err
+}
+
+const unpinNews = `-- name: UnpinNews :exec
+UPDATE news
+SET
+  pinned_at = NULL,
+  pinned_until = NULL,
+  updated_at = NOW()
+WHERE
+  id = $1
+`
+
+func (q *Queries) UnpinNews(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, unpinNews, id)
+	return err
+}
+
 const updateNews = `-- name: UpdateNews :exec
 UPDATE news
 SET 
   title = $2,
   content = $3,
+  content_format = $4,
+  blocks = $5,
+  updated_by = $6,
+  targeting = $7,
+  updated_by_api_key_id = $8,
   updated_at = NOW()
 WHERE
   id = $1
 `
 
 type UpdateNewsParams struct {
-	ID      int32
-	Title   pgtype.Text
-	Content pgtype.Text
+	ID                int32
+	Title             pgtype.Text
+	Content           pgtype.Text
+	ContentFormat     string
+	Blocks            []byte
+	UpdatedBy         pgtype.Int4
+	Targeting         pgtype.Text
+	UpdatedByApiKeyID pgtype.Int4
 }
 
 func (q *Queries) UpdateNews(ctx context.Context, arg UpdateNewsParams) error {
-	_, err := q.db.Exec(ctx, updateNews, arg.ID, arg.Title, arg.Content)
+	_, err := q.db.Exec(ctx, updateNews,
+		arg.ID,
+		arg.Title,
+		arg.Content,
+		arg.ContentFormat,
+		arg.Blocks,
+		arg.UpdatedBy,
+		arg.Targeting,
+		arg.UpdatedByApiKeyID,
+	)
 	return err
 }
@@ -0,0 +1,17 @@
+//go:build integration
+
+package core_test
+
+import (
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/repotest"
+)
+
+func TestNewsRepo(t *testing.T) {
+	repotest.TestNewsRepo(t, func(t *testing.T) (repotest.NewsRepo, int32) {
+		db := pgtest.New(t)
+		return db.Queries, db.SeedAuthor(t, pgtest.AuthorFixture{})
+	})
+}
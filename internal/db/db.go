@@ -2,17 +2,63 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func Connect() *pgx.Conn {
-	db, err := pgx.Connect(context.Background(), os.Getenv("CONNECTION_STRING"))
+func Connect() *pgxpool.Pool {
+	db, err := pgxpool.New(context.Background(), os.Getenv("CONNECTION_STRING"))
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	return db
 }
+
+// Listen holds a connection of the pool to receive notifications sent to
+// channel until ctx is done. Notifications sent while the connection is
+// being reestablished are lost, onReconnect lets callers catch up on them.
+func Listen(ctx context.Context, pool *pgxpool.Pool, channel string, onNotification func(payload string), onReconnect func()) {
+	for ctx.Err() == nil {
+		err := listen(ctx, pool, channel, onNotification)
+		if ctx.Err() != nil {
+			return
+		}
+
+		fmt.Printf("listen %s: [%v]\n", channel, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+		onReconnect()
+	}
+}
+
+func listen(ctx context.Context, pool *pgxpool.Pool, channel string, onNotification func(payload string)) error {
+	pooled, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection stays subscribed, so it must never go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+channel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		onNotification(notification.Payload)
+	}
+}
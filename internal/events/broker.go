@@ -0,0 +1,219 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Channel is notified with the id of every event the news_events trigger
+// records.
+const Channel = "news_events"
+
+const (
+	TypeCreated   = "created"
+	TypeUpdated   = "updated"
+	TypePublished = "published"
+	TypeDeleted   = "deleted"
+)
+
+const (
+	maxEvents   = 100
+	pollTimeout = 5 * time.Second
+)
+
+type Event struct {
+	ID   int64
+	Type string
+	News core.News
+}
+
+// newsRow is the part of a news row, as the trigger stores it, that events
+// carry.
+type newsRow struct {
+	ID                int32           `json:"id"`
+	Title             pgtype.Text     `json:"title"`
+	Content           pgtype.Text     `json:"content"`
+	ContentFormat     string          `json:"content_format"`
+	Blocks            json.RawMessage `json:"blocks"`
+	AuthorID          pgtype.Int4     `json:"author_id"`
+	UpdatedBy         pgtype.Int4     `json:"updated_by"`
+	ApiKeyID          pgtype.Int4     `json:"api_key_id"`
+	UpdatedByApiKeyID pgtype.Int4     `json:"updated_by_api_key_id"`
+	Status            string          `json:"status"`
+	CommentsCount     int32           `json:"comments_count"`
+	Targeting         pgtype.Text     `json:"targeting"`
+}
+
+type newsEventRepo interface {
+	GetLastNewsEventId(ctx context.Context) (int64, error)
+	GetNewsEventsAfter(ctx context.Context, arg core.GetNewsEventsAfterParams) ([]core.NewsEvent, error)
+}
+
+// Broker fans the news change log out to the subscribers of this replica.
+type Broker struct {
+	newsEventRepo newsEventRepo
+	buffer        int
+
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+
+	// pollMu keeps polls in order, cursor is the last event handed out
+	pollMu sync.Mutex
+	cursor int64
+}
+
+func NewBroker(newsEventRepo newsEventRepo, buffer int) *Broker {
+	return &Broker{
+		newsEventRepo: newsEventRepo,
+		buffer:        buffer,
+		subscribers:   map[chan Event]struct{}{},
+	}
+}
+
+// Start skips the events recorded before the broker started, subscribers
+// that want them read them with EventsAfter.
+func (b *Broker) Start(ctx context.Context) error {
+	b.pollMu.Lock()
+	defer b.pollMu.Unlock()
+
+	cursor, err := b.newsEventRepo.GetLastNewsEventId(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	b.cursor = cursor
+	return nil
+}
+
+// Subscribe returns the events published from now on. The channel is closed
+// when the subscriber can't keep up, it has to resume from the log then.
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	events := make(chan Event, b.buffer)
+
+	b.mu.Lock()
+	b.subscribers[events] = struct{}{}
+	b.mu.Unlock()
+
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.drop(events)
+	}
+}
+
+// EventsAfter reads a batch of the log, an empty batch means the caller has
+// caught up.
+func (b *Broker) EventsAfter(ctx context.Context, id int64) ([]Event, error) {
+	rows, err := b.newsEventRepo.GetNewsEventsAfter(ctx, core.GetNewsEventsAfterParams{
+		ID:        id,
+		MaxEvents: maxEvents,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	events := make([]Event, 0, len(rows))
+	for _, row := range rows {
+		event, err := Decode(row)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// Decode reads the news row the trigger stored along with the event.
+func Decode(row core.NewsEvent) (Event, error) {
+	var news newsRow
+	err := json.Unmarshal(row.News, &news)
+	if err != nil {
+		return Event{}, fmt.Errorf("can't decode news event %d: [%w]", row.ID, err)
+	}
+	// read as null for news without blocks, which the column has as NULL
+	if string(news.Blocks) == "null" {
+		news.Blocks = nil
+	}
+
+	return Event{
+		ID:   row.ID,
+		Type: row.Type,
+		News: core.News{
+			ID:                news.ID,
+			Title:             news.Title,
+			Content:           news.Content,
+			ContentFormat:     news.ContentFormat,
+			Blocks:            news.Blocks,
+			AuthorID:          news.AuthorID,
+			UpdatedBy:         news.UpdatedBy,
+			ApiKeyID:          news.ApiKeyID,
+			UpdatedByApiKeyID: news.UpdatedByApiKeyID,
+			Status:            news.Status,
+			CommentsCount:     news.CommentsCount,
+			Targeting:         news.Targeting,
+		},
+	}, nil
+}
+
+// HandleNotification publishes the announced event along with anything
+// recorded before it that wasn't published yet.
+func (b *Broker) HandleNotification(payload string) {
+	b.Poll()
+}
+
+// Poll publishes everything recorded since the last poll.
+func (b *Broker) Poll() {
+	b.pollMu.Lock()
+	defer b.pollMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), pollTimeout)
+	defer cancel()
+
+	for {
+		events, err := b.EventsAfter(ctx, b.cursor)
+		if err != nil {
+			// the next notification picks up from the same cursor
+			return
+		}
+
+		for _, event := range events {
+			b.publish(event)
+			b.cursor = event.ID
+		}
+
+		if len(events) < maxEvents {
+			return
+		}
+	}
+}
+
+func (b *Broker) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			// a slow subscriber mustn't hold up the rest
+			b.drop(events)
+		}
+	}
+}
+
+func (b *Broker) drop(events chan Event) {
+	if _, ok := b.subscribers[events]; ok {
+		delete(b.subscribers, events)
+		close(events)
+	}
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+)
+
+type NewsEventRepoMock struct {
+	Log                  []core.NewsEvent
+	ErrGetEventsToReturn error
+	ErrGetLastIdToReturn error
+}
+
+func (m *NewsEventRepoMock) GetLastNewsEventId(ctx context.Context) (int64, error) {
+	if m.ErrGetLastIdToReturn != nil {
+		return 0, m.ErrGetLastIdToReturn
+	}
+	if len(m.Log) == 0 {
+		return 0, nil
+	}
+	return m.Log[len(m.Log)-1].ID, nil
+}
+
+func (m *NewsEventRepoMock) GetNewsEventsAfter(ctx context.Context, arg core.GetNewsEventsAfterParams) ([]core.NewsEvent, error) {
+	if m.ErrGetEventsToReturn != nil {
+		return nil, m.ErrGetEventsToReturn
+	}
+
+	var result []core.NewsEvent
+	for _, event := range m.Log {
+		if event.ID > arg.ID && len(result) < int(arg.MaxEvents) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (m *NewsEventRepoMock) record(eventType string, newsId int32) {
+	m.Log = append(m.Log, core.NewsEvent{
+		ID:     int64(len(m.Log) + 1),
+		NewsID: newsId,
+		Type:   eventType,
+		News:   []byte(fmt.Sprintf(`{"id": %d, "title": "some title", "author_id": 1, "updated_by": null, "status": "draft", "created_at": "2024-06-01T12:00:00"}`, newsId)),
+	})
+}
+
+func TestEventsAfter(t *testing.T) {
+	repo := &NewsEventRepoMock{}
+	repo.record(TypeCreated, 1)
+	repo.record(TypeUpdated, 1)
+	broker := NewBroker(repo, 1)
+
+	events, err := broker.EventsAfter(context.Background(), 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 1)
+	assert.Equal(t, events[0].ID, int64(2))
+	assert.Equal(t, events[0].Type, TypeUpdated)
+	assert.Equal(t, events[0].News.ID, int32(1))
+	assert.Equal(t, events[0].News.Title.String, "some title")
+	assert.Equal(t, events[0].News.AuthorID.Int32, int32(1))
+	assert.Equal(t, events[0].News.UpdatedBy.Valid, false)
+
+	repo.ErrGetEventsToReturn = errors.New("some unexpected error")
+	_, err = broker.EventsAfter(context.Background(), 1)
+	assert.Equal(t, errors.Is(err, pkg.ErrDbInternal), true)
+}
+
+func TestPoll(t *testing.T) {
+	repo := &NewsEventRepoMock{}
+	repo.record(TypeCreated, 1)
+	broker := NewBroker(repo, maxEvents*2)
+
+	err := broker.Start(context.Background())
+	assert.Equal(t, err, nil)
+
+	subscription, unsubscribe := broker.Subscribe()
+	defer unsubscribe()
+
+	// more than a single batch, all of it after the start
+	for i := range maxEvents + 1 {
+		repo.record(TypeUpdated, int32(i))
+	}
+	broker.HandleNotification("2")
+
+	assert.Equal(t, len(subscription), maxEvents+1)
+	first := <-subscription
+	assert.Equal(t, first.ID, int64(2))
+
+	// nothing new, nothing published twice
+	broker.Poll()
+	assert.Equal(t, len(subscription), maxEvents)
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	repo := &NewsEventRepoMock{}
+	broker := NewBroker(repo, 1)
+
+	slow, unsubscribeSlow := broker.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := broker.Subscribe()
+	defer unsubscribeFast()
+
+	repo.record(TypeCreated, 1)
+	broker.Poll()
+	<-fast
+
+	repo.record(TypeDeleted, 1)
+	broker.Poll()
+
+	event := <-fast
+	assert.Equal(t, event.Type, TypeDeleted)
+
+	<-slow
+	_, ok := <-slow
+	assert.Equal(t, ok, false)
+}
+
+func TestStartErr(t *testing.T) {
+	repo := &NewsEventRepoMock{ErrGetLastIdToReturn: errors.New("some unexpected error")}
+	broker := NewBroker(repo, 1)
+
+	err := broker.Start(context.Background())
+	assert.Equal(t, errors.Is(err, pkg.ErrDbInternal), true)
+}
@@ -0,0 +1,346 @@
+// Package memory keeps what the queries of core read and write in memory,
+// for tests and running locally without Postgres.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxTitleLength is the length of the title column, VARCHAR(255).
+const maxTitleLength = 255
+
+// NewsRepo answers the news queries the way Postgres does with the schema
+// in migrations: ids come from a sequence that failed inserts use up as
+// well, titles are unique, authors have to exist, rows that aren't there
+// are pgx.ErrNoRows for queries returning one and nothing for the rest.
+type NewsRepo struct {
+	mu      sync.RWMutex
+	news    map[int32]core.News
+	authors map[int32]bool
+	lastId  int32
+	now     func() time.Time
+}
+
+func NewNewsRepo() *NewsRepo {
+	return &NewsRepo{
+		news:    map[int32]core.News{},
+		authors: map[int32]bool{},
+		now:     time.Now,
+	}
+}
+
+// AddAuthor lets news reference the author, which is what the authors table
+// is for in Postgres.
+func (r *NewsRepo) AddAuthor(id int32) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.authors[id] = true
+}
+
+func (r *NewsRepo) AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error) {
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the sequence isn't rolled back when the insert fails
+	r.lastId++
+	id := r.lastId
+
+	err := r.checkTitle(id, arg.Title)
+	if err != nil {
+		return 0, err
+	}
+	err = r.checkAuthor("author_id", arg.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := r.timestamp()
+	r.news[id] = core.News{
+		ID:            id,
+		Title:         arg.Title,
+		Content:       arg.Content,
+		ContentFormat: arg.ContentFormat,
+		Blocks:        arg.Blocks,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		AuthorID:      arg.AuthorID,
+		Targeting:     arg.Targeting,
+		ApiKeyID:      arg.ApiKeyID,
+		Status:        authz.StatusDraft,
+	}
+	return id, nil
+}
+
+func (r *NewsRepo) UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	news, ok := r.news[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	err := r.checkTitle(arg.ID, arg.Title)
+	if err != nil {
+		return err
+	}
+	err = r.checkAuthor("updated_by", arg.UpdatedBy)
+	if err != nil {
+		return err
+	}
+
+	news.Title = arg.Title
+	news.Content = arg.Content
+	news.ContentFormat = arg.ContentFormat
+	news.Blocks = arg.Blocks
+	news.UpdatedBy = arg.UpdatedBy
+	news.Targeting = arg.Targeting
+	news.UpdatedByApiKeyID = arg.UpdatedByApiKeyID
+	news.UpdatedAt = r.timestamp()
+	r.news[arg.ID] = news
+	return nil
+}
+
+func (r *NewsRepo) PublishNews(ctx context.Context, id int32) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	news, ok := r.news[id]
+	if !ok {
+		return nil
+	}
+
+	now := r.timestamp()
+	news.Status = authz.StatusPublished
+	news.PublishedAt = now
+	news.UpdatedAt = now
+	r.news[id] = news
+	return nil
+}
+
+func (r *NewsRepo) PinNews(ctx context.Context, arg core.PinNewsParams) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	news, ok := r.news[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	now := r.timestamp()
+	news.PinnedAt = now
+	news.PinnedUntil = arg.PinnedUntil
+	news.UpdatedAt = now
+	r.news[arg.ID] = news
+	return nil
+}
+
+func (r *NewsRepo) UnpinNews(ctx context.Context, id int32) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	news, ok := r.news[id]
+	if !ok {
+		return nil
+	}
+
+	news.PinnedAt = pgtype.Timestamp{}
+	news.PinnedUntil = pgtype.Timestamp{}
+	news.UpdatedAt = r.timestamp()
+	r.news[id] = news
+	return nil
+}
+
+func (r *NewsRepo) DeleteNews(ctx context.Context, id int32) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.news, id)
+	return nil
+}
+
+func (r *NewsRepo) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if ctx.Err() != nil {
+		return core.News{}, ctx.Err()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	news, ok := r.news[id]
+	if !ok {
+		return core.News{}, pgx.ErrNoRows
+	}
+	return news, nil
+}
+
+// GetNewsByIds returns news once however often their id is asked for and
+// leaves out the ids there are no news for, as the query does.
+func (r *NewsRepo) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	var items []core.News
+	for _, id := range slices.Compact(ids) {
+		news, ok := r.news[id]
+		if ok {
+			items = append(items, news)
+		}
+	}
+	return items, nil
+}
+
+// GetAllNews orders news by id, which the query doesn't promise but Postgres
+// does for a table that's only appended to. No news is a nil slice, as sqlc
+// returns it.
+func (r *NewsRepo) GetAllNews(ctx context.Context) ([]core.News, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var items []core.News
+	for _, news := range r.news {
+		items = append(items, news)
+	}
+	slices.SortFunc(items, func(a, b core.News) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return items, nil
+}
+
+func (r *NewsRepo) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	if ctx.Err() != nil {
+		return core.GetNewsStatsRow{}, ctx.Err()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := core.GetNewsStatsRow{
+		Count: int32(len(r.news)),
+	}
+	now := r.now()
+	for _, news := range r.news {
+		if !stats.LastUpdatedAt.Valid || news.UpdatedAt.Time.After(stats.LastUpdatedAt.Time) {
+			stats.LastUpdatedAt = news.UpdatedAt
+		}
+		// pins running out change the order of the list as well
+		expired := news.PinnedUntil.Valid && !news.PinnedUntil.Time.After(now)
+		if expired && news.PinnedUntil.Time.After(stats.LastUpdatedAt.Time) {
+			stats.LastUpdatedAt = news.PinnedUntil
+		}
+	}
+	return stats, nil
+}
+
+// GetNewsActivity is always null, nothing is served along with news kept in
+// memory.
+func (r *NewsRepo) GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error) {
+	if ctx.Err() != nil {
+		return pgtype.Timestamp{}, ctx.Err()
+	}
+
+	return pgtype.Timestamp{}, nil
+}
+
+// timestamp is NOW() read back from a TIMESTAMP column.
+func (r *NewsRepo) timestamp() pgtype.Timestamp {
+	return pgtype.Timestamp{
+		Time:  r.now().UTC().Truncate(time.Microsecond),
+		Valid: true,
+	}
+}
+
+// checkTitle enforces the type and the unique constraint of the title
+// column. NULLs never conflict.
+func (r *NewsRepo) checkTitle(id int32, title pgtype.Text) error {
+	if !title.Valid {
+		return nil
+	}
+
+	if utf8.RuneCountInString(title.String) > maxTitleLength {
+		return &pgconn.PgError{
+			Severity: "ERROR",
+			Code:     "22001",
+			Message:  fmt.Sprintf("value too long for type character varying(%d)", maxTitleLength),
+		}
+	}
+
+	for _, news := range r.news {
+		if news.ID != id && news.Title.Valid && news.Title.String == title.String {
+			return &pgconn.PgError{
+				Severity:       "ERROR",
+				Code:           "23505",
+				Message:        `duplicate key value violates unique constraint "news_title_key"`,
+				Detail:         fmt.Sprintf("Key (title)=(%s) already exists.", title.String),
+				TableName:      "news",
+				ConstraintName: "news_title_key",
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkAuthor enforces the foreign keys to authors.
+func (r *NewsRepo) checkAuthor(column string, author pgtype.Int4) error {
+	if !author.Valid || r.authors[author.Int32] {
+		return nil
+	}
+
+	constraint := "news_" + column + "_fkey"
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf(`insert or update on table "news" violates foreign key constraint "%s"`, constraint),
+		Detail:         fmt.Sprintf(`Key (%s)=(%d) is not present in table "authors".`, column, author.Int32),
+		TableName:      "news",
+		ConstraintName: constraint,
+	}
+}
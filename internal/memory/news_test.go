@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/repotest"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNewsRepo(t *testing.T) {
+	repotest.TestNewsRepo(t, func(t *testing.T) (repotest.NewsRepo, int32) {
+		repo := NewNewsRepo()
+		repo.AddAuthor(1)
+		return repo, 1
+	})
+}
+
+func TestFailedAddUsesUpId(t *testing.T) {
+	repo := NewNewsRepo()
+	ctx := context.Background()
+	params := core.AddNewsParams{Title: pgtype.Text{String: "some title", Valid: true}}
+
+	first, err := repo.AddNews(ctx, params)
+	assert.Equal(t, err, nil)
+	_, err = repo.AddNews(ctx, params)
+	assert.NotEqual(t, err, nil)
+
+	params.Title.String = "other title"
+	third, err := repo.AddNews(ctx, params)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, third, first+2)
+}
+
+func TestConcurrentAddsKeepTitlesUnique(t *testing.T) {
+	repo := NewNewsRepo()
+	params := core.AddNewsParams{Title: pgtype.Text{String: "some title", Valid: true}}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var added int
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.AddNews(context.Background(), params)
+			if err == nil {
+				mu.Lock()
+				added++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, added, 1)
+}
@@ -0,0 +1,210 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	// StatusDead is where deliveries end up after running out of attempts,
+	// only a replay sends them again
+	StatusDead = "dead"
+)
+
+const (
+	maxEvents     = 100
+	maxDeliveries = 50
+	// maxConcurrentDeliveries receivers are sent to at once, so that a batch
+	// of slow ones still fits in the lease
+	maxConcurrentDeliveries = 10
+	// lease is how long a claimed delivery is hidden from other dispatchers
+	lease = time.Minute
+	// leaseMargin is left at the end of the lease to record the attempts
+	// before other dispatchers can claim the deliveries again
+	leaseMargin = 10 * time.Second
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+	// maxErrorLength keeps whatever a receiver answers out of the database
+	maxErrorLength = 512
+)
+
+type deliveryRepo interface {
+	ClaimWebhookDeliveries(ctx context.Context, arg core.ClaimWebhookDeliveriesParams) ([]core.ClaimWebhookDeliveriesRow, error)
+	FanOutOutbox(ctx context.Context, arg core.FanOutOutboxParams) (int64, error)
+	MarkWebhookDeliveryDelivered(ctx context.Context, arg core.MarkWebhookDeliveryDeliveredParams) error
+	MarkWebhookDeliveryFailed(ctx context.Context, arg core.MarkWebhookDeliveryFailedParams) error
+}
+
+type Dispatcher struct {
+	deliveryRepo deliveryRepo
+	client       *http.Client
+	maxAttempts  int
+	now          func() time.Time
+}
+
+func NewDispatcher(deliveryRepo deliveryRepo, client *http.Client, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		deliveryRepo: deliveryRepo,
+		client:       client,
+		maxAttempts:  maxAttempts,
+		now:          time.Now,
+	}
+}
+
+// Run dispatches every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := d.Dispatch(ctx)
+		if err != nil {
+			fmt.Printf("can't dispatch webhooks: [%v]\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch turns pending outbox events into deliveries and makes an attempt
+// at every delivery that is due.
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	// deliveries are due and claimed by the same clock, next_attempt_at
+	// holds UTC whatever the time zone of the database
+	now := d.now().UTC()
+	_, err := d.deliveryRepo.FanOutOutbox(ctx, core.FanOutOutboxParams{
+		MaxEvents: maxEvents,
+		Now:       pgtype.Timestamp{Time: now, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := d.deliveryRepo.ClaimWebhookDeliveries(ctx, core.ClaimWebhookDeliveriesParams{
+		LeaseUntil:    pgtype.Timestamp{Time: now.Add(lease), Valid: true},
+		Now:           pgtype.Timestamp{Time: now, Valid: true},
+		MaxDeliveries: maxDeliveries,
+	})
+	if err != nil {
+		return err
+	}
+
+	// nothing is sent once the lease is about to run out, deliveries that
+	// weren't sent by then are claimed again without being sent twice
+	sendCtx, cancel := context.WithTimeout(ctx, lease-leaseMargin)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	sending := make(chan struct{}, maxConcurrentDeliveries)
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		sending <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sending }()
+
+			// a delivery that can't be recorded is claimed again once its
+			// lease runs out, it mustn't hold up the rest of the batch
+			err := d.deliver(ctx, sendCtx, delivery)
+			if err != nil {
+				fmt.Printf("can't record webhook delivery %d: [%v]\n", delivery.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// deliver sends the delivery within sendCtx and records the attempt within ctx.
+func (d *Dispatcher) deliver(ctx context.Context, sendCtx context.Context, delivery core.ClaimWebhookDeliveriesRow) error {
+	if sendCtx.Err() != nil {
+		return nil
+	}
+
+	status, err := d.send(sendCtx, delivery)
+	responseStatus := pgtype.Int4{Int32: int32(status), Valid: status != 0}
+	if err == nil {
+		return d.deliveryRepo.MarkWebhookDeliveryDelivered(ctx, core.MarkWebhookDeliveryDeliveredParams{
+			ResponseStatus: responseStatus,
+			DeliveredAt:    pgtype.Timestamp{Time: d.now().UTC(), Valid: true},
+			ID:             delivery.ID,
+		})
+	}
+
+	attempts := int(delivery.Attempts) + 1
+	next := StatusPending
+	if attempts >= d.maxAttempts {
+		next = StatusDead
+	}
+
+	lastError := err.Error()
+	if len(lastError) > maxErrorLength {
+		lastError = lastError[:maxErrorLength]
+	}
+
+	return d.deliveryRepo.MarkWebhookDeliveryFailed(ctx, core.MarkWebhookDeliveryFailedParams{
+		Status:         next,
+		ResponseStatus: responseStatus,
+		LastError:      pgtype.Text{String: lastError, Valid: true},
+		NextAttemptAt:  pgtype.Timestamp{Time: d.now().UTC().Add(backoff(attempts)), Valid: true},
+		ID:             delivery.ID,
+	})
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery core.ClaimWebhookDeliveriesRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryId, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEvent, delivery.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the delay with every attempt. The jitter spreads out the
+// retries of deliveries that failed together, e.g. while a receiver was down.
+func backoff(attempt int) time.Duration {
+	delay := maxBackoff
+	if attempt < 32 {
+		exponential := baseBackoff << (attempt - 1)
+		if exponential > 0 && exponential < maxBackoff {
+			delay = exponential
+		}
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
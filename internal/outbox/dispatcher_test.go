@@ -0,0 +1,233 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/go-playground/assert/v2"
+)
+
+type DeliveryRepoMock struct {
+	mu        sync.Mutex
+	Due       []core.ClaimWebhookDeliveriesRow
+	Claimed   core.ClaimWebhookDeliveriesParams
+	FannedOut core.FanOutOutboxParams
+	Delivered []core.MarkWebhookDeliveryDeliveredParams
+	Failed    []core.MarkWebhookDeliveryFailedParams
+	// ErrMarkToReturn is returned when recording the delivery ErrMarkId
+	ErrMarkToReturn error
+	ErrMarkId       int64
+}
+
+func (m *DeliveryRepoMock) ClaimWebhookDeliveries(ctx context.Context, arg core.ClaimWebhookDeliveriesParams) ([]core.ClaimWebhookDeliveriesRow, error) {
+	m.Claimed = arg
+	return m.Due, nil
+}
+
+func (m *DeliveryRepoMock) FanOutOutbox(ctx context.Context, arg core.FanOutOutboxParams) (int64, error) {
+	m.FannedOut = arg
+	return int64(len(m.Due)), nil
+}
+
+func (m *DeliveryRepoMock) MarkWebhookDeliveryDelivered(ctx context.Context, arg core.MarkWebhookDeliveryDeliveredParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ErrMarkToReturn != nil && arg.ID == m.ErrMarkId {
+		return m.ErrMarkToReturn
+	}
+	m.Delivered = append(m.Delivered, arg)
+	return nil
+}
+
+func (m *DeliveryRepoMock) MarkWebhookDeliveryFailed(ctx context.Context, arg core.MarkWebhookDeliveryFailedParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ErrMarkToReturn != nil && arg.ID == m.ErrMarkId {
+		return m.ErrMarkToReturn
+	}
+	m.Failed = append(m.Failed, arg)
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"news.published","data":{"id":1}}`)
+
+	testTable := []struct {
+		Name              string
+		Attempts          int32
+		ReceiverStatus    int
+		ExpectedDelivered bool
+		ExpectedStatus    string
+	}{
+		{
+			Name:              "Delivered",
+			ReceiverStatus:    http.StatusNoContent,
+			ExpectedDelivered: true,
+		},
+		{
+			Name:           "Retried after failure",
+			Attempts:       1,
+			ReceiverStatus: http.StatusInternalServerError,
+			ExpectedStatus: StatusPending,
+		},
+		{
+			Name:           "Dead after the last attempt",
+			Attempts:       2,
+			ReceiverStatus: http.StatusBadGateway,
+			ExpectedStatus: StatusDead,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			var verified bool
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ := io.ReadAll(r.Body)
+				timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+				verified = Verify("secret", timestamp, received, r.Header.Get(HeaderSignature)) &&
+					r.Header.Get(HeaderEvent) == EventNewsPublished &&
+					r.Header.Get(HeaderDeliveryId) == "7"
+
+				w.WriteHeader(testCase.ReceiverStatus)
+			}))
+			defer receiver.Close()
+
+			repo := &DeliveryRepoMock{
+				Due: []core.ClaimWebhookDeliveriesRow{
+					{
+						ID:       7,
+						Attempts: testCase.Attempts,
+						Url:      receiver.URL,
+						Secret:   "secret",
+						EventID:  3,
+						Type:     EventNewsPublished,
+						Payload:  body,
+					},
+				},
+			}
+			dispatcher := NewDispatcher(repo, receiver.Client(), 3)
+			dispatcher.now = func() time.Time { return now }
+
+			err := dispatcher.Dispatch(context.Background())
+			assert.Equal(t, err, nil)
+			// deliveries are due by the clock they're claimed with
+			assert.Equal(t, repo.FannedOut.Now.Time, now)
+			assert.Equal(t, repo.FannedOut.MaxEvents, int32(maxEvents))
+			assert.Equal(t, repo.Claimed.Now.Time, now)
+			assert.Equal(t, repo.Claimed.LeaseUntil.Time, now.Add(lease))
+			assert.Equal(t, verified, true)
+
+			if testCase.ExpectedDelivered {
+				assert.Equal(t, len(repo.Delivered), 1)
+				assert.Equal(t, repo.Delivered[0].ID, int64(7))
+				assert.Equal(t, repo.Delivered[0].ResponseStatus.Int32, int32(testCase.ReceiverStatus))
+				return
+			}
+
+			assert.Equal(t, len(repo.Failed), 1)
+			failed := repo.Failed[0]
+			assert.Equal(t, failed.Status, testCase.ExpectedStatus)
+			assert.Equal(t, failed.ResponseStatus.Int32, int32(testCase.ReceiverStatus))
+			assert.Equal(t, failed.LastError.Valid, true)
+			assert.Equal(t, failed.NextAttemptAt.Time.After(now), true)
+		})
+	}
+}
+
+func TestDispatchUnreachableReceiver(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	receiver.Close()
+
+	repo := &DeliveryRepoMock{
+		Due: []core.ClaimWebhookDeliveriesRow{{ID: 1, Url: receiver.URL, Type: EventNewsCreated}},
+	}
+	dispatcher := NewDispatcher(repo, http.DefaultClient, 3)
+
+	err := dispatcher.Dispatch(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(repo.Failed), 1)
+	assert.Equal(t, repo.Failed[0].Status, StatusPending)
+	assert.Equal(t, repo.Failed[0].ResponseStatus.Valid, false)
+}
+
+func TestDispatchConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	waiting := 0
+	// the receiver answers once as many requests as are sent at once arrived
+	allWaiting := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		waiting++
+		if waiting == maxConcurrentDeliveries {
+			close(allWaiting)
+		}
+		mu.Unlock()
+
+		select {
+		case <-allWaiting:
+			w.WriteHeader(http.StatusNoContent)
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}))
+	defer receiver.Close()
+
+	repo := &DeliveryRepoMock{
+		// the delivery recorded first can't be, the others still are
+		ErrMarkToReturn: errors.New("db is down"),
+		ErrMarkId:       1,
+	}
+	for id := int64(1); id <= maxConcurrentDeliveries*2; id++ {
+		repo.Due = append(repo.Due, core.ClaimWebhookDeliveriesRow{ID: id, Url: receiver.URL, Type: EventNewsCreated})
+	}
+	dispatcher := NewDispatcher(repo, receiver.Client(), 3)
+
+	err := dispatcher.Dispatch(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(repo.Failed), 0)
+	assert.Equal(t, len(repo.Delivered), maxConcurrentDeliveries*2-1)
+}
+
+func TestBackoff(t *testing.T) {
+	testTable := []struct {
+		Attempt int
+		Delay   time.Duration
+	}{
+		{Attempt: 1, Delay: baseBackoff},
+		{Attempt: 2, Delay: 2 * baseBackoff},
+		{Attempt: 5, Delay: 16 * baseBackoff},
+		{Attempt: 20, Delay: maxBackoff},
+		{Attempt: 100, Delay: maxBackoff},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(strconv.Itoa(testCase.Attempt), func(t *testing.T) {
+			for range 100 {
+				delay := backoff(testCase.Attempt)
+				assert.Equal(t, delay >= testCase.Delay/2, true)
+				assert.Equal(t, delay <= testCase.Delay, true)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"news.created"}`)
+	signature := Sign("secret", 1717243200, body)
+
+	assert.Equal(t, Verify("secret", 1717243200, body, signature), true)
+	assert.Equal(t, Verify("other", 1717243200, body, signature), false)
+	assert.Equal(t, Verify("secret", 1717243201, body, signature), false)
+	assert.Equal(t, Verify("secret", 1717243200, []byte(`{}`), signature), false)
+}
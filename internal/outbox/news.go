@@ -0,0 +1,168 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	EventNewsCreated   = "news.created"
+	EventNewsUpdated   = "news.updated"
+	EventNewsPublished = "news.published"
+	EventNewsDeleted   = "news.deleted"
+)
+
+// Events lists what webhooks can subscribe to.
+var Events = []string{
+	EventNewsCreated,
+	EventNewsUpdated,
+	EventNewsPublished,
+	EventNewsDeleted,
+}
+
+// Event is the body webhooks receive.
+type Event struct {
+	Type       string            `json:"type"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Data       response.NewsData `json:"data"`
+}
+
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// NewsRepo records an outbox event in the same transaction as every news
+// write, so an event is only ever sent for a change that was committed.
+// Reads go straight to the queries.
+type NewsRepo struct {
+	*core.Queries
+	db txBeginner
+}
+
+func NewNewsRepo(db txBeginner, queries *core.Queries) *NewsRepo {
+	return &NewsRepo{
+		Queries: queries,
+		db:      db,
+	}
+}
+
+func (r *NewsRepo) AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error) {
+	var id int32
+	err := r.inTx(ctx, func(q *core.Queries) error {
+		var err error
+		id, err = q.AddNews(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, EventNewsCreated, id)
+	})
+
+	return id, err
+}
+
+func (r *NewsRepo) UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error {
+	return r.inTx(ctx, func(q *core.Queries) error {
+		err := q.UpdateNews(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, EventNewsUpdated, arg.ID)
+	})
+}
+
+func (r *NewsRepo) PublishNews(ctx context.Context, id int32) error {
+	return r.inTx(ctx, func(q *core.Queries) error {
+		err := q.PublishNews(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, EventNewsPublished, id)
+	})
+}
+
+func (r *NewsRepo) DeleteNews(ctx context.Context, id int32) error {
+	return r.inTx(ctx, func(q *core.Queries) error {
+		// read first, the event describes what was deleted
+		news, err := q.GetNewsById(ctx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		err = q.DeleteNews(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return addNewsEvent(ctx, q, EventNewsDeleted, news)
+	})
+}
+
+func (r *NewsRepo) inTx(ctx context.Context, fn func(q *core.Queries) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// a no-op once committed
+	defer tx.Rollback(ctx)
+
+	err = fn(r.Queries.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func addEvent(ctx context.Context, q *core.Queries, eventType string, id int32) error {
+	news, err := q.GetNewsById(ctx, id)
+	if err != nil {
+		// nothing was written, so there's nothing to announce
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	return addNewsEvent(ctx, q, eventType, news)
+}
+
+func addNewsEvent(ctx context.Context, q *core.Queries, eventType string, news core.News) error {
+	payload, err := json.Marshal(Event{
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data: response.NewsData{
+			Id:                int(news.ID),
+			Title:             news.Title.String,
+			Content:           news.Content.String,
+			ContentFormat:     news.ContentFormat,
+			Blocks:            news.Blocks,
+			AuthorId:          int(news.AuthorID.Int32),
+			UpdatedBy:         int(news.UpdatedBy.Int32),
+			ApiKeyId:          int(news.ApiKeyID.Int32),
+			UpdatedByApiKeyId: int(news.UpdatedByApiKeyID.Int32),
+			Status:            news.Status,
+			CommentsCount:     int(news.CommentsCount),
+			Targeting:         news.Targeting.String,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.AddOutboxEvent(ctx, core.AddOutboxEventParams{
+		Type:    eventType,
+		Payload: payload,
+	})
+}
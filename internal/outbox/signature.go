@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	HeaderDeliveryId = "X-Webhook-Delivery-Id"
+	HeaderEvent      = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// Sign returns the X-Webhook-Signature of a delivery. The timestamp is signed
+// along with the body so receivers can reject captured requests replayed
+// later.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
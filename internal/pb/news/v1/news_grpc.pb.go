@@ -0,0 +1,287 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: news/v1/news.proto
+
+package newsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	NewsService_CreateNews_FullMethodName = "/news.v1.NewsService/CreateNews"
+	NewsService_UpdateNews_FullMethodName = "/news.v1.NewsService/UpdateNews"
+	NewsService_GetNews_FullMethodName    = "/news.v1.NewsService/GetNews"
+	NewsService_ListNews_FullMethodName   = "/news.v1.NewsService/ListNews"
+	NewsService_DeleteNews_FullMethodName = "/news.v1.NewsService/DeleteNews"
+)
+
+// NewsServiceClient is the client API for NewsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// NewsService exposes the same news as the REST api. Reads are public,
+// writes need an authorization metadata entry in the same format as the
+// Authorization header, either "Bearer <jwt>" or "ApiKey <key>".
+type NewsServiceClient interface {
+	CreateNews(ctx context.Context, in *CreateNewsRequest, opts ...grpc.CallOption) (*CreateNewsResponse, error)
+	UpdateNews(ctx context.Context, in *UpdateNewsRequest, opts ...grpc.CallOption) (*UpdateNewsResponse, error)
+	GetNews(ctx context.Context, in *GetNewsRequest, opts ...grpc.CallOption) (*GetNewsResponse, error)
+	// ListNews streams every news, one message per news.
+	ListNews(ctx context.Context, in *ListNewsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListNewsResponse], error)
+	DeleteNews(ctx context.Context, in *DeleteNewsRequest, opts ...grpc.CallOption) (*DeleteNewsResponse, error)
+}
+
+type newsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNewsServiceClient(cc grpc.ClientConnInterface) NewsServiceClient {
+	return &newsServiceClient{cc}
+}
+
+func (c *newsServiceClient) CreateNews(ctx context.Context, in *CreateNewsRequest, opts ...grpc.CallOption) (*CreateNewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateNewsResponse)
+	err := c.cc.Invoke(ctx, NewsService_CreateNews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) UpdateNews(ctx context.Context, in *UpdateNewsRequest, opts ...grpc.CallOption) (*UpdateNewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateNewsResponse)
+	err := c.cc.Invoke(ctx, NewsService_UpdateNews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) GetNews(ctx context.Context, in *GetNewsRequest, opts ...grpc.CallOption) (*GetNewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetNewsResponse)
+	err := c.cc.Invoke(ctx, NewsService_GetNews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *newsServiceClient) ListNews(ctx context.Context, in *ListNewsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ListNewsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &NewsService_ServiceDesc.Streams[0], NewsService_ListNews_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListNewsRequest, ListNewsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NewsService_ListNewsClient = grpc.ServerStreamingClient[ListNewsResponse]
+
+func (c *newsServiceClient) DeleteNews(ctx context.Context, in *DeleteNewsRequest, opts ...grpc.CallOption) (*DeleteNewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteNewsResponse)
+	err := c.cc.Invoke(ctx, NewsService_DeleteNews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NewsServiceServer is the server API for NewsService service.
+// All implementations must embed UnimplementedNewsServiceServer
+// for forward compatibility.
+//
+// NewsService exposes the same news as the REST api. Reads are public,
+// writes need an authorization metadata entry in the same format as the
+// Authorization header, either "Bearer <jwt>" or "ApiKey <key>".
+type NewsServiceServer interface {
+	CreateNews(context.Context, *CreateNewsRequest) (*CreateNewsResponse, error)
+	UpdateNews(context.Context, *UpdateNewsRequest) (*UpdateNewsResponse, error)
+	GetNews(context.Context, *GetNewsRequest) (*GetNewsResponse, error)
+	// ListNews streams every news, one message per news.
+	ListNews(*ListNewsRequest, grpc.ServerStreamingServer[ListNewsResponse]) error
+	DeleteNews(context.Context, *DeleteNewsRequest) (*DeleteNewsResponse, error)
+	mustEmbedUnimplementedNewsServiceServer()
+}
+
+// UnimplementedNewsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNewsServiceServer struct{}
+
+func (UnimplementedNewsServiceServer) CreateNews(context.Context, *CreateNewsRequest) (*CreateNewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateNews not implemented")
+}
+func (UnimplementedNewsServiceServer) UpdateNews(context.Context, *UpdateNewsRequest) (*UpdateNewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateNews not implemented")
+}
+func (UnimplementedNewsServiceServer) GetNews(context.Context, *GetNewsRequest) (*GetNewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNews not implemented")
+}
+func (UnimplementedNewsServiceServer) ListNews(*ListNewsRequest, grpc.ServerStreamingServer[ListNewsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ListNews not implemented")
+}
+func (UnimplementedNewsServiceServer) DeleteNews(context.Context, *DeleteNewsRequest) (*DeleteNewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteNews not implemented")
+}
+func (UnimplementedNewsServiceServer) mustEmbedUnimplementedNewsServiceServer() {}
+func (UnimplementedNewsServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeNewsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NewsServiceServer will
+// result in compilation errors.
+type UnsafeNewsServiceServer interface {
+	mustEmbedUnimplementedNewsServiceServer()
+}
+
+func RegisterNewsServiceServer(s grpc.ServiceRegistrar, srv NewsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedNewsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&NewsService_ServiceDesc, srv)
+}
+
+func _NewsService_CreateNews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateNewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).CreateNews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NewsService_CreateNews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).CreateNews(ctx, req.(*CreateNewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_UpdateNews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateNewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).UpdateNews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NewsService_UpdateNews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).UpdateNews(ctx, req.(*UpdateNewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_GetNews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).GetNews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NewsService_GetNews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).GetNews(ctx, req.(*GetNewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NewsService_ListNews_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListNewsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NewsServiceServer).ListNews(m, &grpc.GenericServerStream[ListNewsRequest, ListNewsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type NewsService_ListNewsServer = grpc.ServerStreamingServer[ListNewsResponse]
+
+func _NewsService_DeleteNews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteNewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NewsServiceServer).DeleteNews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NewsService_DeleteNews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NewsServiceServer).DeleteNews(ctx, req.(*DeleteNewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NewsService_ServiceDesc is the grpc.ServiceDesc for NewsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NewsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "news.v1.NewsService",
+	HandlerType: (*NewsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateNews",
+			Handler:    _NewsService_CreateNews_Handler,
+		},
+		{
+			MethodName: "UpdateNews",
+			Handler:    _NewsService_UpdateNews_Handler,
+		},
+		{
+			MethodName: "GetNews",
+			Handler:    _NewsService_GetNews_Handler,
+		},
+		{
+			MethodName: "DeleteNews",
+			Handler:    _NewsService_DeleteNews_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListNews",
+			Handler:       _NewsService_ListNews_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "news/v1/news.proto",
+}
@@ -0,0 +1,68 @@
+package pgtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+)
+
+type AuthorFixture struct {
+	Name string
+	// Role defaults to authz.RoleAuthor
+	Role authz.Role
+}
+
+// SeedAuthor inserts the author and returns its id.
+func (db *DB) SeedAuthor(t testing.TB, fixture AuthorFixture) int32 {
+	t.Helper()
+
+	if fixture.Name == "" {
+		fixture.Name = "test author"
+	}
+	if fixture.Role == "" {
+		fixture.Role = authz.RoleAuthor
+	}
+
+	return db.insert(t, "authors", map[string]any{
+		"name":       fixture.Name,
+		"role":       string(fixture.Role),
+		"created_at": time.Now().UTC(),
+	})
+}
+
+type NewsFixture struct {
+	Title   string
+	Content string
+	// AuthorID is left NULL when 0
+	AuthorID int32
+	// Status defaults to authz.StatusDraft, published news get published_at
+	Status string
+}
+
+// SeedNews inserts the news, bypassing the queries and what they record,
+// and returns its id.
+func (db *DB) SeedNews(t testing.TB, fixture NewsFixture) int32 {
+	t.Helper()
+
+	if fixture.Status == "" {
+		fixture.Status = authz.StatusDraft
+	}
+
+	now := time.Now().UTC()
+	values := map[string]any{
+		"title":      fixture.Title,
+		"content":    fixture.Content,
+		"status":     fixture.Status,
+		"created_at": now,
+		"updated_at": now,
+	}
+	if fixture.AuthorID != 0 {
+		values["author_id"] = fixture.AuthorID
+	}
+	if fixture.Status == authz.StatusPublished {
+		values["published_at"] = now
+	}
+
+	return db.insert(t, "news", values)
+}
@@ -0,0 +1,48 @@
+//go:build integration
+
+package pgtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestSchemasAreIsolated(t *testing.T) {
+	var dropped string
+	t.Run("Seed", func(t *testing.T) {
+		first := New(t)
+		second := New(t)
+		dropped = first.Schema
+
+		authorId := first.SeedAuthor(t, AuthorFixture{})
+		first.SeedNews(t, NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+
+		first.AssertCount(t, "news", 1, "status = $1 AND published_at IS NOT NULL", authz.StatusPublished)
+		second.AssertCount(t, "news", 0, "")
+		// the migrations ran in each schema
+		second.AssertCount(t, "schema_migrations", 1, "NOT dirty")
+	})
+
+	db := New(t)
+	var exists bool
+	err := db.Pool.QueryRow(context.Background(),
+		"SELECT EXISTS (SELECT 1 FROM information_schema.schemata WHERE schema_name = $1)", dropped,
+	).Scan(&exists)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exists, false)
+}
+
+func TestTxRollsBack(t *testing.T) {
+	db := New(t)
+
+	t.Run("Insert", func(t *testing.T) {
+		tx := db.Tx(t)
+		_, err := tx.Exec(context.Background(), "INSERT INTO authors (name) VALUES ('test author')")
+		assert.Equal(t, err, nil)
+	})
+
+	db.AssertCount(t, "authors", 0, "")
+}
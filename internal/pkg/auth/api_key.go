@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const apiKeyPrefix = "nk_"
+
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// ApiKey is the plaintext form of a key handed to a client once, e.g.
+// "nk_1a2b3c4d5e6f.<secret>". Only the prefix and a hash of the secret are
+// stored, the prefix is used to find the key and is safe to show in listings.
+type ApiKey struct {
+	Prefix string
+	Secret string
+}
+
+func GenerateApiKey() (ApiKey, error) {
+	prefix := make([]byte, 6)
+	secret := make([]byte, 32)
+	_, err := rand.Read(prefix)
+	if err != nil {
+		return ApiKey{}, err
+	}
+	_, err = rand.Read(secret)
+	if err != nil {
+		return ApiKey{}, err
+	}
+
+	return ApiKey{
+		Prefix: hex.EncodeToString(prefix),
+		Secret: base64.RawURLEncoding.EncodeToString(secret),
+	}, nil
+}
+
+func ParseApiKey(key string) (ApiKey, error) {
+	key, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return ApiKey{}, ErrInvalidApiKey
+	}
+
+	prefix, secret, ok := strings.Cut(key, ".")
+	if !ok || prefix == "" || secret == "" {
+		return ApiKey{}, ErrInvalidApiKey
+	}
+
+	return ApiKey{
+		Prefix: prefix,
+		Secret: secret,
+	}, nil
+}
+
+func (k ApiKey) String() string {
+	return apiKeyPrefix + k.Prefix + "." + k.Secret
+}
+
+// Hash doesn't need a slow KDF, secrets are 256 random bits rather than
+// user chosen passwords.
+func (k ApiKey) Hash() []byte {
+	hash := sha256.Sum256([]byte(k.Secret))
+	return hash[:]
+}
+
+func (k ApiKey) Matches(hash []byte) bool {
+	return subtle.ConstantTimeCompare(k.Hash(), hash) == 1
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidSubject = errors.New("invalid token subject")
+	ErrInvalidRole    = errors.New("invalid token role")
+)
+
+// Author is whoever performs a request. For api keys it is the admin who
+// created the key, with the service role and the key's scopes.
+type Author struct {
+	ID       int32
+	Name     string
+	Role     authz.Role
+	Scopes   []authz.Scope
+	ApiKeyID int32
+}
+
+func (a Author) Subject() authz.Subject {
+	return authz.Subject{
+		ID:     a.ID,
+		Role:   a.Role,
+		Scopes: a.Scopes,
+	}
+}
+
+type Claims struct {
+	Name string     `json:"name,omitempty"`
+	Role authz.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func (c Claims) Author() (Author, error) {
+	id, err := strconv.ParseInt(c.Subject, 10, 32)
+	if err != nil || id <= 0 {
+		return Author{}, ErrInvalidSubject
+	}
+
+	if !c.Role.Valid() {
+		return Author{}, ErrInvalidRole
+	}
+
+	return Author{
+		ID:   int32(id),
+		Name: c.Name,
+		Role: c.Role,
+	}, nil
+}
+
+type authorKey struct{}
+
+func WithAuthor(ctx context.Context, author Author) context.Context {
+	return context.WithValue(ctx, authorKey{}, author)
+}
+
+func AuthorFromContext(ctx context.Context) (Author, bool) {
+	author, ok := ctx.Value(authorKey{}).(Author)
+	return author, ok
+}
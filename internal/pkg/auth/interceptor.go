@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor is Middleware for gRPC. Calls without an
+// authorization metadata entry go through anonymously, the services decide
+// which of them need an author.
+func UnaryServerInterceptor(keyset *Keyset, apiKeys apiKeyAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticateIncoming(ctx, keyset, apiKeys)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls.
+func StreamServerInterceptor(keyset *Keyset, apiKeys apiKeyAuthenticator) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateIncoming(stream.Context(), keyset, apiKeys)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authenticateIncoming(ctx context.Context, keyset *Keyset, apiKeys apiKeyAuthenticator) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := md.Get("authorization")
+	if len(authorization) == 0 {
+		return ctx, nil
+	}
+
+	scheme, credentials, _ := strings.Cut(authorization[0], " ")
+	if credentials == "" {
+		return nil, status.Error(codes.Unauthenticated, pkg.ErrUnauthorized.Error())
+	}
+
+	author, err := authenticate(ctx, keyset, apiKeys, scheme, credentials)
+	if err != nil {
+		if errors.Is(err, pkg.ErrDbInternal) {
+			return nil, status.Error(codes.Internal, pkg.ErrDbInternal.Error())
+		}
+
+		return nil, status.Errorf(codes.Unauthenticated, "%v: [%v]", pkg.ErrUnauthorized, err)
+	}
+
+	return WithAuthor(ctx, author), nil
+}
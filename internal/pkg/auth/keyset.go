@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidKeyFormat = errors.New("invalid key format")
+	ErrNoSigningKey     = errors.New("no signing key configured")
+)
+
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+func NewHMACKey(id string, secret []byte) Key {
+	return Key{
+		ID:        id,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// NewEdDSAKey accepts either a private key, which can both sign and verify,
+// or a public key, which can only verify tokens signed elsewhere.
+func NewEdDSAKey(id string, key []byte) (Key, error) {
+	switch len(key) {
+	case ed25519.PrivateKeySize:
+		private := ed25519.PrivateKey(key)
+		return Key{
+			ID:        id,
+			Method:    jwt.SigningMethodEdDSA,
+			signKey:   private,
+			verifyKey: private.Public(),
+		}, nil
+	case ed25519.PublicKeySize:
+		return Key{
+			ID:        id,
+			Method:    jwt.SigningMethodEdDSA,
+			verifyKey: ed25519.PublicKey(key),
+		}, nil
+	}
+
+	return Key{}, fmt.Errorf("%w: [ed25519 key %s has length %d]", ErrInvalidKeyFormat, id, len(key))
+}
+
+// Keyset holds every key a token may still be signed with. Tokens are
+// matched to a key by their "kid" header, so old keys can stay in the set
+// for verification while new tokens are signed with the active one.
+type Keyset struct {
+	keys   map[string]Key
+	active string
+}
+
+func NewKeyset(active string, keys ...Key) *Keyset {
+	keyset := &Keyset{
+		keys:   make(map[string]Key, len(keys)),
+		active: active,
+	}
+	for _, key := range keys {
+		keyset.keys[key.ID] = key
+	}
+
+	return keyset
+}
+
+// ParseKeyset reads keys in the "kid:alg:base64,kid:alg:base64" format.
+func ParseKeyset(active string, spec string) (*Keyset, error) {
+	var keys []Key
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: [%s]", ErrInvalidKeyFormat, entry)
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: [%w]", ErrInvalidKeyFormat, err)
+		}
+
+		switch parts[1] {
+		case jwt.SigningMethodHS256.Alg():
+			keys = append(keys, NewHMACKey(parts[0], raw))
+		case jwt.SigningMethodEdDSA.Alg():
+			key, err := NewEdDSAKey(parts[0], raw)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("%w: [%s]", ErrUnsupportedAlg, parts[1])
+		}
+	}
+
+	return NewKeyset(active, keys...), nil
+}
+
+func LoadKeyset() *Keyset {
+	keyset, err := ParseKeyset(os.Getenv("JWT_ACTIVE_KID"), os.Getenv("JWT_KEYS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return keyset
+}
+
+func (k *Keyset) Sign(author Author, ttl time.Duration) (string, error) {
+	key, ok := k.keys[k.active]
+	if !ok || key.signKey == nil {
+		return "", ErrNoSigningKey
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(key.Method, Claims{
+		Name: author.Name,
+		Role: author.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   fmt.Sprint(author.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.signKey)
+}
+
+func (k *Keyset) Parse(tokenString string) (Author, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		&claims,
+		k.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Author{}, err
+	}
+
+	return claims.Author()
+}
+
+func (k *Keyset) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = k.active
+	}
+
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: [%s]", ErrUnknownKey, kid)
+	}
+
+	// a token must be signed with the algorithm its key was configured for,
+	// otherwise a public key could be abused as an HMAC secret
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: [%s]", ErrUnsupportedAlg, token.Method.Alg())
+	}
+
+	return key.verifyKey, nil
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeysetParse(t *testing.T) {
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	edKey, _ := NewEdDSAKey("ed", edPrivate)
+	edPublicKey, _ := NewEdDSAKey("ed", edPrivate.Public().(ed25519.PublicKey))
+
+	oldKeyset := NewKeyset("old", NewHMACKey("old", []byte("old secret")))
+	rotatedKeyset := NewKeyset("new", NewHMACKey("new", []byte("new secret")), NewHMACKey("old", []byte("old secret")))
+	edKeyset := NewKeyset("ed", edKey)
+
+	author := Author{ID: 1, Name: "some author", Role: authz.RoleAuthor}
+
+	testTable := []struct {
+		Name           string
+		Signer         *Keyset
+		Verifier       *Keyset
+		Author         Author
+		TTL            time.Duration
+		ExpectedError  error
+		ExpectedResult Author
+	}{
+		{
+			Name:           "Ok HS256",
+			Signer:         oldKeyset,
+			Verifier:       oldKeyset,
+			TTL:            time.Hour,
+			ExpectedResult: author,
+		},
+		{
+			Name:           "Ok EdDSA verified by public key",
+			Signer:         edKeyset,
+			Verifier:       NewKeyset("ed", edPublicKey),
+			TTL:            time.Hour,
+			ExpectedResult: author,
+		},
+		{
+			Name:           "Ok token signed with rotated out key",
+			Signer:         oldKeyset,
+			Verifier:       rotatedKeyset,
+			TTL:            time.Hour,
+			ExpectedResult: author,
+		},
+		{
+			Name:          "Err unknown key",
+			Signer:        rotatedKeyset,
+			Verifier:      oldKeyset,
+			TTL:           time.Hour,
+			ExpectedError: ErrUnknownKey,
+		},
+		{
+			Name:          "Err invalid role",
+			Signer:        oldKeyset,
+			Verifier:      oldKeyset,
+			Author:        Author{ID: 1, Role: "superuser"},
+			TTL:           time.Hour,
+			ExpectedError: ErrInvalidRole,
+		},
+		{
+			Name:          "Err expired",
+			Signer:        oldKeyset,
+			Verifier:      oldKeyset,
+			TTL:           -time.Minute,
+			ExpectedError: jwt.ErrTokenExpired,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			signed := author
+			if testCase.Author.ID != 0 {
+				signed = testCase.Author
+			}
+
+			token, err := testCase.Signer.Sign(signed, testCase.TTL)
+			assert.Equal(t, err, nil)
+
+			result, err := testCase.Verifier.Parse(token)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			assert.Equal(t, result, testCase.ExpectedResult)
+		})
+	}
+}
+
+func TestKeysetRejectsAlgorithmMismatch(t *testing.T) {
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	edPublic := edPrivate.Public().(ed25519.PublicKey)
+	edPublicKey, _ := NewEdDSAKey("ed", edPublic)
+
+	// an attacker signs an HS256 token using the public key as the secret
+	forged := NewKeyset("ed", NewHMACKey("ed", edPublic))
+	token, _ := forged.Sign(Author{ID: 1, Role: authz.RoleAuthor}, time.Hour)
+
+	_, err := NewKeyset("ed", edPublicKey).Parse(token)
+	assert.Equal(t, errors.Is(err, ErrUnsupportedAlg), true)
+}
+
+func TestParseKeyset(t *testing.T) {
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	testTable := []struct {
+		Name          string
+		Spec          string
+		ExpectedError error
+	}{
+		{
+			Name: "Ok",
+			Spec: "a:HS256:" + base64.StdEncoding.EncodeToString([]byte("secret")) +
+				",b:EdDSA:" + base64.StdEncoding.EncodeToString(edPrivate),
+		},
+		{
+			Name:          "Err missing parts",
+			Spec:          "a:HS256",
+			ExpectedError: ErrInvalidKeyFormat,
+		},
+		{
+			Name:          "Err unsupported alg",
+			Spec:          "a:RS256:" + base64.StdEncoding.EncodeToString([]byte("secret")),
+			ExpectedError: ErrUnsupportedAlg,
+		},
+		{
+			Name:          "Err bad ed25519 key",
+			Spec:          "a:EdDSA:" + base64.StdEncoding.EncodeToString([]byte("short")),
+			ExpectedError: ErrInvalidKeyFormat,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			_, err := ParseKeyset("a", testCase.Spec)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type apiKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (Author, error)
+}
+
+func Middleware(keyset *Keyset, apiKeys apiKeyAuthenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scheme, credentials, _ := strings.Cut(ctx.GetHeader("Authorization"), " ")
+		if credentials == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		author, err := authenticate(ctx, keyset, apiKeys, scheme, credentials)
+		if err != nil {
+			if errors.Is(err, pkg.ErrDbInternal) {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+					Code:  response.InternalError,
+					Error: pkg.ErrDbInternal.Error(),
+				})
+				return
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: fmt.Errorf("%w: [%w]", pkg.ErrUnauthorized, err).Error(),
+			})
+			return
+		}
+
+		ctx.Request = ctx.Request.WithContext(WithAuthor(ctx.Request.Context(), author))
+		ctx.Next()
+	}
+}
+
+// OptionalMiddleware authenticates the requests that carry credentials and
+// lets anonymous ones through, for routes where only some operations need an
+// author.
+func OptionalMiddleware(keyset *Keyset, apiKeys apiKeyAuthenticator) gin.HandlerFunc {
+	authenticate := Middleware(keyset, apiKeys)
+
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+
+		authenticate(ctx)
+	}
+}
+
+// authenticate resolves the author behind the credentials of an
+// Authorization header.
+func authenticate(ctx context.Context, keyset *Keyset, apiKeys apiKeyAuthenticator, scheme string, credentials string) (Author, error) {
+	switch scheme {
+	case "Bearer":
+		return keyset.Parse(credentials)
+	case "ApiKey":
+		return apiKeys.Authenticate(ctx, credentials)
+	default:
+		return Author{}, fmt.Errorf("unsupported authorization scheme %q", scheme)
+	}
+}
@@ -0,0 +1,186 @@
+// Package blocks describes news content as a document of typed blocks apps
+// can render natively, and converts it from and to plain text and markdown.
+package blocks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Version is the version of the documents written now. Documents of older
+// versions are upgraded by Parse once there are any.
+const Version = 1
+
+const (
+	TypeParagraph    = "paragraph"
+	TypeHeading      = "heading"
+	TypeImage        = "image"
+	TypeQuote        = "quote"
+	TypeLesson       = "lesson"
+	TypeCallToAction = "cta"
+)
+
+// maxBlocks bounds how large a document can get.
+const maxBlocks = 1000
+
+var ErrInvalidDocument = errors.New("invalid block document")
+
+type Document struct {
+	Version int     `json:"version"`
+	Blocks  []Block `json:"blocks"`
+}
+
+// Block is one of the types, using only the fields of its type:
+//
+//	paragraph  text
+//	heading    text, level from 1 to 6
+//	image      url, optional alt and caption
+//	quote      text, optional attribution
+//	lesson     lesson_id, optional title
+//	cta        label, url
+//
+// Texts are plain text, they may break lines but not have empty ones, which
+// is what separates blocks. Urls are absolute http or https ones.
+type Block struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	Level       int    `json:"level,omitempty"`
+	Url         string `json:"url,omitempty"`
+	Alt         string `json:"alt,omitempty"`
+	Caption     string `json:"caption,omitempty"`
+	Attribution string `json:"attribution,omitempty"`
+	LessonId    int    `json:"lesson_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Label       string `json:"label,omitempty"`
+}
+
+type fields struct {
+	required []string
+	optional []string
+}
+
+var schema = map[string]fields{
+	TypeParagraph:    {required: []string{"text"}},
+	TypeHeading:      {required: []string{"text", "level"}},
+	TypeImage:        {required: []string{"url"}, optional: []string{"alt", "caption"}},
+	TypeQuote:        {required: []string{"text"}, optional: []string{"attribution"}},
+	TypeLesson:       {required: []string{"lesson_id"}, optional: []string{"title"}},
+	TypeCallToAction: {required: []string{"label", "url"}},
+}
+
+// Parse reads and validates a document, fields it doesn't know are an
+// error rather than silently dropped.
+func Parse(data []byte) (Document, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var doc Document
+	err := decoder.Decode(&doc)
+	if err != nil {
+		return Document{}, fmt.Errorf("%w: [%w]", ErrInvalidDocument, err)
+	}
+
+	err = doc.Validate()
+	if err != nil {
+		return Document{}, err
+	}
+
+	return doc, nil
+}
+
+func (d Document) Validate() error {
+	if d.Version != Version {
+		return fmt.Errorf("%w: [unsupported version %d]", ErrInvalidDocument, d.Version)
+	}
+	if len(d.Blocks) == 0 {
+		return fmt.Errorf("%w: [no blocks]", ErrInvalidDocument)
+	}
+	if len(d.Blocks) > maxBlocks {
+		return fmt.Errorf("%w: [more than %d blocks]", ErrInvalidDocument, maxBlocks)
+	}
+
+	for i, block := range d.Blocks {
+		err := block.validate()
+		if err != nil {
+			return fmt.Errorf("%w: [block %d: %w]", ErrInvalidDocument, i, err)
+		}
+	}
+
+	return nil
+}
+
+func (b Block) validate() error {
+	typeFields, ok := schema[b.Type]
+	if !ok {
+		return fmt.Errorf("unknown type %q", b.Type)
+	}
+
+	set := b.setFields()
+	for _, field := range typeFields.required {
+		if !set[field] {
+			return fmt.Errorf("%s is required", field)
+		}
+		delete(set, field)
+	}
+	for _, field := range typeFields.optional {
+		delete(set, field)
+	}
+	for field := range set {
+		return fmt.Errorf("%s isn't a field of %s", field, b.Type)
+	}
+
+	if strings.Contains(b.Text, "\n\n") {
+		return errors.New("text has an empty line")
+	}
+	if b.Type == TypeHeading && (b.Level < 1 || b.Level > 6 || strings.Contains(b.Text, "\n")) {
+		return errors.New("headings are a single line of level 1 to 6")
+	}
+	if b.Type == TypeLesson && b.LessonId < 0 {
+		return errors.New("lesson_id is negative")
+	}
+	if b.Url != "" && !validUrl(b.Url) {
+		return fmt.Errorf("url %q isn't an absolute http url", b.Url)
+	}
+	for _, text := range []string{b.Alt, b.Caption, b.Attribution, b.Title, b.Label} {
+		if strings.Contains(text, "\n") {
+			return errors.New("only text can break lines")
+		}
+	}
+
+	return nil
+}
+
+func (b Block) setFields() map[string]bool {
+	set := map[string]bool{}
+	for field, value := range map[string]bool{
+		"text":        b.Text != "",
+		"level":       b.Level != 0,
+		"url":         b.Url != "",
+		"alt":         b.Alt != "",
+		"caption":     b.Caption != "",
+		"attribution": b.Attribution != "",
+		"lesson_id":   b.LessonId != 0,
+		"title":       b.Title != "",
+		"label":       b.Label != "",
+	} {
+		if value {
+			set[field] = true
+		}
+	}
+
+	return set
+}
+
+// validUrl also keeps out what can't be written as a markdown destination.
+func validUrl(rawUrl string) bool {
+	if strings.ContainsAny(rawUrl, " \t\n<>") {
+		return false
+	}
+
+	u, err := url.Parse(rawUrl)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
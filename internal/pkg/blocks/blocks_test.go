@@ -0,0 +1,211 @@
+package blocks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestParse(t *testing.T) {
+	testTable := []struct {
+		Name          string
+		Data          string
+		ExpectedError bool
+	}{
+		{
+			Name: "Ok every type",
+			Data: `{"version": 1, "blocks": [
+				{"type": "heading", "text": "Title", "level": 1},
+				{"type": "paragraph", "text": "some\ntext"},
+				{"type": "image", "url": "https://example.com/a.png", "alt": "a", "caption": "some caption"},
+				{"type": "quote", "text": "some quote", "attribution": "someone"},
+				{"type": "lesson", "lesson_id": 42, "title": "First lesson"},
+				{"type": "cta", "label": "Start", "url": "https://example.com/start"}
+			]}`,
+		},
+		{
+			Name:          "Error not json",
+			Data:          `blocks`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unknown field",
+			Data:          `{"version": 1, "blocks": [{"type": "paragraph", "text": "some", "color": "red"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unsupported version",
+			Data:          `{"version": 2, "blocks": [{"type": "paragraph", "text": "some"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error no version",
+			Data:          `{"blocks": [{"type": "paragraph", "text": "some"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error no blocks",
+			Data:          `{"version": 1, "blocks": []}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unknown type",
+			Data:          `{"version": 1, "blocks": [{"type": "video", "url": "https://example.com/a.mp4"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error missing required field",
+			Data:          `{"version": 1, "blocks": [{"type": "heading", "text": "Title"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error field of another type",
+			Data:          `{"version": 1, "blocks": [{"type": "paragraph", "text": "some", "url": "https://example.com"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error heading level",
+			Data:          `{"version": 1, "blocks": [{"type": "heading", "text": "Title", "level": 7}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error empty line",
+			Data:          `{"version": 1, "blocks": [{"type": "paragraph", "text": "some\n\ntext"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error javascript url",
+			Data:          `{"version": 1, "blocks": [{"type": "cta", "label": "Start", "url": "javascript:alert(1)"}]}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error relative url",
+			Data:          `{"version": 1, "blocks": [{"type": "image", "url": "/a.png"}]}`,
+			ExpectedError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			_, err := Parse([]byte(testCase.Data))
+
+			assert.Equal(t, err != nil, testCase.ExpectedError)
+			if testCase.ExpectedError {
+				assert.Equal(t, errors.Is(err, ErrInvalidDocument), true)
+			}
+		})
+	}
+}
+
+func TestPlain(t *testing.T) {
+	doc := FromPlain("first line\nsecond line\n\n\n  \nsecond paragraph\n")
+	assert.Equal(t, doc, Document{
+		Version: Version,
+		Blocks: []Block{
+			{Type: TypeParagraph, Text: "first line\nsecond line"},
+			{Type: TypeParagraph, Text: "second paragraph"},
+		},
+	})
+	assert.Equal(t, ToPlain(doc), "first line\nsecond line\n\nsecond paragraph")
+
+	assert.Equal(t, ToPlain(Document{Version: Version, Blocks: []Block{
+		{Type: TypeHeading, Text: "Title", Level: 1},
+		{Type: TypeImage, Url: "https://example.com/a.png", Alt: "a"},
+		{Type: TypeImage, Url: "https://example.com/b.png"},
+		{Type: TypeQuote, Text: "some quote", Attribution: "someone"},
+		{Type: TypeLesson, LessonId: 42, Title: "First lesson"},
+		{Type: TypeCallToAction, Label: "Start", Url: "https://example.com/start"},
+	}}), "Title\n\na\n\nsome quote\n— someone\n\nFirst lesson\n\nStart: https://example.com/start")
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	doc := Document{
+		Version: Version,
+		Blocks: []Block{
+			{Type: TypeHeading, Text: "Title with *stars* and C#", Level: 2},
+			{Type: TypeParagraph, Text: "some **text** with [brackets] and <tags>\nbroken over lines & &amp; entities"},
+			{Type: TypeParagraph, Text: "- not a list\n1. not a list either\n# not a heading\n> not a quote"},
+			{Type: TypeImage, Url: "https://example.com/a(1).png", Alt: "an image", Caption: `the "caption"`},
+			{Type: TypeImage, Url: "https://example.com/b.png"},
+			{Type: TypeQuote, Text: "some quote\nover lines", Attribution: "someone"},
+			{Type: TypeQuote, Text: "anonymous quote"},
+			{Type: TypeLesson, LessonId: 42, Title: "First lesson"},
+			{Type: TypeLesson, LessonId: 43},
+			{Type: TypeCallToAction, Label: "Start now", Url: "https://example.com/start?a=1&b=2"},
+			{Type: TypeParagraph, Text: `back\slash and under_score`},
+		},
+	}
+	assert.Equal(t, doc.Validate(), nil)
+
+	assert.Equal(t, FromMarkdown(ToMarkdown(doc)), doc)
+}
+
+func TestFromMarkdown(t *testing.T) {
+	testTable := []struct {
+		Name     string
+		Source   string
+		Expected []Block
+	}{
+		{
+			Name:   "Formatting is dropped",
+			Source: "some *emphasis*, `code` and a [link](https://example.com) in text",
+			Expected: []Block{
+				{Type: TypeParagraph, Text: "some emphasis, code and a link in text"},
+			},
+		},
+		{
+			Name:   "Setext heading",
+			Source: "Title\n=====",
+			Expected: []Block{
+				{Type: TypeHeading, Text: "Title", Level: 1},
+			},
+		},
+		{
+			Name:   "Lists",
+			Source: "- first\n- second\n  - nested",
+			Expected: []Block{
+				{Type: TypeParagraph, Text: "• first\n• second nested"},
+			},
+		},
+		{
+			Name:   "Code",
+			Source: "```\nfirst line\n\nsecond line\n```",
+			Expected: []Block{
+				{Type: TypeParagraph, Text: "first line\nsecond line"},
+			},
+		},
+		{
+			Name:     "Html and breaks are dropped",
+			Source:   "<div>html</div>\n\n---",
+			Expected: []Block{},
+		},
+		{
+			Name:   "Relative link is text",
+			Source: "[somewhere](/somewhere)",
+			Expected: []Block{
+				{Type: TypeParagraph, Text: "somewhere"},
+			},
+		},
+		{
+			Name:   "Relative image is alt text",
+			Source: "![some image](a.png)",
+			Expected: []Block{
+				{Type: TypeParagraph, Text: "some image"},
+			},
+		},
+		{
+			Name:   "Quote of paragraphs",
+			Source: "> first\n>\n> second",
+			Expected: []Block{
+				{Type: TypeQuote, Text: "first\nsecond"},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, FromMarkdown(testCase.Source), Document{Version: Version, Blocks: testCase.Expected})
+		})
+	}
+}
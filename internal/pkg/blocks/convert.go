@@ -0,0 +1,358 @@
+package blocks
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/content"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// lessonScheme links lessons in markdown, [title](lesson:42).
+const lessonScheme = "lesson:"
+
+// attributionPrefix starts the last paragraph of a quote that names who's
+// quoted.
+const attributionPrefix = "— "
+
+// FromContent converts content written in format, plain or markdown. Html
+// isn't converted, ok is false for it.
+func FromContent(format string, source string) (doc Document, ok bool) {
+	switch format {
+	case content.FormatPlain:
+		return FromPlain(source), true
+	case content.FormatMarkdown:
+		return FromMarkdown(source), true
+	default:
+		return Document{}, false
+	}
+}
+
+// FromPlain makes a paragraph of every block of text separated by an empty
+// line.
+func FromPlain(source string) Document {
+	doc := Document{Version: Version, Blocks: []Block{}}
+	for _, paragraph := range splitParagraphs(source) {
+		doc.Blocks = append(doc.Blocks, Block{Type: TypeParagraph, Text: paragraph})
+	}
+
+	return doc
+}
+
+// ToPlain keeps what can be read of the blocks as text, images are their
+// caption or alt text and calls to action are their label and url.
+func ToPlain(doc Document) string {
+	var paragraphs []string
+	for _, block := range doc.Blocks {
+		var paragraph string
+		switch block.Type {
+		case TypeParagraph, TypeHeading:
+			paragraph = block.Text
+		case TypeImage:
+			paragraph = block.Caption
+			if paragraph == "" {
+				paragraph = block.Alt
+			}
+		case TypeQuote:
+			paragraph = block.Text
+			if block.Attribution != "" {
+				paragraph += "\n" + attributionPrefix + block.Attribution
+			}
+		case TypeLesson:
+			paragraph = block.Title
+		case TypeCallToAction:
+			paragraph = block.Label + ": " + block.Url
+		}
+
+		if paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// ToMarkdown writes blocks so that FromMarkdown reads them back the same.
+// Lessons are links to lesson:<id> and calls to action are links that are a
+// paragraph of their own.
+func ToMarkdown(doc Document) string {
+	var paragraphs []string
+	for _, block := range doc.Blocks {
+		switch block.Type {
+		case TypeParagraph:
+			paragraphs = append(paragraphs, escapeText(block.Text))
+		case TypeHeading:
+			heading := escapeText(block.Text)
+			// or it's taken for the closing sequence of the heading
+			if strings.HasSuffix(heading, "#") {
+				heading = heading[:len(heading)-1] + `\#`
+			}
+			paragraphs = append(paragraphs, strings.Repeat("#", block.Level)+" "+heading)
+		case TypeImage:
+			image := "![" + escapeText(block.Alt) + "](<" + block.Url + ">"
+			if block.Caption != "" {
+				image += ` "` + strings.ReplaceAll(escapeText(block.Caption), `"`, `\"`) + `"`
+			}
+			paragraphs = append(paragraphs, image+")")
+		case TypeQuote:
+			quote := escapeText(block.Text)
+			if block.Attribution != "" {
+				quote += "\n\n" + attributionPrefix + escapeText(block.Attribution)
+			}
+			quote = "> " + strings.ReplaceAll(quote, "\n", "\n> ")
+			paragraphs = append(paragraphs, strings.ReplaceAll(quote, "\n> \n", "\n>\n"))
+		case TypeLesson:
+			paragraphs = append(paragraphs, "["+escapeText(block.Title)+"]("+lessonScheme+strconv.Itoa(block.LessonId)+")")
+		case TypeCallToAction:
+			paragraphs = append(paragraphs, "["+escapeText(block.Label)+"](<"+block.Url+">)")
+		}
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// FromMarkdown reads the blocks of markdown. What blocks have no type for
+// is kept as text: lists and code become paragraphs with a line for every
+// item or line of code, formatting of text is dropped and so is raw html.
+func FromMarkdown(source string) Document {
+	src := []byte(source)
+	root := goldmark.DefaultParser().Parse(text.NewReader(src))
+
+	doc := Document{Version: Version, Blocks: []Block{}}
+	for node := root.FirstChild(); node != nil; node = node.NextSibling() {
+		block, ok := markdownBlock(node, src)
+		if ok {
+			doc.Blocks = append(doc.Blocks, block)
+		}
+	}
+
+	return doc
+}
+
+func markdownBlock(node ast.Node, source []byte) (Block, bool) {
+	switch node := node.(type) {
+	case *ast.Heading:
+		return textBlock(Block{Type: TypeHeading, Level: node.Level, Text: oneLine(inlineText(node, source))})
+	case *ast.Paragraph:
+		if node.ChildCount() == 1 {
+			switch child := node.FirstChild().(type) {
+			case *ast.Image:
+				return imageBlock(child, source)
+			case *ast.Link:
+				return linkBlock(child, source)
+			}
+		}
+		return textBlock(Block{Type: TypeParagraph, Text: inlineText(node, source)})
+	case *ast.Blockquote:
+		return quoteBlock(node, source)
+	case *ast.List:
+		var items []string
+		for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+			items = append(items, "• "+oneLine(blockText(item, source)))
+		}
+		return textBlock(Block{Type: TypeParagraph, Text: strings.Join(items, "\n")})
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		return textBlock(Block{Type: TypeParagraph, Text: linesText(node, source)})
+	default:
+		// thematic breaks and raw html
+		return Block{}, false
+	}
+}
+
+// textBlock drops blocks left without text and empty lines texts can't
+// have.
+func textBlock(block Block) (Block, bool) {
+	lines := strings.Split(block.Text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	block.Text = strings.Join(kept, "\n")
+
+	return block, block.Text != ""
+}
+
+func imageBlock(image *ast.Image, source []byte) (Block, bool) {
+	destination := unescape(image.Destination)
+	if !validUrl(destination) {
+		return textBlock(Block{Type: TypeParagraph, Text: inlineText(image, source)})
+	}
+
+	return Block{
+		Type:    TypeImage,
+		Url:     destination,
+		Alt:     oneLine(inlineText(image, source)),
+		Caption: oneLine(unescape(image.Title)),
+	}, true
+}
+
+func linkBlock(link *ast.Link, source []byte) (Block, bool) {
+	destination := unescape(link.Destination)
+	label := oneLine(inlineText(link, source))
+
+	if id, ok := strings.CutPrefix(destination, lessonScheme); ok {
+		lessonId, err := strconv.Atoi(id)
+		if err == nil && lessonId > 0 {
+			return Block{Type: TypeLesson, LessonId: lessonId, Title: label}, true
+		}
+	}
+	if label != "" && validUrl(destination) {
+		return Block{Type: TypeCallToAction, Label: label, Url: destination}, true
+	}
+
+	return textBlock(Block{Type: TypeParagraph, Text: label})
+}
+
+func quoteBlock(quote *ast.Blockquote, source []byte) (Block, bool) {
+	var paragraphs []string
+	for child := quote.FirstChild(); child != nil; child = child.NextSibling() {
+		paragraphs = append(paragraphs, blockText(child, source))
+	}
+
+	block := Block{Type: TypeQuote}
+	if len(paragraphs) > 1 {
+		last := paragraphs[len(paragraphs)-1]
+		if attribution, ok := strings.CutPrefix(last, attributionPrefix); ok && !strings.Contains(attribution, "\n") {
+			block.Attribution = attribution
+			paragraphs = paragraphs[:len(paragraphs)-1]
+		}
+	}
+	block.Text = strings.Join(paragraphs, "\n")
+
+	return textBlock(block)
+}
+
+// blockText is the text of a block and everything in it.
+func blockText(node ast.Node, source []byte) string {
+	switch node.Kind() {
+	case ast.KindCodeBlock, ast.KindFencedCodeBlock:
+		return linesText(node, source)
+	case ast.KindParagraph, ast.KindTextBlock, ast.KindHeading:
+		return inlineText(node, source)
+	}
+
+	var texts []string
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		texts = append(texts, blockText(child, source))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// inlineText is the text of the inline children of node, breaking lines
+// where the markdown does.
+func inlineText(node ast.Node, source []byte) string {
+	var b strings.Builder
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child := child.(type) {
+		case *ast.Text:
+			if child.IsRaw() {
+				b.Write(child.Segment.Value(source))
+			} else {
+				b.WriteString(unescape(child.Segment.Value(source)))
+			}
+			if child.SoftLineBreak() || child.HardLineBreak() {
+				b.WriteString("\n")
+			}
+		case *ast.String:
+			b.WriteString(unescape(child.Value))
+		case *ast.AutoLink:
+			b.Write(child.Label(source))
+		case *ast.RawHTML:
+			// dropped like html blocks
+		default:
+			b.WriteString(inlineText(child, source))
+		}
+	}
+
+	return b.String()
+}
+
+func linesText(node ast.Node, source []byte) string {
+	var b strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		b.Write(line.Value(source))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func unescape(value []byte) string {
+	value = util.UnescapePunctuations(value)
+	value = util.ResolveNumericReferences(value)
+	value = util.ResolveEntityNames(value)
+	return string(value)
+}
+
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// entity is what markdown would take text starting with & for.
+var entity = regexp.MustCompile(`^&#?[0-9A-Za-z]+;`)
+
+// escapeText escapes what markdown could take for formatting, everywhere
+// or where a line starts. Lines are trimmed, markdown drops the whitespace
+// around them anyway.
+func escapeText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+
+		var b strings.Builder
+		for j, r := range line {
+			// entities are resolved after escapes, so & is written as one
+			if r == '&' && entity.MatchString(line[j:]) {
+				b.WriteString("&amp;")
+				continue
+			}
+
+			if strings.ContainsRune("\\`*_[]<|~", r) || (j == 0 && strings.ContainsRune("-+=#>", r)) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+		line = b.String()
+
+		// ordered list items, 1. or 1)
+		digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+		if digits > 0 && digits < len(line) && (line[digits] == '.' || line[digits] == ')') {
+			line = line[:digits] + `\` + line[digits:]
+		}
+		lines[i] = line
+	}
+
+	// a backslash at the end of a line breaks it, which is what's wanted
+	return strings.Join(lines, "\\\n")
+}
+
+// splitParagraphs splits source by empty lines into paragraphs without
+// empty lines of their own.
+func splitParagraphs(source string) []string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	var paragraphs []string
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(lines) > 0 {
+				paragraphs = append(paragraphs, strings.Join(lines, "\n"))
+				lines = nil
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		paragraphs = append(paragraphs, strings.Join(lines, "\n"))
+	}
+
+	return paragraphs
+}
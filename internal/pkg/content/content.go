@@ -0,0 +1,119 @@
+// Package content renders news content to html that's safe to put on a
+// page as it is.
+package content
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/lru"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// Formats lists the formats content can be written in.
+var Formats = []string{FormatPlain, FormatMarkdown, FormatHTML}
+
+// policy is the allowlist of elements and attributes user written html may
+// use, links get rel="nofollow" and scripts, styles and event handlers are
+// dropped. It's safe for concurrent use.
+var policy = bluemonday.UGCPolicy()
+
+// Sanitize is what's stored for source written in format. Html is cleaned
+// up before it's stored, so it's safe to serve even by clients that don't
+// ask for it to be rendered. Markdown is kept as it's written and sanitised
+// when rendered.
+func Sanitize(format string, source string) string {
+	if format == FormatHTML {
+		return policy.Sanitize(source)
+	}
+
+	return source
+}
+
+type cacheKey [sha256.Size]byte
+
+// Renderer renders content to sanitised html. What it renders is cached by
+// a hash of the content, so content that didn't change is rendered once.
+type Renderer struct {
+	markdown goldmark.Markdown
+	cache    *lru.Cache[cacheKey, string]
+}
+
+func NewRenderer(size int, ttl time.Duration) *Renderer {
+	return &Renderer{
+		markdown: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			// raw html in markdown is kept and sanitised along with the rest
+			goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+		),
+		cache: lru.New[cacheKey, string](size, ttl),
+	}
+}
+
+func (r *Renderer) HTML(format string, source string) (string, error) {
+	key := sha256.Sum256([]byte(format + "\x00" + source))
+	rendered, ok := r.cache.Get(key)
+	if ok {
+		return rendered, nil
+	}
+
+	rendered, err := r.render(format, source)
+	if err != nil {
+		return "", err
+	}
+
+	r.cache.Set(key, rendered)
+	return rendered, nil
+}
+
+func (r *Renderer) render(format string, source string) (string, error) {
+	switch format {
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		err := r.markdown.Convert([]byte(source), &buf)
+		if err != nil {
+			return "", fmt.Errorf("can't render markdown: [%w]", err)
+		}
+		return policy.Sanitize(buf.String()), nil
+	case FormatHTML:
+		// sanitised when written, again in case the policy got stricter since
+		return policy.Sanitize(source), nil
+	default:
+		return plainHTML(source), nil
+	}
+}
+
+// plainHTML makes a paragraph of every block of text separated by an empty
+// line and keeps the line breaks inside of them.
+func plainHTML(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(source, "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+
+	return b.String()
+}
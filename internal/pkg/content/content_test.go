@@ -0,0 +1,143 @@
+package content
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestSanitize(t *testing.T) {
+	testTable := []struct {
+		Name     string
+		Format   string
+		Source   string
+		Expected string
+	}{
+		{
+			Name:     "Html keeps formatting",
+			Format:   FormatHTML,
+			Source:   `<p>some <b>bold</b> <a href="https://example.com">link</a></p>`,
+			Expected: `<p>some <b>bold</b> <a href="https://example.com" rel="nofollow">link</a></p>`,
+		},
+		{
+			Name:     "Html drops scripts",
+			Format:   FormatHTML,
+			Source:   `<p>some</p><script>alert(1)</script>`,
+			Expected: `<p>some</p>`,
+		},
+		{
+			Name:     "Html drops event handlers",
+			Format:   FormatHTML,
+			Source:   `<img src="https://example.com/a.png" onerror="alert(1)">`,
+			Expected: `<img src="https://example.com/a.png">`,
+		},
+		{
+			Name:     "Html drops javascript links",
+			Format:   FormatHTML,
+			Source:   `<a href="javascript:alert(1)">link</a>`,
+			Expected: `link`,
+		},
+		{
+			Name:     "Markdown is kept",
+			Format:   FormatMarkdown,
+			Source:   "<script>alert(1)</script>",
+			Expected: "<script>alert(1)</script>",
+		},
+		{
+			Name:     "Plain is kept",
+			Format:   FormatPlain,
+			Source:   "1 < 2",
+			Expected: "1 < 2",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, Sanitize(testCase.Format, testCase.Source), testCase.Expected)
+		})
+	}
+}
+
+func TestRendererHTML(t *testing.T) {
+	renderer := NewRenderer(10, time.Minute)
+
+	testTable := []struct {
+		Name     string
+		Format   string
+		Source   string
+		Expected string
+	}{
+		{
+			Name:     "Plain is escaped",
+			Format:   FormatPlain,
+			Source:   "1 < 2\nand 3 > 2\n\n<b>not bold</b>",
+			Expected: "<p>1 &lt; 2<br>\nand 3 &gt; 2</p>\n<p>&lt;b&gt;not bold&lt;/b&gt;</p>\n",
+		},
+		{
+			Name:     "Empty plain",
+			Format:   FormatPlain,
+			Source:   "\n\n",
+			Expected: "",
+		},
+		{
+			Name:     "Markdown",
+			Format:   FormatMarkdown,
+			Source:   "# Title\n\nsome *emphasis* and ~~strikethrough~~",
+			Expected: "<h1>Title</h1>\n<p>some <em>emphasis</em> and <del>strikethrough</del></p>\n",
+		},
+		{
+			Name:     "Markdown links get nofollow",
+			Format:   FormatMarkdown,
+			Source:   "[link](https://example.com)",
+			Expected: "<p><a href=\"https://example.com\" rel=\"nofollow\">link</a></p>\n",
+		},
+		{
+			Name:     "Markdown drops javascript links",
+			Format:   FormatMarkdown,
+			Source:   "[link](javascript:alert(1))",
+			Expected: "<p>link</p>\n",
+		},
+		{
+			Name:     "Markdown drops raw scripts",
+			Format:   FormatMarkdown,
+			Source:   "some <script>alert(1)</script> text",
+			Expected: "<p>some  text</p>\n",
+		},
+		{
+			Name:     "Html",
+			Format:   FormatHTML,
+			Source:   `<p onclick="alert(1)">some</p>`,
+			Expected: "<p>some</p>",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			rendered, err := renderer.HTML(testCase.Format, testCase.Source)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, rendered, testCase.Expected)
+		})
+	}
+}
+
+func TestRendererCachesByContent(t *testing.T) {
+	renderer := NewRenderer(10, time.Minute)
+
+	first, err := renderer.HTML(FormatMarkdown, "*some*")
+	assert.Equal(t, err, nil)
+	second, err := renderer.HTML(FormatMarkdown, "*some*")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, second, first)
+
+	// the same source in another format is another entry
+	plain, err := renderer.HTML(FormatPlain, "*some*")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, plain, "<p>*some*</p>\n")
+
+	stats := renderer.cache.Stats()
+	assert.Equal(t, stats.Hits, uint64(1))
+	assert.Equal(t, stats.Misses, uint64(2))
+	assert.Equal(t, stats.Size, 2)
+}
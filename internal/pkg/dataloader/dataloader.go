@@ -0,0 +1,94 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+)
+
+// BatchFunc loads every key in one go. Keys missing from the result are
+// reported as pkg.ErrNotFound.
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+type result[V any] struct {
+	value V
+	err   error
+}
+
+// Loader collects the keys requested while resolving one level of a query
+// and loads them with a single batch once the first of them is needed.
+// Results are kept for the lifetime of the loader, so it's meant to live for
+// a single request.
+type Loader[K comparable, V any] struct {
+	batch BatchFunc[K, V]
+
+	mu      sync.Mutex
+	pending []K
+	queued  map[K]struct{}
+	results map[K]result[V]
+}
+
+func New[K comparable, V any](batch BatchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		batch:   batch,
+		queued:  map[K]struct{}{},
+		results: map[K]result[V]{},
+	}
+}
+
+// Load queues the key and returns a thunk resolving it, calling the thunk
+// dispatches everything queued so far.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	_, loaded := l.results[key]
+	_, queued := l.queued[key]
+	if !loaded && !queued {
+		l.queued[key] = struct{}{}
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		_, loaded := l.results[key]
+		if !loaded {
+			l.dispatch(ctx)
+		}
+
+		r := l.results[key]
+		return r.value, r.err
+	}
+}
+
+// Clear forgets the key, the next Load reads it again.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.results, key)
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	l.queued = map[K]struct{}{}
+
+	values, err := l.batch(ctx, keys)
+	for _, key := range keys {
+		if err != nil {
+			l.results[key] = result[V]{err: err}
+			continue
+		}
+
+		value, ok := values[key]
+		if !ok {
+			l.results[key] = result[V]{err: pkg.ErrNotFound}
+			continue
+		}
+
+		l.results[key] = result[V]{value: value}
+	}
+}
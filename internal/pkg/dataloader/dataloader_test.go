@@ -0,0 +1,66 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLoader(t *testing.T) {
+	var batches [][]int
+	loader := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+
+		values := map[int]string{}
+		for _, key := range keys {
+			if key != 3 {
+				values[key] = "value"
+			}
+		}
+		return values, nil
+	})
+
+	first := loader.Load(context.Background(), 1)
+	second := loader.Load(context.Background(), 2)
+	duplicate := loader.Load(context.Background(), 1)
+	missing := loader.Load(context.Background(), 3)
+
+	value, err := first()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, value, "value")
+
+	_, err = second()
+	assert.Equal(t, err, nil)
+	_, err = duplicate()
+	assert.Equal(t, err, nil)
+	_, err = missing()
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	// already loaded keys don't go into the next batch
+	loader.Load(context.Background(), 1)
+	_, err = loader.Load(context.Background(), 4)()
+	assert.Equal(t, err, nil)
+
+	loader.Clear(1)
+	_, err = loader.Load(context.Background(), 1)()
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, batches, [][]int{{1, 2, 3}, {4}, {1}})
+}
+
+func TestLoaderBatchError(t *testing.T) {
+	loader := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		return nil, pkg.ErrDbInternal
+	})
+
+	first := loader.Load(context.Background(), 1)
+	second := loader.Load(context.Background(), 2)
+
+	_, err := first()
+	assert.Equal(t, err, pkg.ErrDbInternal)
+	_, err = second()
+	assert.Equal(t, err, pkg.ErrDbInternal)
+}
@@ -9,4 +9,8 @@ var (
 	ErrNotFound             = errors.New("entity not found")
 	ErrInvalidUriParameters = errors.New("invalid uri paramteres")
 	ErrEntityAlreadyDeleted = errors.New("entity already delted")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrPayloadTooLarge      = errors.New("payload too large")
 )
@@ -0,0 +1,124 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type Stats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Size        int    `json:"size"`
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is a size bounded least recently used cache whose entries also
+// expire after a fixed time to live.
+type Cache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	items    map[K]*list.Element
+	order    *list.List
+	stats    Stats
+}
+
+func New[K comparable, V any](capacity int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		items:    make(map[K]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+
+	e := element.Value.(*entry[K, V])
+	if !c.now().Before(e.expiresAt) {
+		c.removeElement(element)
+		c.stats.Expirations++
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+	return e.value, true
+}
+
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *Cache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element, c.capacity)
+	c.order.Init()
+}
+
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	return stats
+}
+
+func (c *Cache[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[K, V]).key)
+}
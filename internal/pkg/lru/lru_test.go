@@ -0,0 +1,46 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	cache := New[int, string](2, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.Set(1, "first")
+	cache.Set(2, "second")
+
+	value, ok := cache.Get(1)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, value, "first")
+
+	// 2 is the least recently used now
+	cache.Set(3, "third")
+	_, ok = cache.Get(2)
+	assert.Equal(t, ok, false)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get(1)
+	assert.Equal(t, ok, false)
+
+	cache.Set(4, "fourth")
+	cache.Remove(4)
+	_, ok = cache.Get(4)
+	assert.Equal(t, ok, false)
+
+	assert.Equal(t, cache.Stats(), Stats{
+		Hits:        1,
+		Misses:      3,
+		Evictions:   1,
+		Expirations: 1,
+		Size:        1,
+	})
+
+	cache.Purge()
+	assert.Equal(t, cache.Stats().Size, 0)
+}
@@ -0,0 +1,204 @@
+// Package media checks the files uploaded for news and makes the variants
+// of images apps display instead of the originals.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	// decoders of the images that are accepted
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	RoleCover  = "cover"
+	RoleInline = "inline"
+)
+
+// Roles are what media are to news, news have a single cover.
+var Roles = []string{RoleCover, RoleInline}
+
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+const (
+	maxImageSize = 10 << 20
+	maxVideoSize = 100 << 20
+
+	// MaxSize is the largest file of any type.
+	MaxSize = maxVideoSize
+
+	// maxPixels keeps out images that are small files but take a lot of
+	// memory to decode.
+	maxPixels = 50_000_000
+
+	jpegQuality = 85
+)
+
+// types are the accepted content types and how large files of them can be.
+var types = map[string]int64{
+	"image/jpeg": maxImageSize,
+	"image/png":  maxImageSize,
+	"image/gif":  maxImageSize,
+	"image/webp": maxImageSize,
+	"video/mp4":  maxVideoSize,
+}
+
+// extensions are what stored files are named with.
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+	"video/mp4":  "mp4",
+}
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrTooLarge        = errors.New("media too large")
+	ErrInvalidImage    = errors.New("invalid image")
+)
+
+// Sniff tells the content type of a file by its content, whatever the name
+// or the content type it's uploaded with. Types that aren't accepted are an
+// error, and so are files larger than their type allows.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	maxSize, ok := types[contentType]
+	if !ok {
+		return "", fmt.Errorf("%w: [%s]", ErrUnsupportedType, contentType)
+	}
+	if int64(len(data)) > maxSize {
+		return "", fmt.Errorf("%w: [%s files are up to %d bytes]", ErrTooLarge, contentType, maxSize)
+	}
+
+	return contentType, nil
+}
+
+// Extension is what files of contentType are named with.
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+// IsImage tells content types variants are made for.
+func IsImage(contentType string) bool {
+	return contentType != "video/mp4" && types[contentType] != 0
+}
+
+// Decode reads an image, gifs are their first frame.
+func Decode(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: [%w]", ErrInvalidImage, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: [images are up to %d pixels]", ErrTooLarge, maxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: [%w]", ErrInvalidImage, err)
+	}
+
+	return img, nil
+}
+
+// Variant is a resized copy of an image in one of the formats.
+type Variant struct {
+	Name   string
+	Format string
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// size is a variant of every format. Crop fills the whole box, otherwise
+// images are fit in it.
+type size struct {
+	name   string
+	width  int
+	height int
+	crop   bool
+}
+
+var sizes = []size{
+	{name: "thumbnail", width: 320, height: 320, crop: true},
+	{name: "medium", width: 1280, height: 1280},
+}
+
+// Variants makes the thumbnail of img and the sizes it's larger than, as
+// both JPEG and WebP. There's no lossy WebP encoder in pure Go, so WebP
+// variants are lossless and larger than the JPEG ones for photos.
+func Variants(img image.Image) ([]Variant, error) {
+	var variants []Variant
+	for _, s := range sizes {
+		bounds := img.Bounds()
+		if !s.crop && bounds.Dx() <= s.width && bounds.Dy() <= s.height {
+			continue
+		}
+
+		resized := resize(img, s)
+		for _, format := range []string{FormatJPEG, FormatWebP} {
+			var buf bytes.Buffer
+			var err error
+			switch format {
+			case FormatJPEG:
+				err = jpeg.Encode(&buf, opaque(resized), &jpeg.Options{Quality: jpegQuality})
+			case FormatWebP:
+				err = nativewebp.Encode(&buf, resized, nil)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("can't encode %s %s: [%w]", s.name, format, err)
+			}
+
+			variants = append(variants, Variant{
+				Name:   s.name,
+				Format: format,
+				Width:  resized.Bounds().Dx(),
+				Height: resized.Bounds().Dy(),
+				Data:   buf.Bytes(),
+			})
+		}
+	}
+
+	return variants, nil
+}
+
+// opaque puts img on white, JPEG has no transparency and would show black.
+func opaque(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	return dst
+}
+
+// resize scales img down to s, thumbnails are cropped to the middle of it.
+// Images smaller than a thumbnail aren't scaled up.
+func resize(img image.Image, s size) image.Image {
+	src := img.Bounds()
+	width, height := src.Dx(), src.Dy()
+
+	if s.crop {
+		side := min(width, height)
+		src = image.Rect(0, 0, side, side).Add(src.Min).Add(image.Pt((width-side)/2, (height-side)/2))
+		width, height = side, side
+	}
+
+	scale := min(float64(s.width)/float64(width), float64(s.height)/float64(height), 1)
+	dst := image.NewNRGBA(image.Rect(0, 0, max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+
+	return dst
+}
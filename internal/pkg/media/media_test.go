@@ -0,0 +1,135 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestSniff(t *testing.T) {
+	testTable := []struct {
+		Name                string
+		Data                []byte
+		ExpectedContentType string
+		ExpectedError       error
+	}{
+		{
+			Name:                "Png",
+			Data:                encodePNG(fill(2, 2, func(x, y int) color.NRGBA { return color.NRGBA{A: 255} })),
+			ExpectedContentType: "image/png",
+		},
+		{
+			Name:                "Jpeg",
+			Data:                []byte("\xff\xd8\xff\xe0 some jpeg"),
+			ExpectedContentType: "image/jpeg",
+		},
+		{
+			Name:                "Mp4",
+			Data:                []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"),
+			ExpectedContentType: "video/mp4",
+		},
+		{
+			Name:          "Html named as an image",
+			Data:          []byte("<html><script>alert(1)</script></html>"),
+			ExpectedError: ErrUnsupportedType,
+		},
+		{
+			Name:          "Image too large",
+			Data:          append([]byte("\xff\xd8\xff"), make([]byte, maxImageSize)...),
+			ExpectedError: ErrTooLarge,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			contentType, err := Sniff(testCase.Data)
+
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			assert.Equal(t, contentType, testCase.ExpectedContentType)
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img, err := Decode(encodePNG(fill(3, 2, func(x, y int) color.NRGBA { return color.NRGBA{A: 255} })))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, img.Bounds(), image.Rect(0, 0, 3, 2))
+
+	_, err = Decode([]byte("\xff\xd8\xff not really a jpeg"))
+	assert.Equal(t, errors.Is(err, ErrInvalidImage), true)
+}
+
+func TestVariants(t *testing.T) {
+	testTable := []struct {
+		Name  string
+		Image image.Image
+		// Expected are the names and sizes of the variants, each as jpeg
+		// and webp
+		Expected []Variant
+	}{
+		{
+			Name:  "Large image",
+			Image: fill(2000, 1000, func(x, y int) color.NRGBA { return color.NRGBA{R: uint8(x), A: 255} }),
+			Expected: []Variant{
+				{Name: "thumbnail", Width: 320, Height: 320},
+				{Name: "medium", Width: 1280, Height: 640},
+			},
+		},
+		{
+			Name:  "Tall image",
+			Image: fill(500, 1600, func(x, y int) color.NRGBA { return color.NRGBA{G: uint8(y), A: 255} }),
+			Expected: []Variant{
+				{Name: "thumbnail", Width: 320, Height: 320},
+				{Name: "medium", Width: 400, Height: 1280},
+			},
+		},
+		{
+			Name:  "Small image",
+			Image: fill(200, 100, func(x, y int) color.NRGBA { return color.NRGBA{B: uint8(x), A: 128} }),
+			Expected: []Variant{
+				{Name: "thumbnail", Width: 100, Height: 100},
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			variants, err := Variants(testCase.Image)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(variants), 2*len(testCase.Expected))
+
+			for i, variant := range variants {
+				expected := testCase.Expected[i/2]
+				assert.Equal(t, variant.Name, expected.Name)
+				assert.Equal(t, variant.Format, []string{FormatJPEG, FormatWebP}[i%2])
+
+				decoded, err := Decode(variant.Data)
+				assert.Equal(t, err, nil)
+				assert.Equal(t, decoded.Bounds(), image.Rect(0, 0, expected.Width, expected.Height))
+				assert.Equal(t, variant.Width, expected.Width)
+				assert.Equal(t, variant.Height, expected.Height)
+			}
+		})
+	}
+}
+
+func fill(width int, height int, at func(x, y int) color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, at(x, y))
+		}
+	}
+	return img
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
@@ -0,0 +1,80 @@
+// Package moderation checks comments before they're public.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Statuses of comments, only approved ones are public.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Filter tells why content can't be public, the reason is empty when it
+// can. Content that passes still waits for a moderator.
+type Filter interface {
+	Check(ctx context.Context, content string) (string, error)
+}
+
+// Chain runs filters in order, the first reason is the one given.
+func Chain(filters ...Filter) Filter {
+	return chain(filters)
+}
+
+type chain []Filter
+
+func (c chain) Check(ctx context.Context, content string) (string, error) {
+	for _, filter := range c {
+		reason, err := filter.Check(ctx, content)
+		if err != nil || reason != "" {
+			return reason, err
+		}
+	}
+
+	return "", nil
+}
+
+// WordList rejects content with any of the words, regardless of case.
+// Words are matched whole, so "class" doesn't match "ass".
+type WordList struct {
+	words map[string]struct{}
+}
+
+func NewWordList(words []string) *WordList {
+	list := &WordList{words: map[string]struct{}{}}
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			list.words[word] = struct{}{}
+		}
+	}
+
+	return list
+}
+
+// ParseWordList reads words separated by commas or new lines, the way
+// they're configured.
+func ParseWordList(words string) *WordList {
+	return NewWordList(strings.FieldsFunc(words, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}))
+}
+
+func (l *WordList) Check(ctx context.Context, content string) (string, error) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(content), notWordRune) {
+		if _, ok := l.words[word]; ok {
+			return fmt.Sprintf("contains blocked word %q", word), nil
+		}
+	}
+
+	return "", nil
+}
+
+func notWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
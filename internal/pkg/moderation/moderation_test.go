@@ -0,0 +1,57 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestWordList(t *testing.T) {
+	list := ParseWordList("spam, Scam\ncasino")
+
+	testTable := []struct {
+		Name           string
+		Content        string
+		ExpectedReason string
+	}{
+		{
+			Name:    "Clean",
+			Content: "Great lesson, thanks!",
+		},
+		{
+			Name:           "Blocked word",
+			Content:        "Visit my CASINO now",
+			ExpectedReason: `contains blocked word "casino"`,
+		},
+		{
+			Name:           "Blocked word next to punctuation",
+			Content:        "this is a scam!!!",
+			ExpectedReason: `contains blocked word "scam"`,
+		},
+		{
+			Name:    "Part of a word",
+			Content: "I love spamming nothing, just spammers",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			reason, err := list.Check(context.Background(), testCase.Content)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, reason, testCase.ExpectedReason)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	filter := Chain(NewWordList([]string{"spam"}), NewWordList([]string{"scam"}))
+
+	reason, err := filter.Check(context.Background(), "no scam here")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reason, `contains blocked word "scam"`)
+
+	reason, err = Chain().Check(context.Background(), "spam")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reason, "")
+}
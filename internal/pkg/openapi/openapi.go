@@ -0,0 +1,449 @@
+package openapi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Spec is the OpenAPI 3.1 document of the /posts routes.
+//
+//go:embed openapi.json
+var Spec []byte
+
+const specURL = "openapi.json"
+
+var methods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodPatch,
+}
+
+// document is the part of the spec the validator needs, schemas are
+// compiled straight from the raw document by their location.
+type document struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Parameters map[string]parameterObject `json:"parameters"`
+		Responses  map[string]responseObject  `json:"responses"`
+	} `json:"components"`
+}
+
+type reference struct {
+	Ref string `json:"$ref"`
+}
+
+type parameterObject struct {
+	reference
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   struct {
+		Type string `json:"type"`
+	} `json:"schema"`
+}
+
+type responseObject struct {
+	reference
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+type operationObject struct {
+	Parameters  []parameterObject `json:"parameters"`
+	RequestBody *struct {
+		Required bool                       `json:"required"`
+		Content  map[string]json.RawMessage `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]responseObject `json:"responses"`
+}
+
+// locatedParameter remembers where in the spec a parameter is, which is how
+// its schema is compiled.
+type locatedParameter struct {
+	pointer string
+	object  parameterObject
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	kind     string
+	schema   *jsonschema.Schema
+}
+
+type operation struct {
+	parameters   []parameter
+	body         *jsonschema.Schema
+	bodyRequired bool
+	// responses without a JSON body map to nil
+	responses map[string]*jsonschema.Schema
+}
+
+// Validator checks requests and responses of the routes the spec describes
+// against it, other routes are let through.
+type Validator struct {
+	operations map[string]operation
+
+	// onResponseError is told about responses not matching the spec, they
+	// are already on their way to the client by then
+	onResponseError func(ctx *gin.Context, err error)
+}
+
+func NewValidator() (*Validator, error) {
+	var doc document
+	err := json.Unmarshal(Spec, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode openapi spec: [%w]", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	err = compiler.AddResource(specURL, bytes.NewReader(Spec))
+	if err != nil {
+		return nil, fmt.Errorf("can't load openapi spec: [%w]", err)
+	}
+
+	v := &Validator{
+		operations:      map[string]operation{},
+		onResponseError: logResponseError,
+	}
+	for path, item := range doc.Paths {
+		pathPointer := "/paths/" + escape(path)
+
+		var shared []parameterObject
+		err := decodeField(item, "parameters", &shared)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, method := range methods {
+			var op operationObject
+			ok, err := decodeMethod(item, method, &op)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			opPointer := pathPointer + "/" + strings.ToLower(method)
+			compiled, err := compileOperation(compiler, &doc, pathPointer, shared, opPointer, op)
+			if err != nil {
+				return nil, fmt.Errorf("can't compile %s %s: [%w]", method, path, err)
+			}
+			v.operations[method+" "+path] = compiled
+		}
+	}
+
+	return v, nil
+}
+
+// Operations lists the documented operations as "METHOD /path".
+func (v *Validator) Operations() []string {
+	operations := make([]string, 0, len(v.operations))
+	for key := range v.operations {
+		operations = append(operations, key)
+	}
+	slices.Sort(operations)
+
+	return operations
+}
+
+// Operation names a gin route the way Operations does.
+func Operation(method string, route string) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return method + " " + strings.Join(segments, "/")
+}
+
+func (v *Validator) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		op, ok := v.operations[Operation(ctx.Request.Method, ctx.FullPath())]
+		if !ok {
+			ctx.Next()
+			return
+		}
+
+		err := op.validateRequest(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+			})
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		err = op.validateResponse(recorder.Status(), recorder.body.Bytes(), recorder.recorded)
+		if err != nil {
+			v.onResponseError(ctx, err)
+		}
+	}
+}
+
+func (op operation) validateRequest(ctx *gin.Context) error {
+	for _, param := range op.parameters {
+		var value string
+		var ok bool
+		switch param.in {
+		case "path":
+			value = ctx.Param(param.name)
+			ok = value != ""
+		case "query":
+			value, ok = ctx.GetQuery(param.name)
+		case "header":
+			value = ctx.GetHeader(param.name)
+			ok = value != ""
+		}
+
+		if !ok {
+			if param.required {
+				return fmt.Errorf("%s parameter %s is required", param.in, param.name)
+			}
+			continue
+		}
+
+		err := param.schema.Validate(parameterValue(param.kind, value))
+		if err != nil {
+			return fmt.Errorf("%s parameter %s: %w", param.in, param.name, err)
+		}
+	}
+
+	if op.body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return err
+	}
+	// handlers bind the body again
+	ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) == 0 {
+		if op.bodyRequired {
+			return errors.New("request body is required")
+		}
+		return nil
+	}
+
+	return validateJSON(op.body, body)
+}
+
+func (op operation) validateResponse(status int, body []byte, recorded bool) error {
+	schema, ok := op.responses[strconv.Itoa(status)]
+	if !ok {
+		schema, ok = op.responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("status %d isn't documented", status)
+	}
+
+	if schema == nil || !recorded {
+		return nil
+	}
+
+	return validateJSON(schema, body)
+}
+
+func validateJSON(schema *jsonschema.Schema, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v any
+	err := decoder.Decode(&v)
+	if err != nil {
+		return err
+	}
+
+	return schema.Validate(v)
+}
+
+// parameterValue converts a parameter to what its schema expects, values
+// that don't convert are validated as strings and fail on the type.
+func parameterValue(kind string, value string) any {
+	switch kind {
+	case "integer", "number":
+		_, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err == nil {
+			return b
+		}
+	}
+
+	return value
+}
+
+func compileOperation(
+	compiler *jsonschema.Compiler,
+	doc *document,
+	pathPointer string,
+	shared []parameterObject,
+	opPointer string,
+	op operationObject,
+) (operation, error) {
+	compiled := operation{
+		responses: map[string]*jsonschema.Schema{},
+	}
+
+	var parameters []locatedParameter
+	for i, param := range shared {
+		parameters = append(parameters, locatedParameter{fmt.Sprintf("%s/parameters/%d", pathPointer, i), param})
+	}
+	for i, param := range op.Parameters {
+		parameters = append(parameters, locatedParameter{fmt.Sprintf("%s/parameters/%d", opPointer, i), param})
+	}
+
+	for _, p := range parameters {
+		pointer, object, err := resolveParameter(doc, p.pointer, p.object)
+		if err != nil {
+			return operation{}, err
+		}
+
+		schema, err := compiler.Compile(specURL + "#" + pointer + "/schema")
+		if err != nil {
+			return operation{}, err
+		}
+
+		compiled.parameters = append(compiled.parameters, parameter{
+			name:     object.Name,
+			in:       object.In,
+			required: object.Required,
+			kind:     object.Schema.Type,
+			schema:   schema,
+		})
+	}
+
+	if op.RequestBody != nil {
+		_, ok := op.RequestBody.Content["application/json"]
+		if ok {
+			schema, err := compiler.Compile(specURL + "#" + opPointer + "/requestBody/content/application~1json/schema")
+			if err != nil {
+				return operation{}, err
+			}
+			compiled.body = schema
+			compiled.bodyRequired = op.RequestBody.Required
+		}
+	}
+
+	for status, resp := range op.Responses {
+		pointer := opPointer + "/responses/" + status
+		if resp.Ref != "" {
+			name := strings.TrimPrefix(resp.Ref, "#/components/responses/")
+			shared, ok := doc.Components.Responses[name]
+			if !ok {
+				return operation{}, fmt.Errorf("unknown response %s", resp.Ref)
+			}
+			pointer = "/components/responses/" + escape(name)
+			resp = shared
+		}
+
+		_, ok := resp.Content["application/json"]
+		if !ok {
+			compiled.responses[status] = nil
+			continue
+		}
+
+		schema, err := compiler.Compile(specURL + "#" + pointer + "/content/application~1json/schema")
+		if err != nil {
+			return operation{}, err
+		}
+		compiled.responses[status] = schema
+	}
+
+	return compiled, nil
+}
+
+func resolveParameter(doc *document, pointer string, param parameterObject) (string, parameterObject, error) {
+	if param.Ref == "" {
+		return pointer, param, nil
+	}
+
+	name := strings.TrimPrefix(param.Ref, "#/components/parameters/")
+	shared, ok := doc.Components.Parameters[name]
+	if !ok {
+		return "", parameterObject{}, fmt.Errorf("unknown parameter %s", param.Ref)
+	}
+
+	return "/components/parameters/" + escape(name), shared, nil
+}
+
+func decodeField(item map[string]json.RawMessage, field string, v any) error {
+	raw, ok := item[field]
+	if !ok {
+		return nil
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+func decodeMethod(item map[string]json.RawMessage, method string, op *operationObject) (bool, error) {
+	raw, ok := item[strings.ToLower(method)]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(raw, op)
+}
+
+// escape makes a key usable in a JSON pointer.
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func logResponseError(ctx *gin.Context, err error) {
+	fmt.Printf("response of %s %s doesn't match the openapi spec: [%v]\n", ctx.Request.Method, ctx.FullPath(), err)
+}
+
+// responseRecorder keeps a copy of JSON responses while they're written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	recorded bool
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+func (r *responseRecorder) record(data []byte) {
+	if !strings.Contains(r.Header().Get("Content-Type"), "json") {
+		return
+	}
+
+	r.recorded = true
+	r.body.Write(data)
+}
@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+func newRouter(t *testing.T, respond gin.HandlerFunc) (*gin.Engine, *[]error) {
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var responseErrors []error
+	validator.onResponseError = func(ctx *gin.Context, err error) {
+		responseErrors = append(responseErrors, err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(validator.Middleware())
+	router.POST("/posts", respond)
+	router.GET("/posts/:id", respond)
+	router.GET("/undocumented", respond)
+
+	return router, &responseErrors
+}
+
+func TestMiddlewareValidatesRequests(t *testing.T) {
+	testTable := []struct {
+		Name               string
+		Method             string
+		Path               string
+		Body               string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "Valid body",
+			Method:             http.MethodPost,
+			Path:               "/posts",
+			Body:               `{"title": "some title", "content": "some content"}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Title too short",
+			Method:             http.MethodPost,
+			Path:               "/posts",
+			Body:               `{"title": "f", "content": "some content"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Missing content",
+			Method:             http.MethodPost,
+			Path:               "/posts",
+			Body:               `{"title": "some title"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Missing body",
+			Method:             http.MethodPost,
+			Path:               "/posts",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Valid id",
+			Method:             http.MethodGet,
+			Path:               "/posts/1",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid id",
+			Method:             http.MethodGet,
+			Path:               "/posts/first",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Undocumented route",
+			Method:             http.MethodGet,
+			Path:               "/undocumented",
+			ExpectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			var body []byte
+			router, _ := newRouter(t, func(ctx *gin.Context) {
+				// the body has to survive validation
+				body, _ = ctx.GetRawData()
+				ctx.Status(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(testCase.Method, testCase.Path, bytes.NewBufferString(testCase.Body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, w.Code, testCase.ExpectedStatusCode)
+			if testCase.ExpectedStatusCode == http.StatusOK {
+				assert.Equal(t, string(body), testCase.Body)
+				return
+			}
+
+			var resp response.Response
+			json.NewDecoder(w.Body).Decode(&resp)
+			assert.Equal(t, resp.Code, response.InvalidPayload)
+		})
+	}
+}
+
+func TestMiddlewareValidatesResponses(t *testing.T) {
+	testTable := []struct {
+		Name          string
+		StatusCode    int
+		Response      any
+		ExpectedError bool
+	}{
+		{
+			Name:       "Matching response",
+			StatusCode: http.StatusOK,
+			Response: response.Response{
+				Code: response.Ok,
+				Data: response.NewsData{Id: 1, Title: "some title", Content: "some content", ContentFormat: "plain", Status: "draft"},
+			},
+		},
+		{
+			Name:       "Error response",
+			StatusCode: http.StatusNotFound,
+			Response: response.Response{
+				Code:  response.NotFound,
+				Error: "not found",
+			},
+		},
+		{
+			Name:       "Unknown status",
+			StatusCode: http.StatusOK,
+			Response: response.Response{
+				Code: response.Ok,
+				Data: response.NewsData{Id: 1, Title: "some title", Content: "some content", Status: "archived"},
+			},
+			ExpectedError: true,
+		},
+		{
+			Name:       "Unknown code",
+			StatusCode: http.StatusNotFound,
+			Response: response.Response{
+				Code:  42,
+				Error: "not found",
+			},
+			ExpectedError: true,
+		},
+		{
+			Name:       "Undocumented status code",
+			StatusCode: http.StatusTeapot,
+			Response: response.Response{
+				Code: response.Ok,
+			},
+			ExpectedError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			router, responseErrors := newRouter(t, func(ctx *gin.Context) {
+				ctx.JSON(testCase.StatusCode, testCase.Response)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			// mismatching responses are still sent
+			assert.Equal(t, w.Code, testCase.StatusCode)
+			assert.Equal(t, len(*responseErrors) != 0, testCase.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package payload
+
+import "time"
+
+type AddApiKeyPayload struct {
+	Name      string     `json:"name" binding:"required,gt=2,lt=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=news:read news:write news:delete"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,26 @@
+package payload
+
+// AddCommentPayload replies to the comment with ParentId when it's set.
+type AddCommentPayload struct {
+	Content  string `json:"content" binding:"required,max=2000"`
+	ParentId int    `json:"parent_id,omitempty" binding:"omitempty,gt=0"`
+}
+
+type UpdateCommentPayload struct {
+	Content string `json:"content" binding:"required,max=2000"`
+}
+
+type RejectCommentPayload struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type CommentUriPayload struct {
+	Id        int `uri:"id"`
+	CommentId int `uri:"comment_id"`
+}
+
+// CommentsQueryPayload pages comments, always.
+type CommentsQueryPayload struct {
+	First int    `form:"first" binding:"omitempty,gt=0,lte=100"`
+	After string `form:"after"`
+}
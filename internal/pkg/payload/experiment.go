@@ -0,0 +1,19 @@
+package payload
+
+// AddVariantPayload is an alternate title and content of news, shown to
+// readers in proportion to Weight among the other variants.
+type AddVariantPayload struct {
+	Title   string `json:"title" binding:"required,gt=2,lt=50"`
+	Content string `json:"content" binding:"required"`
+	Weight  int    `json:"weight" binding:"required,gt=0,lte=1000"`
+}
+
+// StopExperimentPayload promotes the best variant when WinnerId isn't set.
+type StopExperimentPayload struct {
+	WinnerId int `json:"winner_id,omitempty" binding:"omitempty,gt=0"`
+}
+
+type VariantUriPayload struct {
+	Id        int `uri:"id"`
+	VariantId int `uri:"variant_id"`
+}
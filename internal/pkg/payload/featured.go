@@ -0,0 +1,20 @@
+package payload
+
+import "time"
+
+// PinNewsPayload pins news until they're unpinned when PinnedUntil isn't
+// set.
+type PinNewsPayload struct {
+	PinnedUntil *time.Time `json:"pinned_until,omitempty"`
+}
+
+// FeatureNewsPayload puts news last when Position isn't set, positions
+// count from zero.
+type FeatureNewsPayload struct {
+	Position *int `json:"position,omitempty" binding:"omitempty,gte=0"`
+}
+
+type FeaturedNewsQueryPayload struct {
+	NewsQueryPayload
+	ClientContextPayload
+}
@@ -0,0 +1,7 @@
+package payload
+
+type GraphqlPayload struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
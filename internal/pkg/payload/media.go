@@ -0,0 +1,13 @@
+package payload
+
+import "mime/multipart"
+
+type UploadMediaPayload struct {
+	File *multipart.FileHeader `form:"file" binding:"required"`
+	Role string                `form:"role" binding:"omitempty,oneof=cover inline"`
+}
+
+type MediaUriPayload struct {
+	Id      int `uri:"id"`
+	MediaId int `uri:"media_id"`
+}
@@ -1,15 +1,64 @@
 package payload
 
+import "encoding/json"
+
+// News are written as either Content or Blocks. ContentFormat of news
+// defaults to plain, or to markdown for the content derived from Blocks.
+// News with Targeting are listed only for clients the rule matches.
 type AddNewsPayload struct {
-	Title   string `json:"title" binding:"required,gt=2,lt=50"`
-	Content string `json:"content" binding:"required"`
+	Title         string          `json:"title" binding:"required,gt=2,lt=50"`
+	Content       string          `json:"content,omitempty" binding:"required_without=Blocks"`
+	ContentFormat string          `json:"content_format,omitempty" binding:"omitempty,oneof=plain markdown html"`
+	Blocks        json.RawMessage `json:"blocks,omitempty"`
+	Targeting     string          `json:"targeting,omitempty" binding:"max=1000"`
 }
 
 type UpdateNewsPayload struct {
-	Title   string `json:"title" binding:"required,gt=2,lt=50"`
-	Content string `json:"content" binding:"required"`
+	Title         string          `json:"title" binding:"required,gt=2,lt=50"`
+	Content       string          `json:"content,omitempty" binding:"required_without=Blocks"`
+	ContentFormat string          `json:"content_format,omitempty" binding:"omitempty,oneof=plain markdown html"`
+	Blocks        json.RawMessage `json:"blocks,omitempty"`
+	Targeting     string          `json:"targeting,omitempty" binding:"max=1000"`
 }
 
 type IdUriPayload struct {
 	Id int `uri:"id"`
 }
+
+// NewsQueryPayload adds the rendered content to news when Render is html.
+type NewsQueryPayload struct {
+	Render string `form:"render" binding:"omitempty,oneof=html"`
+}
+
+type NewsByIdQueryPayload struct {
+	NewsQueryPayload
+	ClientContextPayload
+}
+
+// NewsListQueryPayload pages the list when either First or After is set.
+type NewsListQueryPayload struct {
+	NewsQueryPayload
+	ClientContextPayload
+	First int    `form:"first" binding:"omitempty,gt=0,lte=100"`
+	After string `form:"after"`
+}
+
+// PopularNewsQueryPayload ranks news over the last day unless Window is set.
+type PopularNewsQueryPayload struct {
+	NewsQueryPayload
+	Window string `form:"window" binding:"omitempty,oneof=24h 7d 30d"`
+	First  int    `form:"first" binding:"omitempty,gt=0,lte=100"`
+}
+
+// BookmarksQueryPayload pages bookmarks, always, the latest bookmarked news
+// first.
+type BookmarksQueryPayload struct {
+	NewsQueryPayload
+	First int    `form:"first" binding:"omitempty,gt=0,lte=100"`
+	After string `form:"after"`
+}
+
+type NewsChangesQueryPayload struct {
+	ClientContextPayload
+	Since string `form:"since"`
+}
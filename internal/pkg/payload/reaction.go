@@ -0,0 +1,6 @@
+package payload
+
+type ReactionUriPayload struct {
+	Id   int    `uri:"id"`
+	Kind string `uri:"kind" binding:"required"`
+}
@@ -0,0 +1,16 @@
+package payload
+
+// ClientContextPayload is what a client tells about itself for news targeted
+// at some clients only. Lists take it from query parameters or, for the
+// fields missing there, from headers.
+type ClientContextPayload struct {
+	Locale           string `json:"locale,omitempty" form:"locale" header:"X-App-Locale" binding:"omitempty,max=35"`
+	Platform         string `json:"platform,omitempty" form:"platform" header:"X-App-Platform" binding:"omitempty,oneof=ios android web"`
+	AppVersion       string `json:"app_version,omitempty" form:"app_version" header:"X-App-Version" binding:"omitempty,max=32"`
+	Tier             string `json:"tier,omitempty" form:"tier" header:"X-Subscription-Tier" binding:"omitempty,max=32"`
+	LearningLanguage string `json:"learning_language,omitempty" form:"learning_language" header:"X-Learning-Language" binding:"omitempty,max=35"`
+}
+
+type TargetingDryRunPayload struct {
+	Contexts []ClientContextPayload `json:"contexts" binding:"required,min=1,max=100,dive"`
+}
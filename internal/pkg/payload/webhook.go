@@ -0,0 +1,11 @@
+package payload
+
+type AddWebhookPayload struct {
+	Url    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=news.created news.updated news.published news.deleted"`
+}
+
+type WebhookDeliveryUriPayload struct {
+	Id         int   `uri:"id"`
+	DeliveryId int64 `uri:"delivery_id"`
+}
@@ -0,0 +1,90 @@
+// Package rank orders items by keys that can always be put between two
+// others, moving an item changes its key alone.
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Digits of keys, in the byte order keys are compared in.
+const Digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidKey = errors.New("invalid rank key")
+
+// Between gives a key ordered after before and before after. An empty key is
+// no bound, Between("", "") is the key of the first item of a list.
+func Between(before string, after string) (string, error) {
+	for _, key := range []string{before, after} {
+		if err := validate(key); err != nil {
+			return "", err
+		}
+	}
+	if after != "" && before >= after {
+		return "", fmt.Errorf("%w: [%q isn't before %q]", ErrInvalidKey, before, after)
+	}
+
+	return midpoint(before, after), nil
+}
+
+// validate rejects trailing zeros as well, there is no key between "A" and
+// "A0".
+func validate(key string) error {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(Digits, key[i]) < 0 {
+			return fmt.Errorf("%w: [unexpected %q in %q]", ErrInvalidKey, key[i], key)
+		}
+	}
+	if strings.HasSuffix(key, Digits[:1]) {
+		return fmt.Errorf("%w: [trailing zero in %q]", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// midpoint reads keys as fractions, the digits coming after the point. An
+// empty after is one.
+func midpoint(before string, after string) string {
+	if after != "" {
+		// the common prefix stays, missing digits of before are zeros
+		n := 0
+		for n < len(after) && digit(before, n) == after[n] {
+			n++
+		}
+		if n > 0 {
+			return after[:n] + midpoint(suffix(before, n), after[n:])
+		}
+	}
+
+	low := 0
+	if before != "" {
+		low = strings.IndexByte(Digits, before[0])
+	}
+	high := len(Digits)
+	if after != "" {
+		high = strings.IndexByte(Digits, after[0])
+	}
+	if high-low > 1 {
+		return Digits[(low+high+1)/2 : (low+high+1)/2+1]
+	}
+
+	// the first digits are consecutive, after cut short is still after before
+	if len(after) > 1 {
+		return after[:1]
+	}
+	return Digits[low:low+1] + midpoint(suffix(before, 1), "")
+}
+
+func digit(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return Digits[0]
+}
+
+func suffix(key string, i int) string {
+	if i < len(key) {
+		return key[i:]
+	}
+	return ""
+}
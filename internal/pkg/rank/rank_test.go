@@ -0,0 +1,111 @@
+package rank
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestBetween(t *testing.T) {
+	testTable := []struct {
+		Name     string
+		Before   string
+		After    string
+		Expected string
+		Err      error
+	}{
+		{
+			Name:     "Ok empty list",
+			Expected: "V",
+		},
+		{
+			Name:     "Ok first",
+			After:    "V",
+			Expected: "G",
+		},
+		{
+			Name:     "Ok last",
+			Before:   "V",
+			Expected: "l",
+		},
+		{
+			Name:     "Ok consecutive digits",
+			Before:   "1",
+			After:    "2",
+			Expected: "1V",
+		},
+		{
+			Name:     "Ok common prefix",
+			Before:   "A1",
+			After:    "A3",
+			Expected: "A2",
+		},
+		{
+			Name:     "Ok shorter after",
+			Before:   "A",
+			After:    "AB",
+			Expected: "A6",
+		},
+		{
+			Name:     "Ok last digit",
+			Before:   "z",
+			Expected: "zV",
+		},
+		{
+			Name:   "Same keys",
+			Before: "A",
+			After:  "A",
+			Err:    ErrInvalidKey,
+		},
+		{
+			Name:   "Out of order",
+			Before: "B",
+			After:  "A",
+			Err:    ErrInvalidKey,
+		},
+		{
+			Name:   "Trailing zero",
+			Before: "A0",
+			Err:    ErrInvalidKey,
+		},
+		{
+			Name:  "Unknown digit",
+			After: "A-",
+			Err:   ErrInvalidKey,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			key, err := Between(testCase.Before, testCase.After)
+			assert.Equal(t, errors.Is(err, testCase.Err), true)
+			assert.Equal(t, key, testCase.Expected)
+		})
+	}
+}
+
+func TestBetweenKeepsOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	keys := []string{}
+
+	for i := 0; i < 1000; i++ {
+		at := random.Intn(len(keys) + 1)
+		before, after := "", ""
+		if at > 0 {
+			before = keys[at-1]
+		}
+		if at < len(keys) {
+			after = keys[at]
+		}
+
+		key, err := Between(before, after)
+		assert.Equal(t, err, nil)
+		keys = slices.Insert(keys, at, key)
+	}
+
+	assert.Equal(t, slices.IsSorted(keys), true)
+	assert.Equal(t, len(slices.Compact(slices.Clone(keys))), len(keys))
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Limit is a token bucket holding up to Burst tokens, refilled evenly so an
+// empty bucket is full again after Period.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+func PerSecond(n int) Limit {
+	return Limit{Burst: n, Period: time.Second}
+}
+
+func PerMinute(n int) Limit {
+	return Limit{Burst: n, Period: time.Minute}
+}
+
+func PerHour(n int) Limit {
+	return Limit{Burst: n, Period: time.Hour}
+}
+
+// Rate is the number of tokens added per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Burst) / l.Period.Seconds()
+}
+
+func (l Limit) refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate())
+}
+
+// until returns how long it takes for the bucket to hold the given amount of tokens.
+func (l Limit) until(tokens float64, target float64) time.Duration {
+	if tokens >= target {
+		return 0
+	}
+	return time.Duration((target - tokens) / l.Rate() * float64(time.Second))
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+	RetryAfter time.Duration
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: limit.until(tokens, float64(limit.Burst)),
+	}
+	if !allowed {
+		result.RetryAfter = limit.until(tokens, 1)
+	}
+
+	return result
+}
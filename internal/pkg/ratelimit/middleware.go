@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Route struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+type Limiter struct {
+	store        Store
+	clock        Clock
+	defaultLimit Limit
+	routes       map[string]Limit
+}
+
+func NewLimiter(store Store, clock Clock, defaultLimit Limit, routes ...Route) *Limiter {
+	limiter := &Limiter{
+		store:        store,
+		clock:        clock,
+		defaultLimit: defaultLimit,
+		routes:       make(map[string]Limit, len(routes)),
+	}
+	for _, route := range routes {
+		limiter.routes[route.Method+" "+route.Path] = route.Limit
+	}
+
+	return limiter
+}
+
+// Take spends a token of the client's bucket for the route. Every route has
+// its own bucket, so flooding one endpoint doesn't lock a client out of the rest.
+func (l *Limiter) Take(ctx context.Context, method string, path string, client string) (Result, error) {
+	route := method + " " + path
+	limit, ok := l.routes[route]
+	if !ok {
+		limit = l.defaultLimit
+	}
+
+	tokens, allowed, err := l.store.Take(ctx, route+" "+client, limit, l.clock.Now())
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(limit, tokens, allowed), nil
+}
+
+func clientKey(ctx *gin.Context) string {
+	author, ok := auth.AuthorFromContext(ctx)
+	if ok && author.ApiKeyID != 0 {
+		return fmt.Sprintf("api_key:%d", author.ApiKeyID)
+	}
+	if ok {
+		return fmt.Sprintf("user:%d", author.ID)
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func Middleware(limiter *Limiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := limiter.Take(ctx, ctx.Request.Method, ctx.FullPath(), clientKey(ctx))
+		if err != nil {
+			// an unavailable store shouldn't take the whole api down with it
+			fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Header("RateLimit-Reset", seconds(result.ResetAfter))
+
+		if !result.Allowed {
+			ctx.Header("Retry-After", seconds(result.RetryAfter))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, response.Response{
+				Code:  response.TooManyRequests,
+				Error: pkg.ErrTooManyRequests.Error(),
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,203 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (float64, bool, error) {
+	return 0, false, errors.New("store is down")
+}
+
+func newRouter(limiter *Limiter, author *auth.Author) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(func(ctx *gin.Context) {
+		if author != nil {
+			ctx.Request = ctx.Request.WithContext(auth.WithAuthor(ctx.Request.Context(), *author))
+		}
+	})
+	router.Use(Middleware(limiter))
+	ok := func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, response.Response{Code: response.Ok})
+	}
+	router.POST("/posts", ok)
+	router.GET("/posts", ok)
+
+	return router
+}
+
+func do(router http.Handler, method string, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/posts", nil)
+	r.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddlewareLimitsAndRefills(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := NewLimiter(NewMemoryStore(), clock, PerMinute(100),
+		Route{Method: http.MethodPost, Path: "/posts", Limit: PerMinute(2)},
+	)
+	router := newRouter(limiter, nil)
+
+	w := do(router, http.MethodPost, "10.0.0.1:1000")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("RateLimit-Limit"), "2")
+	assert.Equal(t, w.Header().Get("RateLimit-Remaining"), "1")
+	assert.Equal(t, w.Header().Get("RateLimit-Reset"), "30")
+
+	w = do(router, http.MethodPost, "10.0.0.1:1000")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("RateLimit-Remaining"), "0")
+
+	w = do(router, http.MethodPost, "10.0.0.1:1000")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Equal(t, w.Header().Get("Retry-After"), "30")
+
+	var resp response.Response
+	json.NewDecoder(w.Body).Decode(&resp)
+	assert.Equal(t, resp.Code, response.TooManyRequests)
+
+	// other routes have their own bucket
+	w = do(router, http.MethodGet, "10.0.0.1:1000")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("RateLimit-Limit"), "100")
+
+	// other clients have their own bucket
+	w = do(router, http.MethodPost, "10.0.0.2:1000")
+	assert.Equal(t, w.Code, http.StatusOK)
+
+	clock.Advance(30 * time.Second)
+	w = do(router, http.MethodPost, "10.0.0.1:1000")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("RateLimit-Remaining"), "0")
+
+	w = do(router, http.MethodPost, "10.0.0.1:1000")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+}
+
+func TestMiddlewareKeysByAuthor(t *testing.T) {
+	testTable := []struct {
+		Name        string
+		First       *auth.Author
+		Second      *auth.Author
+		SameBuckets bool
+	}{
+		{
+			Name:        "Same user from different ips",
+			First:       &auth.Author{ID: 1},
+			Second:      &auth.Author{ID: 1},
+			SameBuckets: true,
+		},
+		{
+			Name:        "Different users",
+			First:       &auth.Author{ID: 1},
+			Second:      &auth.Author{ID: 2},
+			SameBuckets: false,
+		},
+		{
+			Name:        "Api keys created by the same user",
+			First:       &auth.Author{ID: 1, ApiKeyID: 1},
+			Second:      &auth.Author{ID: 1, ApiKeyID: 2},
+			SameBuckets: false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			limiter := NewLimiter(NewMemoryStore(), clock, PerMinute(1))
+
+			w := do(newRouter(limiter, testCase.First), http.MethodPost, "10.0.0.1:1000")
+			assert.Equal(t, w.Code, http.StatusOK)
+
+			w = do(newRouter(limiter, testCase.Second), http.MethodPost, "10.0.0.2:1000")
+			assert.Equal(t, w.Code == http.StatusTooManyRequests, testCase.SameBuckets)
+		})
+	}
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	limiter := NewLimiter(failingStore{}, &fakeClock{}, PerMinute(1))
+	router := newRouter(limiter, nil)
+
+	for i := 0; i < 3; i++ {
+		w := do(router, http.MethodPost, "10.0.0.1:1000")
+		assert.Equal(t, w.Code, http.StatusOK)
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	store := NewMemoryStore()
+	store.lastSweep = clock.now
+
+	store.Take(context.Background(), "a", PerMinute(1), clock.Now())
+	store.Take(context.Background(), "b", PerHour(1), clock.Now())
+
+	clock.Advance(2 * time.Minute)
+	store.Take(context.Background(), "c", PerMinute(1), clock.Now())
+
+	_, aExists := store.buckets["a"]
+	_, bExists := store.buckets["b"]
+	assert.Equal(t, aExists, false)
+	assert.Equal(t, bExists, true)
+}
+
+type rateLimitRepoMock struct {
+	Sweeps []time.Time
+}
+
+func (m *rateLimitRepoMock) TakeRateLimitToken(ctx context.Context, arg core.TakeRateLimitTokenParams) (core.TakeRateLimitTokenRow, error) {
+	return core.TakeRateLimitTokenRow{Tokens: arg.Burst - 1, Allowed: true}, nil
+}
+
+func (m *rateLimitRepoMock) DeleteIdleRateLimits(ctx context.Context, now pgtype.Timestamp) error {
+	m.Sweeps = append(m.Sweeps, now.Time)
+	return nil
+}
+
+func TestPostgresStoreSweepsIdleBuckets(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0).UTC()}
+	repo := &rateLimitRepoMock{}
+	store := NewPostgresStore(repo)
+
+	store.Take(context.Background(), "a", PerMinute(1), clock.Now())
+	clock.Advance(30 * time.Second)
+	store.Take(context.Background(), "a", PerMinute(1), clock.Now())
+	assert.Equal(t, repo.Sweeps, []time.Time{time.Unix(0, 0).UTC()})
+
+	// at most once a minute
+	clock.Advance(30 * time.Second)
+	store.Take(context.Background(), "b", PerMinute(1), clock.Now())
+	assert.Equal(t, repo.Sweeps, []time.Time{time.Unix(0, 0).UTC(), time.Unix(60, 0).UTC()})
+}
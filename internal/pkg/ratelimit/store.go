@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Store keeps token buckets. Take must refill the bucket for key and take a
+// token from it atomically, returning the tokens left and whether one was taken.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (float64, bool, error)
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	b.limit = limit
+	b.tokens = limit.refill(b.tokens, now.Sub(b.updatedAt))
+	if now.After(b.updatedAt) {
+		b.updatedAt = now
+	}
+
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// sweep drops buckets that have refilled completely, they are
+// indistinguishable from buckets that were never used.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if b.limit.refill(b.tokens, now.Sub(b.updatedAt)) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+type rateLimitRepo interface {
+	TakeRateLimitToken(ctx context.Context, arg core.TakeRateLimitTokenParams) (core.TakeRateLimitTokenRow, error)
+	DeleteIdleRateLimits(ctx context.Context, now pgtype.Timestamp) error
+}
+
+// PostgresStore shares buckets between replicas.
+type PostgresStore struct {
+	rateLimitRepo rateLimitRepo
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(rateLimitRepo rateLimitRepo) *PostgresStore {
+	return &PostgresStore{
+		rateLimitRepo: rateLimitRepo,
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (float64, bool, error) {
+	s.sweep(ctx, now)
+
+	row, err := s.rateLimitRepo.TakeRateLimitToken(ctx, core.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Burst),
+		Now:   pgtype.Timestamp{Time: now.UTC(), Valid: true},
+		Rate:  limit.Rate(),
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return row.Tokens, row.Allowed, nil
+}
+
+// sweep deletes the rows of buckets that have refilled completely, like
+// MemoryStore.sweep. Every replica sweeps, deleting twice is harmless.
+func (s *PostgresStore) sweep(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	err := s.rateLimitRepo.DeleteIdleRateLimits(ctx, pgtype.Timestamp{Time: now.UTC(), Valid: true})
+	if err != nil {
+		// buckets left behind are swept next time
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+	}
+}
@@ -0,0 +1,21 @@
+package response
+
+import "time"
+
+type ApiKeyData struct {
+	Id         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  int        `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// AddApiKeyData is the only response containing the plaintext key.
+type AddApiKeyData struct {
+	ApiKeyData
+	Key string `json:"key"`
+}
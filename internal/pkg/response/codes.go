@@ -7,4 +7,8 @@ const (
 	EntityAlreadyExists   = 0o04
 	InternalError         = 0o05
 	NotFound              = 0o06
+	Unauthorized          = 0o07
+	Forbidden             = 0o10
+	TooManyRequests       = 0o11
+	PayloadTooLarge       = 0o12
 )
@@ -0,0 +1,16 @@
+package response
+
+import "time"
+
+// CommentData has ModerationReason for rejected comments only.
+type CommentData struct {
+	Id               int       `json:"id"`
+	NewsId           int       `json:"news_id"`
+	ParentId         int       `json:"parent_id,omitempty"`
+	AuthorId         int       `json:"author_id"`
+	Content          string    `json:"content"`
+	Status           string    `json:"status"`
+	ModerationReason string    `json:"moderation_reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package response
+
+import "time"
+
+type AddVariantData struct {
+	Id int `json:"id"`
+}
+
+// ExperimentData has StartedAt only for news experimented on, and StoppedAt
+// and WinnerId once the experiment stops.
+type ExperimentData struct {
+	Running   bool          `json:"running"`
+	StartedAt *time.Time    `json:"started_at,omitempty"`
+	StoppedAt *time.Time    `json:"stopped_at,omitempty"`
+	WinnerId  int           `json:"winner_id,omitempty"`
+	Variants  []VariantData `json:"variants"`
+}
+
+type VariantData struct {
+	Id        int    `json:"id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Weight    int    `json:"weight"`
+	Exposures int    `json:"exposures"`
+	Clicks    int    `json:"clicks"`
+}
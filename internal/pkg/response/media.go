@@ -0,0 +1,21 @@
+package response
+
+// MediaData has Width and Height for images only, and so Variants.
+type MediaData struct {
+	Id          int                `json:"id"`
+	Role        string             `json:"role"`
+	ContentType string             `json:"content_type"`
+	Size        int64              `json:"size"`
+	Width       int                `json:"width,omitempty"`
+	Height      int                `json:"height,omitempty"`
+	Url         string             `json:"url"`
+	Variants    []MediaVariantData `json:"variants"`
+}
+
+type MediaVariantData struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Url    string `json:"url"`
+}
@@ -1,11 +1,54 @@
 package response
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type AddNewsData struct {
 	Id int `json:"id"`
 }
 
+// NewsData has ContentHtml only when clients ask for content to be
+// rendered. Blocks are missing for html content, which isn't converted.
+// Media are only there for single news. Reactions are counts by kind and
+// MyReactions the kinds the author who asked reacted with, both are missing
+// from events and changes, and so are pins. PinnedUntil is missing for news
+// pinned until they're unpinned. IsRead and IsBookmarked are there for
+// authenticated authors only. Targeting is missing for news shown to
+// everyone. VariantId is the variant of a running experiment in place of
+// the title and content, readers count its exposures and clicks with it.
+// ApiKeyId and UpdatedByApiKeyId are the api keys news were written with,
+// AuthorId and UpdatedBy are then the admins who created the keys.
 type NewsData struct {
-	Id      int    `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Id                int             `json:"id"`
+	Title             string          `json:"title"`
+	Content           string          `json:"content"`
+	ContentFormat     string          `json:"content_format"`
+	ContentHtml       string          `json:"content_html,omitempty"`
+	Blocks            json.RawMessage `json:"blocks,omitempty"`
+	AuthorId          int             `json:"author_id,omitempty"`
+	UpdatedBy         int             `json:"updated_by,omitempty"`
+	ApiKeyId          int             `json:"api_key_id,omitempty"`
+	UpdatedByApiKeyId int             `json:"updated_by_api_key_id,omitempty"`
+	Status            string          `json:"status"`
+	CommentsCount     int             `json:"comments_count"`
+	Targeting         string          `json:"targeting,omitempty"`
+	VariantId         int             `json:"variant_id,omitempty"`
+	Pinned            bool            `json:"pinned,omitempty"`
+	PinnedUntil       *time.Time      `json:"pinned_until,omitempty"`
+	Reactions         map[string]int  `json:"reactions,omitempty"`
+	MyReactions       []string        `json:"my_reactions,omitempty"`
+	IsRead            *bool           `json:"is_read,omitempty"`
+	IsBookmarked      *bool           `json:"is_bookmarked,omitempty"`
+	Media             []MediaData     `json:"media,omitempty"`
+}
+
+// NewsChangesData is applied by clients on top of what they have, Token is
+// what they pass as since next time.
+type NewsChangesData struct {
+	Upserted []NewsData `json:"upserted"`
+	Deleted  []int      `json:"deleted"`
+	Token    string     `json:"token"`
+	HasMore  bool       `json:"has_more"`
 }
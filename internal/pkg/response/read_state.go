@@ -0,0 +1,5 @@
+package response
+
+type UnreadCountData struct {
+	Count int `json:"count"`
+}
@@ -0,0 +1,16 @@
+package response
+
+type ClientContextData struct {
+	Locale           string `json:"locale,omitempty"`
+	Platform         string `json:"platform,omitempty"`
+	AppVersion       string `json:"app_version,omitempty"`
+	Tier             string `json:"tier,omitempty"`
+	LearningLanguage string `json:"learning_language,omitempty"`
+}
+
+// TargetingDryRunData splits the contexts asked about by whether the news
+// are shown to them.
+type TargetingDryRunData struct {
+	Visible []ClientContextData `json:"visible"`
+	Hidden  []ClientContextData `json:"hidden"`
+}
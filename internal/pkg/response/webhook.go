@@ -0,0 +1,30 @@
+package response
+
+import "time"
+
+type WebhookData struct {
+	Id        int       `json:"id"`
+	Url       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddWebhookData is the only response containing the signing secret.
+type AddWebhookData struct {
+	WebhookData
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryData struct {
+	Id             int64      `json:"id"`
+	WebhookId      int        `json:"webhook_id"`
+	EventId        int64      `json:"event_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	newsv1 "github.com/anton-uvarenko/promova_test/internal/pb/news/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewGrpcServer registers the news service along with the health and
+// reflection services, so probes and tools like grpcurl work out of the box.
+func NewGrpcServer(newsServer newsv1.NewsServiceServer, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	newsv1.RegisterNewsServiceServer(s, newsServer)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(newsv1.NewsService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	reflection.Register(s)
+
+	return s
+}
@@ -18,18 +18,200 @@ type newsHandler interface {
 	UpdateNews(ctx *gin.Context)
 	GetNewsById(ctx *gin.Context)
 	GetAllNews(ctx *gin.Context)
+	GetPopularNews(ctx *gin.Context)
+	GetBookmarks(ctx *gin.Context)
 	DeleteNews(ctx *gin.Context)
+	PublishNews(ctx *gin.Context)
+	DryRunTargeting(ctx *gin.Context)
+	PinNews(ctx *gin.Context)
+	UnpinNews(ctx *gin.Context)
+	GetFeaturedNews(ctx *gin.Context)
+	FeatureNews(ctx *gin.Context)
+	UnfeatureNews(ctx *gin.Context)
 }
 
-func SetUpRoutes(newsHandler newsHandler) http.Handler {
+type newsEventHandler interface {
+	StreamNewsEvents(ctx *gin.Context)
+}
+
+type syncHandler interface {
+	GetNewsChanges(ctx *gin.Context)
+}
+
+type apiKeyHandler interface {
+	AddApiKey(ctx *gin.Context)
+	GetAllApiKeys(ctx *gin.Context)
+	RevokeApiKey(ctx *gin.Context)
+}
+
+type webhookHandler interface {
+	AddWebhook(ctx *gin.Context)
+	GetAllWebhooks(ctx *gin.Context)
+	GetWebhookDeliveries(ctx *gin.Context)
+	ReplayWebhookDelivery(ctx *gin.Context)
+}
+
+type graphqlHandler interface {
+	Query(ctx *gin.Context)
+}
+
+type mediaHandler interface {
+	UploadMedia(ctx *gin.Context)
+	DeleteMedia(ctx *gin.Context)
+}
+
+type commentHandler interface {
+	AddComment(ctx *gin.Context)
+	GetNewsComments(ctx *gin.Context)
+	UpdateComment(ctx *gin.Context)
+	DeleteComment(ctx *gin.Context)
+	GetPendingComments(ctx *gin.Context)
+	ApproveComment(ctx *gin.Context)
+	RejectComment(ctx *gin.Context)
+}
+
+type reactionHandler interface {
+	AddReaction(ctx *gin.Context)
+	RemoveReaction(ctx *gin.Context)
+}
+
+type viewHandler interface {
+	RecordView(ctx *gin.Context)
+}
+
+type readStateHandler interface {
+	MarkRead(ctx *gin.Context)
+	MarkAllRead(ctx *gin.Context)
+	GetUnreadCount(ctx *gin.Context)
+	AddBookmark(ctx *gin.Context)
+	RemoveBookmark(ctx *gin.Context)
+}
+
+type experimentHandler interface {
+	GetExperiment(ctx *gin.Context)
+	AddVariant(ctx *gin.Context)
+	DeleteVariant(ctx *gin.Context)
+	StartExperiment(ctx *gin.Context)
+	StopExperiment(ctx *gin.Context)
+	RecordExposure(ctx *gin.Context)
+	RecordClick(ctx *gin.Context)
+}
+
+type openapiHandler interface {
+	GetSpec(ctx *gin.Context)
+	GetDocs(ctx *gin.Context)
+}
+
+func SetUpRoutes(
+	newsHandler newsHandler,
+	newsEventHandler newsEventHandler,
+	syncHandler syncHandler,
+	apiKeyHandler apiKeyHandler,
+	webhookHandler webhookHandler,
+	graphqlHandler graphqlHandler,
+	openapiHandler openapiHandler,
+	mediaHandler mediaHandler,
+	// mediaFiles serves media kept on the local filesystem, it's nil when
+	// they're served by the storage
+	mediaFiles http.Handler,
+	commentHandler commentHandler,
+	reactionHandler reactionHandler,
+	viewHandler viewHandler,
+	readStateHandler readStateHandler,
+	experimentHandler experimentHandler,
+	authMiddleware gin.HandlerFunc,
+	optionalAuthMiddleware gin.HandlerFunc,
+	rateLimitMiddleware gin.HandlerFunc,
+	validationMiddleware gin.HandlerFunc,
+	// trustedProxies are the addresses or CIDRs of the proxies in front of
+	// the api, nil when there are none
+	trustedProxies []string,
+) (http.Handler, error) {
 	router := gin.New()
+	// lets handlers and services read values put on the request context by middlewares
+	router.ContextWithFallback = true
 	gin.SetMode(gin.ReleaseMode)
 
-	router.POST("/posts", newsHandler.AddNews)
-	router.GET("/posts", newsHandler.GetAllNews)
-	router.PUT("/posts/:id", newsHandler.UpdateNews)
-	router.GET("/posts/:id", newsHandler.GetNewsById)
-	router.DELETE("/posts/:id", newsHandler.DeleteNews)
+	// anonymous clients are rate limited and have their views told apart by
+	// address, X-Forwarded-For is only believed when a known proxy sets it
+	err := router.SetTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	// runs first so that it sees every response of the routes it validates
+	router.Use(validationMiddleware)
+
+	router.GET("/openapi.json", openapiHandler.GetSpec)
+	router.GET("/docs/*filepath", openapiHandler.GetDocs)
+
+	if mediaFiles != nil {
+		router.GET("/media/*filepath", gin.WrapH(http.StripPrefix("/media", mediaFiles)))
+	}
+
+	public := router.Group("/", rateLimitMiddleware)
+	public.GET("/posts/:id/comments", commentHandler.GetNewsComments)
+
+	// queries are public, mutations are authorized by the services
+	optionallyAuthorized := router.Group("/", optionalAuthMiddleware, rateLimitMiddleware)
+	optionallyAuthorized.POST("/graphql", graphqlHandler.Query)
+	// drafts are streamed and synced to those who may read them
+	optionallyAuthorized.GET("/posts/events", newsEventHandler.StreamNewsEvents)
+	optionallyAuthorized.GET("/posts/changes", syncHandler.GetNewsChanges)
+	// news carry the reactions of authors who ask
+	optionallyAuthorized.GET("/posts", newsHandler.GetAllNews)
+	optionallyAuthorized.GET("/posts/popular", newsHandler.GetPopularNews)
+	optionallyAuthorized.GET("/posts/featured", newsHandler.GetFeaturedNews)
+	optionallyAuthorized.GET("/posts/:id", newsHandler.GetNewsById)
+	// views are told apart by author, or by address for anonymous ones
+	optionallyAuthorized.POST("/posts/:id/views", viewHandler.RecordView)
+
+	// rate limiting goes after authentication so clients are limited per key or user
+	authorized := router.Group("/", authMiddleware, rateLimitMiddleware)
+	authorized.POST("/posts", newsHandler.AddNews)
+	authorized.PUT("/posts/:id", newsHandler.UpdateNews)
+	authorized.DELETE("/posts/:id", newsHandler.DeleteNews)
+	authorized.POST("/posts/:id/publish", newsHandler.PublishNews)
+	authorized.POST("/posts/:id/targeting/dry-run", newsHandler.DryRunTargeting)
+	authorized.PUT("/posts/:id/pin", newsHandler.PinNews)
+	authorized.DELETE("/posts/:id/pin", newsHandler.UnpinNews)
+	authorized.PUT("/posts/:id/featured", newsHandler.FeatureNews)
+	authorized.DELETE("/posts/:id/featured", newsHandler.UnfeatureNews)
+	authorized.POST("/posts/:id/media", mediaHandler.UploadMedia)
+	authorized.DELETE("/posts/:id/media/:media_id", mediaHandler.DeleteMedia)
+	authorized.POST("/posts/:id/comments", commentHandler.AddComment)
+	authorized.PUT("/posts/:id/comments/:comment_id", commentHandler.UpdateComment)
+	authorized.DELETE("/posts/:id/comments/:comment_id", commentHandler.DeleteComment)
+	authorized.PUT("/posts/:id/reactions/:kind", reactionHandler.AddReaction)
+	authorized.DELETE("/posts/:id/reactions/:kind", reactionHandler.RemoveReaction)
+	authorized.POST("/posts/read-all", readStateHandler.MarkAllRead)
+	authorized.POST("/posts/:id/read", readStateHandler.MarkRead)
+	authorized.PUT("/posts/:id/bookmark", readStateHandler.AddBookmark)
+	authorized.DELETE("/posts/:id/bookmark", readStateHandler.RemoveBookmark)
+	authorized.GET("/posts/:id/experiment", experimentHandler.GetExperiment)
+	authorized.POST("/posts/:id/experiment/start", experimentHandler.StartExperiment)
+	authorized.POST("/posts/:id/experiment/stop", experimentHandler.StopExperiment)
+	authorized.POST("/posts/:id/variants", experimentHandler.AddVariant)
+	authorized.DELETE("/posts/:id/variants/:variant_id", experimentHandler.DeleteVariant)
+	// variants are counted for the readers they're assigned to, who are known
+	authorized.POST("/posts/:id/variants/:variant_id/exposures", experimentHandler.RecordExposure)
+	authorized.POST("/posts/:id/variants/:variant_id/clicks", experimentHandler.RecordClick)
+
+	authorized.GET("/me/unread-count", readStateHandler.GetUnreadCount)
+	authorized.GET("/me/bookmarks", newsHandler.GetBookmarks)
+
+	authorized.GET("/moderation/comments", commentHandler.GetPendingComments)
+	authorized.POST("/moderation/comments/:id/approve", commentHandler.ApproveComment)
+	authorized.POST("/moderation/comments/:id/reject", commentHandler.RejectComment)
+
+	authorized.POST("/api-keys", apiKeyHandler.AddApiKey)
+	authorized.GET("/api-keys", apiKeyHandler.GetAllApiKeys)
+	authorized.DELETE("/api-keys/:id", apiKeyHandler.RevokeApiKey)
+
+	authorized.POST("/webhooks", webhookHandler.AddWebhook)
+	authorized.GET("/webhooks", webhookHandler.GetAllWebhooks)
+	authorized.GET("/webhooks/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+	authorized.POST("/webhooks/:id/deliveries/:delivery_id/replay", webhookHandler.ReplayWebhookDelivery)
 
-	return router
+	return router, nil
 }
@@ -0,0 +1,394 @@
+package targeting
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/lru"
+)
+
+// Rule is a parsed rule, written as
+//
+//	rule       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" rule ")" | condition
+//	condition  = field ( "==" | "!=" ) value
+//	           | field [ "not" ] "in" "(" value { "," value } ")"
+//	           | "app_version" ( "<" | "<=" | ">" | ">=" ) value
+//
+// Values are double-quoted strings. A locale of a language alone, such as
+// "en", matches every locale of the language, "en-US" among them.
+// Conditions about what the client didn't tell are false.
+type Rule struct {
+	source string
+	expr   expr
+}
+
+// Parse reads and validates a rule.
+func Parse(source string) (*Rule, error) {
+	if len(source) > MaxRuleLength {
+		return nil, fmt.Errorf("%w: [longer than %d characters]", ErrInvalidRule, MaxRuleLength)
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEnd {
+		return nil, p.unexpected()
+	}
+
+	return &Rule{source: source, expr: expr}, nil
+}
+
+// Matches reports whether the client is to be shown the news.
+func (r *Rule) Matches(client Client) bool {
+	return r.expr.matches(client)
+}
+
+func (r *Rule) String() string {
+	return r.source
+}
+
+type expr interface {
+	matches(client Client) bool
+}
+
+type orExpr []expr
+
+func (e orExpr) matches(client Client) bool {
+	return slices.ContainsFunc(e, func(operand expr) bool { return operand.matches(client) })
+}
+
+type andExpr []expr
+
+func (e andExpr) matches(client Client) bool {
+	return !slices.ContainsFunc(e, func(operand expr) bool { return !operand.matches(client) })
+}
+
+type notExpr struct {
+	operand expr
+}
+
+func (e notExpr) matches(client Client) bool {
+	return !e.operand.matches(client)
+}
+
+type condition struct {
+	field  string
+	op     string
+	values []string
+	// versions are values of app_version conditions, parsed
+	versions []Version
+}
+
+func (c condition) matches(client Client) bool {
+	value := client.value(c.field)
+	if value == "" {
+		return false
+	}
+
+	switch c.op {
+	case "==", "in":
+		return c.anyEqual(client, value)
+	case "!=", "not in":
+		return !c.anyEqual(client, value)
+	}
+
+	order := client.AppVersion.Compare(c.versions[0])
+	switch c.op {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+func (c condition) anyEqual(client Client, value string) bool {
+	for i, v := range c.values {
+		switch {
+		case c.field == FieldAppVersion:
+			if client.AppVersion.Compare(c.versions[i]) == 0 {
+				return true
+			}
+		case c.field == FieldLocale && !strings.Contains(v, "-"):
+			language, _, _ := strings.Cut(value, "-")
+			if strings.EqualFold(language, v) {
+				return true
+			}
+		default:
+			if strings.EqualFold(value, v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+const (
+	tokenEnd = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenPunct
+)
+
+type token struct {
+	kind  int
+	text  string
+	start int
+}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), start: i})
+			i++
+		case c == '"':
+			end := strings.IndexByte(source[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: [unterminated string at %d]", ErrInvalidRule, i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: source[i+1 : i+1+end], start: i})
+			i += end + 2
+		case strings.ContainsRune("=!<>", c):
+			op := string(c)
+			if i+1 < len(source) && source[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("%w: [unknown operator %q at %d]", ErrInvalidRule, op, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, start: i})
+			i += len(op)
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(source) && (source[i] == '_' || unicode.IsLetter(rune(source[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: source[start:i], start: start})
+		default:
+			return nil, fmt.Errorf("%w: [unexpected %q at %d]", ErrInvalidRule, c, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, start: len(source)}), nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+// accept takes the next token when it's text.
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenWord || t.kind == tokenPunct) && t.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEnd {
+		return fmt.Errorf("%w: [unexpected end]", ErrInvalidRule)
+	}
+	return fmt.Errorf("%w: [unexpected %q at %d]", ErrInvalidRule, t.text, t.start)
+}
+
+func (p *parser) or() (expr, error) {
+	return p.list("or", p.and, func(operands []expr) expr { return orExpr(operands) })
+}
+
+func (p *parser) and() (expr, error) {
+	return p.list("and", p.unary, func(operands []expr) expr { return andExpr(operands) })
+}
+
+func (p *parser) list(keyword string, operand func() (expr, error), join func([]expr) expr) (expr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []expr{first}
+	for p.accept(keyword) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return join(operands), nil
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.accept("not") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+
+	if p.accept("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected()
+		}
+		return inner, nil
+	}
+
+	return p.condition()
+}
+
+func (p *parser) condition() (expr, error) {
+	field := p.peek()
+	if field.kind != tokenWord {
+		return nil, p.unexpected()
+	}
+	switch field.text {
+	case FieldLocale, FieldPlatform, FieldAppVersion, FieldTier, FieldLearningLanguage:
+	default:
+		return nil, fmt.Errorf("%w: [unknown field %q at %d]", ErrInvalidRule, field.text, field.start)
+	}
+	p.take()
+
+	c := condition{field: field.text}
+	switch {
+	case p.accept("in"):
+		c.op = "in"
+	case p.accept("not"):
+		if !p.accept("in") {
+			return nil, p.unexpected()
+		}
+		c.op = "not in"
+	case p.peek().kind == tokenOp:
+		c.op = p.take().text
+	default:
+		return nil, p.unexpected()
+	}
+
+	if c.op == "in" || c.op == "not in" {
+		if !p.accept("(") {
+			return nil, p.unexpected()
+		}
+		for {
+			value, err := p.value(c.field)
+			if err != nil {
+				return nil, err
+			}
+			c.values = append(c.values, value)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected()
+		}
+	} else {
+		if c.op != "==" && c.op != "!=" && c.field != FieldAppVersion {
+			return nil, fmt.Errorf("%w: [%s can't be compared with %s at %d]", ErrInvalidRule, c.field, c.op, field.start)
+		}
+		value, err := p.value(c.field)
+		if err != nil {
+			return nil, err
+		}
+		c.values = []string{value}
+	}
+
+	if c.field == FieldAppVersion {
+		for _, value := range c.values {
+			version, err := ParseVersion(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: [%w]", ErrInvalidRule, err)
+			}
+			c.versions = append(c.versions, version)
+		}
+	}
+
+	return c, nil
+}
+
+func (p *parser) value(field string) (string, error) {
+	t := p.peek()
+	if t.kind != tokenString {
+		return "", p.unexpected()
+	}
+	if t.text == "" {
+		return "", fmt.Errorf("%w: [empty value at %d]", ErrInvalidRule, t.start)
+	}
+	if field == FieldPlatform && !slices.Contains(Platforms, strings.ToLower(t.text)) {
+		return "", fmt.Errorf("%w: [unknown platform %q at %d]", ErrInvalidRule, t.text, t.start)
+	}
+	p.take()
+
+	return t.text, nil
+}
+
+// Rules parses rules once, news are filtered by the same few rules over and
+// over.
+type Rules struct {
+	cache *lru.Cache[string, *Rule]
+}
+
+func NewRules(size int, ttl time.Duration) *Rules {
+	return &Rules{
+		cache: lru.New[string, *Rule](size, ttl),
+	}
+}
+
+// Parse is Parse of the package, invalid rules aren't cached.
+func (r *Rules) Parse(source string) (*Rule, error) {
+	rule, ok := r.cache.Get(source)
+	if ok {
+		return rule, nil
+	}
+
+	rule, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Set(source, rule)
+
+	return rule, nil
+}
@@ -0,0 +1,145 @@
+// Package targeting decides which clients news are shown to. News carry a
+// rule such as
+//
+//	platform in ("ios", "android") and app_version >= "3.2" and not tier == "free"
+//
+// which is evaluated against what clients tell about themselves.
+package targeting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Fields rules are written about.
+const (
+	FieldLocale           = "locale"
+	FieldPlatform         = "platform"
+	FieldAppVersion       = "app_version"
+	FieldTier             = "tier"
+	FieldLearningLanguage = "learning_language"
+)
+
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWeb     = "web"
+)
+
+var Platforms = []string{PlatformIOS, PlatformAndroid, PlatformWeb}
+
+// MaxRuleLength bounds how long a rule can get.
+const MaxRuleLength = 1000
+
+var (
+	ErrInvalidRule    = errors.New("invalid targeting rule")
+	ErrInvalidVersion = errors.New("invalid app version")
+)
+
+// Client is what a client tells about itself, everything is optional.
+// Strings are compared regardless of case.
+type Client struct {
+	Locale           string
+	Platform         string
+	AppVersion       Version
+	Tier             string
+	LearningLanguage string
+}
+
+// Key tells clients apart, clients with the same key match the same rules.
+func (c Client) Key() string {
+	return fmt.Sprintf("locale=%s;platform=%s;app_version=%s;tier=%s;learning_language=%s",
+		strings.ToLower(c.Locale),
+		strings.ToLower(c.Platform),
+		c.AppVersion,
+		strings.ToLower(c.Tier),
+		strings.ToLower(c.LearningLanguage),
+	)
+}
+
+func (c Client) value(field string) string {
+	switch field {
+	case FieldLocale:
+		return c.Locale
+	case FieldPlatform:
+		return c.Platform
+	case FieldAppVersion:
+		return c.AppVersion.String()
+	case FieldTier:
+		return c.Tier
+	case FieldLearningLanguage:
+		return c.LearningLanguage
+	}
+
+	return ""
+}
+
+type clientKey struct{}
+
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext is false for requests that didn't tell anything about
+// the client, which see everything.
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientKey{}).(Client)
+	return client, ok
+}
+
+// Version is a dotted app version such as 3.2.1, missing parts count as 0.
+type Version []int
+
+// ParseVersion is nil for an empty version.
+func ParseVersion(s string) (Version, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return nil, fmt.Errorf("%w: [%q has more than 4 parts]", ErrInvalidVersion, s)
+	}
+
+	version := make(Version, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return nil, fmt.Errorf("%w: [%q]", ErrInvalidVersion, s)
+		}
+		version = append(version, n)
+	}
+
+	return version, nil
+}
+
+func (v Version) String() string {
+	parts := make([]string, 0, len(v))
+	for _, n := range v {
+		parts = append(parts, strconv.Itoa(n))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Compare is negative when v is older than other, positive when it's newer
+// and 0 when they're the same.
+func (v Version) Compare(other Version) int {
+	for i := 0; i < max(len(v), len(other)); i++ {
+		var a, b int
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(other) {
+			b = other[i]
+		}
+		if a != b {
+			return a - b
+		}
+	}
+
+	return 0
+}
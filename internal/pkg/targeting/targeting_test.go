@@ -0,0 +1,224 @@
+package targeting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestParse(t *testing.T) {
+	testTable := []struct {
+		Name          string
+		Source        string
+		ExpectedError bool
+	}{
+		{
+			Name:   "Ok every field",
+			Source: `locale == "en" and platform in ("ios", "android") and app_version >= "3.2" and tier != "free" and learning_language not in ("es")`,
+		},
+		{
+			Name:   "Ok nested",
+			Source: `not (platform == "web" or (tier == "premium" and not app_version < "2"))`,
+		},
+		{
+			Name:          "Error empty",
+			Source:        ``,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unknown field",
+			Source:        `country == "ua"`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unknown platform",
+			Source:        `platform == "windows"`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error ordered string",
+			Source:        `tier > "free"`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error invalid version",
+			Source:        `app_version >= "3.x"`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unquoted value",
+			Source:        `tier == free`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error empty value",
+			Source:        `tier == ""`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unterminated string",
+			Source:        `tier == "free`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error unbalanced parentheses",
+			Source:        `(tier == "free"`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error dangling operator",
+			Source:        `tier == "free" and`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error single equals",
+			Source:        `tier = "free"`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error too long",
+			Source:        `tier in ("` + strings.Repeat("a", MaxRuleLength) + `")`,
+			ExpectedError: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			rule, err := Parse(testCase.Source)
+			assert.Equal(t, err != nil, testCase.ExpectedError)
+			if testCase.ExpectedError {
+				assert.Equal(t, errors.Is(err, ErrInvalidRule), true)
+				return
+			}
+			assert.Equal(t, rule.String(), testCase.Source)
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	iosClient := Client{
+		Locale:           "en-US",
+		Platform:         "iOS",
+		AppVersion:       Version{3, 2, 1},
+		Tier:             "premium",
+		LearningLanguage: "es",
+	}
+
+	testTable := []struct {
+		Name     string
+		Source   string
+		Client   Client
+		Expected bool
+	}{
+		{
+			Name:     "Language matches every locale of it",
+			Source:   `locale == "en"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Locale matches itself only",
+			Source:   `locale in ("en-GB", "uk")`,
+			Client:   iosClient,
+			Expected: false,
+		},
+		{
+			Name:     "Regardless of case",
+			Source:   `platform == "ios" and locale == "EN-us"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Newer version",
+			Source:   `app_version >= "3.2"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Older version",
+			Source:   `app_version < "3.10"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Same version with missing parts",
+			Source:   `app_version == "3.2.1.0"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Not in",
+			Source:   `learning_language not in ("fr", "de") and tier != "free"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "And binds tighter than or",
+			Source:   `tier == "free" and platform == "web" or learning_language == "es"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Parentheses",
+			Source:   `tier == "free" and (platform == "web" or learning_language == "es")`,
+			Client:   iosClient,
+			Expected: false,
+		},
+		{
+			Name:     "Not",
+			Source:   `not platform == "android"`,
+			Client:   iosClient,
+			Expected: true,
+		},
+		{
+			Name:     "Unknown value doesn't match",
+			Source:   `app_version >= "1"`,
+			Client:   Client{Platform: "web"},
+			Expected: false,
+		},
+		{
+			Name:     "Unknown value isn't different either",
+			Source:   `tier != "free"`,
+			Client:   Client{Platform: "web"},
+			Expected: false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			rule, err := Parse(testCase.Source)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, rule.Matches(testCase.Client), testCase.Expected)
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	version, err := ParseVersion("3.10.2")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version, Version{3, 10, 2})
+	assert.Equal(t, version.String(), "3.10.2")
+	assert.Equal(t, version.Compare(Version{3, 9}) > 0, true)
+
+	version, err = ParseVersion("")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version == nil, true)
+
+	for _, invalid := range []string{"3.", "v3", "3.-1", "3.+1", "1.2.3.4.5"} {
+		_, err = ParseVersion(invalid)
+		assert.Equal(t, errors.Is(err, ErrInvalidVersion), true)
+	}
+}
+
+func TestClientFromContext(t *testing.T) {
+	_, ok := ClientFromContext(context.Background())
+	assert.Equal(t, ok, false)
+
+	client := Client{Platform: PlatformWeb, AppVersion: Version{1}}
+	got, ok := ClientFromContext(WithClient(context.Background(), client))
+	assert.Equal(t, ok, true)
+	assert.Equal(t, got.Key(), client.Key())
+}
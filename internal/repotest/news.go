@@ -0,0 +1,423 @@
+// Package repotest checks that implementations of the repositories behave
+// the way the services expect of Postgres.
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type NewsRepo interface {
+	AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error)
+	DeleteNews(ctx context.Context, id int32) error
+	GetAllNews(ctx context.Context) ([]core.News, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error)
+	GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error)
+	GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error)
+	UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error
+	PublishNews(ctx context.Context, id int32) error
+	PinNews(ctx context.Context, arg core.PinNewsParams) error
+	UnpinNews(ctx context.Context, id int32) error
+}
+
+// NewNewsRepo returns an empty repository and the id of an author news can
+// reference. It's called for every test.
+type NewNewsRepo func(t *testing.T) (NewsRepo, int32)
+
+// missingId is an id no news or author has.
+const missingId = 1 << 30
+
+// TestNewsRepo runs the conformance suite against a news repository. Every
+// test stops at the first constraint violation, after which Postgres
+// rejects whatever else is sent in the same transaction.
+func TestNewsRepo(t *testing.T, newRepo NewNewsRepo) {
+	t.Run("Add and get", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		news, err := repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news.ID, id)
+		assert.Equal(t, news.Title, pgtype.Text{String: "some title", Valid: true})
+		assert.Equal(t, news.Content, pgtype.Text{String: "some content", Valid: true})
+		assert.Equal(t, news.ContentFormat, "plain")
+		assertJSON(t, news.Blocks, someBlocks)
+		assert.Equal(t, news.Targeting, pgtype.Text{String: someTargeting, Valid: true})
+		assert.Equal(t, news.AuthorID, pgtype.Int4{Int32: authorId, Valid: true})
+		assert.Equal(t, news.UpdatedBy.Valid, false)
+		assert.Equal(t, news.ApiKeyID.Valid, false)
+		assert.Equal(t, news.UpdatedByApiKeyID.Valid, false)
+		assert.Equal(t, news.Status, "draft")
+		assert.Equal(t, news.PublishedAt.Valid, false)
+		assert.Equal(t, news.CreatedAt.Valid, true)
+		assert.Equal(t, news.UpdatedAt, news.CreatedAt)
+	})
+
+	t.Run("Ids increase", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		first, err := repo.AddNews(ctx, newsParams("first title", authorId))
+		assert.Equal(t, err, nil)
+		second, err := repo.AddNews(ctx, newsParams("second title", authorId))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, second > first, true)
+	})
+
+	t.Run("Anonymous news", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, core.AddNewsParams{
+			Title:   pgtype.Text{String: "some title", Valid: true},
+			Content: pgtype.Text{String: "some content", Valid: true},
+		})
+		assert.Equal(t, err, nil)
+
+		news, err := repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news.AuthorID.Valid, false)
+	})
+
+	t.Run("Null titles don't conflict", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		for range 2 {
+			_, err := repo.AddNews(ctx, core.AddNewsParams{
+				Content:  pgtype.Text{String: "some content", Valid: true},
+				AuthorID: pgtype.Int4{Int32: authorId, Valid: true},
+			})
+			assert.Equal(t, err, nil)
+		}
+	})
+
+	t.Run("Add duplicate title", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		_, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		_, err = repo.AddNews(ctx, newsParams("some title", authorId))
+		assertPgError(t, err, "23505", "news_title_key")
+	})
+
+	t.Run("Add title too long", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+
+		_, err := repo.AddNews(context.Background(), newsParams(strings.Repeat("a", 256), authorId))
+		assertPgError(t, err, "22001", "")
+	})
+
+	t.Run("Add missing author", func(t *testing.T) {
+		repo, _ := newRepo(t)
+
+		_, err := repo.AddNews(context.Background(), newsParams("some title", missingId))
+		assertPgError(t, err, "23503", "news_author_id_fkey")
+	})
+
+	t.Run("Get missing", func(t *testing.T) {
+		repo, _ := newRepo(t)
+
+		_, err := repo.GetNewsById(context.Background(), missingId)
+		assert.Equal(t, errors.Is(err, pgx.ErrNoRows), true)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+		before, err := repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+
+		err = repo.UpdateNews(ctx, updateParams(id, "other title", authorId))
+		assert.Equal(t, err, nil)
+
+		news, err := repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news.Title, pgtype.Text{String: "other title", Valid: true})
+		assert.Equal(t, news.Content, pgtype.Text{String: "other content", Valid: true})
+		assert.Equal(t, news.ContentFormat, "markdown")
+		// updated without blocks, which clears them
+		assert.Equal(t, news.Blocks == nil, true)
+		// and without targeting, which shows them to everyone
+		assert.Equal(t, news.Targeting.Valid, false)
+		assert.Equal(t, news.UpdatedBy, pgtype.Int4{Int32: authorId, Valid: true})
+		assert.Equal(t, news.AuthorID, before.AuthorID)
+		assert.Equal(t, news.CreatedAt, before.CreatedAt)
+		assert.Equal(t, news.UpdatedAt.Time.Before(before.UpdatedAt.Time), false)
+	})
+
+	t.Run("Update keeping title", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		err = repo.UpdateNews(ctx, updateParams(id, "some title", authorId))
+		assert.Equal(t, err, nil)
+	})
+
+	t.Run("Update missing", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		err := repo.UpdateNews(ctx, updateParams(missingId, "some title", authorId))
+		assert.Equal(t, err, nil)
+
+		_, err = repo.GetNewsById(ctx, missingId)
+		assert.Equal(t, errors.Is(err, pgx.ErrNoRows), true)
+	})
+
+	t.Run("Update duplicate title", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		_, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+		id, err := repo.AddNews(ctx, newsParams("other title", authorId))
+		assert.Equal(t, err, nil)
+
+		err = repo.UpdateNews(ctx, updateParams(id, "some title", authorId))
+		assertPgError(t, err, "23505", "news_title_key")
+	})
+
+	t.Run("Update missing author", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		err = repo.UpdateNews(ctx, updateParams(id, "other title", missingId))
+		assertPgError(t, err, "23503", "news_updated_by_fkey")
+	})
+
+	t.Run("Publish", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		err = repo.PublishNews(ctx, id)
+		assert.Equal(t, err, nil)
+
+		news, err := repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news.Status, "published")
+		assert.Equal(t, news.PublishedAt.Valid, true)
+		assert.Equal(t, news.PublishedAt, news.UpdatedAt)
+
+		err = repo.PublishNews(ctx, missingId)
+		assert.Equal(t, err, nil)
+	})
+
+	t.Run("Pin", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		until := pgtype.Timestamp{Time: time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond), Valid: true}
+		err = repo.PinNews(ctx, core.PinNewsParams{ID: id, PinnedUntil: until})
+		assert.Equal(t, err, nil)
+
+		news, err := repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news.PinnedAt.Valid, true)
+		assert.Equal(t, news.PinnedAt, news.UpdatedAt)
+		assert.Equal(t, news.PinnedUntil, until)
+
+		err = repo.UnpinNews(ctx, id)
+		assert.Equal(t, err, nil)
+
+		news, err = repo.GetNewsById(ctx, id)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, news.PinnedAt.Valid, false)
+		assert.Equal(t, news.PinnedUntil.Valid, false)
+
+		err = repo.PinNews(ctx, core.PinNewsParams{ID: missingId})
+		assert.Equal(t, err, nil)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		id, err := repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+
+		err = repo.DeleteNews(ctx, id)
+		assert.Equal(t, err, nil)
+
+		_, err = repo.GetNewsById(ctx, id)
+		assert.Equal(t, errors.Is(err, pgx.ErrNoRows), true)
+
+		// deleting again isn't an error, the service tells it apart
+		err = repo.DeleteNews(ctx, id)
+		assert.Equal(t, err, nil)
+
+		// the title is free again
+		_, err = repo.AddNews(ctx, newsParams("some title", authorId))
+		assert.Equal(t, err, nil)
+	})
+
+	t.Run("Get all and stats", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		news, err := repo.GetAllNews(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(news), 0)
+
+		stats, err := repo.GetNewsStats(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, stats, core.GetNewsStatsRow{})
+
+		var ids []int32
+		for _, title := range []string{"first title", "second title", "third title"} {
+			id, err := repo.AddNews(ctx, newsParams(title, authorId))
+			assert.Equal(t, err, nil)
+			ids = append(ids, id)
+		}
+		err = repo.PublishNews(ctx, ids[1])
+		assert.Equal(t, err, nil)
+
+		news, err = repo.GetAllNews(ctx)
+		assert.Equal(t, err, nil)
+		// the query doesn't order news
+		slices.SortFunc(news, func(a, b core.News) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+
+		var lastUpdatedAt pgtype.Timestamp
+		var newsIds []int32
+		for _, n := range news {
+			newsIds = append(newsIds, n.ID)
+			if n.UpdatedAt.Time.After(lastUpdatedAt.Time) {
+				lastUpdatedAt = n.UpdatedAt
+			}
+		}
+		assert.Equal(t, newsIds, ids)
+
+		stats, err = repo.GetNewsStats(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, stats, core.GetNewsStatsRow{Count: 3, LastUpdatedAt: lastUpdatedAt})
+
+		// nothing was served along with the news yet
+		activity, err := repo.GetNewsActivity(ctx, ids[0])
+		assert.Equal(t, err, nil)
+		assert.Equal(t, activity, pgtype.Timestamp{})
+	})
+
+	t.Run("Get by ids", func(t *testing.T) {
+		repo, authorId := newRepo(t)
+		ctx := context.Background()
+
+		var ids []int32
+		for _, title := range []string{"first title", "second title", "third title"} {
+			id, err := repo.AddNews(ctx, newsParams(title, authorId))
+			assert.Equal(t, err, nil)
+			ids = append(ids, id)
+		}
+
+		// missing and repeated ids don't add news
+		news, err := repo.GetNewsByIds(ctx, []int32{ids[2], ids[0], ids[2], ids[2] + 100})
+		assert.Equal(t, err, nil)
+		slices.SortFunc(news, func(a, b core.News) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		assert.Equal(t, len(news), 2)
+		assert.Equal(t, news[0].ID, ids[0])
+		assert.Equal(t, news[0].Title.String, "first title")
+		assert.Equal(t, news[1].ID, ids[2])
+
+		news, err = repo.GetNewsByIds(ctx, nil)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(news), 0)
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.GetNewsById(ctx, 1)
+		assert.Equal(t, errors.Is(err, context.Canceled), true)
+	})
+}
+
+// someBlocks are the blocks of the content news are added with.
+const someBlocks = `{"version": 1, "blocks": [{"type": "paragraph", "text": "some content"}]}`
+
+// someTargeting is the rule news are added with.
+const someTargeting = `platform == "ios"`
+
+func newsParams(title string, authorId int32) core.AddNewsParams {
+	return core.AddNewsParams{
+		Title:         pgtype.Text{String: title, Valid: true},
+		Content:       pgtype.Text{String: "some content", Valid: true},
+		ContentFormat: "plain",
+		Blocks:        []byte(someBlocks),
+		AuthorID:      pgtype.Int4{Int32: authorId, Valid: true},
+		Targeting:     pgtype.Text{String: someTargeting, Valid: true},
+	}
+}
+
+func updateParams(id int32, title string, authorId int32) core.UpdateNewsParams {
+	return core.UpdateNewsParams{
+		ID:            id,
+		Title:         pgtype.Text{String: title, Valid: true},
+		Content:       pgtype.Text{String: "other content", Valid: true},
+		ContentFormat: "markdown",
+		UpdatedBy:     pgtype.Int4{Int32: authorId, Valid: true},
+	}
+}
+
+// assertJSON compares documents rather than bytes, jsonb doesn't keep the
+// formatting or the order of keys.
+func assertJSON(t *testing.T, data []byte, expected string) {
+	t.Helper()
+
+	var actual, expectedValue any
+	err := json.Unmarshal(data, &actual)
+	assert.Equal(t, err, nil)
+	err = json.Unmarshal([]byte(expected), &expectedValue)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, actual, expectedValue)
+}
+
+// assertPgError checks err the way the services do, they type assert
+// without unwrapping.
+func assertPgError(t *testing.T, err error, code string, constraint string) {
+	t.Helper()
+
+	pgError, ok := err.(*pgconn.PgError)
+	if !ok {
+		t.Fatalf("expected *pgconn.PgError, got %T: %v", err, err)
+	}
+	assert.Equal(t, pgError.Code, code)
+	assert.Equal(t, pgError.ConstraintName, constraint)
+}
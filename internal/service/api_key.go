@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ApiKeyService struct {
+	apiKeyRepo apiKeyRepo
+}
+
+func NewApiKeyService(apiKeyRepo apiKeyRepo) *ApiKeyService {
+	return &ApiKeyService{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+type apiKeyRepo interface {
+	AddApiKey(ctx context.Context, arg core.AddApiKeyParams) (core.ApiKey, error)
+	GetApiKeyByPrefix(ctx context.Context, prefix string) (core.ApiKey, error)
+	GetAllApiKeys(ctx context.Context) ([]core.ApiKey, error)
+	RevokeApiKey(ctx context.Context, id int32) (int64, error)
+	TouchApiKey(ctx context.Context, id int32) error
+}
+
+// AddApiKey returns the stored key together with its plaintext form, which
+// can't be recovered later.
+func (s *ApiKeyService) AddApiKey(ctx context.Context, name string, scopes []authz.Scope, expiresAt pgtype.Timestamp) (core.ApiKey, string, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return core.ApiKey{}, "", err
+	}
+
+	err = authz.Authorize(subject, authz.ActionManageApiKeys, authz.Resource{})
+	if err != nil {
+		return core.ApiKey{}, "", err
+	}
+
+	scopeNames := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return core.ApiKey{}, "", fmt.Errorf("%w: [unknown scope %s]", pkg.ErrInvalidPayload, scope)
+		}
+		scopeNames = append(scopeNames, string(scope))
+	}
+
+	key, err := auth.GenerateApiKey()
+	if err != nil {
+		return core.ApiKey{}, "", err
+	}
+
+	apiKey, err := s.apiKeyRepo.AddApiKey(ctx, core.AddApiKeyParams{
+		Name:       name,
+		Prefix:     key.Prefix,
+		SecretHash: key.Hash(),
+		Scopes:     scopeNames,
+		CreatedBy:  subject.ID,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.ApiKey{}, "", fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return apiKey, key.String(), nil
+}
+
+func (s *ApiKeyService) GetAllApiKeys(ctx context.Context) ([]core.ApiKey, error) {
+	err := authorize(ctx, authz.ActionManageApiKeys, authz.Resource{})
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := s.apiKeyRepo.GetAllApiKeys(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return apiKeys, nil
+}
+
+func (s *ApiKeyService) RevokeApiKey(ctx context.Context, id int32) error {
+	err := authorize(ctx, authz.ActionManageApiKeys, authz.Resource{})
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.apiKeyRepo.RevokeApiKey(ctx, id)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if revoked == 0 {
+		return pkg.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *ApiKeyService) Authenticate(ctx context.Context, plaintext string) (auth.Author, error) {
+	key, err := auth.ParseApiKey(plaintext)
+	if err != nil {
+		return auth.Author{}, err
+	}
+
+	apiKey, err := s.apiKeyRepo.GetApiKeyByPrefix(ctx, key.Prefix)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return auth.Author{}, auth.ErrInvalidApiKey
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return auth.Author{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if !key.Matches(apiKey.SecretHash) {
+		return auth.Author{}, auth.ErrInvalidApiKey
+	}
+
+	if apiKey.RevokedAt.Valid {
+		return auth.Author{}, fmt.Errorf("%w: [revoked]", auth.ErrInvalidApiKey)
+	}
+
+	if apiKey.ExpiresAt.Valid && apiKey.ExpiresAt.Time.Before(time.Now()) {
+		return auth.Author{}, fmt.Errorf("%w: [expired]", auth.ErrInvalidApiKey)
+	}
+
+	err = s.apiKeyRepo.TouchApiKey(ctx, apiKey.ID)
+	if err != nil {
+		// failing to track usage shouldn't reject a valid key
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+	}
+
+	scopes := make([]authz.Scope, 0, len(apiKey.Scopes))
+	for _, scope := range apiKey.Scopes {
+		scopes = append(scopes, authz.Scope(scope))
+	}
+
+	return auth.Author{
+		ID:       apiKey.CreatedBy,
+		Name:     apiKey.Name,
+		Role:     authz.RoleService,
+		Scopes:   scopes,
+		ApiKeyID: apiKey.ID,
+	}, nil
+}
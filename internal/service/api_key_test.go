@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ApiKeyRepoMock struct {
+	ApiKeyToReturn           core.ApiKey
+	RevokedToReturn          int64
+	ErrAddApiKeyToReturn     error
+	ErrGetApiKeyToReturn     error
+	ErrGetAllApiKeysToReturn error
+	ErrRevokeApiKeyToReturn  error
+	Touched                  bool
+}
+
+func (m *ApiKeyRepoMock) AddApiKey(ctx context.Context, arg core.AddApiKeyParams) (core.ApiKey, error) {
+	if m.ErrAddApiKeyToReturn != nil {
+		return core.ApiKey{}, m.ErrAddApiKeyToReturn
+	}
+	return core.ApiKey{
+		ID:         1,
+		Name:       arg.Name,
+		Prefix:     arg.Prefix,
+		SecretHash: arg.SecretHash,
+		Scopes:     arg.Scopes,
+		CreatedBy:  arg.CreatedBy,
+	}, nil
+}
+
+func (m *ApiKeyRepoMock) GetApiKeyByPrefix(ctx context.Context, prefix string) (core.ApiKey, error) {
+	if m.ErrGetApiKeyToReturn != nil {
+		return core.ApiKey{}, m.ErrGetApiKeyToReturn
+	}
+	return m.ApiKeyToReturn, nil
+}
+
+func (m *ApiKeyRepoMock) GetAllApiKeys(ctx context.Context) ([]core.ApiKey, error) {
+	if m.ErrGetAllApiKeysToReturn != nil {
+		return nil, m.ErrGetAllApiKeysToReturn
+	}
+	return []core.ApiKey{m.ApiKeyToReturn}, nil
+}
+
+func (m *ApiKeyRepoMock) RevokeApiKey(ctx context.Context, id int32) (int64, error) {
+	if m.ErrRevokeApiKeyToReturn != nil {
+		return 0, m.ErrRevokeApiKeyToReturn
+	}
+	return m.RevokedToReturn, nil
+}
+
+func (m *ApiKeyRepoMock) TouchApiKey(ctx context.Context, id int32) error {
+	m.Touched = true
+	return nil
+}
+
+func TestAddApiKey(t *testing.T) {
+	repo := &ApiKeyRepoMock{}
+	service := NewApiKeyService(repo)
+
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		Scopes              []authz.Scope
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name:   "Ok",
+			Ctx:    adminCtx,
+			Scopes: []authz.Scope{authz.ScopeNewsWrite},
+		},
+		{
+			Name:          "Err editor can't create keys",
+			Ctx:           editorCtx,
+			Scopes:        []authz.Scope{authz.ScopeNewsWrite},
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err unknown scope",
+			Ctx:           adminCtx,
+			Scopes:        []authz.Scope{"news:everything"},
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 adminCtx,
+			Scopes:              []authz.Scope{authz.ScopeNewsWrite},
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrAddApiKeyToReturn = testCase.ErrRepoShouldReturn
+
+			apiKey, plaintext, err := service.AddApiKey(testCase.Ctx, "importer", testCase.Scopes, pgtype.Timestamp{})
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+
+			if testCase.ExpectedError == nil {
+				key, err := auth.ParseApiKey(plaintext)
+				assert.Equal(t, err, nil)
+				assert.Equal(t, key.Prefix, apiKey.Prefix)
+				assert.Equal(t, key.Matches(apiKey.SecretHash), true)
+				assert.Equal(t, apiKey.CreatedBy, int32(3))
+			}
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	repo := &ApiKeyRepoMock{}
+	service := NewApiKeyService(repo)
+
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		Revoked             int64
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name:    "Ok",
+			Ctx:     adminCtx,
+			Revoked: 1,
+		},
+		{
+			Name:          "Err forbidden",
+			Ctx:           authorCtx,
+			Revoked:       1,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err not found or already revoked",
+			Ctx:           adminCtx,
+			Revoked:       0,
+			ExpectedError: pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 adminCtx,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.RevokedToReturn = testCase.Revoked
+			repo.ErrRevokeApiKeyToReturn = testCase.ErrRepoShouldReturn
+
+			err := service.RevokeApiKey(testCase.Ctx, 1)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	repo := &ApiKeyRepoMock{}
+	service := NewApiKeyService(repo)
+
+	key, _ := auth.GenerateApiKey()
+	otherKey, _ := auth.GenerateApiKey()
+	storedKey := core.ApiKey{
+		ID:         1,
+		Name:       "importer",
+		Prefix:     key.Prefix,
+		SecretHash: key.Hash(),
+		Scopes:     []string{"news:write"},
+		CreatedBy:  3,
+	}
+
+	revokedKey := storedKey
+	revokedKey.RevokedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+
+	expiredKey := storedKey
+	expiredKey.ExpiresAt = pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true}
+
+	testTable := []struct {
+		Name                string
+		Key                 string
+		StoredKey           core.ApiKey
+		ErrRepoShouldReturn error
+		ExpectedError       error
+		ExpectedResult      auth.Author
+	}{
+		{
+			Name:      "Ok",
+			Key:       key.String(),
+			StoredKey: storedKey,
+			ExpectedResult: auth.Author{
+				ID:       3,
+				Name:     "importer",
+				Role:     authz.RoleService,
+				Scopes:   []authz.Scope{authz.ScopeNewsWrite},
+				ApiKeyID: 1,
+			},
+		},
+		{
+			Name:          "Err malformed key",
+			Key:           "not a key",
+			StoredKey:     storedKey,
+			ExpectedError: auth.ErrInvalidApiKey,
+		},
+		{
+			Name:          "Err wrong secret",
+			Key:           auth.ApiKey{Prefix: key.Prefix, Secret: otherKey.Secret}.String(),
+			StoredKey:     storedKey,
+			ExpectedError: auth.ErrInvalidApiKey,
+		},
+		{
+			Name:                "Err unknown prefix",
+			Key:                 otherKey.String(),
+			ErrRepoShouldReturn: pgx.ErrNoRows,
+			ExpectedError:       auth.ErrInvalidApiKey,
+		},
+		{
+			Name:          "Err revoked",
+			Key:           key.String(),
+			StoredKey:     revokedKey,
+			ExpectedError: auth.ErrInvalidApiKey,
+		},
+		{
+			Name:          "Err expired",
+			Key:           key.String(),
+			StoredKey:     expiredKey,
+			ExpectedError: auth.ErrInvalidApiKey,
+		},
+		{
+			Name:                "Err db internal",
+			Key:                 key.String(),
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ApiKeyToReturn = testCase.StoredKey
+			repo.ErrGetApiKeyToReturn = testCase.ErrRepoShouldReturn
+			repo.Touched = false
+
+			author, err := service.Authenticate(context.Background(), testCase.Key)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			assert.Equal(t, author, testCase.ExpectedResult)
+			assert.Equal(t, repo.Touched, testCase.ExpectedError == nil)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+)
+
+type AuthorService struct {
+	authorRepo authorRepo
+}
+
+func NewAuthorService(authorRepo authorRepo) *AuthorService {
+	return &AuthorService{
+		authorRepo: authorRepo,
+	}
+}
+
+type authorRepo interface {
+	GetAuthorsByIds(ctx context.Context, ids []int32) ([]core.Author, error)
+}
+
+// GetAuthorsByIds reads the authors in one go, ids with no author are left
+// out.
+func (s *AuthorService) GetAuthorsByIds(ctx context.Context, ids []int32) ([]core.Author, error) {
+	authors, err := s.authorRepo.GetAuthorsByIds(ctx, ids)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return authors, nil
+}
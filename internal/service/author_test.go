@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+)
+
+type AuthorRepoMock struct {
+	ErrGetAuthorsToReturn error
+}
+
+func (m *AuthorRepoMock) GetAuthorsByIds(ctx context.Context, ids []int32) ([]core.Author, error) {
+	if m.ErrGetAuthorsToReturn != nil {
+		return nil, m.ErrGetAuthorsToReturn
+	}
+
+	var authors []core.Author
+	for _, id := range ids {
+		authors = append(authors, core.Author{ID: id, Name: "some name"})
+	}
+	return authors, nil
+}
+
+func TestGetAuthorsByIds(t *testing.T) {
+	repo := &AuthorRepoMock{}
+	service := NewAuthorService(repo)
+	testTable := []struct {
+		Name                string
+		ErrRepoShouldReturn error
+		ExpectedError       error
+		ExpectedCount       int
+	}{
+		{
+			Name:          "Ok",
+			ExpectedCount: 2,
+		},
+		{
+			Name:                "Err db internal",
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrGetAuthorsToReturn = testCase.ErrRepoShouldReturn
+
+			result, err := service.GetAuthorsByIds(context.Background(), []int32{1, 2})
+
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			assert.Equal(t, len(result), testCase.ExpectedCount)
+		})
+	}
+}
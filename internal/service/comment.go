@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/moderation"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CommentService struct {
+	commentRepo commentRepo
+	filter      moderation.Filter
+}
+
+// NewCommentService checks comments with filter, which lets everything
+// through when it's nil.
+func NewCommentService(commentRepo commentRepo, filter moderation.Filter) *CommentService {
+	if filter == nil {
+		filter = moderation.Chain()
+	}
+
+	return &CommentService{
+		commentRepo: commentRepo,
+		filter:      filter,
+	}
+}
+
+type commentRepo interface {
+	AddComment(ctx context.Context, arg core.AddCommentParams) (core.Comment, error)
+	DeleteComment(ctx context.Context, id int32) error
+	GetCommentById(ctx context.Context, id int32) (core.Comment, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsComments(ctx context.Context, arg core.GetNewsCommentsParams) ([]core.Comment, error)
+	GetPendingComments(ctx context.Context, arg core.GetPendingCommentsParams) ([]core.Comment, error)
+	ModerateComment(ctx context.Context, arg core.ModerateCommentParams) (core.Comment, error)
+	NotifyNewsChanged(ctx context.Context, payload string) error
+	UpdateComment(ctx context.Context, arg core.UpdateCommentParams) (core.Comment, error)
+}
+
+// AddComment comments the news, replying to parentId unless it's 0. The
+// comment is pending, or rejected right away when the filter says so.
+func (s *CommentService) AddComment(ctx context.Context, newsId int32, parentId int32, content string) (core.Comment, error) {
+	news, err := s.commentRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Comment{}, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Comment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	err = authz.Authorize(subject, authz.ActionComment, newsResource(news))
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	params := core.AddCommentParams{
+		NewsID:   newsId,
+		AuthorID: subject.ID,
+		Content:  content,
+	}
+
+	if parentId != 0 {
+		parent, err := s.commentRepo.GetCommentById(ctx, parentId)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+			return core.Comment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+		}
+		// replies are to comments readers see
+		if err != nil || parent.NewsID != newsId || parent.Status != moderation.StatusApproved {
+			return core.Comment{}, fmt.Errorf("%w: [no comment %d to reply to]", pkg.ErrInvalidPayload, parentId)
+		}
+		params.ParentID = pgtype.Int4{Int32: parentId, Valid: true}
+	}
+
+	params.Status, params.ModerationReason, err = s.check(ctx, content)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	comment, err := s.commentRepo.AddComment(ctx, params)
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == "23503" {
+			switch pgError.ConstraintName {
+			// news deleted in the meantime
+			case "comments_news_id_fkey":
+				return core.Comment{}, pkg.ErrNotFound
+			case "comments_parent_id_fkey":
+				return core.Comment{}, fmt.Errorf("%w: [no comment %d to reply to]", pkg.ErrInvalidPayload, parentId)
+			}
+			// author from the token doesn't exist
+			return core.Comment{}, pkg.ErrUnauthorized
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Comment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return comment, nil
+}
+
+// GetNewsComments returns up to first approved comments of the news with
+// ids greater than after, and whether there are more.
+func (s *CommentService) GetNewsComments(ctx context.Context, newsId int32, after int32, first int) ([]core.Comment, bool, error) {
+	_, err := s.commentRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, false, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	comments, err := s.commentRepo.GetNewsComments(ctx, core.GetNewsCommentsParams{
+		NewsID:      newsId,
+		After:       after,
+		MaxComments: int32(first + 1),
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, false, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	comments, hasMore := commentsPage(comments, first)
+	return comments, hasMore, nil
+}
+
+// UpdateComment lets authors change their comments, which go through
+// moderation again.
+func (s *CommentService) UpdateComment(ctx context.Context, newsId int32, commentId int32, content string) (core.Comment, error) {
+	comment, err := s.getComment(ctx, newsId, commentId)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	err = authorize(ctx, authz.ActionUpdateComment, authz.Resource{AuthorID: comment.AuthorID})
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	params := core.UpdateCommentParams{
+		ID:      commentId,
+		Content: content,
+	}
+	params.Status, params.ModerationReason, err = s.check(ctx, content)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	updated, err := s.commentRepo.UpdateComment(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Comment{}, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Comment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if comment.Status == moderation.StatusApproved {
+		s.notify(ctx, newsId)
+	}
+
+	return updated, nil
+}
+
+// DeleteComment deletes the comment along with the replies to it.
+func (s *CommentService) DeleteComment(ctx context.Context, newsId int32, commentId int32) error {
+	comment, err := s.getComment(ctx, newsId, commentId)
+	if err != nil {
+		return err
+	}
+
+	err = authorize(ctx, authz.ActionDeleteComment, authz.Resource{AuthorID: comment.AuthorID})
+	if err != nil {
+		return err
+	}
+
+	err = s.commentRepo.DeleteComment(ctx, commentId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if comment.Status == moderation.StatusApproved {
+		s.notify(ctx, newsId)
+	}
+
+	return nil
+}
+
+// GetPendingComments is the moderation queue, oldest first. It returns up
+// to first comments with ids greater than after, and whether there are
+// more.
+func (s *CommentService) GetPendingComments(ctx context.Context, after int32, first int) ([]core.Comment, bool, error) {
+	err := authorize(ctx, authz.ActionModerateComments, authz.Resource{})
+	if err != nil {
+		return nil, false, err
+	}
+
+	comments, err := s.commentRepo.GetPendingComments(ctx, core.GetPendingCommentsParams{
+		After:       after,
+		MaxComments: int32(first + 1),
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, false, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	comments, hasMore := commentsPage(comments, first)
+	return comments, hasMore, nil
+}
+
+func (s *CommentService) ApproveComment(ctx context.Context, commentId int32) (core.Comment, error) {
+	return s.moderate(ctx, commentId, moderation.StatusApproved, "")
+}
+
+// RejectComment hides the comment, approved ones included. Reason is shown
+// to the author.
+func (s *CommentService) RejectComment(ctx context.Context, commentId int32, reason string) (core.Comment, error) {
+	return s.moderate(ctx, commentId, moderation.StatusRejected, reason)
+}
+
+func (s *CommentService) moderate(ctx context.Context, commentId int32, status string, reason string) (core.Comment, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	err = authz.Authorize(subject, authz.ActionModerateComments, authz.Resource{})
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	comment, err := s.commentRepo.ModerateComment(ctx, core.ModerateCommentParams{
+		ID:               commentId,
+		Status:           status,
+		ModerationReason: pgtype.Text{String: reason, Valid: reason != ""},
+		ModeratedBy:      pgtype.Int4{Int32: subject.ID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Comment{}, pkg.ErrNotFound
+		}
+
+		var pgError *pgconn.PgError
+		// moderator from the token doesn't exist
+		if errors.As(err, &pgError) && pgError.Code == "23503" {
+			return core.Comment{}, pkg.ErrUnauthorized
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Comment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	s.notify(ctx, comment.NewsID)
+
+	return comment, nil
+}
+
+// getComment finds a comment of the news.
+func (s *CommentService) getComment(ctx context.Context, newsId int32, commentId int32) (core.Comment, error) {
+	comment, err := s.commentRepo.GetCommentById(ctx, commentId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Comment{}, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Comment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if comment.NewsID != newsId {
+		return core.Comment{}, pkg.ErrNotFound
+	}
+
+	return comment, nil
+}
+
+// check runs the filter, content it rejects doesn't wait for moderators.
+func (s *CommentService) check(ctx context.Context, content string) (string, pgtype.Text, error) {
+	reason, err := s.filter.Check(ctx, content)
+	if err != nil {
+		fmt.Printf("can't check comment: [%v]\n", err)
+		return "", pgtype.Text{}, err
+	}
+	if reason != "" {
+		return moderation.StatusRejected, pgtype.Text{String: reason, Valid: true}, nil
+	}
+
+	return moderation.StatusPending, pgtype.Text{}, nil
+}
+
+// notify drops the news from caches, they have the count of comments.
+func (s *CommentService) notify(ctx context.Context, newsId int32) {
+	err := s.commentRepo.NotifyNewsChanged(ctx, strconv.Itoa(int(newsId)))
+	if err != nil {
+		fmt.Printf("can't notify news change: [%v]\n", err)
+	}
+}
+
+// commentsPage cuts the comment requested to tell whether there are more.
+func commentsPage(comments []core.Comment, first int) ([]core.Comment, bool) {
+	if len(comments) > first {
+		return comments[:first], true
+	}
+
+	return comments, false
+}
@@ -0,0 +1,70 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/moderation"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCommentServiceIntegration(t *testing.T) {
+	db := pgtest.New(t)
+	service := NewCommentService(db.Queries, moderation.NewWordList([]string{"spam"}))
+	viewerId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleViewer})
+	editorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleEditor})
+	viewerCtx := auth.WithAuthor(context.Background(), auth.Author{ID: viewerId, Role: authz.RoleViewer})
+	editorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: editorId, Role: authz.RoleEditor})
+	newsId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: editorId, Status: authz.StatusPublished})
+
+	comment, err := service.AddComment(viewerCtx, newsId, 0, "nice read")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, comment.Status, moderation.StatusPending)
+
+	blocked, err := service.AddComment(viewerCtx, newsId, 0, "buy spam")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, blocked.Status, moderation.StatusRejected)
+
+	pending, _, err := service.GetPendingComments(editorCtx, 0, 20)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 1)
+
+	// only approved comments are counted
+	db.AssertCount(t, "news", 1, "id = $1 AND comments_count = 0", newsId)
+	_, err = service.ApproveComment(editorCtx, comment.ID)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 1, "id = $1 AND comments_count = 1", newsId)
+	db.AssertCount(t, "news_activity", 1, "news_id = $1", newsId)
+
+	reply, err := service.AddComment(viewerCtx, newsId, comment.ID, "me again")
+	assert.Equal(t, err, nil)
+	_, err = service.ApproveComment(editorCtx, reply.ID)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 1, "id = $1 AND comments_count = 2", newsId)
+
+	comments, hasMore, err := service.GetNewsComments(context.Background(), newsId, 0, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(comments), 1)
+	assert.Equal(t, hasMore, true)
+
+	// editing sends the comment back to moderation
+	_, err = service.UpdateComment(viewerCtx, newsId, reply.ID, "me again, edited")
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 1, "id = $1 AND comments_count = 1", newsId)
+
+	// replies go along with the comment
+	err = service.DeleteComment(viewerCtx, newsId, comment.ID)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "comments", 1, "")
+	db.AssertCount(t, "news", 1, "id = $1 AND comments_count = 0", newsId)
+
+	// counting comments doesn't change the news, other updates still do
+	db.AssertCount(t, "news_events", 0, "news_id = $1 AND type = 'updated'", newsId)
+	db.Exec(t, "UPDATE news SET title = 'other title' WHERE id = $1", newsId)
+	db.AssertCount(t, "news_events", 1, "news_id = $1 AND type = 'updated'", newsId)
+}
@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/moderation"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CommentRepoMock struct {
+	Comment                    core.Comment
+	NewsStatus                 string
+	Notified                   []string
+	ErrAddCommentToReturn      error
+	ErrGetCommentToReturn      error
+	ErrGetNewsByIdToReturn     error
+	ErrModerateCommentToReturn error
+}
+
+func (m *CommentRepoMock) AddComment(ctx context.Context, arg core.AddCommentParams) (core.Comment, error) {
+	if m.ErrAddCommentToReturn != nil {
+		return core.Comment{}, m.ErrAddCommentToReturn
+	}
+	return core.Comment{
+		ID:               2,
+		NewsID:           arg.NewsID,
+		ParentID:         arg.ParentID,
+		AuthorID:         arg.AuthorID,
+		Content:          arg.Content,
+		Status:           arg.Status,
+		ModerationReason: arg.ModerationReason,
+	}, nil
+}
+
+func (m *CommentRepoMock) DeleteComment(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (m *CommentRepoMock) GetCommentById(ctx context.Context, id int32) (core.Comment, error) {
+	if m.ErrGetCommentToReturn != nil {
+		return core.Comment{}, m.ErrGetCommentToReturn
+	}
+	return m.Comment, nil
+}
+
+func (m *CommentRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if m.ErrGetNewsByIdToReturn != nil {
+		return core.News{}, m.ErrGetNewsByIdToReturn
+	}
+	return core.News{
+		ID:       id,
+		AuthorID: pgtype.Int4{Int32: 1, Valid: true},
+		Status:   m.NewsStatus,
+	}, nil
+}
+
+func (m *CommentRepoMock) GetNewsComments(ctx context.Context, arg core.GetNewsCommentsParams) ([]core.Comment, error) {
+	comments := []core.Comment{}
+	for id := arg.After + 1; id <= 3 && len(comments) < int(arg.MaxComments); id++ {
+		comments = append(comments, core.Comment{ID: id, NewsID: arg.NewsID, Status: moderation.StatusApproved})
+	}
+	return comments, nil
+}
+
+func (m *CommentRepoMock) GetPendingComments(ctx context.Context, arg core.GetPendingCommentsParams) ([]core.Comment, error) {
+	return []core.Comment{{ID: 1, Status: moderation.StatusPending}}, nil
+}
+
+func (m *CommentRepoMock) ModerateComment(ctx context.Context, arg core.ModerateCommentParams) (core.Comment, error) {
+	if m.ErrModerateCommentToReturn != nil {
+		return core.Comment{}, m.ErrModerateCommentToReturn
+	}
+	comment := m.Comment
+	comment.Status = arg.Status
+	comment.ModerationReason = arg.ModerationReason
+	comment.ModeratedBy = arg.ModeratedBy
+	return comment, nil
+}
+
+func (m *CommentRepoMock) NotifyNewsChanged(ctx context.Context, payload string) error {
+	m.Notified = append(m.Notified, payload)
+	return nil
+}
+
+func (m *CommentRepoMock) UpdateComment(ctx context.Context, arg core.UpdateCommentParams) (core.Comment, error) {
+	comment := m.Comment
+	comment.Content = arg.Content
+	comment.Status = arg.Status
+	comment.ModerationReason = arg.ModerationReason
+	return comment, nil
+}
+
+func TestAddComment(t *testing.T) {
+	testTable := []struct {
+		Name                   string
+		Ctx                    context.Context
+		NewsStatus             string
+		ParentId               int32
+		Parent                 core.Comment
+		Content                string
+		ErrGetNewsShouldReturn error
+		ErrRepoShouldReturn    error
+		ExpectedStatus         string
+		ExpectedError          error
+	}{
+		{
+			Name:           "Ok",
+			Ctx:            viewerCtx,
+			NewsStatus:     authz.StatusPublished,
+			Content:        "nice read",
+			ExpectedStatus: moderation.StatusPending,
+		},
+		{
+			Name:           "Ok reply",
+			Ctx:            viewerCtx,
+			NewsStatus:     authz.StatusPublished,
+			ParentId:       1,
+			Parent:         core.Comment{ID: 1, NewsID: 1, Status: moderation.StatusApproved},
+			Content:        "agreed",
+			ExpectedStatus: moderation.StatusPending,
+		},
+		{
+			Name:           "Ok blocked word",
+			Ctx:            viewerCtx,
+			NewsStatus:     authz.StatusPublished,
+			Content:        "buy Spam now",
+			ExpectedStatus: moderation.StatusRejected,
+		},
+		{
+			Name:          "Err reply to pending comment",
+			Ctx:           viewerCtx,
+			NewsStatus:    authz.StatusPublished,
+			ParentId:      1,
+			Parent:        core.Comment{ID: 1, NewsID: 1, Status: moderation.StatusPending},
+			Content:       "agreed",
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err reply to comment of other news",
+			Ctx:           viewerCtx,
+			NewsStatus:    authz.StatusPublished,
+			ParentId:      1,
+			Parent:        core.Comment{ID: 1, NewsID: 2, Status: moderation.StatusApproved},
+			Content:       "agreed",
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err draft",
+			Ctx:           viewerCtx,
+			NewsStatus:    authz.StatusDraft,
+			Content:       "nice read",
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err unauthorized",
+			Ctx:           context.Background(),
+			NewsStatus:    authz.StatusPublished,
+			Content:       "nice read",
+			ExpectedError: pkg.ErrUnauthorized,
+		},
+		{
+			Name:                   "Err news not found",
+			Ctx:                    viewerCtx,
+			Content:                "nice read",
+			ErrGetNewsShouldReturn: pgx.ErrNoRows,
+			ExpectedError:          pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err news deleted meanwhile",
+			Ctx:                 viewerCtx,
+			NewsStatus:          authz.StatusPublished,
+			Content:             "nice read",
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23503", ConstraintName: "comments_news_id_fkey"},
+			ExpectedError:       pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 viewerCtx,
+			NewsStatus:          authz.StatusPublished,
+			Content:             "nice read",
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &CommentRepoMock{
+				Comment:                testCase.Parent,
+				NewsStatus:             testCase.NewsStatus,
+				ErrGetNewsByIdToReturn: testCase.ErrGetNewsShouldReturn,
+				ErrAddCommentToReturn:  testCase.ErrRepoShouldReturn,
+			}
+			service := NewCommentService(repo, moderation.NewWordList([]string{"spam"}))
+
+			comment, err := service.AddComment(testCase.Ctx, 1, testCase.ParentId, testCase.Content)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				return
+			}
+			assert.Equal(t, comment.Status, testCase.ExpectedStatus)
+			assert.Equal(t, comment.AuthorID, int32(4))
+			assert.Equal(t, comment.ParentID.Valid, testCase.ParentId != 0)
+			assert.Equal(t, comment.ModerationReason.Valid, testCase.ExpectedStatus == moderation.StatusRejected)
+			// nothing public changed yet
+			assert.Equal(t, len(repo.Notified), 0)
+		})
+	}
+}
+
+func TestGetNewsComments(t *testing.T) {
+	service := NewCommentService(&CommentRepoMock{}, nil)
+
+	comments, hasMore, err := service.GetNewsComments(context.Background(), 1, 0, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(comments), 2)
+	assert.Equal(t, hasMore, true)
+
+	comments, hasMore, err = service.GetNewsComments(context.Background(), 1, comments[1].ID, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(comments), 1)
+	assert.Equal(t, hasMore, false)
+
+	service = NewCommentService(&CommentRepoMock{ErrGetNewsByIdToReturn: pgx.ErrNoRows}, nil)
+	_, _, err = service.GetNewsComments(context.Background(), 1, 0, 2)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+}
+
+func TestUpdateComment(t *testing.T) {
+	testTable := []struct {
+		Name           string
+		Ctx            context.Context
+		NewsId         int32
+		Status         string
+		ExpectedError  error
+		ExpectedNotify bool
+	}{
+		{
+			Name:           "Ok approved goes back to moderation",
+			Ctx:            viewerCtx,
+			NewsId:         1,
+			Status:         moderation.StatusApproved,
+			ExpectedNotify: true,
+		},
+		{
+			Name:   "Ok pending",
+			Ctx:    viewerCtx,
+			NewsId: 1,
+			Status: moderation.StatusPending,
+		},
+		{
+			Name:          "Err someone else's comment",
+			Ctx:           editorCtx,
+			NewsId:        1,
+			Status:        moderation.StatusApproved,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err comment of other news",
+			Ctx:           viewerCtx,
+			NewsId:        2,
+			Status:        moderation.StatusApproved,
+			ExpectedError: pkg.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &CommentRepoMock{
+				Comment: core.Comment{ID: 1, NewsID: 1, AuthorID: 4, Status: testCase.Status},
+			}
+			service := NewCommentService(repo, nil)
+
+			comment, err := service.UpdateComment(testCase.Ctx, testCase.NewsId, 1, "edited")
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				return
+			}
+			assert.Equal(t, comment.Content, "edited")
+			assert.Equal(t, comment.Status, moderation.StatusPending)
+			assert.Equal(t, len(repo.Notified) == 1, testCase.ExpectedNotify)
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name: "Ok own",
+			Ctx:  viewerCtx,
+		},
+		{
+			Name: "Ok editor",
+			Ctx:  editorCtx,
+		},
+		{
+			Name:          "Err someone else's comment",
+			Ctx:           authorCtx,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:                "Err not found",
+			Ctx:                 viewerCtx,
+			ErrRepoShouldReturn: pgx.ErrNoRows,
+			ExpectedError:       pkg.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &CommentRepoMock{
+				Comment:               core.Comment{ID: 1, NewsID: 1, AuthorID: 4, Status: moderation.StatusApproved},
+				ErrGetCommentToReturn: testCase.ErrRepoShouldReturn,
+			}
+			service := NewCommentService(repo, nil)
+
+			err := service.DeleteComment(testCase.Ctx, 1, 1)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError == nil {
+				assert.Equal(t, repo.Notified, []string{"1"})
+			}
+		})
+	}
+}
+
+func TestModerateComment(t *testing.T) {
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		Reject              bool
+		ErrRepoShouldReturn error
+		ExpectedStatus      string
+		ExpectedError       error
+	}{
+		{
+			Name:           "Ok approve",
+			Ctx:            editorCtx,
+			ExpectedStatus: moderation.StatusApproved,
+		},
+		{
+			Name:           "Ok reject",
+			Ctx:            adminCtx,
+			Reject:         true,
+			ExpectedStatus: moderation.StatusRejected,
+		},
+		{
+			Name:          "Err author can't moderate",
+			Ctx:           authorCtx,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:                "Err not found",
+			Ctx:                 editorCtx,
+			ErrRepoShouldReturn: pgx.ErrNoRows,
+			ExpectedError:       pkg.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &CommentRepoMock{
+				Comment:                    core.Comment{ID: 1, NewsID: 1, AuthorID: 4, Status: moderation.StatusPending},
+				ErrModerateCommentToReturn: testCase.ErrRepoShouldReturn,
+			}
+			service := NewCommentService(repo, nil)
+
+			var comment core.Comment
+			var err error
+			if testCase.Reject {
+				comment, err = service.RejectComment(testCase.Ctx, 1, "off topic")
+			} else {
+				comment, err = service.ApproveComment(testCase.Ctx, 1)
+			}
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				return
+			}
+			assert.Equal(t, comment.Status, testCase.ExpectedStatus)
+			assert.Equal(t, comment.ModeratedBy.Valid, true)
+			assert.Equal(t, comment.ModerationReason.Valid, testCase.Reject)
+			assert.Equal(t, repo.Notified, []string{"1"})
+		})
+	}
+}
+
+func TestGetPendingComments(t *testing.T) {
+	service := NewCommentService(&CommentRepoMock{}, nil)
+
+	comments, hasMore, err := service.GetPendingComments(editorCtx, 0, 20)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(comments), 1)
+	assert.Equal(t, hasMore, false)
+
+	_, _, err = service.GetPendingComments(viewerCtx, 0, 20)
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+}
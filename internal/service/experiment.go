@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/content"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ExperimentService tests alternate titles and content of news. While an
+// experiment runs every reader gets one of the variants of the news, the
+// same one every time, and the one that was opened the most is promoted
+// into the news when it stops.
+type ExperimentService struct {
+	experimentRepo experimentRepo
+	newsUpdater    newsUpdater
+}
+
+func NewExperimentService(experimentRepo experimentRepo, newsUpdater newsUpdater) *ExperimentService {
+	return &ExperimentService{
+		experimentRepo: experimentRepo,
+		newsUpdater:    newsUpdater,
+	}
+}
+
+type experimentRepo interface {
+	AddNewsVariant(ctx context.Context, arg core.AddNewsVariantParams) (int32, error)
+	CountNewsVariant(ctx context.Context, arg core.CountNewsVariantParams) (int64, error)
+	DeleteNewsVariant(ctx context.Context, arg core.DeleteNewsVariantParams) (int64, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsExperiment(ctx context.Context, newsID int32) (core.NewsExperiment, error)
+	GetNewsVariants(ctx context.Context, newsID int32) ([]core.NewsVariant, error)
+	GetRunningVariants(ctx context.Context, newsIds []int32) ([]core.NewsVariant, error)
+	NotifyNewsChanged(ctx context.Context, payload string) error
+	StartNewsExperiment(ctx context.Context, newsID int32) error
+	StopNewsExperiment(ctx context.Context, arg core.StopNewsExperimentParams) (int64, error)
+}
+
+// newsUpdater promotes winning variants, the way authors update news.
+type newsUpdater interface {
+	UpdatNews(ctx context.Context, params core.UpdateNewsParams) error
+}
+
+// minVariants is how many variants an experiment needs to compare anything.
+const minVariants = 2
+
+// what readers count of variants
+const (
+	variantExposure = "exposure"
+	variantClick    = "click"
+)
+
+// Experiment is the experiment of news with all their variants. Running is
+// false for news never experimented on as well, their StartedAt is zero.
+type Experiment struct {
+	Running   bool
+	StartedAt pgtype.Timestamp
+	StoppedAt pgtype.Timestamp
+	WinnerID  int32
+	Variants  []core.NewsVariant
+}
+
+// AddVariant adds a variant to news without a running experiment. Content
+// is written in the content format of the news.
+func (s *ExperimentService) AddVariant(ctx context.Context, params core.AddNewsVariantParams) (int32, error) {
+	news, err := s.editableNews(ctx, params.NewsID)
+	if err != nil {
+		return 0, err
+	}
+
+	running, err := s.running(ctx, params.NewsID)
+	if err != nil {
+		return 0, err
+	}
+	if running {
+		return 0, fmt.Errorf("%w: [variants can't change while the experiment runs]", pkg.ErrInvalidPayload)
+	}
+
+	params.Content = content.Sanitize(news.ContentFormat, params.Content)
+	id, err := s.experimentRepo.AddNewsVariant(ctx, params)
+	if err != nil {
+		var pgError *pgconn.PgError
+		// the news were deleted since
+		if errors.As(err, &pgError) && pgError.Code == "23503" {
+			return 0, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return 0, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return id, nil
+}
+
+// DeleteVariant deletes a variant of news without a running experiment.
+func (s *ExperimentService) DeleteVariant(ctx context.Context, newsId int32, variantId int32) error {
+	_, err := s.editableNews(ctx, newsId)
+	if err != nil {
+		return err
+	}
+
+	running, err := s.running(ctx, newsId)
+	if err != nil {
+		return err
+	}
+	if running {
+		return fmt.Errorf("%w: [variants can't change while the experiment runs]", pkg.ErrInvalidPayload)
+	}
+
+	deleted, err := s.experimentRepo.DeleteNewsVariant(ctx, core.DeleteNewsVariantParams{
+		ID:     variantId,
+		NewsID: newsId,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if deleted == 0 {
+		return pkg.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetExperiment returns the experiment of news with the counts of its
+// variants.
+func (s *ExperimentService) GetExperiment(ctx context.Context, newsId int32) (Experiment, error) {
+	_, err := s.editableNews(ctx, newsId)
+	if err != nil {
+		return Experiment{}, err
+	}
+
+	var experiment Experiment
+	stored, err := s.experimentRepo.GetNewsExperiment(ctx, newsId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return Experiment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if err == nil {
+		experiment = Experiment{
+			Running:   !stored.StoppedAt.Valid,
+			StartedAt: stored.StartedAt,
+			StoppedAt: stored.StoppedAt,
+			WinnerID:  stored.WinnerID.Int32,
+		}
+	}
+
+	experiment.Variants, err = s.experimentRepo.GetNewsVariants(ctx, newsId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return Experiment{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return experiment, nil
+}
+
+// StartExperiment splits readers of the news between its variants, with the
+// counts of the variants starting over.
+func (s *ExperimentService) StartExperiment(ctx context.Context, newsId int32) error {
+	_, err := s.editableNews(ctx, newsId)
+	if err != nil {
+		return err
+	}
+
+	running, err := s.running(ctx, newsId)
+	if err != nil {
+		return err
+	}
+	if running {
+		return fmt.Errorf("%w: [the experiment runs already]", pkg.ErrEntityAlreadyExists)
+	}
+
+	variants, err := s.experimentRepo.GetNewsVariants(ctx, newsId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if len(variants) < minVariants {
+		return fmt.Errorf("%w: [experiments need at least %d variants]", pkg.ErrInvalidPayload, minVariants)
+	}
+
+	err = s.experimentRepo.StartNewsExperiment(ctx, newsId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	s.notify(ctx, newsId)
+
+	return nil
+}
+
+// StopExperiment promotes the title and content of the winner into the news
+// and stops the experiment. The winner is the variant with winnerId, or the
+// best one when it's 0.
+func (s *ExperimentService) StopExperiment(ctx context.Context, newsId int32, winnerId int32) (core.NewsVariant, error) {
+	news, err := s.editableNews(ctx, newsId)
+	if err != nil {
+		return core.NewsVariant{}, err
+	}
+
+	running, err := s.running(ctx, newsId)
+	if err != nil {
+		return core.NewsVariant{}, err
+	}
+	if !running {
+		return core.NewsVariant{}, fmt.Errorf("%w: [no experiment runs]", pkg.ErrNotFound)
+	}
+
+	variants, err := s.experimentRepo.GetNewsVariants(ctx, newsId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.NewsVariant{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	winner, ok := bestVariant(variants)
+	if winnerId != 0 {
+		ok = false
+		for _, variant := range variants {
+			if variant.ID == winnerId {
+				winner, ok = variant, true
+			}
+		}
+	}
+	if !ok {
+		return core.NewsVariant{}, fmt.Errorf("%w: [no variant %d of the news]", pkg.ErrInvalidPayload, winnerId)
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return core.NewsVariant{}, err
+	}
+
+	// the winner is promoted before the experiment stops, so that it can be
+	// stopped again when stopping fails
+	err = s.newsUpdater.UpdatNews(ctx, core.UpdateNewsParams{
+		ID:            newsId,
+		Title:         pgtype.Text{String: winner.Title, Valid: true},
+		Content:       pgtype.Text{String: winner.Content, Valid: true},
+		ContentFormat: news.ContentFormat,
+		Targeting:     news.Targeting,
+		UpdatedBy:     pgtype.Int4{Int32: subject.ID, Valid: true},
+	})
+	if err != nil {
+		return core.NewsVariant{}, err
+	}
+
+	_, err = s.experimentRepo.StopNewsExperiment(ctx, core.StopNewsExperimentParams{
+		NewsID:   newsId,
+		WinnerID: pgtype.Int4{Int32: winner.ID, Valid: true},
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.NewsVariant{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return winner, nil
+}
+
+// RecordExposure counts the variant shown to the caller.
+func (s *ExperimentService) RecordExposure(ctx context.Context, newsId int32, variantId int32) error {
+	return s.count(ctx, newsId, variantId, variantExposure)
+}
+
+// RecordClick counts the variant opened by the caller.
+func (s *ExperimentService) RecordClick(ctx context.Context, newsId int32, variantId int32) error {
+	return s.count(ctx, newsId, variantId, variantClick)
+}
+
+// count counts variants of running experiments only, others aren't found.
+// Callers count the variant they're assigned and only once, so that counts
+// pick the winner rather than whoever counts the most.
+func (s *ExperimentService) count(ctx context.Context, newsId int32, variantId int32, kind string) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	variants, err := s.experimentRepo.GetRunningVariants(ctx, []int32{newsId})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if !slices.ContainsFunc(variants, func(variant core.NewsVariant) bool { return variant.ID == variantId }) {
+		return pkg.ErrNotFound
+	}
+	if assignVariant(variants, subject.ID, newsId).ID != variantId {
+		return fmt.Errorf("%w: [variant %d isn't the one of the caller]", pkg.ErrForbidden, variantId)
+	}
+
+	// nothing is counted when the caller counted the variant already, or
+	// the experiment stopped since
+	_, err = s.experimentRepo.CountNewsVariant(ctx, core.CountNewsVariantParams{
+		AuthorID: subject.ID,
+		Kind:     kind,
+		ID:       variantId,
+		NewsID:   newsId,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+// ApplyVariants puts the variants the caller is assigned in place of the
+// title and content of news with a running experiment, and returns the ids
+// of the variants by news. Anonymous callers get the news as they are.
+func (s *ExperimentService) ApplyVariants(ctx context.Context, news []core.News) ([]core.News, map[int32]int32, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil || len(news) == 0 {
+		return news, nil, nil
+	}
+
+	newsIds := make([]int32, 0, len(news))
+	for _, n := range news {
+		newsIds = append(newsIds, n.ID)
+	}
+
+	running, err := s.experimentRepo.GetRunningVariants(ctx, newsIds)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if len(running) == 0 {
+		return news, nil, nil
+	}
+
+	variants := map[int32][]core.NewsVariant{}
+	for _, variant := range running {
+		variants[variant.NewsID] = append(variants[variant.NewsID], variant)
+	}
+
+	// news can be shared with a cache, they're copied rather than changed in
+	// place
+	applied := make([]core.News, 0, len(news))
+	variantIds := map[int32]int32{}
+	for _, n := range news {
+		if len(variants[n.ID]) == 0 {
+			applied = append(applied, n)
+			continue
+		}
+
+		variant := assignVariant(variants[n.ID], subject.ID, n.ID)
+		stored, err := newsContent{format: n.ContentFormat, content: pgtype.Text{String: variant.Content, Valid: true}}.stored()
+		if err != nil {
+			fmt.Printf("can't apply variant %d: [%v]\n", variant.ID, err)
+			applied = append(applied, n)
+			continue
+		}
+
+		n.Title = pgtype.Text{String: variant.Title, Valid: true}
+		n.Content, n.Blocks = stored.content, stored.blocks
+		applied = append(applied, n)
+		variantIds[n.ID] = variant.ID
+	}
+
+	return applied, variantIds, nil
+}
+
+// notify drops the news from caches, starting the experiment touched them.
+// Stopping it goes through the update of the news, which does the same.
+func (s *ExperimentService) notify(ctx context.Context, newsId int32) {
+	err := s.experimentRepo.NotifyNewsChanged(ctx, strconv.Itoa(int(newsId)))
+	if err != nil {
+		fmt.Printf("can't notify news change: [%v]\n", err)
+	}
+}
+
+// editableNews gets news the caller can run experiments on, the ones they
+// can update.
+func (s *ExperimentService) editableNews(ctx context.Context, newsId int32) (core.News, error) {
+	news, err := s.experimentRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.News{}, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.News{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = authorize(ctx, authz.ActionUpdate, newsResource(news))
+	if err != nil {
+		return core.News{}, err
+	}
+
+	return news, nil
+}
+
+func (s *ExperimentService) running(ctx context.Context, newsId int32) (bool, error) {
+	experiment, err := s.experimentRepo.GetNewsExperiment(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return false, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return !experiment.StoppedAt.Valid, nil
+}
+
+// assignVariant picks the variant of the reader by weight, hashing the
+// reader with the news so the reader gets the same variant every time and
+// isn't in the same share of traffic for every experiment.
+func assignVariant(variants []core.NewsVariant, readerId int32, newsId int32) core.NewsVariant {
+	var total uint64
+	for _, variant := range variants {
+		total += uint64(variant.Weight)
+	}
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d:%d", readerId, newsId)
+	point := hash.Sum64() % total
+
+	for _, variant := range variants {
+		if point < uint64(variant.Weight) {
+			return variant
+		}
+		point -= uint64(variant.Weight)
+	}
+
+	return variants[len(variants)-1]
+}
+
+// bestVariant is the variant opened the most for the times it was shown,
+// the one shown more on a tie. Variants never shown are opened 0 times.
+func bestVariant(variants []core.NewsVariant) (core.NewsVariant, bool) {
+	if len(variants) == 0 {
+		return core.NewsVariant{}, false
+	}
+
+	best := variants[0]
+	for _, variant := range variants[1:] {
+		// clicks / exposures compared without dividing by 0
+		order := int64(variant.Clicks)*int64(max(best.Exposures, 1)) - int64(best.Clicks)*int64(max(variant.Exposures, 1))
+		if order > 0 || order == 0 && variant.Exposures > best.Exposures {
+			best = variant
+		}
+	}
+
+	return best, true
+}
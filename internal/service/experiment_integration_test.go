@@ -0,0 +1,75 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestExperimentServiceIntegration(t *testing.T) {
+	db := pgtest.New(t)
+	service := NewExperimentService(db.Queries, NewNewsService(db.Queries))
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleAuthor})
+	readerId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleViewer})
+	newsId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+	authorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: authorId, Role: authz.RoleAuthor})
+	readerCtx := auth.WithAuthor(context.Background(), auth.Author{ID: readerId, Role: authz.RoleViewer})
+
+	firstId, err := service.AddVariant(authorCtx, core.AddNewsVariantParams{NewsID: newsId, Title: "first title", Content: "first content", Weight: 1})
+	assert.Equal(t, err, nil)
+	secondId, err := service.AddVariant(authorCtx, core.AddNewsVariantParams{NewsID: newsId, Title: "second title", Content: "second content", Weight: 1})
+	assert.Equal(t, err, nil)
+
+	err = service.StartExperiment(authorCtx, newsId)
+	assert.Equal(t, err, nil)
+
+	news, err := db.Queries.GetNewsById(context.Background(), newsId)
+	assert.Equal(t, err, nil)
+	applied, variantIds, err := service.ApplyVariants(readerCtx, []core.News{news})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, variantIds[newsId] == firstId || variantIds[newsId] == secondId, true)
+	assert.Equal(t, applied[0].Title.String != "some title", true)
+
+	// the reader counts the variant they got, once
+	assignedId, otherId := variantIds[newsId], firstId
+	if assignedId == firstId {
+		otherId = secondId
+	}
+	err = service.RecordExposure(readerCtx, newsId, assignedId)
+	assert.Equal(t, err, nil)
+	for i := 0; i < 2; i++ {
+		err = service.RecordClick(readerCtx, newsId, assignedId)
+		assert.Equal(t, err, nil)
+	}
+	err = service.RecordClick(readerCtx, newsId, otherId)
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	assert.Equal(t, pgtest.Column[int32](t, db, "news_variants", "clicks", "id = $1", assignedId), []int32{1})
+	assert.Equal(t, pgtest.Column[int32](t, db, "news_variants", "clicks", "id = $1", otherId), []int32{0})
+
+	// the winner is promoted into the news
+	winner, err := service.StopExperiment(authorCtx, newsId, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, winner.ID, assignedId)
+	db.AssertCount(t, "news", 1, "id = $1 AND title = $2 AND content = $3", newsId, winner.Title, winner.Content)
+	db.AssertCount(t, "news_experiments", 1, "news_id = $1 AND stopped_at IS NOT NULL AND winner_id = $2", newsId, assignedId)
+
+	// and readers get it as it is
+	_, variantIds, err = service.ApplyVariants(readerCtx, []core.News{news})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(variantIds), 0)
+
+	// starting again starts the counts over
+	err = service.StartExperiment(authorCtx, newsId)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news_variants", 0, "news_id = $1 AND (exposures > 0 OR clicks > 0)", newsId)
+	db.AssertCount(t, "news_variant_readers", 0, "author_id = $1", readerId)
+}
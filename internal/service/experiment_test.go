@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ExperimentRepoMock keeps the variants and the experiment of a single
+// draft of author 1.
+type ExperimentRepoMock struct {
+	Variants   []core.NewsVariant
+	Experiment *core.NewsExperiment
+	NextId     int32
+	Notified   []string
+	// Readers are the readers who counted variants, by variant, reader and
+	// kind
+	Readers map[string]bool
+}
+
+func (m *ExperimentRepoMock) AddNewsVariant(ctx context.Context, arg core.AddNewsVariantParams) (int32, error) {
+	m.NextId++
+	m.Variants = append(m.Variants, core.NewsVariant{
+		ID:      m.NextId,
+		NewsID:  arg.NewsID,
+		Title:   arg.Title,
+		Content: arg.Content,
+		Weight:  arg.Weight,
+	})
+	return m.NextId, nil
+}
+
+func (m *ExperimentRepoMock) CountNewsVariant(ctx context.Context, arg core.CountNewsVariantParams) (int64, error) {
+	if m.Experiment == nil || m.Experiment.StoppedAt.Valid {
+		return 0, nil
+	}
+	reader := fmt.Sprintf("%d:%d:%s", arg.ID, arg.AuthorID, arg.Kind)
+	if m.Readers[reader] {
+		return 0, nil
+	}
+	for i, variant := range m.Variants {
+		if variant.ID == arg.ID && variant.NewsID == arg.NewsID {
+			if m.Readers == nil {
+				m.Readers = map[string]bool{}
+			}
+			m.Readers[reader] = true
+			switch arg.Kind {
+			case variantExposure:
+				m.Variants[i].Exposures++
+			case variantClick:
+				m.Variants[i].Clicks++
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *ExperimentRepoMock) DeleteNewsVariant(ctx context.Context, arg core.DeleteNewsVariantParams) (int64, error) {
+	for i, variant := range m.Variants {
+		if variant.ID == arg.ID && variant.NewsID == arg.NewsID {
+			m.Variants = append(m.Variants[:i], m.Variants[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *ExperimentRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if id != 1 {
+		return core.News{}, pgx.ErrNoRows
+	}
+	return core.News{
+		ID:            1,
+		Title:         pgtype.Text{String: "some title", Valid: true},
+		Content:       pgtype.Text{String: "some content", Valid: true},
+		ContentFormat: "plain",
+		AuthorID:      pgtype.Int4{Int32: 1, Valid: true},
+		Status:        authz.StatusDraft,
+	}, nil
+}
+
+func (m *ExperimentRepoMock) GetNewsExperiment(ctx context.Context, newsID int32) (core.NewsExperiment, error) {
+	if m.Experiment == nil {
+		return core.NewsExperiment{}, pgx.ErrNoRows
+	}
+	return *m.Experiment, nil
+}
+
+func (m *ExperimentRepoMock) GetNewsVariants(ctx context.Context, newsID int32) ([]core.NewsVariant, error) {
+	return m.Variants, nil
+}
+
+func (m *ExperimentRepoMock) GetRunningVariants(ctx context.Context, newsIds []int32) ([]core.NewsVariant, error) {
+	if m.Experiment == nil || m.Experiment.StoppedAt.Valid {
+		return nil, nil
+	}
+	return m.Variants, nil
+}
+
+func (m *ExperimentRepoMock) NotifyNewsChanged(ctx context.Context, payload string) error {
+	m.Notified = append(m.Notified, payload)
+	return nil
+}
+
+func (m *ExperimentRepoMock) StartNewsExperiment(ctx context.Context, newsID int32) error {
+	for i := range m.Variants {
+		m.Variants[i].Exposures, m.Variants[i].Clicks = 0, 0
+	}
+	m.Experiment = &core.NewsExperiment{NewsID: newsID}
+	m.Readers = nil
+	return nil
+}
+
+func (m *ExperimentRepoMock) StopNewsExperiment(ctx context.Context, arg core.StopNewsExperimentParams) (int64, error) {
+	m.Experiment.StoppedAt = pgtype.Timestamp{Valid: true}
+	m.Experiment.WinnerID = arg.WinnerID
+	return 1, nil
+}
+
+type newsUpdaterMock struct {
+	Updated   []core.UpdateNewsParams
+	ErrUpdate error
+}
+
+func (m *newsUpdaterMock) UpdatNews(ctx context.Context, params core.UpdateNewsParams) error {
+	if m.ErrUpdate != nil {
+		return m.ErrUpdate
+	}
+	m.Updated = append(m.Updated, params)
+	return nil
+}
+
+func TestExperiment(t *testing.T) {
+	repo := &ExperimentRepoMock{}
+	updater := &newsUpdaterMock{}
+	service := NewExperimentService(repo, updater)
+
+	firstId, err := service.AddVariant(authorCtx, core.AddNewsVariantParams{NewsID: 1, Title: "first title", Content: "first content", Weight: 1})
+	assert.Equal(t, err, nil)
+
+	// a single variant compares nothing
+	err = service.StartExperiment(authorCtx, 1)
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+
+	secondId, err := service.AddVariant(authorCtx, core.AddNewsVariantParams{NewsID: 1, Title: "second title", Content: "second content", Weight: 3})
+	assert.Equal(t, err, nil)
+
+	_, err = service.AddVariant(viewerCtx, core.AddNewsVariantParams{NewsID: 1, Title: "third title", Content: "third content", Weight: 1})
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	_, err = service.AddVariant(authorCtx, core.AddNewsVariantParams{NewsID: 2, Title: "third title", Content: "third content", Weight: 1})
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	// nothing is counted before the experiment starts
+	err = service.RecordExposure(viewerCtx, 1, firstId)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	err = service.StartExperiment(authorCtx, 1)
+	assert.Equal(t, err, nil)
+	// cached news are dropped, their ETag changed with the variants
+	assert.Equal(t, repo.Notified, []string{"1"})
+
+	err = service.StartExperiment(authorCtx, 1)
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+	_, err = service.AddVariant(authorCtx, core.AddNewsVariantParams{NewsID: 1, Title: "third title", Content: "third content", Weight: 1})
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+	err = service.DeleteVariant(authorCtx, 1, firstId)
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+
+	// the viewer counts the variant they're assigned, once
+	assigned := assignVariant(repo.Variants, 4, 1)
+	other := firstId
+	if assigned.ID == firstId {
+		other = secondId
+	}
+	for i := 0; i < 4; i++ {
+		err = service.RecordExposure(viewerCtx, 1, assigned.ID)
+		assert.Equal(t, err, nil)
+	}
+	err = service.RecordClick(viewerCtx, 1, assigned.ID)
+	assert.Equal(t, err, nil)
+	err = service.RecordClick(viewerCtx, 1, assigned.ID)
+	assert.Equal(t, err, nil)
+	err = service.RecordExposure(viewerCtx, 1, other)
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	err = service.RecordClick(viewerCtx, 1, 1000)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+	err = service.RecordClick(context.Background(), 1, firstId)
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+
+	experiment, err := service.GetExperiment(authorCtx, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, experiment.Running, true)
+	assert.Equal(t, len(experiment.Variants), 2)
+	for _, variant := range experiment.Variants {
+		if variant.ID == assigned.ID {
+			assert.Equal(t, variant.Exposures, int32(1))
+			assert.Equal(t, variant.Clicks, int32(1))
+		} else {
+			assert.Equal(t, variant.Exposures, int32(0))
+		}
+	}
+
+	_, err = service.StopExperiment(authorCtx, 1, 1000)
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+
+	// the winner is promoted through the update of the news
+	winner, err := service.StopExperiment(authorCtx, 1, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, winner.ID, assigned.ID)
+	assert.Equal(t, updater.Updated, []core.UpdateNewsParams{{
+		ID:            1,
+		Title:         pgtype.Text{String: assigned.Title, Valid: true},
+		Content:       pgtype.Text{String: assigned.Content, Valid: true},
+		ContentFormat: "plain",
+		UpdatedBy:     pgtype.Int4{Int32: 1, Valid: true},
+	}})
+
+	experiment, err = service.GetExperiment(authorCtx, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, experiment.Running, false)
+	assert.Equal(t, experiment.WinnerID, assigned.ID)
+
+	_, err = service.StopExperiment(authorCtx, 1, 0)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	err = service.DeleteVariant(authorCtx, 1, secondId)
+	assert.Equal(t, err, nil)
+	err = service.DeleteVariant(authorCtx, 1, secondId)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+}
+
+func TestStopExperimentUpdateFails(t *testing.T) {
+	repo := &ExperimentRepoMock{
+		Variants:   []core.NewsVariant{{ID: 1, NewsID: 1, Weight: 1}, {ID: 2, NewsID: 1, Weight: 1}},
+		Experiment: &core.NewsExperiment{NewsID: 1},
+	}
+	service := NewExperimentService(repo, &newsUpdaterMock{ErrUpdate: pkg.ErrEntityAlreadyExists})
+
+	// the experiment keeps running when the winner can't be promoted
+	_, err := service.StopExperiment(authorCtx, 1, 2)
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+	assert.Equal(t, repo.Experiment.StoppedAt.Valid, false)
+}
+
+func TestApplyVariants(t *testing.T) {
+	repo := &ExperimentRepoMock{
+		Variants: []core.NewsVariant{
+			{ID: 1, NewsID: 1, Title: "first title", Content: "first content", Weight: 1},
+			{ID: 2, NewsID: 1, Title: "second title", Content: "second content", Weight: 3},
+		},
+		Experiment: &core.NewsExperiment{NewsID: 1},
+	}
+	service := NewExperimentService(repo, &newsUpdaterMock{})
+	news := []core.News{{ID: 1, Title: pgtype.Text{String: "some title", Valid: true}, ContentFormat: "plain"}}
+
+	applied, variantIds, err := service.ApplyVariants(viewerCtx, news)
+	assert.Equal(t, err, nil)
+	variant := repo.Variants[variantIds[1]-1]
+	assert.Equal(t, applied[0].Title.String, variant.Title)
+	assert.Equal(t, applied[0].Content.String, variant.Content)
+	assert.Equal(t, string(applied[0].Blocks), `{"version":1,"blocks":[{"type":"paragraph","text":"`+variant.Content+`"}]}`)
+	// the news given aren't changed, they can be cached
+	assert.Equal(t, news[0].Title.String, "some title")
+
+	// the same variant every time
+	for i := 0; i < 10; i++ {
+		_, again, err := service.ApplyVariants(viewerCtx, news)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, again, variantIds)
+	}
+
+	applied, variantIds, err = service.ApplyVariants(context.Background(), news)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, applied[0].Title.String, "some title")
+	assert.Equal(t, len(variantIds), 0)
+}
+
+func TestAssignVariant(t *testing.T) {
+	variants := []core.NewsVariant{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}}
+
+	assigned := map[int32]int{}
+	for readerId := int32(1); readerId <= 4000; readerId++ {
+		assigned[assignVariant(variants, readerId, 7).ID]++
+	}
+
+	// readers are split by weight, roughly
+	assert.Equal(t, assigned[1] > 800 && assigned[1] < 1200, true)
+	assert.Equal(t, assigned[1]+assigned[2], 4000)
+}
+
+func TestBestVariant(t *testing.T) {
+	_, ok := bestVariant(nil)
+	assert.Equal(t, ok, false)
+
+	best, _ := bestVariant([]core.NewsVariant{
+		{ID: 1, Exposures: 100, Clicks: 10},
+		{ID: 2, Exposures: 10, Clicks: 2},
+		{ID: 3},
+	})
+	assert.Equal(t, best.ID, int32(2))
+
+	best, _ = bestVariant([]core.NewsVariant{
+		{ID: 1, Exposures: 10, Clicks: 1},
+		{ID: 2, Exposures: 20, Clicks: 2},
+	})
+	assert.Equal(t, best.ID, int32(2))
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/rank"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// FeaturedService keeps the featured news in the order editors put them
+// in. Every featured news has a rank key, moving news gives them a key
+// between their new neighbours and leaves the other rows alone.
+type FeaturedService struct {
+	featuredRepo featuredRepo
+	newsLister   newsLister
+}
+
+func NewFeaturedService(featuredRepo featuredRepo, newsLister newsLister) *FeaturedService {
+	return &FeaturedService{
+		featuredRepo: featuredRepo,
+		newsLister:   newsLister,
+	}
+}
+
+type featuredRepo interface {
+	FeatureNews(ctx context.Context, arg core.FeatureNewsParams) error
+	GetFeaturedNews(ctx context.Context) ([]core.FeaturedNews, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	UnfeatureNews(ctx context.Context, newsID int32) (int64, error)
+}
+
+// newsLister lists news the way readers see them, targeting applied.
+type newsLister interface {
+	GetAllNews(ctx context.Context) ([]core.News, error)
+}
+
+// GetFeaturedNews returns the featured news shown to the client, in order.
+func (s *FeaturedService) GetFeaturedNews(ctx context.Context) ([]core.News, error) {
+	featured, err := s.featuredRepo.GetFeaturedNews(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	news, err := s.newsLister.GetAllNews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int32]core.News, len(news))
+	for _, n := range news {
+		byId[n.ID] = n
+	}
+
+	result := make([]core.News, 0, len(featured))
+	for _, f := range featured {
+		if n, ok := byId[f.NewsID]; ok {
+			result = append(result, n)
+		}
+	}
+
+	return result, nil
+}
+
+// FeatureNews puts the news at position of the featured list, counting from
+// zero, or last when position is negative or past the end. Featured news
+// are moved.
+func (s *FeaturedService) FeatureNews(ctx context.Context, id int32, position int) error {
+	news, err := s.featuredRepo.GetNewsById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = authorize(ctx, authz.ActionFeature, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	featured, err := s.featuredRepo.GetFeaturedNews(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	others := make([]core.FeaturedNews, 0, len(featured))
+	for _, f := range featured {
+		if f.NewsID != id {
+			others = append(others, f)
+		}
+	}
+	if position < 0 || position > len(others) {
+		position = len(others)
+	}
+
+	var before, after string
+	if position > 0 {
+		before = others[position-1].Position
+	}
+	if position < len(others) {
+		after = others[position].Position
+	}
+	key, err := rank.Between(before, after)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = s.featuredRepo.FeatureNews(ctx, core.FeatureNewsParams{NewsID: id, Position: key})
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) {
+			switch pgError.Code {
+			// another editor took the same place in the meantime
+			case "23505":
+				return pkg.ErrEntityAlreadyExists
+			// the news were deleted since
+			case "23503":
+				return pkg.ErrNotFound
+			}
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+func (s *FeaturedService) UnfeatureNews(ctx context.Context, id int32) error {
+	news, err := s.featuredRepo.GetNewsById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = authorize(ctx, authz.ActionFeature, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.featuredRepo.UnfeatureNews(ctx, id)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if rows == 0 {
+		return pkg.ErrNotFound
+	}
+
+	return nil
+}
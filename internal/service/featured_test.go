@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// FeaturedRepoMock has published news 1 to 5 and a draft 6.
+type FeaturedRepoMock struct {
+	Featured []core.FeaturedNews
+	// Writes counts the rows written, moving news writes one
+	Writes int
+}
+
+func (m *FeaturedRepoMock) FeatureNews(ctx context.Context, arg core.FeatureNewsParams) error {
+	m.Writes++
+	m.Featured = slices.DeleteFunc(m.Featured, func(f core.FeaturedNews) bool { return f.NewsID == arg.NewsID })
+	for _, f := range m.Featured {
+		if f.Position == arg.Position {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "featured_news_position_key"}
+		}
+	}
+	m.Featured = append(m.Featured, core.FeaturedNews{NewsID: arg.NewsID, Position: arg.Position})
+	return nil
+}
+
+func (m *FeaturedRepoMock) GetFeaturedNews(ctx context.Context) ([]core.FeaturedNews, error) {
+	featured := slices.Clone(m.Featured)
+	slices.SortFunc(featured, func(a, b core.FeaturedNews) int { return strings.Compare(a.Position, b.Position) })
+	return featured, nil
+}
+
+func (m *FeaturedRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	switch {
+	case id >= 1 && id <= 5:
+		return core.News{ID: id, Status: authz.StatusPublished}, nil
+	case id == 6:
+		return core.News{ID: id, Status: authz.StatusDraft}, nil
+	}
+	return core.News{}, pgx.ErrNoRows
+}
+
+func (m *FeaturedRepoMock) UnfeatureNews(ctx context.Context, newsID int32) (int64, error) {
+	before := len(m.Featured)
+	m.Featured = slices.DeleteFunc(m.Featured, func(f core.FeaturedNews) bool { return f.NewsID == newsID })
+	return int64(before - len(m.Featured)), nil
+}
+
+// newsListerMock lists the news of FeaturedRepoMock but for Hidden, the way
+// targeting hides news.
+type newsListerMock struct {
+	Hidden int32
+}
+
+func (m *newsListerMock) GetAllNews(ctx context.Context) ([]core.News, error) {
+	var news []core.News
+	for id := int32(1); id <= 6; id++ {
+		if id != m.Hidden {
+			news = append(news, core.News{ID: id})
+		}
+	}
+	return news, nil
+}
+
+func featuredIds(t *testing.T, service *FeaturedService) []int32 {
+	t.Helper()
+
+	news, err := service.GetFeaturedNews(context.Background())
+	assert.Equal(t, err, nil)
+	ids := []int32{}
+	for _, n := range news {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestFeaturedNews(t *testing.T) {
+	repo := &FeaturedRepoMock{}
+	lister := &newsListerMock{}
+	service := NewFeaturedService(repo, lister)
+
+	assert.Equal(t, featuredIds(t, service), []int32{})
+
+	for _, id := range []int32{1, 2, 3} {
+		err := service.FeatureNews(editorCtx, id, -1)
+		assert.Equal(t, err, nil)
+	}
+	assert.Equal(t, featuredIds(t, service), []int32{1, 2, 3})
+
+	err := service.FeatureNews(editorCtx, 4, 0)
+	assert.Equal(t, err, nil)
+	err = service.FeatureNews(editorCtx, 5, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, featuredIds(t, service), []int32{4, 1, 5, 2, 3})
+
+	// moving news rewrites their row alone
+	repo.Writes = 0
+	err = service.FeatureNews(editorCtx, 3, 1)
+	assert.Equal(t, err, nil)
+	err = service.FeatureNews(editorCtx, 4, 100)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, featuredIds(t, service), []int32{3, 1, 5, 2, 4})
+	assert.Equal(t, repo.Writes, 2)
+
+	lister.Hidden = 5
+	assert.Equal(t, featuredIds(t, service), []int32{3, 1, 2, 4})
+	lister.Hidden = 0
+
+	err = service.FeatureNews(authorCtx, 1, 0)
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	err = service.FeatureNews(editorCtx, 6, 0)
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	err = service.FeatureNews(editorCtx, 1000, 0)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+	err = service.FeatureNews(context.Background(), 1, 0)
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+
+	err = service.UnfeatureNews(editorCtx, 3)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, featuredIds(t, service), []int32{1, 5, 2, 4})
+	err = service.UnfeatureNews(editorCtx, 3)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+	err = service.UnfeatureNews(viewerCtx, 1)
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+}
+
+func TestFeatureNewsConflict(t *testing.T) {
+	repo := &conflictingFeaturedRepo{
+		FeaturedRepoMock: &FeaturedRepoMock{
+			Featured: []core.FeaturedNews{{NewsID: 1, Position: "V"}},
+		},
+		// the key after "V", which news featured last get
+		Position: "l",
+	}
+	service := NewFeaturedService(repo, &newsListerMock{})
+
+	err := service.FeatureNews(editorCtx, 3, -1)
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+	assert.Equal(t, len(repo.Featured), 1)
+}
+
+// conflictingFeaturedRepo has Position taken by another editor between the
+// reads of the service and its write.
+type conflictingFeaturedRepo struct {
+	*FeaturedRepoMock
+	Position string
+}
+
+func (r *conflictingFeaturedRepo) FeatureNews(ctx context.Context, arg core.FeatureNewsParams) error {
+	if arg.Position == r.Position {
+		return &pgconn.PgError{Code: "23505", ConstraintName: "featured_news_position_key"}
+	}
+	return r.FeaturedRepoMock.FeatureNews(ctx, arg)
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/media"
+	"github.com/anton-uvarenko/promova_test/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type MediaService struct {
+	mediaRepo mediaRepo
+	storage   storage.Storage
+}
+
+func NewMediaService(mediaRepo mediaRepo, storage storage.Storage) *MediaService {
+	return &MediaService{
+		mediaRepo: mediaRepo,
+		storage:   storage,
+	}
+}
+
+type mediaRepo interface {
+	AddMedia(ctx context.Context, arg core.AddMediaParams) (core.Media, error)
+	DeleteMedia(ctx context.Context, id int32) error
+	GetMediaById(ctx context.Context, id int32) (core.Media, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsMedia(ctx context.Context, newsID int32) ([]core.Media, error)
+}
+
+// MediaVariant is a resized copy of an image, media keep them as json.
+type MediaVariant struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Key    string `json:"key"`
+}
+
+// MediaVariants reads the variants media keep.
+func MediaVariants(m core.Media) ([]MediaVariant, error) {
+	var variants []MediaVariant
+	err := json.Unmarshal(m.Variants, &variants)
+	return variants, err
+}
+
+// UploadMedia stores data as media of the news, along with the variants of
+// images. Role is inline unless it's set.
+func (s *MediaService) UploadMedia(ctx context.Context, newsId int32, role string, data []byte) (core.Media, error) {
+	news, err := s.mediaRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return core.Media{}, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Media{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return core.Media{}, err
+	}
+
+	err = authz.Authorize(subject, authz.ActionUpdate, newsResource(news))
+	if err != nil {
+		return core.Media{}, err
+	}
+
+	if role == "" {
+		role = media.RoleInline
+	}
+	if !slices.Contains(media.Roles, role) {
+		return core.Media{}, fmt.Errorf("%w: [unknown media role %q]", pkg.ErrInvalidPayload, role)
+	}
+
+	contentType, err := media.Sniff(data)
+	if err != nil {
+		return core.Media{}, mediaError(err)
+	}
+
+	params := core.AddMediaParams{
+		NewsID:      newsId,
+		Role:        role,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UploadedBy:  subject.ID,
+	}
+
+	prefix, err := mediaPrefix(newsId)
+	if err != nil {
+		return core.Media{}, err
+	}
+	params.StorageKey = prefix + "original." + media.Extension(contentType)
+
+	var variants []media.Variant
+	if media.IsImage(contentType) {
+		img, err := media.Decode(data)
+		if err != nil {
+			return core.Media{}, mediaError(err)
+		}
+		params.Width = pgtype.Int4{Int32: int32(img.Bounds().Dx()), Valid: true}
+		params.Height = pgtype.Int4{Int32: int32(img.Bounds().Dy()), Valid: true}
+
+		variants, err = media.Variants(img)
+		if err != nil {
+			return core.Media{}, err
+		}
+	}
+
+	stored := []MediaVariant{}
+	for _, variant := range variants {
+		stored = append(stored, MediaVariant{
+			Name:   variant.Name,
+			Format: variant.Format,
+			Width:  variant.Width,
+			Height: variant.Height,
+			Key:    prefix + variant.Name + "." + variant.Format,
+		})
+	}
+	params.Variants, err = json.Marshal(stored)
+	if err != nil {
+		return core.Media{}, err
+	}
+
+	var keys []string
+	put := func(key string, data []byte, contentType string) error {
+		keys = append(keys, key)
+		return s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+	}
+
+	err = put(params.StorageKey, data, contentType)
+	for i := 0; err == nil && i < len(variants); i++ {
+		err = put(stored[i].Key, variants[i].Data, "image/"+variants[i].Format)
+	}
+	if err != nil {
+		s.deleteFiles(ctx, keys)
+		fmt.Printf("can't store media: [%v]\n", err)
+		return core.Media{}, err
+	}
+
+	m, err := s.mediaRepo.AddMedia(ctx, params)
+	if err != nil {
+		s.deleteFiles(ctx, keys)
+
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) {
+			// the news already have a cover
+			if pgError.Code == "23505" {
+				return core.Media{}, pkg.ErrEntityAlreadyExists
+			}
+			if pgError.Code == "23503" {
+				// news deleted in the meantime
+				if pgError.ConstraintName == "media_news_id_fkey" {
+					return core.Media{}, pkg.ErrNotFound
+				}
+				// author from the token doesn't exist
+				return core.Media{}, pkg.ErrUnauthorized
+			}
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Media{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return m, nil
+}
+
+func (s *MediaService) GetNewsMedia(ctx context.Context, newsId int32) ([]core.Media, error) {
+	m, err := s.mediaRepo.GetNewsMedia(ctx, newsId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return m, nil
+}
+
+// DeleteMedia deletes the media of the news, and then its files. Files that
+// can't be deleted are left in storage.
+func (s *MediaService) DeleteMedia(ctx context.Context, newsId int32, mediaId int32) error {
+	m, err := s.mediaRepo.GetMediaById(ctx, mediaId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	if m.NewsID != newsId {
+		return pkg.ErrNotFound
+	}
+
+	news, err := s.mediaRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = authorize(ctx, authz.ActionUpdate, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	err = s.mediaRepo.DeleteMedia(ctx, mediaId)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	keys := []string{m.StorageKey}
+	variants, err := MediaVariants(m)
+	if err != nil {
+		fmt.Printf("can't read media variants: [%v]\n", err)
+	}
+	for _, variant := range variants {
+		keys = append(keys, variant.Key)
+	}
+	s.deleteFiles(ctx, keys)
+
+	return nil
+}
+
+// URL is where the file under key is downloaded from.
+func (s *MediaService) URL(key string) string {
+	return s.storage.URL(key)
+}
+
+func (s *MediaService) deleteFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		err := s.storage.Delete(ctx, key)
+		if err != nil {
+			fmt.Printf("can't delete media file %s: [%v]\n", key, err)
+		}
+	}
+}
+
+// mediaPrefix is where the files of an upload are kept, a random part keeps
+// keys of new uploads from ever being cached.
+func mediaPrefix(newsId int32) (string, error) {
+	random := make([]byte, 8)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("news/%d/%s/", newsId, hex.EncodeToString(random)), nil
+}
+
+func mediaError(err error) error {
+	if errors.Is(err, media.ErrTooLarge) {
+		return fmt.Errorf("%w: [%w]", pkg.ErrPayloadTooLarge, err)
+	}
+
+	return fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err)
+}
@@ -0,0 +1,55 @@
+//go:build integration
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/storage"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestMediaServiceIntegration(t *testing.T) {
+	db := pgtest.New(t)
+	service := NewMediaService(db.Queries, storage.NewLocal(t.TempDir(), ""))
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleAuthor})
+	authorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: authorId, Role: authz.RoleAuthor})
+	newsId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId})
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 400, 300)))
+
+	cover, err := service.UploadMedia(authorCtx, newsId, "cover", buf.Bytes())
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "media", 1, "id = $1 AND news_id = $2 AND width = 400 AND uploaded_by = $3", cover.ID, newsId, authorId)
+	// media change what's served along with the news, not the news
+	db.AssertCount(t, "news_activity", 1, "news_id = $1 AND updated_at >= $2", newsId, cover.CreatedAt)
+
+	_, err = service.UploadMedia(authorCtx, newsId, "cover", buf.Bytes())
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+
+	_, err = service.UploadMedia(authorCtx, newsId, "inline", buf.Bytes())
+	assert.Equal(t, err, nil)
+
+	media, err := service.GetNewsMedia(authorCtx, newsId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(media), 2)
+
+	err = service.DeleteMedia(authorCtx, newsId, cover.ID)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "media", 1, "")
+	db.AssertCount(t, "news_events", 0, "news_id = $1 AND type = 'updated'", newsId)
+
+	db.Exec(t, "DELETE FROM news WHERE id = $1", newsId)
+	db.AssertCount(t, "media", 0, "")
+	db.AssertCount(t, "news_activity", 0, "")
+}
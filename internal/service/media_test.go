@@ -0,0 +1,290 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type MediaRepoMock struct {
+	Media                   core.Media
+	ErrAddMediaToReturn     error
+	ErrGetMediaToReturn     error
+	ErrGetNewsByIdToReturn  error
+	ErrGetNewsMediaToReturn error
+}
+
+func (m *MediaRepoMock) AddMedia(ctx context.Context, arg core.AddMediaParams) (core.Media, error) {
+	if m.ErrAddMediaToReturn != nil {
+		return core.Media{}, m.ErrAddMediaToReturn
+	}
+	return core.Media{
+		ID:          1,
+		NewsID:      arg.NewsID,
+		Role:        arg.Role,
+		ContentType: arg.ContentType,
+		Size:        arg.Size,
+		Width:       arg.Width,
+		Height:      arg.Height,
+		StorageKey:  arg.StorageKey,
+		Variants:    arg.Variants,
+		UploadedBy:  arg.UploadedBy,
+	}, nil
+}
+
+func (m *MediaRepoMock) DeleteMedia(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (m *MediaRepoMock) GetMediaById(ctx context.Context, id int32) (core.Media, error) {
+	if m.ErrGetMediaToReturn != nil {
+		return core.Media{}, m.ErrGetMediaToReturn
+	}
+	return m.Media, nil
+}
+
+func (m *MediaRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if m.ErrGetNewsByIdToReturn != nil {
+		return core.News{}, m.ErrGetNewsByIdToReturn
+	}
+	return core.News{
+		ID:       id,
+		AuthorID: pgtype.Int4{Int32: 1, Valid: true},
+		Status:   authz.StatusDraft,
+	}, nil
+}
+
+func (m *MediaRepoMock) GetNewsMedia(ctx context.Context, newsID int32) ([]core.Media, error) {
+	if m.ErrGetNewsMediaToReturn != nil {
+		return nil, m.ErrGetNewsMediaToReturn
+	}
+	return []core.Media{{ID: 1, NewsID: newsID}}, nil
+}
+
+type StorageMock struct {
+	Files          map[string]string
+	ErrPutToReturn error
+}
+
+func (m *StorageMock) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if m.ErrPutToReturn != nil {
+		return m.ErrPutToReturn
+	}
+	m.Files[key] = contentType
+	return nil
+}
+
+func (m *StorageMock) Delete(ctx context.Context, key string) error {
+	delete(m.Files, key)
+	return nil
+}
+
+func (m *StorageMock) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func TestUploadMedia(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 400, 300)))
+	somePng := buf.Bytes()
+
+	testTable := []struct {
+		Name                   string
+		Ctx                    context.Context
+		Role                   string
+		Data                   []byte
+		ErrGetNewsShouldReturn error
+		ErrRepoShouldReturn    error
+		ErrPutShouldReturn     error
+		ExpectedError          error
+		ExpectedFiles          int
+	}{
+		{
+			Name:          "Ok image",
+			Ctx:           authorCtx,
+			Data:          somePng,
+			ExpectedFiles: 3,
+		},
+		{
+			Name:          "Ok video",
+			Ctx:           authorCtx,
+			Role:          "cover",
+			Data:          []byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"),
+			ExpectedFiles: 1,
+		},
+		{
+			Name:          "Err unknown role",
+			Ctx:           authorCtx,
+			Role:          "background",
+			Data:          somePng,
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err unsupported type",
+			Ctx:           authorCtx,
+			Data:          []byte("<html></html>"),
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err broken image",
+			Ctx:           authorCtx,
+			Data:          somePng[:100],
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err too large",
+			Ctx:           authorCtx,
+			Data:          append([]byte("\xff\xd8\xff"), make([]byte, 10<<20)...),
+			ExpectedError: pkg.ErrPayloadTooLarge,
+		},
+		{
+			Name:          "Err viewer can't upload",
+			Ctx:           viewerCtx,
+			Data:          somePng,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:                   "Err news not found",
+			Ctx:                    authorCtx,
+			Data:                   somePng,
+			ErrGetNewsShouldReturn: pgx.ErrNoRows,
+			ExpectedError:          pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err second cover",
+			Ctx:                 authorCtx,
+			Role:                "cover",
+			Data:                somePng,
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23505"},
+			ExpectedError:       pkg.ErrEntityAlreadyExists,
+		},
+		{
+			Name:                "Err news deleted meanwhile",
+			Ctx:                 authorCtx,
+			Data:                somePng,
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23503", ConstraintName: "media_news_id_fkey"},
+			ExpectedError:       pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 authorCtx,
+			Data:                somePng,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+		{
+			Name:               "Err storage",
+			Ctx:                authorCtx,
+			Data:               somePng,
+			ErrPutShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:      errors.New("some unexpected error"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &MediaRepoMock{
+				ErrGetNewsByIdToReturn: testCase.ErrGetNewsShouldReturn,
+				ErrAddMediaToReturn:    testCase.ErrRepoShouldReturn,
+			}
+			storage := &StorageMock{Files: map[string]string{}, ErrPutToReturn: testCase.ErrPutShouldReturn}
+			service := NewMediaService(repo, storage)
+
+			m, err := service.UploadMedia(testCase.Ctx, 1, testCase.Role, testCase.Data)
+			if testCase.ExpectedError != nil {
+				assert.NotEqual(t, err, nil)
+				if testCase.ErrPutShouldReturn == nil {
+					assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+				}
+				// nothing is left behind
+				assert.Equal(t, len(storage.Files), 0)
+				return
+			}
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(storage.Files), testCase.ExpectedFiles)
+			assert.Equal(t, m.UploadedBy, int32(1))
+
+			variants, err := MediaVariants(m)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(variants), testCase.ExpectedFiles-1)
+			for _, variant := range variants {
+				assert.Equal(t, storage.Files[variant.Key], "image/"+variant.Format)
+			}
+		})
+	}
+}
+
+func TestDeleteMedia(t *testing.T) {
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		NewsId              int32
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name:   "Ok",
+			Ctx:    authorCtx,
+			NewsId: 1,
+		},
+		{
+			Name:          "Err media of other news",
+			Ctx:           authorCtx,
+			NewsId:        2,
+			ExpectedError: pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err not found",
+			Ctx:                 authorCtx,
+			NewsId:              1,
+			ErrRepoShouldReturn: pgx.ErrNoRows,
+			ExpectedError:       pkg.ErrNotFound,
+		},
+		{
+			Name:          "Err viewer can't delete",
+			Ctx:           viewerCtx,
+			NewsId:        1,
+			ExpectedError: pkg.ErrForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &MediaRepoMock{
+				Media: core.Media{
+					ID:         1,
+					NewsID:     1,
+					StorageKey: "news/1/abc/original.png",
+					Variants:   []byte(`[{"name":"thumbnail","format":"jpeg","width":320,"height":320,"key":"news/1/abc/thumbnail.jpeg"}]`),
+				},
+				ErrGetMediaToReturn: testCase.ErrRepoShouldReturn,
+			}
+			storage := &StorageMock{Files: map[string]string{
+				"news/1/abc/original.png":   "image/png",
+				"news/1/abc/thumbnail.jpeg": "image/jpeg",
+			}}
+			service := NewMediaService(repo, storage)
+
+			err := service.DeleteMedia(testCase.Ctx, testCase.NewsId, 1)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+
+			if testCase.ExpectedError == nil {
+				assert.Equal(t, len(storage.Files), 0)
+			} else {
+				assert.Equal(t, len(storage.Files), 2)
+			}
+		})
+	}
+}
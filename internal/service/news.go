@@ -2,22 +2,39 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/anton-uvarenko/promova_test/internal/authz"
 	"github.com/anton-uvarenko/promova_test/internal/core"
 	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/blocks"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/content"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	rulesCacheSize = 1000
+	rulesCacheTTL  = time.Hour
 )
 
 type NewsService struct {
 	newsRepo newsRepo
+	rules    *targeting.Rules
 }
 
 func NewNewsService(newsRepo newsRepo) *NewsService {
 	return &NewsService{
 		newsRepo: newsRepo,
+		rules:    targeting.NewRules(rulesCacheSize, rulesCacheTTL),
 	}
 }
 
@@ -26,10 +43,171 @@ type newsRepo interface {
 	DeleteNews(ctx context.Context, id int32) error
 	GetAllNews(ctx context.Context) ([]core.News, error)
 	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error)
+	GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error)
+	GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error)
 	UpdateNews(ctx context.Context, arg core.UpdateNewsParams) error
+	PublishNews(ctx context.Context, id int32) error
+	PinNews(ctx context.Context, arg core.PinNewsParams) error
+	UnpinNews(ctx context.Context, id int32) error
+}
+
+func subjectFromContext(ctx context.Context) (authz.Subject, error) {
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return authz.Subject{}, pkg.ErrUnauthorized
+	}
+
+	return author.Subject(), nil
+}
+
+// apiKeyOf is the api key the caller authenticated with. Writes with keys
+// are attributed to the admin who created the key and to the key itself.
+func apiKeyOf(ctx context.Context) pgtype.Int4 {
+	author, ok := auth.AuthorFromContext(ctx)
+	return pgtype.Int4{Int32: author.ApiKeyID, Valid: ok && author.ApiKeyID != 0}
+}
+
+func newsResource(news core.News) authz.Resource {
+	return authz.Resource{
+		AuthorID: news.AuthorID.Int32,
+		Status:   news.Status,
+	}
+}
+
+func authorize(ctx context.Context, action authz.Action, resource authz.Resource) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return authz.Authorize(subject, action, resource)
+}
+
+// readable reports whether the caller may read news, anonymous callers
+// read published news only.
+func readable(ctx context.Context, news core.News) bool {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return news.Status == authz.StatusPublished
+	}
+
+	return authz.Authorize(subject, authz.ActionRead, newsResource(news)) == nil
+}
+
+// newsContent is what news are written with, either content or blocks.
+type newsContent struct {
+	format  string
+	content pgtype.Text
+	blocks  []byte
+}
+
+// stored returns what's stored for c. News written as blocks get content in
+// format, markdown unless it's set, for clients that don't read blocks.
+// Content is written in format, plain unless it's set, and gets blocks
+// unless it's html.
+func (c newsContent) stored() (newsContent, error) {
+	if len(c.blocks) > 0 {
+		if c.content.String != "" {
+			return newsContent{}, fmt.Errorf("%w: [either content or blocks can be written]", pkg.ErrInvalidPayload)
+		}
+
+		doc, err := blocks.Parse(c.blocks)
+		if err != nil {
+			return newsContent{}, fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err)
+		}
+
+		if c.format == "" {
+			c.format = content.FormatMarkdown
+		}
+		switch c.format {
+		case content.FormatMarkdown:
+			c.content = pgtype.Text{String: blocks.ToMarkdown(doc), Valid: true}
+		case content.FormatPlain:
+			c.content = pgtype.Text{String: blocks.ToPlain(doc), Valid: true}
+		default:
+			return newsContent{}, fmt.Errorf("%w: [blocks can't be written as %q]", pkg.ErrInvalidPayload, c.format)
+		}
+
+		// stored the way they're read, whatever the formatting they came in
+		c.blocks, err = json.Marshal(doc)
+		return c, err
+	}
+
+	if c.format == "" {
+		c.format = content.FormatPlain
+	}
+	if !slices.Contains(content.Formats, c.format) {
+		return newsContent{}, fmt.Errorf("%w: [unknown content format %q]", pkg.ErrInvalidPayload, c.format)
+	}
+
+	if c.content.Valid {
+		c.content.String = content.Sanitize(c.format, c.content.String)
+	}
+
+	c.blocks = nil
+	doc, ok := blocks.FromContent(c.format, c.content.String)
+	if ok {
+		var err error
+		c.blocks, err = json.Marshal(doc)
+		if err != nil {
+			return newsContent{}, err
+		}
+	}
+
+	return c, nil
+}
+
+// storedTargeting validates a targeting rule, no rule shows news to
+// everyone.
+func (s *NewsService) storedTargeting(rule pgtype.Text) (pgtype.Text, error) {
+	rule.String = strings.TrimSpace(rule.String)
+	if rule.String == "" {
+		return pgtype.Text{}, nil
+	}
+
+	_, err := s.rules.Parse(rule.String)
+	if err != nil {
+		return pgtype.Text{}, fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err)
+	}
+
+	return pgtype.Text{String: rule.String, Valid: true}, nil
+}
+
+// shownTo reports whether news are shown to the client. News with a rule
+// that no longer parses aren't shown to anyone.
+func (s *NewsService) shownTo(news core.News, client targeting.Client) bool {
+	if !news.Targeting.Valid {
+		return true
+	}
+
+	rule, err := s.rules.Parse(news.Targeting.String)
+	if err != nil {
+		fmt.Printf("news %d: %v\n", news.ID, err)
+		return false
+	}
+
+	return rule.Matches(client)
 }
 
 func (s *NewsService) AddNews(ctx context.Context, params core.AddNewsParams) (int32, error) {
+	err := authorize(ctx, authz.ActionCreate, authz.Resource{})
+	if err != nil {
+		return 0, err
+	}
+
+	stored, err := newsContent{params.ContentFormat, params.Content, params.Blocks}.stored()
+	if err != nil {
+		return 0, err
+	}
+	params.ContentFormat, params.Content, params.Blocks = stored.format, stored.content, stored.blocks
+
+	params.Targeting, err = s.storedTargeting(params.Targeting)
+	if err != nil {
+		return 0, err
+	}
+	params.ApiKeyID = apiKeyOf(ctx)
+
 	id, err := s.newsRepo.AddNews(ctx, params)
 	if err != nil {
 		var pgError *pgconn.PgError
@@ -39,6 +217,10 @@ func (s *NewsService) AddNews(ctx context.Context, params core.AddNewsParams) (i
 			if err.Code == "23505" {
 				return 0, pkg.ErrEntityAlreadyExists
 			}
+			// author from the token doesn't exist
+			if err.Code == "23503" {
+				return 0, pkg.ErrUnauthorized
+			}
 		}
 
 		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
@@ -49,7 +231,7 @@ func (s *NewsService) AddNews(ctx context.Context, params core.AddNewsParams) (i
 }
 
 func (s *NewsService) UpdatNews(ctx context.Context, params core.UpdateNewsParams) error {
-	_, err := s.newsRepo.GetNewsById(ctx, params.ID)
+	news, err := s.newsRepo.GetNewsById(ctx, params.ID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return pkg.ErrNotFound
@@ -59,6 +241,28 @@ func (s *NewsService) UpdatNews(ctx context.Context, params core.UpdateNewsParam
 		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
 	}
 
+	err = authorize(ctx, authz.ActionUpdate, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	stored, err := newsContent{params.ContentFormat, params.Content, params.Blocks}.stored()
+	if err != nil {
+		return err
+	}
+	params.ContentFormat, params.Content, params.Blocks = stored.format, stored.content, stored.blocks
+
+	// news keep their rule unless it's set, an empty one shows them to
+	// everyone
+	if !params.Targeting.Valid {
+		params.Targeting = news.Targeting
+	}
+	params.Targeting, err = s.storedTargeting(params.Targeting)
+	if err != nil {
+		return err
+	}
+	params.UpdatedByApiKeyID = apiKeyOf(ctx)
+
 	err = s.newsRepo.UpdateNews(ctx, params)
 	if err != nil {
 		var pgError *pgconn.PgError
@@ -67,6 +271,9 @@ func (s *NewsService) UpdatNews(ctx context.Context, params core.UpdateNewsParam
 			if err.Code == "23505" {
 				return pkg.ErrEntityAlreadyExists
 			}
+			if err.Code == "23503" {
+				return pkg.ErrUnauthorized
+			}
 		}
 
 		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
@@ -85,7 +292,44 @@ func (s *NewsService) GetAllNews(ctx context.Context) ([]core.News, error) {
 		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
 	}
 
-	return news, nil
+	return s.VisibleNews(ctx, news), nil
+}
+
+// VisibleNews returns the news the caller may read and, when the client is
+// known, the news shown to it. News can be shared with a cache, they're
+// copied rather than filtered in place.
+func (s *NewsService) VisibleNews(ctx context.Context, news []core.News) []core.News {
+	visible := make([]core.News, 0, len(news))
+	for _, n := range news {
+		n, ok := s.visible(ctx, n)
+		if ok {
+			visible = append(visible, n)
+		}
+	}
+
+	return visible
+}
+
+// visible returns the news as the caller sees them, false for news it may
+// not read or that aren't shown to the client. Rules tell about the
+// audience, so only those who may update news see them, and see the news
+// whoever they're targeted at.
+func (s *NewsService) visible(ctx context.Context, news core.News) (core.News, bool) {
+	if !readable(ctx, news) {
+		return core.News{}, false
+	}
+
+	if authorize(ctx, authz.ActionUpdate, newsResource(news)) == nil {
+		return news, true
+	}
+
+	client, targeted := targeting.ClientFromContext(ctx)
+	if targeted && !s.shownTo(news, client) {
+		return core.News{}, false
+	}
+
+	news.Targeting = pgtype.Text{}
+	return news, true
 }
 
 func (s *NewsService) GetNewsById(ctx context.Context, id int32) (core.News, error) {
@@ -99,11 +343,155 @@ func (s *NewsService) GetNewsById(ctx context.Context, id int32) (core.News, err
 		return core.News{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
 	}
 
+	// news the caller can't read don't exist for it
+	news, ok := s.visible(ctx, news)
+	if !ok {
+		return core.News{}, pkg.ErrNotFound
+	}
+
 	return news, nil
 }
 
+// GetNewsByIds reads the news in one go. Ids with no news the caller can
+// read are left out.
+func (s *NewsService) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	news, err := s.newsRepo.GetNewsByIds(ctx, ids)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return s.VisibleNews(ctx, news), nil
+}
+
+// GetNewsActivity is when what's served along with the news last changed,
+// zero when it never did. It's apart from UpdatedAt so it doesn't make news
+// events.
+func (s *NewsService) GetNewsActivity(ctx context.Context, id int32) (time.Time, error) {
+	activity, err := s.newsRepo.GetNewsActivity(ctx, id)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return time.Time{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return activity.Time, nil
+}
+
+// GetNewsStats is a cheap summary of the news table for clients to
+// revalidate cached lists against.
+func (s *NewsService) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	stats, err := s.newsRepo.GetNewsStats(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.GetNewsStatsRow{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return stats, nil
+}
+
+// DryRunTargeting tells which of the clients the news are shown to, in the
+// order of clients.
+func (s *NewsService) DryRunTargeting(ctx context.Context, id int32, clients []targeting.Client) ([]bool, error) {
+	news, err := s.newsRepo.GetNewsById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = authorize(ctx, authz.ActionUpdate, newsResource(news))
+	if err != nil {
+		return nil, err
+	}
+
+	shown := make([]bool, 0, len(clients))
+	for _, client := range clients {
+		shown = append(shown, s.shownTo(news, client))
+	}
+
+	return shown, nil
+}
+
+func (s *NewsService) PublishNews(ctx context.Context, id int32) error {
+	news, err := s.newsRepo.GetNewsById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = authorize(ctx, authz.ActionPublish, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	err = s.newsRepo.PublishNews(ctx, id)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+// PinNews puts the news at the top of the list, until the time given when
+// it's valid.
+func (s *NewsService) PinNews(ctx context.Context, id int32, until pgtype.Timestamp) error {
+	if until.Valid && !until.Time.After(time.Now()) {
+		return fmt.Errorf("%w: [news can't be pinned until the past]", pkg.ErrInvalidPayload)
+	}
+
+	err := s.authorizeFeature(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.newsRepo.PinNews(ctx, core.PinNewsParams{ID: id, PinnedUntil: until})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+func (s *NewsService) UnpinNews(ctx context.Context, id int32) error {
+	err := s.authorizeFeature(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.newsRepo.UnpinNews(ctx, id)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+func (s *NewsService) authorizeFeature(ctx context.Context, id int32) error {
+	news, err := s.newsRepo.GetNewsById(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return authorize(ctx, authz.ActionFeature, newsResource(news))
+}
+
 func (s *NewsService) DeleteNews(ctx context.Context, id int32) error {
-	_, err := s.newsRepo.GetNewsById(ctx, id)
+	news, err := s.newsRepo.GetNewsById(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return pkg.ErrEntityAlreadyDeleted
@@ -113,6 +501,11 @@ func (s *NewsService) DeleteNews(ctx context.Context, id int32) error {
 		return pkg.ErrDbInternal
 	}
 
+	err = authorize(ctx, authz.ActionDelete, newsResource(news))
+	if err != nil {
+		return err
+	}
+
 	err = s.newsRepo.DeleteNews(ctx, id)
 	if err != nil {
 		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
@@ -0,0 +1,90 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/outbox"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// newIntegrationNewsService wires the news service to Postgres the way the
+// app does, minus the cache.
+func newIntegrationNewsService(t *testing.T) (*NewsService, *pgtest.DB) {
+	db := pgtest.New(t)
+	return NewNewsService(outbox.NewNewsRepo(db.Pool, db.Queries)), db
+}
+
+func TestNewsServiceIntegration(t *testing.T) {
+	service, db := newIntegrationNewsService(t)
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleAuthor})
+	editorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleEditor})
+	authorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: authorId, Role: authz.RoleAuthor})
+	editorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: editorId, Role: authz.RoleEditor})
+
+	id, err := service.AddNews(authorCtx, addNewsParams("some title", authorId))
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 1, "id = $1 AND author_id = $2 AND status = $3", id, authorId, authz.StatusDraft)
+
+	err = service.UpdatNews(editorCtx, core.UpdateNewsParams{
+		ID:        id,
+		Title:     pgtype.Text{String: "other title", Valid: true},
+		Content:   pgtype.Text{String: "other content", Valid: true},
+		UpdatedBy: pgtype.Int4{Int32: editorId, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 1, "id = $1 AND title = $2 AND updated_by = $3", id, "other title", editorId)
+
+	err = service.PublishNews(editorCtx, id)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 1, "id = $1 AND status = $2 AND published_at IS NOT NULL", id, authz.StatusPublished)
+
+	err = service.DeleteNews(editorCtx, id)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news", 0, "")
+
+	// every change is in the change log and the outbox
+	assert.Equal(t, pgtest.Column[string](t, db, "news_events", "type", "news_id = $1", id), []string{"created", "updated", "published", "deleted"})
+	assert.Equal(t, pgtest.Column[string](t, db, "outbox", "type", ""), []string{
+		outbox.EventNewsCreated,
+		outbox.EventNewsUpdated,
+		outbox.EventNewsPublished,
+		outbox.EventNewsDeleted,
+	})
+}
+
+func TestNewsServiceIntegrationErrors(t *testing.T) {
+	service, db := newIntegrationNewsService(t)
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{})
+	authorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: authorId, Role: authz.RoleAuthor})
+	db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId})
+	// seeded news are recorded by the trigger, but not in the outbox
+	db.AssertCount(t, "news_events", 1, "")
+
+	_, err := service.AddNews(authorCtx, addNewsParams("some title", authorId))
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+
+	deletedAuthorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: authorId + 1000, Role: authz.RoleAuthor})
+	_, err = service.AddNews(deletedAuthorCtx, addNewsParams("other title", authorId+1000))
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+
+	err = service.UpdatNews(authorCtx, core.UpdateNewsParams{ID: 1000})
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	err = service.DeleteNews(authorCtx, 1000)
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyDeleted), true)
+
+	// failed writes are rolled back with their events
+	db.AssertCount(t, "news", 1, "")
+	db.AssertCount(t, "news_events", 1, "")
+	db.AssertCount(t, "outbox", 0, "")
+}
@@ -4,22 +4,39 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/anton-uvarenko/promova_test/internal/authz"
 	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/memory"
 	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/content"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
 	"github.com/go-playground/assert/v2"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type NewsRepoMock struct {
-	ErrAddNewsToReturn     error
-	ErrUpdateNewsToReturn  error
-	ErrGetAllNewsToReturn  error
-	ErrGetNewsByIdToReturn error
-	ErrDeleteNewsToReturn  error
+	ErrAddNewsToReturn      error
+	ErrUpdateNewsToReturn   error
+	ErrGetAllNewsToReturn   error
+	ErrGetNewsByIdToReturn  error
+	ErrGetNewsStatsToReturn error
+	ErrDeleteNewsToReturn   error
+	ErrPublishNewsToReturn  error
+	ErrPinNewsToReturn      error
 }
 
+var (
+	adminCtx  = auth.WithAuthor(context.Background(), auth.Author{ID: 3, Role: authz.RoleAdmin})
+	editorCtx = auth.WithAuthor(context.Background(), auth.Author{ID: 2, Role: authz.RoleEditor})
+	authorCtx = auth.WithAuthor(context.Background(), auth.Author{ID: 1, Role: authz.RoleAuthor})
+	viewerCtx = auth.WithAuthor(context.Background(), auth.Author{ID: 4, Role: authz.RoleViewer})
+)
+
 func (m *NewsRepoMock) AddNews(ctx context.Context, arg core.AddNewsParams) (int32, error) {
 	if m.ErrAddNewsToReturn != nil {
 		return 0, m.ErrAddNewsToReturn
@@ -40,7 +57,8 @@ func (m *NewsRepoMock) GetAllNews(ctx context.Context) ([]core.News, error) {
 	}
 	return []core.News{
 		{
-			ID: 1,
+			ID:     1,
+			Status: authz.StatusPublished,
 		},
 	}, nil
 }
@@ -50,10 +68,60 @@ func (m *NewsRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, er
 		return core.News{}, m.ErrGetNewsByIdToReturn
 	}
 	return core.News{
-		ID: 1,
+		ID:       1,
+		AuthorID: pgtype.Int4{Int32: 1, Valid: true},
+		Status:   authz.StatusDraft,
 	}, nil
 }
 
+func (m *NewsRepoMock) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	if m.ErrGetNewsByIdToReturn != nil {
+		return nil, m.ErrGetNewsByIdToReturn
+	}
+
+	var news []core.News
+	for _, id := range ids {
+		news = append(news, core.News{
+			ID:       id,
+			AuthorID: pgtype.Int4{Int32: 1, Valid: true},
+			Status:   authz.StatusDraft,
+		})
+	}
+	return news, nil
+}
+
+func (m *NewsRepoMock) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	if m.ErrGetNewsStatsToReturn != nil {
+		return core.GetNewsStatsRow{}, m.ErrGetNewsStatsToReturn
+	}
+	return core.GetNewsStatsRow{Count: 1}, nil
+}
+
+func (m *NewsRepoMock) GetNewsActivity(ctx context.Context, newsID int32) (pgtype.Timestamp, error) {
+	return pgtype.Timestamp{}, nil
+}
+
+func (m *NewsRepoMock) PublishNews(ctx context.Context, id int32) error {
+	if m.ErrPublishNewsToReturn != nil {
+		return m.ErrPublishNewsToReturn
+	}
+	return nil
+}
+
+func (m *NewsRepoMock) PinNews(ctx context.Context, arg core.PinNewsParams) error {
+	if m.ErrPinNewsToReturn != nil {
+		return m.ErrPinNewsToReturn
+	}
+	return nil
+}
+
+func (m *NewsRepoMock) UnpinNews(ctx context.Context, id int32) error {
+	if m.ErrPinNewsToReturn != nil {
+		return m.ErrPinNewsToReturn
+	}
+	return nil
+}
+
 func (m *NewsRepoMock) DeleteNews(ctx context.Context, id int32) error {
 	if m.ErrDeleteNewsToReturn != nil {
 		return m.ErrDeleteNewsToReturn
@@ -67,25 +135,50 @@ func TestAddNews(t *testing.T) {
 
 	testTable := []struct {
 		Name                string
+		Ctx                 context.Context
 		ErrRepoShouldReturn error
 		ExpectedError       error
 		ExpectedResult      int32
 	}{
 		{
 			Name:           "Ok",
+			Ctx:            authorCtx,
 			ExpectedError:  nil,
 			ExpectedResult: 1,
 		},
+		{
+			Name:           "Err unauthenticated",
+			Ctx:            context.Background(),
+			ExpectedError:  pkg.ErrUnauthorized,
+			ExpectedResult: 0,
+		},
+		{
+			Name:           "Err viewer can't create",
+			Ctx:            viewerCtx,
+			ExpectedError:  pkg.ErrForbidden,
+			ExpectedResult: 0,
+		},
 		{
 			Name: "Err entity already exist",
+			Ctx:  authorCtx,
 			ErrRepoShouldReturn: &pgconn.PgError{
 				Code: "23505",
 			},
 			ExpectedError:  pkg.ErrEntityAlreadyExists,
 			ExpectedResult: 0,
 		},
+		{
+			Name: "Err unknown author",
+			Ctx:  authorCtx,
+			ErrRepoShouldReturn: &pgconn.PgError{
+				Code: "23503",
+			},
+			ExpectedError:  pkg.ErrUnauthorized,
+			ExpectedResult: 0,
+		},
 		{
 			Name:                "Err db internal",
+			Ctx:                 authorCtx,
 			ErrRepoShouldReturn: errors.New("some unexpected error"),
 			ExpectedError:       pkg.ErrDbInternal,
 			ExpectedResult:      0,
@@ -96,7 +189,7 @@ func TestAddNews(t *testing.T) {
 		t.Run(testCase.Name, func(t *testing.T) {
 			repo.ErrAddNewsToReturn = testCase.ErrRepoShouldReturn
 
-			id, err := service.AddNews(context.Background(), core.AddNewsParams{})
+			id, err := service.AddNews(testCase.Ctx, core.AddNewsParams{})
 
 			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
 			assert.Equal(t, id, testCase.ExpectedResult)
@@ -110,23 +203,42 @@ func TestUpdatNews(t *testing.T) {
 
 	testTable := []struct {
 		Name                      string
+		Ctx                       context.Context
 		ErrUpdateNewsShouldReturn error
 		ErrGetNewsByIdToReturn    error
 		ExpectedError             error
 	}{
 		{
 			Name:                      "Ok",
+			Ctx:                       editorCtx,
 			ErrUpdateNewsShouldReturn: nil,
 			ExpectedError:             nil,
 		},
+		{
+			Name:          "Ok author edits own draft",
+			Ctx:           authorCtx,
+			ExpectedError: nil,
+		},
+		{
+			Name:          "Err author edits someone else's draft",
+			Ctx:           auth.WithAuthor(context.Background(), auth.Author{ID: 5, Role: authz.RoleAuthor}),
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err unauthenticated",
+			Ctx:           context.Background(),
+			ExpectedError: pkg.ErrUnauthorized,
+		},
 		{
 			Name:                      "Err not found",
+			Ctx:                       editorCtx,
 			ErrUpdateNewsShouldReturn: nil,
 			ErrGetNewsByIdToReturn:    pgx.ErrNoRows,
 			ExpectedError:             pkg.ErrNotFound,
 		},
 		{
 			Name: "Err duplicate key",
+			Ctx:  editorCtx,
 			ErrUpdateNewsShouldReturn: &pgconn.PgError{
 				Code: "23505",
 			},
@@ -134,6 +246,7 @@ func TestUpdatNews(t *testing.T) {
 		},
 		{
 			Name:                      "Err db internal",
+			Ctx:                       editorCtx,
 			ErrUpdateNewsShouldReturn: errors.New("some unexpected error"),
 			ExpectedError:             pkg.ErrDbInternal,
 		},
@@ -144,7 +257,7 @@ func TestUpdatNews(t *testing.T) {
 			repo.ErrUpdateNewsToReturn = testCase.ErrUpdateNewsShouldReturn
 			repo.ErrGetNewsByIdToReturn = testCase.ErrGetNewsByIdToReturn
 
-			err := service.UpdatNews(context.Background(), core.UpdateNewsParams{})
+			err := service.UpdatNews(testCase.Ctx, core.UpdateNewsParams{})
 			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
 		})
 	}
@@ -232,7 +345,7 @@ func TestGetNewsById(t *testing.T) {
 		t.Run(testCase.Name, func(t *testing.T) {
 			repo.ErrGetNewsByIdToReturn = testCase.ErrRepoShouldReturn
 
-			result, err := service.GetNewsById(context.Background(), 1)
+			result, err := service.GetNewsById(authorCtx, 1)
 
 			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
 			assert.Equal(t, result.ID, testCase.ExpectedResult.ID)
@@ -240,29 +353,125 @@ func TestGetNewsById(t *testing.T) {
 	}
 }
 
+func TestGetNewsByIds(t *testing.T) {
+	repo := &NewsRepoMock{}
+	service := NewNewsService(repo)
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		ErrRepoShouldReturn error
+		ExpectedError       error
+		ExpectedIds         []int32
+	}{
+		{
+			Name:        "Ok",
+			Ctx:         authorCtx,
+			ExpectedIds: []int32{1, 2},
+		},
+		{
+			Name:        "Drafts left out for viewers",
+			Ctx:         viewerCtx,
+			ExpectedIds: []int32{},
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 authorCtx,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrGetNewsByIdToReturn = testCase.ErrRepoShouldReturn
+
+			result, err := service.GetNewsByIds(testCase.Ctx, []int32{1, 2})
+
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				return
+			}
+			ids := []int32{}
+			for _, n := range result {
+				ids = append(ids, n.ID)
+			}
+			assert.Equal(t, ids, testCase.ExpectedIds)
+		})
+	}
+}
+
+func TestGetNewsStats(t *testing.T) {
+	repo := &NewsRepoMock{}
+	service := NewNewsService(repo)
+	testTable := []struct {
+		Name                string
+		ErrRepoShouldReturn error
+		ExpectedError       error
+		ExpectedResult      core.GetNewsStatsRow
+	}{
+		{
+			Name:                "Ok",
+			ErrRepoShouldReturn: nil,
+			ExpectedError:       nil,
+			ExpectedResult:      core.GetNewsStatsRow{Count: 1},
+		},
+		{
+			Name:                "Err db internal",
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+			ExpectedResult:      core.GetNewsStatsRow{},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrGetNewsStatsToReturn = testCase.ErrRepoShouldReturn
+
+			result, err := service.GetNewsStats(context.Background())
+
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			assert.Equal(t, result, testCase.ExpectedResult)
+		})
+	}
+}
+
 func TestDeleteNews(t *testing.T) {
 	repo := &NewsRepoMock{}
 	service := NewNewsService(repo)
 	testTable := []struct {
 		Name                       string
+		Ctx                        context.Context
 		ErrDelteNewsShouldReturn   error
 		ErrGetNewsByIdShouldReturn error
 		ExpectedError              error
 	}{
 		{
 			Name:                       "Ok",
+			Ctx:                        adminCtx,
 			ErrDelteNewsShouldReturn:   nil,
 			ErrGetNewsByIdShouldReturn: nil,
 			ExpectedError:              nil,
 		},
+		{
+			Name:          "Err editor can't delete",
+			Ctx:           editorCtx,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err author can't delete own draft",
+			Ctx:           authorCtx,
+			ExpectedError: pkg.ErrForbidden,
+		},
 		{
 			Name:                       "Err already deleted",
+			Ctx:                        adminCtx,
 			ErrDelteNewsShouldReturn:   nil,
 			ErrGetNewsByIdShouldReturn: pgx.ErrNoRows,
 			ExpectedError:              pkg.ErrEntityAlreadyDeleted,
 		},
 		{
 			Name:                       "Err db internal",
+			Ctx:                        adminCtx,
 			ErrDelteNewsShouldReturn:   errors.New(`some unexpected err`),
 			ErrGetNewsByIdShouldReturn: nil,
 			ExpectedError:              pkg.ErrDbInternal,
@@ -274,8 +483,522 @@ func TestDeleteNews(t *testing.T) {
 			repo.ErrDeleteNewsToReturn = testCase.ErrDelteNewsShouldReturn
 			repo.ErrGetNewsByIdToReturn = testCase.ErrGetNewsByIdShouldReturn
 
-			err := service.DeleteNews(context.Background(), 1)
+			err := service.DeleteNews(testCase.Ctx, 1)
 			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
 		})
 	}
 }
+
+func TestPublishNews(t *testing.T) {
+	repo := &NewsRepoMock{}
+	service := NewNewsService(repo)
+	testTable := []struct {
+		Name                       string
+		Ctx                        context.Context
+		ErrPublishNewsShouldReturn error
+		ErrGetNewsByIdShouldReturn error
+		ExpectedError              error
+	}{
+		{
+			Name:          "Ok",
+			Ctx:           editorCtx,
+			ExpectedError: nil,
+		},
+		{
+			Name:          "Err author can't publish own draft",
+			Ctx:           authorCtx,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:                       "Err not found",
+			Ctx:                        editorCtx,
+			ErrGetNewsByIdShouldReturn: pgx.ErrNoRows,
+			ExpectedError:              pkg.ErrNotFound,
+		},
+		{
+			Name:                       "Err db internal",
+			Ctx:                        editorCtx,
+			ErrPublishNewsShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:              pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrPublishNewsToReturn = testCase.ErrPublishNewsShouldReturn
+			repo.ErrGetNewsByIdToReturn = testCase.ErrGetNewsByIdShouldReturn
+
+			err := service.PublishNews(testCase.Ctx, 1)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+		})
+	}
+}
+
+func TestPinNews(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	service := NewNewsService(repo)
+
+	draftId, err := service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+	id, err := service.AddNews(authorCtx, addNewsParams("other title", 1))
+	assert.Equal(t, err, nil)
+	err = service.PublishNews(editorCtx, id)
+	assert.Equal(t, err, nil)
+
+	until := pgtype.Timestamp{Time: time.Now().UTC().Add(time.Hour), Valid: true}
+	err = service.PinNews(editorCtx, id, until)
+	assert.Equal(t, err, nil)
+
+	news, err := service.GetNewsById(editorCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.PinnedAt.Valid, true)
+	assert.Equal(t, news.PinnedUntil, until)
+
+	err = service.PinNews(editorCtx, id, pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true})
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+	err = service.PinNews(authorCtx, id, pgtype.Timestamp{})
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	// drafts aren't listed for readers, there's nothing to pin
+	err = service.PinNews(editorCtx, draftId, pgtype.Timestamp{})
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+	err = service.PinNews(editorCtx, 1000, pgtype.Timestamp{})
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	err = service.UnpinNews(editorCtx, id)
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(editorCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.PinnedAt.Valid, false)
+	assert.Equal(t, news.PinnedUntil.Valid, false)
+}
+
+func TestNewsDraftsVisibility(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	repo.AddAuthor(2)
+	service := NewNewsService(repo)
+
+	publishedId, err := service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+	err = service.PublishNews(editorCtx, publishedId)
+	assert.Equal(t, err, nil)
+	draftId, err := service.AddNews(authorCtx, addNewsParams("other title", 1))
+	assert.Equal(t, err, nil)
+	_, err = service.AddNews(editorCtx, addNewsParams("third title", 2))
+	assert.Equal(t, err, nil)
+
+	testTable := []struct {
+		Name          string
+		Ctx           context.Context
+		ExpectedCount int
+		ExpectedError error
+	}{
+		{
+			Name:          "Anonymous reads published",
+			Ctx:           context.Background(),
+			ExpectedCount: 1,
+			ExpectedError: pkg.ErrNotFound,
+		},
+		{
+			Name:          "Viewer reads published",
+			Ctx:           viewerCtx,
+			ExpectedCount: 1,
+			ExpectedError: pkg.ErrNotFound,
+		},
+		{
+			Name:          "Author reads own drafts",
+			Ctx:           authorCtx,
+			ExpectedCount: 2,
+		},
+		{
+			Name:          "Editor reads every draft",
+			Ctx:           editorCtx,
+			ExpectedCount: 3,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			all, err := service.GetAllNews(testCase.Ctx)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(all), testCase.ExpectedCount)
+
+			_, err = service.GetNewsById(testCase.Ctx, draftId)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+		})
+	}
+}
+
+func TestNewsApiKeyAttribution(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	service := NewNewsService(repo)
+
+	keyCtx := auth.WithAuthor(context.Background(), auth.Author{
+		ID:       1,
+		Role:     authz.RoleService,
+		Scopes:   []authz.Scope{authz.ScopeNewsWrite},
+		ApiKeyID: 7,
+	})
+
+	id, err := service.AddNews(keyCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+
+	news, err := service.GetNewsById(keyCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.AuthorID, pgtype.Int4{Int32: 1, Valid: true})
+	assert.Equal(t, news.ApiKeyID, pgtype.Int4{Int32: 7, Valid: true})
+	assert.Equal(t, news.UpdatedByApiKeyID.Valid, false)
+
+	err = service.UpdatNews(keyCtx, core.UpdateNewsParams{
+		ID:        id,
+		Title:     pgtype.Text{String: "some title", Valid: true},
+		UpdatedBy: pgtype.Int4{Int32: 1, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(keyCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.UpdatedByApiKeyID, pgtype.Int4{Int32: 7, Valid: true})
+
+	// updates without a key clear it
+	err = service.UpdatNews(authorCtx, core.UpdateNewsParams{
+		ID:        id,
+		Title:     pgtype.Text{String: "some title", Valid: true},
+		UpdatedBy: pgtype.Int4{Int32: 1, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(keyCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.ApiKeyID, pgtype.Int4{Int32: 7, Valid: true})
+	assert.Equal(t, news.UpdatedByApiKeyID.Valid, false)
+
+	// keys with the read scope read drafts, other keys read published news
+	readerCtx := auth.WithAuthor(context.Background(), auth.Author{
+		ID:       1,
+		Role:     authz.RoleService,
+		Scopes:   []authz.Scope{authz.ScopeNewsRead},
+		ApiKeyID: 8,
+	})
+	_, err = service.GetNewsById(readerCtx, id)
+	assert.Equal(t, err, nil)
+
+	deleterCtx := auth.WithAuthor(context.Background(), auth.Author{
+		ID:       1,
+		Role:     authz.RoleService,
+		Scopes:   []authz.Scope{authz.ScopeNewsDelete},
+		ApiKeyID: 9,
+	})
+	_, err = service.GetNewsById(deleterCtx, id)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+}
+
+// TestNewsServiceWithMemoryRepo checks how the service maps what the
+// database actually answers, rather than canned errors.
+func TestNewsServiceWithMemoryRepo(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	repo.AddAuthor(2)
+	service := NewNewsService(repo)
+
+	id, err := service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+
+	_, err = service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+
+	unknownAuthorCtx := auth.WithAuthor(context.Background(), auth.Author{ID: 5, Role: authz.RoleAuthor})
+	_, err = service.AddNews(unknownAuthorCtx, addNewsParams("other title", 5))
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+
+	otherId, err := service.AddNews(authorCtx, addNewsParams("other title", 1))
+	assert.Equal(t, err, nil)
+
+	err = service.UpdatNews(editorCtx, core.UpdateNewsParams{
+		ID:        otherId,
+		Title:     pgtype.Text{String: "some title", Valid: true},
+		UpdatedBy: pgtype.Int4{Int32: 2, Valid: true},
+	})
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyExists), true)
+
+	err = service.UpdatNews(editorCtx, core.UpdateNewsParams{ID: 1000})
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	err = service.PublishNews(editorCtx, id)
+	assert.Equal(t, err, nil)
+
+	news, err := service.GetNewsById(context.Background(), id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Status, authz.StatusPublished)
+
+	err = service.DeleteNews(adminCtx, id)
+	assert.Equal(t, err, nil)
+
+	err = service.DeleteNews(adminCtx, id)
+	assert.Equal(t, errors.Is(err, pkg.ErrEntityAlreadyDeleted), true)
+
+	_, err = service.GetNewsById(context.Background(), id)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+}
+
+func TestNewsContentFormat(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	service := NewNewsService(repo)
+
+	id, err := service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+
+	news, err := service.GetNewsById(authorCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.ContentFormat, content.FormatPlain)
+
+	params := addNewsParams("other title", 1)
+	params.ContentFormat = content.FormatHTML
+	params.Content = pgtype.Text{String: `<p onclick="steal()">some <b>content</b></p><script>steal()</script>`, Valid: true}
+	otherId, err := service.AddNews(authorCtx, params)
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(authorCtx, otherId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.ContentFormat, content.FormatHTML)
+	assert.Equal(t, news.Content.String, "<p>some <b>content</b></p>")
+
+	// markdown is kept as it's written
+	err = service.UpdatNews(authorCtx, core.UpdateNewsParams{
+		ID:            otherId,
+		Title:         pgtype.Text{String: "other title", Valid: true},
+		Content:       pgtype.Text{String: "some <script>steal()</script>", Valid: true},
+		ContentFormat: content.FormatMarkdown,
+		UpdatedBy:     pgtype.Int4{Int32: 1, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(authorCtx, otherId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.ContentFormat, content.FormatMarkdown)
+	assert.Equal(t, news.Content.String, "some <script>steal()</script>")
+
+	params = addNewsParams("third title", 1)
+	params.ContentFormat = "rtf"
+	_, err = service.AddNews(authorCtx, params)
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+
+	err = service.UpdatNews(authorCtx, core.UpdateNewsParams{ID: id, ContentFormat: "rtf"})
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+}
+
+func TestNewsBlocks(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	service := NewNewsService(repo)
+
+	// content gets blocks
+	id, err := service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+
+	news, err := service.GetNewsById(authorCtx, id)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(news.Blocks), `{"version":1,"blocks":[{"type":"paragraph","text":"some content"}]}`)
+
+	// blocks get markdown content and are stored the way they're read
+	params := addNewsParams("other title", 1)
+	params.Content = pgtype.Text{}
+	params.Blocks = []byte(`{"version": 1, "blocks": [
+		{"type": "heading", "text": "Title", "level": 1},
+		{"type": "lesson", "lesson_id": 42, "title": "First lesson"}
+	]}`)
+	otherId, err := service.AddNews(authorCtx, params)
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(authorCtx, otherId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.ContentFormat, content.FormatMarkdown)
+	assert.Equal(t, news.Content.String, "# Title\n\n[First lesson](lesson:42)")
+	assert.Equal(t, string(news.Blocks), `{"version":1,"blocks":[{"type":"heading","text":"Title","level":1},{"type":"lesson","lesson_id":42,"title":"First lesson"}]}`)
+
+	// or plain content when it's asked for
+	err = service.UpdatNews(authorCtx, core.UpdateNewsParams{
+		ID:            otherId,
+		Title:         pgtype.Text{String: "other title", Valid: true},
+		ContentFormat: content.FormatPlain,
+		Blocks:        []byte(`{"version": 1, "blocks": [{"type": "quote", "text": "some quote", "attribution": "someone"}]}`),
+		UpdatedBy:     pgtype.Int4{Int32: 1, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(authorCtx, otherId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Content.String, "some quote\n— someone")
+
+	// html has no blocks
+	err = service.UpdatNews(authorCtx, core.UpdateNewsParams{
+		ID:            otherId,
+		Title:         pgtype.Text{String: "other title", Valid: true},
+		Content:       pgtype.Text{String: "<p>some</p>", Valid: true},
+		ContentFormat: content.FormatHTML,
+		UpdatedBy:     pgtype.Int4{Int32: 1, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	news, err = service.GetNewsById(authorCtx, otherId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Blocks == nil, true)
+
+	testTable := []struct {
+		Name          string
+		Content       string
+		ContentFormat string
+		Blocks        string
+	}{
+		{
+			Name:   "Error invalid blocks",
+			Blocks: `{"version": 1, "blocks": [{"type": "video"}]}`,
+		},
+		{
+			Name:   "Error null blocks",
+			Blocks: `null`,
+		},
+		{
+			Name:    "Error content and blocks",
+			Content: "some content",
+			Blocks:  `{"version": 1, "blocks": [{"type": "paragraph", "text": "some"}]}`,
+		},
+		{
+			Name:          "Error blocks as html",
+			ContentFormat: content.FormatHTML,
+			Blocks:        `{"version": 1, "blocks": [{"type": "paragraph", "text": "some"}]}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			params := addNewsParams("third title", 1)
+			params.Content = pgtype.Text{String: testCase.Content, Valid: testCase.Content != ""}
+			params.ContentFormat = testCase.ContentFormat
+			params.Blocks = []byte(testCase.Blocks)
+
+			_, err := service.AddNews(authorCtx, params)
+			assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+		})
+	}
+}
+
+func TestNewsTargeting(t *testing.T) {
+	repo := memory.NewNewsRepo()
+	repo.AddAuthor(1)
+	repo.AddAuthor(2)
+	service := NewNewsService(repo)
+
+	everyoneId, err := service.AddNews(authorCtx, addNewsParams("some title", 1))
+	assert.Equal(t, err, nil)
+
+	params := addNewsParams("other title", 1)
+	params.Targeting = pgtype.Text{String: ` platform == "ios" and app_version >= "3.2" `, Valid: true}
+	iosId, err := service.AddNews(authorCtx, params)
+	assert.Equal(t, err, nil)
+
+	news, err := service.GetNewsById(authorCtx, iosId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Targeting, pgtype.Text{String: `platform == "ios" and app_version >= "3.2"`, Valid: true})
+
+	err = service.PublishNews(editorCtx, everyoneId)
+	assert.Equal(t, err, nil)
+	err = service.PublishNews(editorCtx, iosId)
+	assert.Equal(t, err, nil)
+
+	// without a client everything is listed
+	all, err := service.GetAllNews(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 2)
+
+	ios := targeting.Client{Platform: targeting.PlatformIOS, AppVersion: targeting.Version{3, 4}}
+	all, err = service.GetAllNews(targeting.WithClient(context.Background(), ios))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 2)
+
+	web := targeting.Client{Platform: targeting.PlatformWeb}
+	all, err = service.GetAllNews(targeting.WithClient(context.Background(), web))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 1)
+	assert.Equal(t, all[0].ID, everyoneId)
+
+	// news that aren't shown to the client aren't found either
+	_, err = service.GetNewsById(targeting.WithClient(context.Background(), web), iosId)
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	// the rule is only there for those who may update news, and so are the
+	// news whoever they're targeted at
+	news, err = service.GetNewsById(targeting.WithClient(viewerCtx, ios), iosId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Targeting.Valid, false)
+
+	news, err = service.GetNewsById(targeting.WithClient(editorCtx, web), iosId)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news.Targeting.Valid, true)
+
+	all, err = service.GetAllNews(targeting.WithClient(editorCtx, web))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 2)
+
+	shown, err := service.DryRunTargeting(editorCtx, iosId, []targeting.Client{ios, web, {}})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, shown, []bool{true, false, false})
+
+	shown, err = service.DryRunTargeting(editorCtx, everyoneId, []targeting.Client{web})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, shown, []bool{true})
+
+	_, err = service.DryRunTargeting(viewerCtx, iosId, []targeting.Client{web})
+	assert.Equal(t, errors.Is(err, pkg.ErrForbidden), true)
+
+	_, err = service.DryRunTargeting(editorCtx, 1000, []targeting.Client{web})
+	assert.Equal(t, errors.Is(err, pkg.ErrNotFound), true)
+
+	// an update without a rule keeps it
+	err = service.UpdatNews(editorCtx, core.UpdateNewsParams{
+		ID:        iosId,
+		Title:     pgtype.Text{String: "other title", Valid: true},
+		UpdatedBy: pgtype.Int4{Int32: 2, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	all, err = service.GetAllNews(targeting.WithClient(context.Background(), web))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 1)
+
+	// an empty rule shows news to everyone again
+	err = service.UpdatNews(editorCtx, core.UpdateNewsParams{
+		ID:        iosId,
+		Title:     pgtype.Text{String: "other title", Valid: true},
+		Targeting: pgtype.Text{String: "  ", Valid: true},
+		UpdatedBy: pgtype.Int4{Int32: 2, Valid: true},
+	})
+	assert.Equal(t, err, nil)
+
+	all, err = service.GetAllNews(targeting.WithClient(context.Background(), web))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(all), 2)
+
+	params = addNewsParams("third title", 1)
+	params.Targeting = pgtype.Text{String: `platform == "windows"`, Valid: true}
+	_, err = service.AddNews(authorCtx, params)
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+
+	err = service.UpdatNews(editorCtx, core.UpdateNewsParams{
+		ID:        iosId,
+		Targeting: pgtype.Text{String: `tier ==`, Valid: true},
+	})
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+}
+
+func addNewsParams(title string, authorId int32) core.AddNewsParams {
+	return core.AddNewsParams{
+		Title:    pgtype.Text{String: title, Valid: true},
+		Content:  pgtype.Text{String: "some content", Valid: true},
+		AuthorID: pgtype.Int4{Int32: authorId, Valid: true},
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ReactionKinds are the reactions news get, named the way they go in urls:
+// 👍 like, ❤️ love, 😂 laugh, 😮 wow, 😢 sad and 👏 clap.
+var ReactionKinds = []string{"like", "love", "laugh", "wow", "sad", "clap"}
+
+type ReactionService struct {
+	reactionRepo reactionRepo
+}
+
+func NewReactionService(reactionRepo reactionRepo) *ReactionService {
+	return &ReactionService{
+		reactionRepo: reactionRepo,
+	}
+}
+
+type reactionRepo interface {
+	AddReaction(ctx context.Context, arg core.AddReactionParams) (int64, error)
+	GetAuthorReactions(ctx context.Context, arg core.GetAuthorReactionsParams) ([]core.Reaction, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetReactionCounts(ctx context.Context, newsIds []int32) ([]core.ReactionCount, error)
+	RemoveReaction(ctx context.Context, arg core.RemoveReactionParams) (int64, error)
+}
+
+// NewsReactions has counts by kind, kinds nobody reacted with are missing.
+// Mine are the kinds the caller reacted with, nil for anonymous callers.
+type NewsReactions struct {
+	Counts map[string]int
+	Mine   []string
+}
+
+// AddReaction reacts to published news, reacting again with the same kind
+// changes nothing.
+func (s *ReactionService) AddReaction(ctx context.Context, newsId int32, kind string) error {
+	if !slices.Contains(ReactionKinds, kind) {
+		return fmt.Errorf("%w: [unknown reaction %q]", pkg.ErrInvalidPayload, kind)
+	}
+
+	news, err := s.reactionRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = authz.Authorize(subject, authz.ActionReact, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reactionRepo.AddReaction(ctx, core.AddReactionParams{
+		NewsID:   newsId,
+		AuthorID: subject.ID,
+		Kind:     kind,
+	})
+	if err != nil {
+		var pgError *pgconn.PgError
+		if errors.As(err, &pgError) && pgError.Code == "23503" {
+			// news deleted in the meantime
+			if pgError.ConstraintName == "reactions_news_id_fkey" {
+				return pkg.ErrNotFound
+			}
+			// author from the token doesn't exist
+			return pkg.ErrUnauthorized
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+// RemoveReaction takes the reaction of the caller back, removing one that
+// isn't there changes nothing.
+func (s *ReactionService) RemoveReaction(ctx context.Context, newsId int32, kind string) error {
+	if !slices.Contains(ReactionKinds, kind) {
+		return fmt.Errorf("%w: [unknown reaction %q]", pkg.ErrInvalidPayload, kind)
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.reactionRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	_, err = s.reactionRepo.RemoveReaction(ctx, core.RemoveReactionParams{
+		NewsID:   newsId,
+		AuthorID: subject.ID,
+		Kind:     kind,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+// GetReactions returns reactions of each of the news, along with the ones
+// of the caller when there is an author on ctx.
+func (s *ReactionService) GetReactions(ctx context.Context, newsIds []int32) (map[int32]NewsReactions, error) {
+	reactions := map[int32]NewsReactions{}
+	if len(newsIds) == 0 {
+		return reactions, nil
+	}
+
+	counts, err := s.reactionRepo.GetReactionCounts(ctx, newsIds)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	for _, count := range counts {
+		newsReactions := reactions[count.NewsID]
+		if newsReactions.Counts == nil {
+			newsReactions.Counts = map[string]int{}
+		}
+		newsReactions.Counts[count.Kind] = int(count.Count)
+		reactions[count.NewsID] = newsReactions
+	}
+
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return reactions, nil
+	}
+
+	mine, err := s.reactionRepo.GetAuthorReactions(ctx, core.GetAuthorReactionsParams{
+		AuthorID: author.ID,
+		NewsIds:  newsIds,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	for _, reaction := range mine {
+		newsReactions := reactions[reaction.NewsID]
+		newsReactions.Mine = append(newsReactions.Mine, reaction.Kind)
+		reactions[reaction.NewsID] = newsReactions
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,70 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestReactionServiceIntegration(t *testing.T) {
+	db := pgtest.New(t)
+	service := NewReactionService(db.Queries)
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleAuthor})
+	newsId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+
+	var ctxs []context.Context
+	for i := 0; i < 10; i++ {
+		viewerId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleViewer})
+		ctxs = append(ctxs, auth.WithAuthor(context.Background(), auth.Author{ID: viewerId, Role: authz.RoleViewer}))
+	}
+
+	// every viewer likes twice at once, each like is counted once
+	var wg sync.WaitGroup
+	for _, ctx := range ctxs {
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := service.AddReaction(ctx, newsId, "like")
+				assert.Equal(t, err, nil)
+			}()
+		}
+	}
+	wg.Wait()
+	db.AssertCount(t, "reactions", 10, "news_id = $1 AND kind = 'like'", newsId)
+	db.AssertCount(t, "reaction_counts", 1, "news_id = $1 AND kind = 'like' AND count = 10", newsId)
+	// reactions change what's served along with the news, not the news
+	db.AssertCount(t, "news_activity", 1, "news_id = $1", newsId)
+	db.AssertCount(t, "news_events", 0, "news_id = $1 AND type = 'updated'", newsId)
+
+	for _, ctx := range ctxs[:4] {
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := service.RemoveReaction(ctx, newsId, "like")
+				assert.Equal(t, err, nil)
+			}()
+		}
+	}
+	wg.Wait()
+	db.AssertCount(t, "reaction_counts", 1, "news_id = $1 AND kind = 'like' AND count = 6", newsId)
+
+	err := service.AddReaction(ctxs[0], newsId, "wow")
+	assert.Equal(t, err, nil)
+
+	reactions, err := service.GetReactions(ctxs[0], []int32{newsId})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reactions[newsId], NewsReactions{Counts: map[string]int{"like": 6, "wow": 1}, Mine: []string{"wow"}})
+
+	db.Exec(t, "DELETE FROM news WHERE id = $1", newsId)
+	db.AssertCount(t, "reactions", 0, "")
+	db.AssertCount(t, "reaction_counts", 0, "")
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ReactionRepoMock reacts the way the queries do, each author once per kind.
+type ReactionRepoMock struct {
+	Reactions              map[core.Reaction]bool
+	NewsStatus             string
+	ErrAddReactionToReturn error
+	ErrGetNewsByIdToReturn error
+}
+
+func (m *ReactionRepoMock) AddReaction(ctx context.Context, arg core.AddReactionParams) (int64, error) {
+	if m.ErrAddReactionToReturn != nil {
+		return 0, m.ErrAddReactionToReturn
+	}
+	reaction := core.Reaction{NewsID: arg.NewsID, AuthorID: arg.AuthorID, Kind: arg.Kind}
+	if m.Reactions[reaction] {
+		return 0, nil
+	}
+	m.Reactions[reaction] = true
+	return 1, nil
+}
+
+func (m *ReactionRepoMock) GetAuthorReactions(ctx context.Context, arg core.GetAuthorReactionsParams) ([]core.Reaction, error) {
+	var reactions []core.Reaction
+	for _, kind := range ReactionKinds {
+		for _, newsId := range arg.NewsIds {
+			reaction := core.Reaction{NewsID: newsId, AuthorID: arg.AuthorID, Kind: kind}
+			if m.Reactions[reaction] {
+				reactions = append(reactions, reaction)
+			}
+		}
+	}
+	return reactions, nil
+}
+
+func (m *ReactionRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if m.ErrGetNewsByIdToReturn != nil {
+		return core.News{}, m.ErrGetNewsByIdToReturn
+	}
+	return core.News{
+		ID:       id,
+		AuthorID: pgtype.Int4{Int32: 1, Valid: true},
+		Status:   m.NewsStatus,
+	}, nil
+}
+
+func (m *ReactionRepoMock) GetReactionCounts(ctx context.Context, newsIds []int32) ([]core.ReactionCount, error) {
+	var counts []core.ReactionCount
+	for _, newsId := range newsIds {
+		for _, kind := range ReactionKinds {
+			count := core.ReactionCount{NewsID: newsId, Kind: kind}
+			for reaction := range m.Reactions {
+				if reaction.NewsID == newsId && reaction.Kind == kind {
+					count.Count++
+				}
+			}
+			if count.Count > 0 {
+				counts = append(counts, count)
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (m *ReactionRepoMock) RemoveReaction(ctx context.Context, arg core.RemoveReactionParams) (int64, error) {
+	reaction := core.Reaction{NewsID: arg.NewsID, AuthorID: arg.AuthorID, Kind: arg.Kind}
+	if !m.Reactions[reaction] {
+		return 0, nil
+	}
+	delete(m.Reactions, reaction)
+	return 1, nil
+}
+
+func TestAddReaction(t *testing.T) {
+	testTable := []struct {
+		Name                   string
+		Ctx                    context.Context
+		Kind                   string
+		NewsStatus             string
+		ErrGetNewsShouldReturn error
+		ErrRepoShouldReturn    error
+		ExpectedError          error
+	}{
+		{
+			Name:       "Ok",
+			Ctx:        viewerCtx,
+			Kind:       "like",
+			NewsStatus: authz.StatusPublished,
+		},
+		{
+			Name:          "Err unknown kind",
+			Ctx:           viewerCtx,
+			Kind:          "dislike",
+			NewsStatus:    authz.StatusPublished,
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err draft",
+			Ctx:           viewerCtx,
+			Kind:          "like",
+			NewsStatus:    authz.StatusDraft,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err unauthorized",
+			Ctx:           context.Background(),
+			Kind:          "like",
+			NewsStatus:    authz.StatusPublished,
+			ExpectedError: pkg.ErrUnauthorized,
+		},
+		{
+			Name:                   "Err news not found",
+			Ctx:                    viewerCtx,
+			Kind:                   "like",
+			ErrGetNewsShouldReturn: pgx.ErrNoRows,
+			ExpectedError:          pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err news deleted meanwhile",
+			Ctx:                 viewerCtx,
+			Kind:                "like",
+			NewsStatus:          authz.StatusPublished,
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23503", ConstraintName: "reactions_news_id_fkey"},
+			ExpectedError:       pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 viewerCtx,
+			Kind:                "like",
+			NewsStatus:          authz.StatusPublished,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &ReactionRepoMock{
+				Reactions:              map[core.Reaction]bool{},
+				NewsStatus:             testCase.NewsStatus,
+				ErrGetNewsByIdToReturn: testCase.ErrGetNewsShouldReturn,
+				ErrAddReactionToReturn: testCase.ErrRepoShouldReturn,
+			}
+			service := NewReactionService(repo)
+
+			err := service.AddReaction(testCase.Ctx, 1, testCase.Kind)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				assert.Equal(t, len(repo.Reactions), 0)
+				return
+			}
+			assert.Equal(t, len(repo.Reactions), 1)
+
+			// reacting again changes nothing
+			err = service.AddReaction(testCase.Ctx, 1, testCase.Kind)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(repo.Reactions), 1)
+		})
+	}
+}
+
+func TestRemoveReaction(t *testing.T) {
+	repo := &ReactionRepoMock{Reactions: map[core.Reaction]bool{
+		{NewsID: 1, AuthorID: 4, Kind: "like"}: true,
+		{NewsID: 1, AuthorID: 1, Kind: "like"}: true,
+	}}
+	service := NewReactionService(repo)
+
+	err := service.RemoveReaction(viewerCtx, 1, "like")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(repo.Reactions), 1)
+
+	// removing again changes nothing
+	err = service.RemoveReaction(viewerCtx, 1, "like")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(repo.Reactions), 1)
+
+	err = service.RemoveReaction(viewerCtx, 1, "dislike")
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+
+	err = service.RemoveReaction(context.Background(), 1, "like")
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+}
+
+func TestGetReactions(t *testing.T) {
+	repo := &ReactionRepoMock{Reactions: map[core.Reaction]bool{
+		{NewsID: 1, AuthorID: 4, Kind: "like"}: true,
+		{NewsID: 1, AuthorID: 4, Kind: "wow"}:  true,
+		{NewsID: 1, AuthorID: 1, Kind: "like"}: true,
+		{NewsID: 2, AuthorID: 1, Kind: "sad"}:  true,
+	}}
+	service := NewReactionService(repo)
+
+	reactions, err := service.GetReactions(viewerCtx, []int32{1, 2, 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reactions, map[int32]NewsReactions{
+		1: {Counts: map[string]int{"like": 2, "wow": 1}, Mine: []string{"like", "wow"}},
+		2: {Counts: map[string]int{"sad": 1}},
+	})
+
+	reactions, err = service.GetReactions(context.Background(), []int32{1})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, reactions[1].Mine, []string(nil))
+	assert.Equal(t, reactions[1].Counts["like"], 2)
+}
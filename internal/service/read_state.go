@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ReadStateService keeps track of what each reader has read and bookmarked.
+type ReadStateService struct {
+	readStateRepo readStateRepo
+	newsLister    newsLister
+}
+
+func NewReadStateService(readStateRepo readStateRepo, newsLister newsLister) *ReadStateService {
+	return &ReadStateService{
+		readStateRepo: readStateRepo,
+		newsLister:    newsLister,
+	}
+}
+
+type readStateRepo interface {
+	AddBookmark(ctx context.Context, arg core.AddBookmarkParams) (int64, error)
+	GetBookmarkedNews(ctx context.Context, arg core.GetBookmarkedNewsParams) ([]core.News, error)
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsReadState(ctx context.Context, arg core.GetNewsReadStateParams) ([]core.GetNewsReadStateRow, error)
+	GetReadStateUpdatedAt(ctx context.Context, authorID int32) (pgtype.Timestamp, error)
+	GetUnreadNewsIds(ctx context.Context, authorID int32) ([]int32, error)
+	MarkAllNewsRead(ctx context.Context, authorID int32) error
+	MarkNewsRead(ctx context.Context, arg core.MarkNewsReadParams) error
+	RemoveBookmark(ctx context.Context, arg core.RemoveBookmarkParams) (int64, error)
+}
+
+type ReadState struct {
+	IsRead       bool
+	IsBookmarked bool
+}
+
+// MarkRead marks published news read by the caller, drafts are never read.
+func (s *ReadStateService) MarkRead(ctx context.Context, newsId int32) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.readStateRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	err = s.readStateRepo.MarkNewsRead(ctx, core.MarkNewsReadParams{
+		AuthorID: subject.ID,
+		NewsID:   newsId,
+	})
+	if err != nil {
+		return s.writeError(err, "news_reads_news_id_fkey")
+	}
+
+	return nil
+}
+
+// MarkAllRead marks all the news published so far read by the caller.
+func (s *ReadStateService) MarkAllRead(ctx context.Context) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.readStateRepo.MarkAllNewsRead(ctx, subject.ID)
+	if err != nil {
+		return s.writeError(err, "")
+	}
+
+	return nil
+}
+
+// GetUnreadCount counts the unread news the caller is shown, the way
+// they're listed for the client in the context.
+func (s *ReadStateService) GetUnreadCount(ctx context.Context) (int, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	unreadIds, err := s.readStateRepo.GetUnreadNewsIds(ctx, subject.ID)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return 0, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	unread := make(map[int32]bool, len(unreadIds))
+	for _, id := range unreadIds {
+		unread[id] = true
+	}
+
+	// news the caller isn't shown, drafts and news targeted at other
+	// clients, never get read
+	news, err := s.newsLister.GetAllNews(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, n := range news {
+		if unread[n.ID] {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// AddBookmark bookmarks published news, bookmarking them again changes
+// nothing.
+func (s *ReadStateService) AddBookmark(ctx context.Context, newsId int32) error {
+	news, err := s.readStateRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = authz.Authorize(subject, authz.ActionBookmark, newsResource(news))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.readStateRepo.AddBookmark(ctx, core.AddBookmarkParams{
+		AuthorID: subject.ID,
+		NewsID:   newsId,
+	})
+	if err != nil {
+		return s.writeError(err, "bookmarks_news_id_fkey")
+	}
+
+	return nil
+}
+
+// RemoveBookmark removes the bookmark of the caller, removing one that isn't
+// there changes nothing.
+func (s *ReadStateService) RemoveBookmark(ctx context.Context, newsId int32) error {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.readStateRepo.RemoveBookmark(ctx, core.RemoveBookmarkParams{
+		AuthorID: subject.ID,
+		NewsID:   newsId,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return nil
+}
+
+// GetBookmarks returns up to first news bookmarked by the caller with ids
+// less than before, newest first, and whether there are more.
+func (s *ReadStateService) GetBookmarks(ctx context.Context, before int32, first int) ([]core.News, bool, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// one more tells whether there is a next page
+	news, err := s.readStateRepo.GetBookmarkedNews(ctx, core.GetBookmarkedNewsParams{
+		AuthorID: subject.ID,
+		Before:   before,
+		MaxNews:  int32(first + 1),
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, false, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if len(news) > first {
+		return news[:first], true, nil
+	}
+	return news, false, nil
+}
+
+// GetReadStates returns what the caller has read and bookmarked of the news,
+// nothing for anonymous callers.
+func (s *ReadStateService) GetReadStates(ctx context.Context, newsIds []int32) (map[int32]ReadState, error) {
+	states := map[int32]ReadState{}
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok || len(newsIds) == 0 {
+		return states, nil
+	}
+
+	rows, err := s.readStateRepo.GetNewsReadState(ctx, core.GetNewsReadStateParams{
+		AuthorID: author.ID,
+		NewsIds:  newsIds,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+	for _, row := range rows {
+		states[row.NewsID] = ReadState{IsRead: row.IsRead, IsBookmarked: row.IsBookmarked}
+	}
+
+	return states, nil
+}
+
+// GetUpdatedAt returns when the caller last read or bookmarked anything, zero
+// for anonymous callers and the ones who never did.
+func (s *ReadStateService) GetUpdatedAt(ctx context.Context) (time.Time, error) {
+	author, ok := auth.AuthorFromContext(ctx)
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	updatedAt, err := s.readStateRepo.GetReadStateUpdatedAt(ctx, author.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return time.Time{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return updatedAt.Time, nil
+}
+
+// writeError maps a failed write, newsConstraint is the foreign key to the
+// news written about.
+func (s *ReadStateService) writeError(err error, newsConstraint string) error {
+	var pgError *pgconn.PgError
+	if errors.As(err, &pgError) && pgError.Code == "23503" {
+		// news deleted in the meantime
+		if newsConstraint != "" && pgError.ConstraintName == newsConstraint {
+			return pkg.ErrNotFound
+		}
+		// author from the token doesn't exist
+		return pkg.ErrUnauthorized
+	}
+
+	fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+	return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+}
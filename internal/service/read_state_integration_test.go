@@ -0,0 +1,119 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestReadStateServiceIntegration(t *testing.T) {
+	db := pgtest.New(t)
+	service := NewReadStateService(db.Queries, NewNewsService(db.Queries))
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleAuthor})
+	readerId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleViewer})
+	ctx := auth.WithAuthor(context.Background(), auth.Author{ID: readerId, Role: authz.RoleViewer})
+
+	var newsIds []int32
+	for i := 0; i < 3; i++ {
+		newsId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+		db.Exec(t, "UPDATE news SET published_at = NOW() - INTERVAL '1 hour' WHERE id = $1", newsId)
+		newsIds = append(newsIds, newsId)
+	}
+	draftId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId})
+
+	count, err := service.GetUnreadCount(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 3)
+
+	updatedAt, err := service.GetUpdatedAt(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, updatedAt.IsZero(), true)
+
+	// reads are kept as exceptions while there's no watermark
+	err = service.MarkRead(ctx, newsIds[0])
+	assert.Equal(t, err, nil)
+	err = service.MarkRead(ctx, newsIds[0])
+	assert.Equal(t, err, nil)
+	err = service.MarkRead(ctx, draftId)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news_reads", 1, "author_id = $1", readerId)
+
+	// reads change what the reader gets
+	updatedAt, err = service.GetUpdatedAt(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, updatedAt.IsZero(), false)
+
+	count, err = service.GetUnreadCount(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 2)
+
+	// the watermark takes the place of the reads it covers
+	err = service.MarkAllRead(ctx)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "read_markers", 1, "author_id = $1", readerId)
+	db.AssertCount(t, "news_reads", 0, "author_id = $1", readerId)
+
+	count, err = service.GetUnreadCount(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 0)
+
+	// news published after it are unread until read
+	laterId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+	otherId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+	db.Exec(t, "UPDATE news SET published_at = NOW() + INTERVAL '1 second' WHERE id IN ($1, $2)", laterId, otherId)
+
+	err = service.MarkRead(ctx, newsIds[1])
+	assert.Equal(t, err, nil)
+	err = service.MarkRead(ctx, laterId)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news_reads", 1, "author_id = $1 AND news_id = $2", readerId, laterId)
+
+	count, err = service.GetUnreadCount(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 1)
+
+	// news targeted at other clients aren't counted for the client
+	db.Exec(t, "UPDATE news SET targeting = 'platform == \"ios\"' WHERE id = $1", otherId)
+	count, err = service.GetUnreadCount(targeting.WithClient(ctx, targeting.Client{Platform: targeting.PlatformWeb}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 0)
+	count, err = service.GetUnreadCount(targeting.WithClient(ctx, targeting.Client{Platform: targeting.PlatformIOS}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 1)
+
+	err = service.AddBookmark(ctx, otherId)
+	assert.Equal(t, err, nil)
+	err = service.AddBookmark(ctx, newsIds[2])
+	assert.Equal(t, err, nil)
+
+	states, err := service.GetReadStates(ctx, []int32{newsIds[0], laterId, otherId, draftId})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, states, map[int32]ReadState{
+		newsIds[0]: {IsRead: true},
+		laterId:    {IsRead: true},
+		otherId:    {IsBookmarked: true},
+		draftId:    {},
+	})
+
+	bookmarks, hasNextPage, err := service.GetBookmarks(ctx, 0, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hasNextPage, true)
+	assert.Equal(t, bookmarks[0].ID, otherId)
+
+	bookmarks, hasNextPage, err = service.GetBookmarks(ctx, otherId, 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hasNextPage, false)
+	assert.Equal(t, bookmarks[0].ID, newsIds[2])
+
+	// read state goes with the news
+	db.Exec(t, "DELETE FROM news WHERE id IN ($1, $2)", laterId, otherId)
+	db.AssertCount(t, "news_reads", 0, "author_id = $1", readerId)
+	db.AssertCount(t, "bookmarks", 1, "author_id = $1", readerId)
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ReadStateRepoMock keeps read news and bookmarks of every author, without
+// a watermark.
+type ReadStateRepoMock struct {
+	Read                    map[core.NewsRead]bool
+	Bookmarks               map[core.NewsRead]bool
+	ReadAll                 []int32
+	NewsStatus              string
+	UpdatedAt               time.Time
+	ErrGetNewsByIdToReturn  error
+	ErrAddBookmarkToReturn  error
+	ErrMarkNewsReadToReturn error
+}
+
+func (m *ReadStateRepoMock) AddBookmark(ctx context.Context, arg core.AddBookmarkParams) (int64, error) {
+	if m.ErrAddBookmarkToReturn != nil {
+		return 0, m.ErrAddBookmarkToReturn
+	}
+	bookmark := core.NewsRead{AuthorID: arg.AuthorID, NewsID: arg.NewsID}
+	if m.Bookmarks[bookmark] {
+		return 0, nil
+	}
+	m.Bookmarks[bookmark] = true
+	return 1, nil
+}
+
+func (m *ReadStateRepoMock) GetBookmarkedNews(ctx context.Context, arg core.GetBookmarkedNewsParams) ([]core.News, error) {
+	var news []core.News
+	for id := int32(10); id > 0; id-- {
+		if m.Bookmarks[core.NewsRead{AuthorID: arg.AuthorID, NewsID: id}] && (arg.Before == 0 || id < arg.Before) {
+			news = append(news, core.News{ID: id})
+		}
+	}
+	if len(news) > int(arg.MaxNews) {
+		news = news[:arg.MaxNews]
+	}
+	return news, nil
+}
+
+func (m *ReadStateRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if m.ErrGetNewsByIdToReturn != nil {
+		return core.News{}, m.ErrGetNewsByIdToReturn
+	}
+	return core.News{
+		ID:       id,
+		AuthorID: pgtype.Int4{Int32: 1, Valid: true},
+		Status:   m.NewsStatus,
+	}, nil
+}
+
+func (m *ReadStateRepoMock) GetNewsReadState(ctx context.Context, arg core.GetNewsReadStateParams) ([]core.GetNewsReadStateRow, error) {
+	var rows []core.GetNewsReadStateRow
+	for _, id := range arg.NewsIds {
+		key := core.NewsRead{AuthorID: arg.AuthorID, NewsID: id}
+		rows = append(rows, core.GetNewsReadStateRow{NewsID: id, IsRead: m.Read[key], IsBookmarked: m.Bookmarks[key]})
+	}
+	return rows, nil
+}
+
+func (m *ReadStateRepoMock) GetReadStateUpdatedAt(ctx context.Context, authorID int32) (pgtype.Timestamp, error) {
+	if m.UpdatedAt.IsZero() {
+		return pgtype.Timestamp{}, pgx.ErrNoRows
+	}
+	return pgtype.Timestamp{Time: m.UpdatedAt, Valid: true}, nil
+}
+
+func (m *ReadStateRepoMock) GetUnreadNewsIds(ctx context.Context, authorID int32) ([]int32, error) {
+	var unread []int32
+	for id := int32(1); id <= 10; id++ {
+		if !m.Read[core.NewsRead{AuthorID: authorID, NewsID: id}] {
+			unread = append(unread, id)
+		}
+	}
+	return unread, nil
+}
+
+func (m *ReadStateRepoMock) MarkAllNewsRead(ctx context.Context, authorID int32) error {
+	m.ReadAll = append(m.ReadAll, authorID)
+	return nil
+}
+
+func (m *ReadStateRepoMock) MarkNewsRead(ctx context.Context, arg core.MarkNewsReadParams) error {
+	if m.ErrMarkNewsReadToReturn != nil {
+		return m.ErrMarkNewsReadToReturn
+	}
+	m.Read[core.NewsRead{AuthorID: arg.AuthorID, NewsID: arg.NewsID}] = true
+	return nil
+}
+
+func (m *ReadStateRepoMock) RemoveBookmark(ctx context.Context, arg core.RemoveBookmarkParams) (int64, error) {
+	bookmark := core.NewsRead{AuthorID: arg.AuthorID, NewsID: arg.NewsID}
+	if !m.Bookmarks[bookmark] {
+		return 0, nil
+	}
+	delete(m.Bookmarks, bookmark)
+	return 1, nil
+}
+
+func TestMarkRead(t *testing.T) {
+	testTable := []struct {
+		Name                   string
+		Ctx                    context.Context
+		ErrGetNewsShouldReturn error
+		ErrRepoShouldReturn    error
+		ExpectedError          error
+	}{
+		{
+			Name: "Ok",
+			Ctx:  viewerCtx,
+		},
+		{
+			Name:          "Err unauthorized",
+			Ctx:           context.Background(),
+			ExpectedError: pkg.ErrUnauthorized,
+		},
+		{
+			Name:                   "Err news not found",
+			Ctx:                    viewerCtx,
+			ErrGetNewsShouldReturn: pgx.ErrNoRows,
+			ExpectedError:          pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err news deleted meanwhile",
+			Ctx:                 viewerCtx,
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23503", ConstraintName: "news_reads_news_id_fkey"},
+			ExpectedError:       pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err author doesn't exist",
+			Ctx:                 viewerCtx,
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23503", ConstraintName: "news_reads_author_id_fkey"},
+			ExpectedError:       pkg.ErrUnauthorized,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 viewerCtx,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &ReadStateRepoMock{
+				Read:                    map[core.NewsRead]bool{},
+				ErrGetNewsByIdToReturn:  testCase.ErrGetNewsShouldReturn,
+				ErrMarkNewsReadToReturn: testCase.ErrRepoShouldReturn,
+			}
+			service := NewReadStateService(repo, &newsListerMock{Hidden: 6})
+
+			err := service.MarkRead(testCase.Ctx, 1)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				return
+			}
+
+			// news the reader isn't shown aren't counted
+			count, err := service.GetUnreadCount(testCase.Ctx)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, count, 4)
+		})
+	}
+}
+
+func TestMarkAllRead(t *testing.T) {
+	repo := &ReadStateRepoMock{}
+	service := NewReadStateService(repo, &newsListerMock{})
+
+	err := service.MarkAllRead(viewerCtx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.ReadAll, []int32{4})
+
+	err = service.MarkAllRead(context.Background())
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+
+	_, err = service.GetUnreadCount(context.Background())
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+}
+
+func TestAddBookmark(t *testing.T) {
+	testTable := []struct {
+		Name                   string
+		Ctx                    context.Context
+		NewsStatus             string
+		ErrGetNewsShouldReturn error
+		ErrRepoShouldReturn    error
+		ExpectedError          error
+	}{
+		{
+			Name:       "Ok",
+			Ctx:        viewerCtx,
+			NewsStatus: authz.StatusPublished,
+		},
+		{
+			Name:          "Err draft",
+			Ctx:           authorCtx,
+			NewsStatus:    authz.StatusDraft,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err unauthorized",
+			Ctx:           context.Background(),
+			NewsStatus:    authz.StatusPublished,
+			ExpectedError: pkg.ErrUnauthorized,
+		},
+		{
+			Name:                   "Err news not found",
+			Ctx:                    viewerCtx,
+			ErrGetNewsShouldReturn: pgx.ErrNoRows,
+			ExpectedError:          pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err news deleted meanwhile",
+			Ctx:                 viewerCtx,
+			NewsStatus:          authz.StatusPublished,
+			ErrRepoShouldReturn: &pgconn.PgError{Code: "23503", ConstraintName: "bookmarks_news_id_fkey"},
+			ExpectedError:       pkg.ErrNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &ReadStateRepoMock{
+				Bookmarks:              map[core.NewsRead]bool{},
+				NewsStatus:             testCase.NewsStatus,
+				ErrGetNewsByIdToReturn: testCase.ErrGetNewsShouldReturn,
+				ErrAddBookmarkToReturn: testCase.ErrRepoShouldReturn,
+			}
+			service := NewReadStateService(repo, &newsListerMock{})
+
+			err := service.AddBookmark(testCase.Ctx, 1)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				assert.Equal(t, len(repo.Bookmarks), 0)
+				return
+			}
+
+			// bookmarking again changes nothing
+			err = service.AddBookmark(testCase.Ctx, 1)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(repo.Bookmarks), 1)
+
+			err = service.RemoveBookmark(testCase.Ctx, 1)
+			assert.Equal(t, err, nil)
+			err = service.RemoveBookmark(testCase.Ctx, 1)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(repo.Bookmarks), 0)
+		})
+	}
+}
+
+func TestGetBookmarks(t *testing.T) {
+	repo := &ReadStateRepoMock{Bookmarks: map[core.NewsRead]bool{
+		{AuthorID: 4, NewsID: 1}: true,
+		{AuthorID: 4, NewsID: 3}: true,
+		{AuthorID: 4, NewsID: 7}: true,
+		{AuthorID: 1, NewsID: 5}: true,
+	}}
+	service := NewReadStateService(repo, &newsListerMock{})
+
+	news, hasNextPage, err := service.GetBookmarks(viewerCtx, 0, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news, []core.News{{ID: 7}, {ID: 3}})
+	assert.Equal(t, hasNextPage, true)
+
+	news, hasNextPage, err = service.GetBookmarks(viewerCtx, 3, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, news, []core.News{{ID: 1}})
+	assert.Equal(t, hasNextPage, false)
+
+	_, _, err = service.GetBookmarks(context.Background(), 0, 2)
+	assert.Equal(t, errors.Is(err, pkg.ErrUnauthorized), true)
+}
+
+func TestGetReadStates(t *testing.T) {
+	repo := &ReadStateRepoMock{
+		Read:      map[core.NewsRead]bool{{AuthorID: 4, NewsID: 1}: true},
+		Bookmarks: map[core.NewsRead]bool{{AuthorID: 4, NewsID: 2}: true},
+	}
+	service := NewReadStateService(repo, &newsListerMock{})
+
+	states, err := service.GetReadStates(viewerCtx, []int32{1, 2, 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, states, map[int32]ReadState{
+		1: {IsRead: true},
+		2: {IsBookmarked: true},
+		3: {},
+	})
+
+	states, err = service.GetReadStates(context.Background(), []int32{1, 2, 3})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(states), 0)
+}
+
+func TestGetReadStateUpdatedAt(t *testing.T) {
+	repo := &ReadStateRepoMock{}
+	service := NewReadStateService(repo, &newsListerMock{})
+
+	updatedAt, err := service.GetUpdatedAt(viewerCtx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, updatedAt.IsZero(), true)
+
+	repo.UpdatedAt = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	updatedAt, err = service.GetUpdatedAt(viewerCtx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, updatedAt, repo.UpdatedAt)
+
+	updatedAt, err = service.GetUpdatedAt(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, updatedAt.IsZero(), true)
+}
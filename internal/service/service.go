@@ -1,11 +1,58 @@
 package service
 
+import (
+	"github.com/anton-uvarenko/promova_test/internal/pkg/moderation"
+	"github.com/anton-uvarenko/promova_test/internal/storage"
+)
+
 type Service struct {
-	NewsService *NewsService
+	NewsService      *NewsService
+	ApiKeyService    *ApiKeyService
+	WebhookService   *WebhookService
+	SyncService      *SyncService
+	MediaService     *MediaService
+	CommentService   *CommentService
+	ReactionService  *ReactionService
+	ViewService      *ViewService
+	ReadStateService *ReadStateService
+	AuthorService    *AuthorService
+	// ExperimentService promotes winning variants through NewsService
+	ExperimentService *ExperimentService
+	// FeaturedService lists news through NewsService, targeting applied
+	FeaturedService *FeaturedService
 }
 
-func NewService(newsRepo newsRepo) *Service {
+func NewService(
+	newsRepo newsRepo,
+	apiKeyRepo apiKeyRepo,
+	webhookRepo webhookRepo,
+	syncRepo syncRepo,
+	mediaRepo mediaRepo,
+	mediaStorage storage.Storage,
+	commentRepo commentRepo,
+	commentFilter moderation.Filter,
+	reactionRepo reactionRepo,
+	viewRepo viewRepo,
+	viewRecorder viewRecorder,
+	readStateRepo readStateRepo,
+	experimentRepo experimentRepo,
+	featuredRepo featuredRepo,
+	authorRepo authorRepo,
+) *Service {
+	newsService := NewNewsService(newsRepo)
+
 	return &Service{
-		NewsService: NewNewsService(newsRepo),
+		NewsService:       newsService,
+		ApiKeyService:     NewApiKeyService(apiKeyRepo),
+		WebhookService:    NewWebhookService(webhookRepo),
+		SyncService:       NewSyncService(syncRepo, newsService),
+		MediaService:      NewMediaService(mediaRepo, mediaStorage),
+		CommentService:    NewCommentService(commentRepo, commentFilter),
+		ReactionService:   NewReactionService(reactionRepo),
+		ViewService:       NewViewService(newsRepo, viewRepo, viewRecorder),
+		ReadStateService:  NewReadStateService(readStateRepo, newsService),
+		ExperimentService: NewExperimentService(experimentRepo, newsService),
+		FeaturedService:   NewFeaturedService(featuredRepo, newsService),
+		AuthorService:     NewAuthorService(authorRepo),
 	}
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/events"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+)
+
+const (
+	// maxChanges is how many log entries a single sync reads, clients keep
+	// syncing while there is more
+	maxChanges = 500
+
+	syncTokenPrefix = "news-events:"
+)
+
+// NewsChanges brings a client from one sync token to the next.
+type NewsChanges struct {
+	Upserted []core.News
+	Deleted  []int32
+	Token    string
+	HasMore  bool
+}
+
+type SyncService struct {
+	syncRepo   syncRepo
+	newsFilter newsFilter
+}
+
+func NewSyncService(syncRepo syncRepo, newsFilter newsFilter) *SyncService {
+	return &SyncService{
+		syncRepo:   syncRepo,
+		newsFilter: newsFilter,
+	}
+}
+
+// newsFilter drops the news the caller may not read.
+type newsFilter interface {
+	VisibleNews(ctx context.Context, news []core.News) []core.News
+}
+
+type syncRepo interface {
+	GetAllNews(ctx context.Context) ([]core.News, error)
+	GetLastNewsEventId(ctx context.Context) (int64, error)
+	GetNewsChanges(ctx context.Context, arg core.GetNewsChangesParams) ([]core.GetNewsChangesRow, error)
+}
+
+// GetNewsChanges returns everything when since is empty. Otherwise it
+// returns the news changed after the position in the change log since
+// points to. The log orders changes by commit, so nothing committed
+// concurrently is skipped.
+func (s *SyncService) GetNewsChanges(ctx context.Context, since string) (NewsChanges, error) {
+	if since == "" {
+		return s.getAllNews(ctx)
+	}
+
+	position, err := parseSyncToken(since)
+	if err != nil {
+		return NewsChanges{}, err
+	}
+
+	rows, err := s.syncRepo.GetNewsChanges(ctx, core.GetNewsChangesParams{
+		Since:     position,
+		MaxEvents: maxChanges,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return NewsChanges{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	changes := NewsChanges{
+		Upserted: []core.News{},
+		Deleted:  []int32{},
+	}
+	for _, row := range rows {
+		event, err := events.Decode(core.NewsEvent(row))
+		if err != nil {
+			return NewsChanges{}, err
+		}
+
+		if event.Type == events.TypeDeleted {
+			changes.Deleted = append(changes.Deleted, row.NewsID)
+		} else {
+			changes.Upserted = append(changes.Upserted, event.News)
+		}
+
+		position = max(position, row.ID)
+	}
+
+	// news the caller may not read, or that aren't shown to the client, are
+	// deleted for it, it may have them from before they were changed
+	visible := s.newsFilter.VisibleNews(ctx, changes.Upserted)
+	shown := make(map[int32]bool, len(visible))
+	for _, news := range visible {
+		shown[news.ID] = true
+	}
+	for _, news := range changes.Upserted {
+		if !shown[news.ID] {
+			changes.Deleted = append(changes.Deleted, news.ID)
+		}
+	}
+	changes.Upserted = visible
+
+	last, err := s.syncRepo.GetLastNewsEventId(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return NewsChanges{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	changes.Token = syncToken(position)
+	changes.HasMore = position < last
+	return changes, nil
+}
+
+func (s *SyncService) getAllNews(ctx context.Context) (NewsChanges, error) {
+	// the position is taken first, whatever changes while reading the news
+	// is sent again by the next sync
+	position, err := s.syncRepo.GetLastNewsEventId(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return NewsChanges{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	news, err := s.syncRepo.GetAllNews(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return NewsChanges{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return NewsChanges{
+		Upserted: s.newsFilter.VisibleNews(ctx, news),
+		Deleted:  []int32{},
+		Token:    syncToken(position),
+	}, nil
+}
+
+// sync tokens are opaque to clients so the log can be compacted or
+// replaced without breaking them
+func syncToken(position int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(position, 10)))
+}
+
+func parseSyncToken(token string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: [invalid sync token]", pkg.ErrInvalidPayload)
+	}
+
+	position, ok := strings.CutPrefix(string(decoded), syncTokenPrefix)
+	if !ok {
+		return 0, fmt.Errorf("%w: [invalid sync token]", pkg.ErrInvalidPayload)
+	}
+
+	id, err := strconv.ParseInt(position, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("%w: [invalid sync token]", pkg.ErrInvalidPayload)
+	}
+
+	return id, nil
+}
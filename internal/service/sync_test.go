@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/events"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SyncRepoMock struct {
+	Log                       []core.GetNewsChangesRow
+	ErrGetNewsChangesToReturn error
+}
+
+func (m *SyncRepoMock) GetAllNews(ctx context.Context) ([]core.News, error) {
+	return []core.News{{ID: 1, Status: authz.StatusPublished}, {ID: 2, Status: authz.StatusDraft}}, nil
+}
+
+func (m *SyncRepoMock) GetLastNewsEventId(ctx context.Context) (int64, error) {
+	return m.Log[len(m.Log)-1].ID, nil
+}
+
+// GetNewsChanges compacts the log the way the query does.
+func (m *SyncRepoMock) GetNewsChanges(ctx context.Context, arg core.GetNewsChangesParams) ([]core.GetNewsChangesRow, error) {
+	if m.ErrGetNewsChangesToReturn != nil {
+		return nil, m.ErrGetNewsChangesToReturn
+	}
+
+	latest := map[int32]core.GetNewsChangesRow{}
+	var order []int32
+	var read int32
+	for _, row := range m.Log {
+		if row.ID <= arg.Since || read == arg.MaxEvents {
+			continue
+		}
+		read++
+
+		if _, ok := latest[row.NewsID]; !ok {
+			order = append(order, row.NewsID)
+		}
+		latest[row.NewsID] = row
+	}
+
+	var result []core.GetNewsChangesRow
+	for _, id := range order {
+		result = append(result, latest[id])
+	}
+	return result, nil
+}
+
+func (m *SyncRepoMock) record(eventType string, newsId int32) {
+	status := authz.StatusDraft
+	if eventType == events.TypePublished {
+		status = authz.StatusPublished
+	}
+
+	m.Log = append(m.Log, core.GetNewsChangesRow{
+		ID:     int64(len(m.Log) + 1),
+		NewsID: newsId,
+		Type:   eventType,
+		News:   []byte(fmt.Sprintf(`{"id": %d, "title": "title %d", "status": %q}`, newsId, len(m.Log)+1, status)),
+	})
+}
+
+func TestGetNewsChanges(t *testing.T) {
+	repo := &SyncRepoMock{}
+	repo.record(events.TypeCreated, 1)
+	repo.record(events.TypeCreated, 2)
+	service := NewSyncService(repo, NewNewsService(&NewsRepoMock{}))
+
+	full, err := service.GetNewsChanges(editorCtx, "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(full.Upserted), 2)
+	assert.Equal(t, full.HasMore, false)
+
+	repo.record(events.TypeUpdated, 1)
+	repo.record(events.TypeUpdated, 1)
+	repo.record(events.TypeDeleted, 2)
+	repo.record(events.TypeCreated, 3)
+
+	delta, err := service.GetNewsChanges(editorCtx, full.Token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(delta.Upserted), 2)
+	assert.Equal(t, delta.Upserted[0].ID, int32(1))
+	assert.Equal(t, delta.Upserted[0].Title.String, "title 4")
+	assert.Equal(t, delta.Upserted[1].ID, int32(3))
+	assert.Equal(t, delta.Deleted, []int32{2})
+	assert.Equal(t, delta.HasMore, false)
+
+	empty, err := service.GetNewsChanges(editorCtx, delta.Token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(empty.Upserted), 0)
+	assert.Equal(t, len(empty.Deleted), 0)
+	assert.Equal(t, empty.Token, delta.Token)
+}
+
+func TestGetNewsChangesHidesDrafts(t *testing.T) {
+	repo := &SyncRepoMock{}
+	repo.record(events.TypeCreated, 1)
+	service := NewSyncService(repo, NewNewsService(&NewsRepoMock{}))
+
+	full, err := service.GetNewsChanges(context.Background(), "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(full.Upserted), 1)
+	assert.Equal(t, full.Upserted[0].ID, int32(1))
+
+	repo.record(events.TypeCreated, 3)
+	repo.record(events.TypeUpdated, 1)
+
+	delta, err := service.GetNewsChanges(context.Background(), full.Token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(delta.Upserted), 0)
+	assert.Equal(t, delta.Deleted, []int32{3, 1})
+	assert.Equal(t, delta.HasMore, false)
+
+	repo.record(events.TypePublished, 3)
+
+	delta, err = service.GetNewsChanges(context.Background(), delta.Token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(delta.Upserted), 1)
+	assert.Equal(t, delta.Upserted[0].ID, int32(3))
+}
+
+func TestGetNewsChangesTargeting(t *testing.T) {
+	repo := &SyncRepoMock{}
+	repo.record(events.TypeCreated, 1)
+	repo.Log = append(repo.Log, core.GetNewsChangesRow{
+		ID:     2,
+		NewsID: 2,
+		Type:   events.TypePublished,
+		News:   []byte(`{"id": 2, "title": "ios news", "status": "published", "targeting": "platform == \"ios\""}`),
+	})
+	service := NewSyncService(repo, NewNewsService(&NewsRepoMock{}))
+
+	web := targeting.WithClient(context.Background(), targeting.Client{Platform: targeting.PlatformWeb})
+	delta, err := service.GetNewsChanges(web, syncToken(1))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(delta.Upserted), 0)
+	assert.Equal(t, delta.Deleted, []int32{2})
+
+	// the rule isn't synced to readers
+	ios := targeting.WithClient(context.Background(), targeting.Client{Platform: targeting.PlatformIOS})
+	delta, err = service.GetNewsChanges(ios, syncToken(1))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(delta.Upserted), 1)
+	assert.Equal(t, delta.Upserted[0].Targeting.Valid, false)
+
+	delta, err = service.GetNewsChanges(editorCtx, syncToken(1))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, delta.Upserted[0].Targeting, pgtype.Text{String: `platform == "ios"`, Valid: true})
+}
+
+func TestGetNewsChangesInBatches(t *testing.T) {
+	repo := &SyncRepoMock{}
+	for i := range maxChanges + 1 {
+		repo.record(events.TypeCreated, int32(i))
+	}
+	service := NewSyncService(repo, NewNewsService(&NewsRepoMock{}))
+
+	first, err := service.GetNewsChanges(editorCtx, syncToken(0))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(first.Upserted), maxChanges)
+	assert.Equal(t, first.HasMore, true)
+
+	second, err := service.GetNewsChanges(editorCtx, first.Token)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(second.Upserted), 1)
+	assert.Equal(t, second.HasMore, false)
+}
+
+func TestGetNewsChangesErr(t *testing.T) {
+	repo := &SyncRepoMock{}
+	repo.record(events.TypeCreated, 1)
+	service := NewSyncService(repo, NewNewsService(&NewsRepoMock{}))
+
+	testTable := []struct {
+		Name                string
+		Since               string
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name:          "Err malformed token",
+			Since:         "not a token",
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:          "Err foreign token",
+			Since:         "MTI",
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:                "Err db internal",
+			Since:               syncToken(0),
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrGetNewsChangesToReturn = testCase.ErrRepoShouldReturn
+
+			_, err := service.GetNewsChanges(editorCtx, testCase.Since)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+		})
+	}
+}
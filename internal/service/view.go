@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PopularWindows are the periods popular news are ranked over. Views lose
+// half their weight every quarter of the period, so news read a lot a
+// while ago give way to the ones read now.
+var PopularWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+type ViewService struct {
+	newsRepo     newsRepo
+	viewRepo     viewRepo
+	viewRecorder viewRecorder
+	now          func() time.Time
+}
+
+func NewViewService(newsRepo newsRepo, viewRepo viewRepo, viewRecorder viewRecorder) *ViewService {
+	return &ViewService{
+		newsRepo:     newsRepo,
+		viewRepo:     viewRepo,
+		viewRecorder: viewRecorder,
+		now:          time.Now,
+	}
+}
+
+type viewRepo interface {
+	GetPopularNews(ctx context.Context, arg core.GetPopularNewsParams) ([]core.News, error)
+}
+
+type viewRecorder interface {
+	Record(newsId int32, client string) bool
+}
+
+// RecordView counts a view of the news by client, which is anything telling
+// clients apart. Views of drafts aren't counted.
+func (s *ViewService) RecordView(ctx context.Context, newsId int32, client string) error {
+	news, err := s.newsRepo.GetNewsById(ctx, newsId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pkg.ErrNotFound
+		}
+
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if news.Status == authz.StatusPublished {
+		s.viewRecorder.Record(newsId, client)
+	}
+
+	return nil
+}
+
+// GetPopularNews returns up to first published news, the most viewed within
+// the window first.
+func (s *ViewService) GetPopularNews(ctx context.Context, window string, first int) ([]core.News, error) {
+	period, ok := PopularWindows[window]
+	if !ok {
+		return nil, fmt.Errorf("%w: [unknown window %q]", pkg.ErrInvalidPayload, window)
+	}
+
+	now := s.now().UTC()
+	news, err := s.viewRepo.GetPopularNews(ctx, core.GetPopularNewsParams{
+		Now:      pgtype.Timestamp{Time: now, Valid: true},
+		HalfLife: (period / 4).Seconds(),
+		Since:    pgtype.Timestamp{Time: now.Add(-period), Valid: true},
+		MaxNews:  int32(first),
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return news, nil
+}
@@ -0,0 +1,70 @@
+//go:build integration
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/pgtest"
+	"github.com/anton-uvarenko/promova_test/internal/views"
+	"github.com/go-playground/assert/v2"
+)
+
+func TestViewServiceIntegration(t *testing.T) {
+	db := pgtest.New(t)
+	recorder := views.NewRecorder(db.Queries, time.Hour)
+	service := NewViewService(db.Queries, db.Queries, recorder)
+	authorId := db.SeedAuthor(t, pgtest.AuthorFixture{Role: authz.RoleAuthor})
+	oldNewsId := db.SeedNews(t, pgtest.NewsFixture{Title: "old title", AuthorID: authorId, Status: authz.StatusPublished})
+	newNewsId := db.SeedNews(t, pgtest.NewsFixture{Title: "new title", AuthorID: authorId, Status: authz.StatusPublished})
+	draftId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusDraft})
+	deletedId := db.SeedNews(t, pgtest.NewsFixture{Title: "some title", AuthorID: authorId, Status: authz.StatusPublished})
+
+	// old news had a lot of views a few days ago
+	db.Exec(t, "INSERT INTO news_views (news_id, hour, views) VALUES ($1, date_trunc('hour', NOW()) - INTERVAL '3 days', 20)", oldNewsId)
+
+	ctx := context.Background()
+	for _, client := range []string{"ip:1", "ip:2", "ip:3", "ip:1"} {
+		err := service.RecordView(ctx, newNewsId, client)
+		assert.Equal(t, err, nil)
+		err = service.RecordView(ctx, draftId, client)
+		assert.Equal(t, err, nil)
+	}
+	err := service.RecordView(ctx, deletedId, "ip:1")
+	assert.Equal(t, err, nil)
+	db.Exec(t, "DELETE FROM news WHERE id = $1", deletedId)
+
+	err = recorder.Flush(ctx)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news_views", 1, "news_id = $1 AND views = 3", newNewsId)
+	db.AssertCount(t, "news_views", 0, "news_id = $1", draftId)
+	db.AssertCount(t, "news_views", 0, "news_id = $1", deletedId)
+
+	// later flushes add up
+	err = service.RecordView(ctx, newNewsId, "ip:4")
+	assert.Equal(t, err, nil)
+	err = recorder.Flush(ctx)
+	assert.Equal(t, err, nil)
+	db.AssertCount(t, "news_views", 1, "news_id = $1 AND views = 4", newNewsId)
+
+	popular, err := service.GetPopularNews(ctx, "24h", 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(popular), 1)
+	assert.Equal(t, popular[0].ID, newNewsId)
+
+	// 20 views of 3 days ago weigh 20 * 0.5^(72/42) ≈ 6, more than 4 views now
+	popular, err = service.GetPopularNews(ctx, "7d", 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(popular), 2)
+	assert.Equal(t, popular[0].ID, oldNewsId)
+	assert.Equal(t, popular[1].ID, newNewsId)
+
+	// and over a month they lose even less of their weight
+	popular, err = service.GetPopularNews(ctx, "30d", 1)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(popular), 1)
+	assert.Equal(t, popular[0].ID, oldNewsId)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5"
+)
+
+// PublishedNewsRepoMock is NewsRepoMock with the news published.
+type PublishedNewsRepoMock struct {
+	NewsRepoMock
+}
+
+func (m *PublishedNewsRepoMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	news, err := m.NewsRepoMock.GetNewsById(ctx, id)
+	news.Status = authz.StatusPublished
+	return news, err
+}
+
+type ViewRepoMock struct {
+	Params                    core.GetPopularNewsParams
+	ErrGetPopularNewsToReturn error
+}
+
+func (m *ViewRepoMock) GetPopularNews(ctx context.Context, arg core.GetPopularNewsParams) ([]core.News, error) {
+	m.Params = arg
+	if m.ErrGetPopularNewsToReturn != nil {
+		return nil, m.ErrGetPopularNewsToReturn
+	}
+	return []core.News{{ID: 2}, {ID: 1}}, nil
+}
+
+type ViewRecorderMock struct {
+	Recorded []string
+}
+
+func (m *ViewRecorderMock) Record(newsId int32, client string) bool {
+	m.Recorded = append(m.Recorded, client)
+	return true
+}
+
+func TestRecordView(t *testing.T) {
+	testTable := []struct {
+		Name             string
+		NewsRepo         newsRepo
+		ExpectedRecorded []string
+		ExpectedError    error
+	}{
+		{
+			Name:             "Ok",
+			NewsRepo:         &PublishedNewsRepoMock{},
+			ExpectedRecorded: []string{"ip:1"},
+		},
+		{
+			Name:     "Ok draft not counted",
+			NewsRepo: &NewsRepoMock{},
+		},
+		{
+			Name:          "Err news not found",
+			NewsRepo:      &NewsRepoMock{ErrGetNewsByIdToReturn: pgx.ErrNoRows},
+			ExpectedError: pkg.ErrNotFound,
+		},
+		{
+			Name:          "Err db internal",
+			NewsRepo:      &NewsRepoMock{ErrGetNewsByIdToReturn: errors.New("some unexpected error")},
+			ExpectedError: pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			recorder := &ViewRecorderMock{}
+			service := NewViewService(testCase.NewsRepo, &ViewRepoMock{}, recorder)
+
+			err := service.RecordView(context.Background(), 1, "ip:1")
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			assert.Equal(t, recorder.Recorded, testCase.ExpectedRecorded)
+		})
+	}
+}
+
+func TestGetPopularNews(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name                string
+		Window              string
+		ErrRepoShouldReturn error
+		ExpectedHalfLife    float64
+		ExpectedSince       time.Time
+		ExpectedError       error
+	}{
+		{
+			Name:             "Ok day",
+			Window:           "24h",
+			ExpectedHalfLife: (6 * time.Hour).Seconds(),
+			ExpectedSince:    now.Add(-24 * time.Hour),
+		},
+		{
+			Name:             "Ok month",
+			Window:           "30d",
+			ExpectedHalfLife: (180 * time.Hour).Seconds(),
+			ExpectedSince:    now.Add(-30 * 24 * time.Hour),
+		},
+		{
+			Name:          "Err unknown window",
+			Window:        "1y",
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:                "Err db internal",
+			Window:              "7d",
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &ViewRepoMock{ErrGetPopularNewsToReturn: testCase.ErrRepoShouldReturn}
+			service := NewViewService(&NewsRepoMock{}, repo, &ViewRecorderMock{})
+			service.now = func() time.Time { return now }
+
+			news, err := service.GetPopularNews(context.Background(), testCase.Window, 10)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError != nil {
+				return
+			}
+
+			assert.Equal(t, len(news), 2)
+			assert.Equal(t, repo.Params.HalfLife, testCase.ExpectedHalfLife)
+			assert.Equal(t, repo.Params.Since.Time, testCase.ExpectedSince)
+			assert.Equal(t, repo.Params.Now.Time, now)
+			assert.Equal(t, repo.Params.MaxNews, int32(10))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/outbox"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// deliveriesLimit is how many of the latest deliveries of a webhook are
+// listed.
+const deliveriesLimit = 100
+
+type WebhookService struct {
+	webhookRepo webhookRepo
+	now         func() time.Time
+}
+
+func NewWebhookService(webhookRepo webhookRepo) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		now:         time.Now,
+	}
+}
+
+type webhookRepo interface {
+	AddWebhook(ctx context.Context, arg core.AddWebhookParams) (core.Webhook, error)
+	GetAllWebhooks(ctx context.Context) ([]core.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, arg core.GetWebhookDeliveriesParams) ([]core.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, arg core.ReplayWebhookDeliveryParams) (int64, error)
+}
+
+// AddWebhook generates the secret deliveries are signed with, it is only
+// returned here.
+func (s *WebhookService) AddWebhook(ctx context.Context, url string, events []string) (core.Webhook, error) {
+	subject, err := subjectFromContext(ctx)
+	if err != nil {
+		return core.Webhook{}, err
+	}
+
+	err = authz.Authorize(subject, authz.ActionManageWebhooks, authz.Resource{})
+	if err != nil {
+		return core.Webhook{}, err
+	}
+
+	for _, event := range events {
+		if !slices.Contains(outbox.Events, event) {
+			return core.Webhook{}, fmt.Errorf("%w: [unknown event %s]", pkg.ErrInvalidPayload, event)
+		}
+	}
+
+	secret, err := outbox.GenerateSecret()
+	if err != nil {
+		return core.Webhook{}, err
+	}
+
+	webhook, err := s.webhookRepo.AddWebhook(ctx, core.AddWebhookParams{
+		Url:       url,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: subject.ID,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return core.Webhook{}, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return webhook, nil
+}
+
+func (s *WebhookService) GetAllWebhooks(ctx context.Context) ([]core.Webhook, error) {
+	err := authorize(ctx, authz.ActionManageWebhooks, authz.Resource{})
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepo.GetAllWebhooks(ctx)
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *WebhookService) GetWebhookDeliveries(ctx context.Context, webhookId int32) ([]core.WebhookDelivery, error) {
+	err := authorize(ctx, authz.ActionManageWebhooks, authz.Resource{})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.GetWebhookDeliveries(ctx, core.GetWebhookDeliveriesParams{
+		WebhookID: webhookId,
+		Limit:     deliveriesLimit,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return nil, fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery sends a delivery again from scratch, including ones
+// that were already delivered or given up on. It's due right away by the
+// UTC clock the dispatcher claims deliveries with.
+func (s *WebhookService) ReplayWebhookDelivery(ctx context.Context, webhookId int32, deliveryId int64) error {
+	err := authorize(ctx, authz.ActionManageWebhooks, authz.Resource{})
+	if err != nil {
+		return err
+	}
+
+	replayed, err := s.webhookRepo.ReplayWebhookDelivery(ctx, core.ReplayWebhookDeliveryParams{
+		Now:       pgtype.Timestamp{Time: s.now().UTC(), Valid: true},
+		ID:        deliveryId,
+		WebhookID: webhookId,
+	})
+	if err != nil {
+		fmt.Printf("%v: [%v]\n", pkg.ErrDbInternal, err)
+		return fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, err)
+	}
+
+	if replayed == 0 {
+		return pkg.ErrNotFound
+	}
+
+	return nil
+}
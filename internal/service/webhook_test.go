@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+)
+
+type WebhookRepoMock struct {
+	ReplayedToReturn                 int64
+	ErrAddWebhookToReturn            error
+	ErrGetAllWebhooksToReturn        error
+	ErrGetWebhookDeliveriesToReturn  error
+	ErrReplayWebhookDeliveryToReturn error
+	// Replayed is what a delivery was last replayed with
+	Replayed core.ReplayWebhookDeliveryParams
+}
+
+func (m *WebhookRepoMock) AddWebhook(ctx context.Context, arg core.AddWebhookParams) (core.Webhook, error) {
+	if m.ErrAddWebhookToReturn != nil {
+		return core.Webhook{}, m.ErrAddWebhookToReturn
+	}
+	return core.Webhook{
+		ID:        1,
+		Url:       arg.Url,
+		Secret:    arg.Secret,
+		Events:    arg.Events,
+		CreatedBy: arg.CreatedBy,
+	}, nil
+}
+
+func (m *WebhookRepoMock) GetAllWebhooks(ctx context.Context) ([]core.Webhook, error) {
+	if m.ErrGetAllWebhooksToReturn != nil {
+		return nil, m.ErrGetAllWebhooksToReturn
+	}
+	return []core.Webhook{{ID: 1}}, nil
+}
+
+func (m *WebhookRepoMock) GetWebhookDeliveries(ctx context.Context, arg core.GetWebhookDeliveriesParams) ([]core.WebhookDelivery, error) {
+	if m.ErrGetWebhookDeliveriesToReturn != nil {
+		return nil, m.ErrGetWebhookDeliveriesToReturn
+	}
+	return []core.WebhookDelivery{{ID: 1, WebhookID: arg.WebhookID}}, nil
+}
+
+func (m *WebhookRepoMock) ReplayWebhookDelivery(ctx context.Context, arg core.ReplayWebhookDeliveryParams) (int64, error) {
+	m.Replayed = arg
+	if m.ErrReplayWebhookDeliveryToReturn != nil {
+		return 0, m.ErrReplayWebhookDeliveryToReturn
+	}
+	return m.ReplayedToReturn, nil
+}
+
+func TestAddWebhook(t *testing.T) {
+	repo := &WebhookRepoMock{}
+	service := NewWebhookService(repo)
+
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		Events              []string
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name:   "Ok",
+			Ctx:    adminCtx,
+			Events: []string{"news.published"},
+		},
+		{
+			Name:          "Err editor can't register webhooks",
+			Ctx:           editorCtx,
+			Events:        []string{"news.published"},
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err unauthenticated",
+			Ctx:           context.Background(),
+			Events:        []string{"news.published"},
+			ExpectedError: pkg.ErrUnauthorized,
+		},
+		{
+			Name:          "Err unknown event",
+			Ctx:           adminCtx,
+			Events:        []string{"news.read"},
+			ExpectedError: pkg.ErrInvalidPayload,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 adminCtx,
+			Events:              []string{"news.published"},
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrAddWebhookToReturn = testCase.ErrRepoShouldReturn
+
+			webhook, err := service.AddWebhook(testCase.Ctx, "https://partner.example/hooks", testCase.Events)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+
+			if testCase.ExpectedError == nil {
+				assert.Equal(t, strings.HasPrefix(webhook.Secret, "whsec_"), true)
+				assert.Equal(t, webhook.CreatedBy, int32(3))
+			}
+		})
+	}
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	repo := &WebhookRepoMock{}
+	service := NewWebhookService(repo)
+
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name: "Ok",
+			Ctx:  adminCtx,
+		},
+		{
+			Name:          "Err forbidden",
+			Ctx:           authorCtx,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 adminCtx,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ErrGetWebhookDeliveriesToReturn = testCase.ErrRepoShouldReturn
+
+			deliveries, err := service.GetWebhookDeliveries(testCase.Ctx, 2)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+
+			if testCase.ExpectedError == nil {
+				assert.Equal(t, deliveries[0].WebhookID, int32(2))
+			}
+		})
+	}
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	repo := &WebhookRepoMock{}
+	service := NewWebhookService(repo)
+	// the server runs off UTC, deliveries are due by the UTC clock anyway
+	now := time.Date(2024, 6, 1, 15, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	service.now = func() time.Time { return now }
+
+	testTable := []struct {
+		Name                string
+		Ctx                 context.Context
+		Replayed            int64
+		ErrRepoShouldReturn error
+		ExpectedError       error
+	}{
+		{
+			Name:     "Ok",
+			Ctx:      adminCtx,
+			Replayed: 1,
+		},
+		{
+			Name:          "Err forbidden",
+			Ctx:           editorCtx,
+			Replayed:      1,
+			ExpectedError: pkg.ErrForbidden,
+		},
+		{
+			Name:          "Err not found",
+			Ctx:           adminCtx,
+			Replayed:      0,
+			ExpectedError: pkg.ErrNotFound,
+		},
+		{
+			Name:                "Err db internal",
+			Ctx:                 adminCtx,
+			ErrRepoShouldReturn: errors.New("some unexpected error"),
+			ExpectedError:       pkg.ErrDbInternal,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo.ReplayedToReturn = testCase.Replayed
+			repo.ErrReplayWebhookDeliveryToReturn = testCase.ErrRepoShouldReturn
+
+			err := service.ReplayWebhookDelivery(testCase.Ctx, 1, 7)
+			assert.Equal(t, errors.Is(err, testCase.ExpectedError), true)
+			if testCase.ExpectedError == nil {
+				assert.Equal(t, repo.Replayed.Now.Time, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+			}
+		})
+	}
+}
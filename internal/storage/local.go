@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local keeps files in a directory and serves them with Handler, which is
+// meant for development and single replica setups.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal keeps files in dir, the URLs of files start with baseURL, e.g.
+// "http://localhost:8080/media" when Handler is served under /media.
+func NewLocal(dir string, baseURL string) *Local {
+	return &Local{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Put writes to a temporary file first, so files are never seen half
+// written.
+func (s *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, r)
+	if err == nil && written != size {
+		err = fmt.Errorf("got %d bytes of %d", written, size)
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+func (s *Local) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Local) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// Handler serves the files, without listing directories.
+func (s *Local) Handler() http.Handler {
+	return http.FileServer(filesOnly{http.Dir(s.dir)})
+}
+
+// path keeps keys inside the directory.
+func (s *Local) path(key string) (string, error) {
+	if key == "" || key != path.Clean(key) || strings.HasPrefix(key, "/") || strings.HasPrefix(key, "..") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// filesOnly answers not found for directories.
+type filesOnly struct {
+	fs http.FileSystem
+}
+
+func (f filesOnly) Open(name string) (http.File, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fs.ErrNotExist
+	}
+
+	return file, nil
+}
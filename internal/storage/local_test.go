@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLocal(t *testing.T) {
+	storage := NewLocal(t.TempDir(), "http://localhost:8080/media/")
+	server := httptest.NewServer(http.StripPrefix("/media", storage.Handler()))
+	defer server.Close()
+
+	key := "news/1/abc/original.jpg"
+	err := storage.Put(context.Background(), key, strings.NewReader("image"), 5, "image/jpeg")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storage.URL(key), "http://localhost:8080/media/news/1/abc/original.jpg")
+
+	assert.Equal(t, get(t, server.URL+"/media/"+key), "image")
+
+	res, err := http.Get(server.URL + "/media/news/1/abc/")
+	assert.Equal(t, err, nil)
+	res.Body.Close()
+	assert.Equal(t, res.StatusCode, http.StatusNotFound)
+
+	err = storage.Delete(context.Background(), key)
+	assert.Equal(t, err, nil)
+
+	res, err = http.Get(server.URL + "/media/" + key)
+	assert.Equal(t, err, nil)
+	res.Body.Close()
+	assert.Equal(t, res.StatusCode, http.StatusNotFound)
+
+	// deleting again is fine
+	err = storage.Delete(context.Background(), key)
+	assert.Equal(t, err, nil)
+}
+
+func TestLocalInvalidKey(t *testing.T) {
+	storage := NewLocal(t.TempDir(), "")
+
+	for _, key := range []string{"", "../outside", "/absolute", "news/../../outside", "news//double"} {
+		err := storage.Put(context.Background(), key, strings.NewReader("image"), 5, "image/jpeg")
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestLocalShortRead(t *testing.T) {
+	storage := NewLocal(t.TempDir(), "")
+
+	err := storage.Put(context.Background(), "short", strings.NewReader("ima"), 5, "image/jpeg")
+	assert.NotEqual(t, err, nil)
+
+	err = storage.Delete(context.Background(), "short")
+	assert.Equal(t, err, nil)
+}
+
+func get(t *testing.T, url string) string {
+	res, err := http.Get(url)
+	assert.Equal(t, err, nil)
+	defer res.Body.Close()
+	assert.Equal(t, res.StatusCode, http.StatusOK)
+
+	body, err := io.ReadAll(res.Body)
+	assert.Equal(t, err, nil)
+	return string(body)
+}
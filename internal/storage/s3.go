@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// cacheControl lets clients keep files for good, keys of new content are
+// new keys.
+const cacheControl = "public, max-age=31536000, immutable"
+
+type S3Config struct {
+	// Endpoint is the host and port of the API, e.g. "s3.amazonaws.com" or
+	// "localhost:9000".
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	// Region defaults to us-east-1, which S3 compatible stores usually
+	// don't care about.
+	Region string
+	// Insecure talks plain http to the endpoint.
+	Insecure bool
+	// PublicURL is what the URLs of files start with, the bucket on the
+	// endpoint when it's empty. It's where a CDN in front of the bucket
+	// goes.
+	PublicURL string
+	// Transport defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// S3 keeps files in a bucket of S3 or a store compatible with it, like
+// MinIO. The bucket has to allow public reads of the files.
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func NewS3(config S3Config) (*S3, error) {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure:    !config.Insecure,
+		Region:    config.Region,
+		Transport: config.Transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicURL := config.PublicURL
+	if publicURL == "" {
+		publicURL = client.EndpointURL().String() + "/" + config.Bucket
+	}
+
+	return &S3{
+		client:    client,
+		bucket:    config.Bucket,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: cacheControl,
+	})
+	return err
+}
+
+// Delete doesn't fail for missing keys, S3 doesn't tell them apart.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) URL(key string) string {
+	return s.publicURL + "/" + key
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// bucket stands in for an S3 compatible store, with path style requests
+// of single objects.
+type bucket struct {
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+type object struct {
+	data         string
+	contentType  string
+	cacheControl string
+}
+
+func (b *bucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b.objects[r.URL.Path] = object{
+			data:         string(data),
+			contentType:  r.Header.Get("Content-Type"),
+			cacheControl: r.Header.Get("Cache-Control"),
+		}
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodDelete:
+		delete(b.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3(t *testing.T) {
+	bucket := &bucket{objects: map[string]object{}}
+	server := httptest.NewTLSServer(bucket)
+	defer server.Close()
+
+	storage, err := NewS3(S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "https://"),
+		AccessKey: "key",
+		SecretKey: "secret",
+		Bucket:    "media",
+		Transport: server.Client().Transport,
+	})
+	assert.Equal(t, err, nil)
+
+	key := "news/1/abc/original.jpg"
+	err = storage.Put(context.Background(), key, strings.NewReader("image"), 5, "image/jpeg")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, bucket.objects["/media/"+key], object{
+		data:         "image",
+		contentType:  "image/jpeg",
+		cacheControl: cacheControl,
+	})
+	assert.Equal(t, storage.URL(key), server.URL+"/media/"+key)
+
+	err = storage.Delete(context.Background(), key)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(bucket.objects), 0)
+}
+
+func TestS3PublicURL(t *testing.T) {
+	storage, err := NewS3(S3Config{
+		Endpoint:  "s3.amazonaws.com",
+		Bucket:    "media",
+		PublicURL: "https://cdn.example.com/",
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, storage.URL("news/1/abc/original.jpg"), "https://cdn.example.com/news/1/abc/original.jpg")
+}
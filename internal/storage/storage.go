@@ -0,0 +1,19 @@
+// Package storage keeps the files of media, on the local filesystem or in
+// an S3 compatible bucket.
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// Storage keeps files by key, keys are paths with forward slashes like
+// "news/1/abc/original.jpg".
+type Storage interface {
+	// Put stores size bytes of r under key, replacing what's there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete removes the file under key, there being none isn't an error.
+	Delete(ctx context.Context, key string) error
+	// URL is where clients download the file under key from.
+	URL(key string) string
+}
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ApiKeyHandler struct {
+	apiKeyService apiKeyService
+}
+
+func NewApiKeyHandler(apiKeyService apiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type apiKeyService interface {
+	AddApiKey(ctx context.Context, name string, scopes []authz.Scope, expiresAt pgtype.Timestamp) (core.ApiKey, string, error)
+	GetAllApiKeys(ctx context.Context) ([]core.ApiKey, error)
+	RevokeApiKey(ctx context.Context, id int32) error
+}
+
+func (h *ApiKeyHandler) AddApiKey(ctx *gin.Context) {
+	var pl payload.AddApiKeyPayload
+	err := ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	scopes := make([]authz.Scope, 0, len(pl.Scopes))
+	for _, scope := range pl.Scopes {
+		scopes = append(scopes, authz.Scope(scope))
+	}
+
+	var expiresAt pgtype.Timestamp
+	if pl.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamp{Time: pl.ExpiresAt.UTC(), Valid: true}
+	}
+
+	apiKey, key, err := h.apiKeyService.AddApiKey(ctx, pl.Name, scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: response.AddApiKeyData{
+			ApiKeyData: apiKeyData(apiKey),
+			Key:        key,
+		},
+	})
+}
+
+func (h *ApiKeyHandler) GetAllApiKeys(ctx *gin.Context) {
+	apiKeys, err := h.apiKeyService.GetAllApiKeys(ctx)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	resultData := []response.ApiKeyData{}
+	for _, v := range apiKeys {
+		resultData = append(resultData, apiKeyData(v))
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+func (h *ApiKeyHandler) RevokeApiKey(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.apiKeyService.RevokeApiKey(ctx, int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+func apiKeyData(apiKey core.ApiKey) response.ApiKeyData {
+	return response.ApiKeyData{
+		Id:         int(apiKey.ID),
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		CreatedBy:  int(apiKey.CreatedBy),
+		CreatedAt:  apiKey.CreatedAt.Time,
+		ExpiresAt:  timePtr(apiKey.ExpiresAt),
+		LastUsedAt: timePtr(apiKey.LastUsedAt),
+		RevokedAt:  timePtr(apiKey.RevokedAt),
+	}
+}
+
+func timePtr(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
@@ -0,0 +1,327 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const validApiKey = "nk_abc.valid"
+
+type apiKeyServiceMock struct {
+	ErrAddApiKeyToReturn     error
+	ErrGetAllApiKeysToReturn error
+	ErrRevokeApiKeyToReturn  error
+}
+
+func (m *apiKeyServiceMock) AddApiKey(ctx context.Context, name string, scopes []authz.Scope, expiresAt pgtype.Timestamp) (core.ApiKey, string, error) {
+	if m.ErrAddApiKeyToReturn != nil {
+		return core.ApiKey{}, "", m.ErrAddApiKeyToReturn
+	}
+
+	return core.ApiKey{
+		ID:     1,
+		Name:   name,
+		Prefix: "abc",
+		Scopes: []string{"news:write"},
+	}, validApiKey, nil
+}
+
+func (m *apiKeyServiceMock) GetAllApiKeys(ctx context.Context) ([]core.ApiKey, error) {
+	if m.ErrGetAllApiKeysToReturn != nil {
+		return nil, m.ErrGetAllApiKeysToReturn
+	}
+
+	return []core.ApiKey{
+		{
+			ID:     1,
+			Name:   "importer",
+			Prefix: "abc",
+			Scopes: []string{"news:write"},
+		},
+	}, nil
+}
+
+func (m *apiKeyServiceMock) RevokeApiKey(ctx context.Context, id int32) error {
+	if m.ErrRevokeApiKeyToReturn != nil {
+		return m.ErrRevokeApiKeyToReturn
+	}
+
+	return nil
+}
+
+func (m *apiKeyServiceMock) Authenticate(ctx context.Context, key string) (auth.Author, error) {
+	if key != validApiKey {
+		return auth.Author{}, auth.ErrInvalidApiKey
+	}
+
+	return auth.Author{
+		ID:     1,
+		Role:   authz.RoleService,
+		Scopes: []authz.Scope{authz.ScopeNewsWrite},
+	}, nil
+}
+
+type AddApiKeyResponse struct {
+	Code int                    `json:"code"`
+	Data response.AddApiKeyData `json:"data"`
+}
+
+func TestAddApiKey(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		RequestPayload           any
+		ErrorServiceShouldReturn error
+		ExpectedResult           AddApiKeyResponse
+		ExpectedStatusCode       int
+	}{
+		{
+			Name: "Ok",
+			RequestPayload: payload.AddApiKeyPayload{
+				Name:   "importer",
+				Scopes: []string{"news:write"},
+			},
+			ExpectedResult: AddApiKeyResponse{
+				Code: response.Ok,
+				Data: response.AddApiKeyData{
+					ApiKeyData: response.ApiKeyData{Id: 1, Prefix: "abc"},
+					Key:        validApiKey,
+				},
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name: "Validation error unknown scope",
+			RequestPayload: payload.AddApiKeyPayload{
+				Name:   "importer",
+				Scopes: []string{"news:everything"},
+			},
+			ExpectedResult:     AddApiKeyResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Forbidden",
+			RequestPayload: payload.AddApiKeyPayload{
+				Name:   "importer",
+				Scopes: []string{"news:write"},
+			},
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult:           AddApiKeyResponse{Code: response.Forbidden},
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name: "Internal error",
+			RequestPayload: payload.AddApiKeyPayload{
+				Name:   "importer",
+				Scopes: []string{"news:write"},
+			},
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedResult:           AddApiKeyResponse{Code: response.InternalError},
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			apiKeyServiceInstance.ErrAddApiKeyToReturn = testCase.ErrorServiceShouldReturn
+			pl, _ := json.Marshal(testCase.RequestPayload)
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/api-keys", bytes.NewBuffer(pl))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult AddApiKeyResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedResult.Code)
+			assert.Equal(t, respResult.Data.Id, testCase.ExpectedResult.Data.Id)
+			assert.Equal(t, respResult.Data.Key, testCase.ExpectedResult.Data.Key)
+		})
+	}
+}
+
+func TestGetAllApiKeys(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Forbidden",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "Internal error",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedCode:             response.InternalError,
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			apiKeyServiceInstance.ErrGetAllApiKeysToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/api-keys", nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult struct {
+				Code int                   `json:"code"`
+				Data []response.ApiKeyData `json:"data"`
+			}
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if resp.StatusCode == http.StatusOK {
+				assert.Equal(t, respResult.Data[0].Prefix, "abc")
+			}
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			UriParam:           "1",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid uri param",
+			UriParam:           "abc",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Not found",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Forbidden",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			apiKeyServiceInstance.ErrRevokeApiKeyToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodDelete, "http://localhost:8081/api-keys/"+testCase.UriParam, nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+		})
+	}
+}
+
+func TestApiKeyAuthentication(t *testing.T) {
+	testTable := []struct {
+		Name               string
+		Authorization      string
+		ExpectedCode       int
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "Ok",
+			Authorization:      "ApiKey " + validApiKey,
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid key",
+			Authorization:      "ApiKey nk_abc.wrong",
+			ExpectedCode:       response.Unauthorized,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:               "Unknown scheme",
+			Authorization:      "Basic dXNlcjpwYXNz",
+			ExpectedCode:       response.Unauthorized,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrAddNewsToReturn = nil
+			pl, _ := json.Marshal(payload.AddNewsPayload{
+				Title:   "some title",
+				Content: "some content",
+			})
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/posts", bytes.NewBuffer(pl))
+			r.Header.Set("Authorization", testCase.Authorization)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentService commentService
+}
+
+func NewCommentHandler(commentService commentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+type commentService interface {
+	AddComment(ctx context.Context, newsId int32, parentId int32, content string) (core.Comment, error)
+	GetNewsComments(ctx context.Context, newsId int32, after int32, first int) ([]core.Comment, bool, error)
+	UpdateComment(ctx context.Context, newsId int32, commentId int32, content string) (core.Comment, error)
+	DeleteComment(ctx context.Context, newsId int32, commentId int32) error
+	GetPendingComments(ctx context.Context, after int32, first int) ([]core.Comment, bool, error)
+	ApproveComment(ctx context.Context, commentId int32) (core.Comment, error)
+	RejectComment(ctx context.Context, commentId int32, reason string) (core.Comment, error)
+}
+
+func commentData(comment core.Comment) response.CommentData {
+	return response.CommentData{
+		Id:               int(comment.ID),
+		NewsId:           int(comment.NewsID),
+		ParentId:         int(comment.ParentID.Int32),
+		AuthorId:         int(comment.AuthorID),
+		Content:          comment.Content,
+		Status:           comment.Status,
+		ModerationReason: comment.ModerationReason.String,
+		CreatedAt:        comment.CreatedAt.Time,
+		UpdatedAt:        comment.UpdatedAt.Time,
+	}
+}
+
+// commentsPage writes comments along with the Link to the next page.
+func commentsPage(ctx *gin.Context, comments []core.Comment, hasMore bool, first int) {
+	if hasMore {
+		next := url.Values{
+			"first": {strconv.Itoa(first)},
+			"after": {encodeCommentCursor(comments[len(comments)-1].ID)},
+		}
+		ctx.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, ctx.Request.URL.Path, next.Encode()))
+	}
+
+	resultData := []response.CommentData{}
+	for _, comment := range comments {
+		resultData = append(resultData, commentData(comment))
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+// bindCommentsQuery returns the size of the page and the id comments are
+// after.
+func bindCommentsQuery(ctx *gin.Context) (int, int32, bool) {
+	var queryPayload payload.CommentsQueryPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return 0, 0, false
+	}
+
+	first := queryPayload.First
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	var after int32
+	if queryPayload.After != "" {
+		after, err = decodeCommentCursor(queryPayload.After)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return 0, 0, false
+		}
+	}
+
+	return first, after, true
+}
+
+func (h *CommentHandler) AddComment(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.AddCommentPayload
+	err = ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.AddComment(ctx, int32(uriPayload.Id), int32(pl.ParentId), pl.Content)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		// the comment to reply to isn't there
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: commentData(comment),
+	})
+}
+
+func (h *CommentHandler) GetNewsComments(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	first, after, ok := bindCommentsQuery(ctx)
+	if !ok {
+		return
+	}
+
+	comments, hasMore, err := h.commentService.GetNewsComments(ctx, int32(uriPayload.Id), after, first)
+	if err != nil {
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	commentsPage(ctx, comments, hasMore, first)
+}
+
+func (h *CommentHandler) UpdateComment(ctx *gin.Context) {
+	var uriPayload payload.CommentUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.UpdateCommentPayload
+	err = ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(ctx, int32(uriPayload.Id), int32(uriPayload.CommentId), pl.Content)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: commentData(comment),
+	})
+}
+
+func (h *CommentHandler) DeleteComment(ctx *gin.Context) {
+	var uriPayload payload.CommentUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.commentService.DeleteComment(ctx, int32(uriPayload.Id), int32(uriPayload.CommentId))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+func (h *CommentHandler) GetPendingComments(ctx *gin.Context) {
+	first, after, ok := bindCommentsQuery(ctx)
+	if !ok {
+		return
+	}
+
+	comments, hasMore, err := h.commentService.GetPendingComments(ctx, after, first)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	commentsPage(ctx, comments, hasMore, first)
+}
+
+func (h *CommentHandler) ApproveComment(ctx *gin.Context) {
+	h.moderateComment(ctx, func(id int32) (core.Comment, error) {
+		return h.commentService.ApproveComment(ctx, id)
+	})
+}
+
+func (h *CommentHandler) RejectComment(ctx *gin.Context) {
+	var pl payload.RejectCommentPayload
+	// the reason is optional, so is the body
+	if ctx.Request.ContentLength != 0 {
+		err := ctx.ShouldBindJSON(&pl)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+			})
+			return
+		}
+	}
+
+	h.moderateComment(ctx, func(id int32) (core.Comment, error) {
+		return h.commentService.RejectComment(ctx, id, pl.Reason)
+	})
+}
+
+func (h *CommentHandler) moderateComment(ctx *gin.Context, moderate func(id int32) (core.Comment, error)) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	comment, err := moderate(int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: commentData(comment),
+	})
+}
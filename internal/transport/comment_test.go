@@ -0,0 +1,501 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var commentCreatedAt = time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC)
+
+type commentServiceMock struct {
+	ParentId                   int32
+	Reason                     string
+	ErrAddCommentToReturn      error
+	ErrGetCommentsToReturn     error
+	ErrUpdateCommentToReturn   error
+	ErrDeleteCommentToReturn   error
+	ErrModerateCommentToReturn error
+}
+
+func someComment(id int32, status string) core.Comment {
+	return core.Comment{
+		ID:        id,
+		NewsID:    1,
+		AuthorID:  1,
+		Content:   "some comment",
+		Status:    status,
+		CreatedAt: pgtype.Timestamp{Time: commentCreatedAt, Valid: true},
+		UpdatedAt: pgtype.Timestamp{Time: commentCreatedAt, Valid: true},
+	}
+}
+
+func (m *commentServiceMock) AddComment(ctx context.Context, newsId int32, parentId int32, content string) (core.Comment, error) {
+	if m.ErrAddCommentToReturn != nil {
+		return core.Comment{}, m.ErrAddCommentToReturn
+	}
+	m.ParentId = parentId
+
+	comment := someComment(1, "pending")
+	comment.ParentID = pgtype.Int4{Int32: parentId, Valid: parentId != 0}
+	return comment, nil
+}
+
+func (m *commentServiceMock) GetNewsComments(ctx context.Context, newsId int32, after int32, first int) ([]core.Comment, bool, error) {
+	if m.ErrGetCommentsToReturn != nil {
+		return nil, false, m.ErrGetCommentsToReturn
+	}
+
+	comments := []core.Comment{}
+	for id := after + 1; id <= 3 && len(comments) < first; id++ {
+		comments = append(comments, someComment(id, "approved"))
+	}
+	return comments, after+int32(len(comments)) < 3, nil
+}
+
+func (m *commentServiceMock) UpdateComment(ctx context.Context, newsId int32, commentId int32, content string) (core.Comment, error) {
+	if m.ErrUpdateCommentToReturn != nil {
+		return core.Comment{}, m.ErrUpdateCommentToReturn
+	}
+
+	comment := someComment(commentId, "pending")
+	comment.Content = content
+	return comment, nil
+}
+
+func (m *commentServiceMock) DeleteComment(ctx context.Context, newsId int32, commentId int32) error {
+	return m.ErrDeleteCommentToReturn
+}
+
+func (m *commentServiceMock) GetPendingComments(ctx context.Context, after int32, first int) ([]core.Comment, bool, error) {
+	if m.ErrGetCommentsToReturn != nil {
+		return nil, false, m.ErrGetCommentsToReturn
+	}
+
+	return []core.Comment{someComment(1, "pending")}, false, nil
+}
+
+func (m *commentServiceMock) ApproveComment(ctx context.Context, commentId int32) (core.Comment, error) {
+	if m.ErrModerateCommentToReturn != nil {
+		return core.Comment{}, m.ErrModerateCommentToReturn
+	}
+
+	return someComment(commentId, "approved"), nil
+}
+
+func (m *commentServiceMock) RejectComment(ctx context.Context, commentId int32, reason string) (core.Comment, error) {
+	if m.ErrModerateCommentToReturn != nil {
+		return core.Comment{}, m.ErrModerateCommentToReturn
+	}
+	m.Reason = reason
+
+	comment := someComment(commentId, "rejected")
+	comment.ModerationReason = pgtype.Text{String: reason, Valid: reason != ""}
+	return comment, nil
+}
+
+type CommentResponse struct {
+	Code int                  `json:"code"`
+	Data response.CommentData `json:"data"`
+}
+
+type CommentsResponse struct {
+	Code int                    `json:"code"`
+	Data []response.CommentData `json:"data"`
+}
+
+func TestAddComment(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		RequestPayload           any
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedResult           CommentResponse
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:           "Ok",
+			RequestPayload: payload.AddCommentPayload{Content: "some comment"},
+			ExpectedResult: CommentResponse{
+				Code: response.Ok,
+				Data: response.CommentData{
+					Id:        1,
+					NewsId:    1,
+					AuthorId:  1,
+					Content:   "some comment",
+					Status:    "pending",
+					CreatedAt: commentCreatedAt,
+					UpdatedAt: commentCreatedAt,
+				},
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:           "Ok reply",
+			RequestPayload: payload.AddCommentPayload{Content: "some comment", ParentId: 2},
+			ExpectedResult: CommentResponse{
+				Code: response.Ok,
+				Data: response.CommentData{
+					Id:        1,
+					NewsId:    1,
+					ParentId:  2,
+					AuthorId:  1,
+					Content:   "some comment",
+					Status:    "pending",
+					CreatedAt: commentCreatedAt,
+					UpdatedAt: commentCreatedAt,
+				},
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Empty content",
+			RequestPayload:     payload.AddCommentPayload{},
+			ExpectedResult:     CommentResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Without token",
+			RequestPayload:     payload.AddCommentPayload{Content: "some comment"},
+			WithoutToken:       true,
+			ExpectedResult:     CommentResponse{Code: response.Unauthorized},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Parent not found",
+			RequestPayload:           payload.AddCommentPayload{Content: "some comment", ParentId: 2},
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedResult:           CommentResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode:       http.StatusBadRequest,
+		},
+		{
+			Name:                     "Draft",
+			RequestPayload:           payload.AddCommentPayload{Content: "some comment"},
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult:           CommentResponse{Code: response.Forbidden},
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "News not found",
+			RequestPayload:           payload.AddCommentPayload{Content: "some comment"},
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedResult:           CommentResponse{Code: response.NotFound},
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			commentServiceInstance.ErrAddCommentToReturn = testCase.ErrorServiceShouldReturn
+
+			body, _ := json.Marshal(testCase.RequestPayload)
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/posts/1/comments", bytes.NewReader(body))
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult CommentResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult, testCase.ExpectedResult)
+		})
+	}
+}
+
+func TestGetNewsComments(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Query                    string
+		ErrorServiceShouldReturn error
+		ExpectedIds              []int
+		ExpectedLink             string
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok first page",
+			Query:              "first=2",
+			ExpectedIds:        []int{1, 2},
+			ExpectedLink:       `</posts/1/comments?after=` + encodeCommentCursor(2) + `&first=2>; rel="next"`,
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok last page",
+			Query:              "first=2&after=" + encodeCommentCursor(2),
+			ExpectedIds:        []int{3},
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "News cursor",
+			Query:              "after=" + encodeNewsCursor(newsCursor{id: 2}),
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "News not found",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			commentServiceInstance.ErrGetCommentsToReturn = testCase.ErrorServiceShouldReturn
+
+			resp, _ := http.Get("http://localhost:8081/posts/1/comments?" + testCase.Query)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, resp.Header.Get("Link"), testCase.ExpectedLink)
+
+			var respResult CommentsResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if testCase.ExpectedStatusCode == http.StatusOK {
+				ids := []int{}
+				for _, comment := range respResult.Data {
+					ids = append(ids, comment.Id)
+				}
+				assert.Equal(t, ids, testCase.ExpectedIds)
+			}
+		})
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		RequestPayload           any
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			UriParam:           "1/comments/1",
+			RequestPayload:     payload.UpdateCommentPayload{Content: "edited"},
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Empty content",
+			UriParam:           "1/comments/1",
+			RequestPayload:     payload.UpdateCommentPayload{},
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Someone else's comment",
+			UriParam:                 "1/comments/1",
+			RequestPayload:           payload.UpdateCommentPayload{Content: "edited"},
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "Not found",
+			UriParam:                 "1/comments/2",
+			RequestPayload:           payload.UpdateCommentPayload{Content: "edited"},
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			commentServiceInstance.ErrUpdateCommentToReturn = testCase.ErrorServiceShouldReturn
+
+			body, _ := json.Marshal(testCase.RequestPayload)
+			r, _ := http.NewRequest(http.MethodPut, "http://localhost:8081/posts/"+testCase.UriParam, bytes.NewReader(body))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult CommentResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if testCase.ExpectedStatusCode == http.StatusOK {
+				assert.Equal(t, respResult.Data.Content, "edited")
+			}
+		})
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			UriParam:           "1/comments/1",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid uri param",
+			UriParam:           "1/comments/abc",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Forbidden",
+			UriParam:                 "1/comments/1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			commentServiceInstance.ErrDeleteCommentToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodDelete, "http://localhost:8081/posts/"+testCase.UriParam, nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+		})
+	}
+}
+
+func TestModerateComments(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Method                   string
+		Path                     string
+		Body                     string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatus           string
+		ExpectedReason           string
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok queue",
+			Method:             http.MethodGet,
+			Path:               "/moderation/comments",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Queue forbidden",
+			Method:                   http.MethodGet,
+			Path:                     "/moderation/comments",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:               "Ok approve",
+			Method:             http.MethodPost,
+			Path:               "/moderation/comments/1/approve",
+			ExpectedCode:       response.Ok,
+			ExpectedStatus:     "approved",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok reject",
+			Method:             http.MethodPost,
+			Path:               "/moderation/comments/1/reject",
+			Body:               `{"reason":"off topic"}`,
+			ExpectedCode:       response.Ok,
+			ExpectedStatus:     "rejected",
+			ExpectedReason:     "off topic",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok reject without reason",
+			Method:             http.MethodPost,
+			Path:               "/moderation/comments/1/reject",
+			ExpectedCode:       response.Ok,
+			ExpectedStatus:     "rejected",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Approve not found",
+			Method:                   http.MethodPost,
+			Path:                     "/moderation/comments/2/approve",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			commentServiceInstance.ErrGetCommentsToReturn = testCase.ErrorServiceShouldReturn
+			commentServiceInstance.ErrModerateCommentToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(testCase.Method, "http://localhost:8081"+testCase.Path, bytes.NewReader([]byte(testCase.Body)))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if testCase.ExpectedStatus != "" {
+				data := respResult.Data.(map[string]any)
+				assert.Equal(t, data["status"], testCase.ExpectedStatus)
+				assert.Equal(t, commentServiceInstance.Reason, testCase.ExpectedReason)
+			}
+		})
+	}
+}
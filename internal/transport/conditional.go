@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultCacheControl lets clients and shared caches reuse news for a
+// minute and revalidate them with the validators afterwards.
+const DefaultCacheControl = "public, max-age=60"
+
+// privateCacheControl is for responses that carry reactions of the author
+// who asked, clients keep them to themselves and revalidate them every time.
+const privateCacheControl = "private, no-cache"
+
+// newsETag tells news apart by activity, when what's served along with them
+// last changed, zero when it never did, and news with content rendered apart
+// by render, empty when it isn't.
+func newsETag(news core.News, activity time.Time, render string) string {
+	version := fmt.Sprintf("news:%d:%d", news.ID, news.UpdatedAt.Time.UnixNano())
+	if !activity.IsZero() {
+		version += fmt.Sprintf(":activity=%d", activity.UnixNano())
+	}
+	if render != "" {
+		version += ":render=" + render
+	}
+
+	return strongETag(version)
+}
+
+// newsListETag tells pages of the list apart by page, empty for the whole
+// list, by render like newsETag and by audience, the key of the client the
+// list is filtered for, empty when it isn't.
+func newsListETag(stats core.GetNewsStatsRow, page string, render string, audience string) string {
+	version := fmt.Sprintf("news-list:%d:%d", stats.Count, stats.LastUpdatedAt.Time.UnixNano())
+	if page != "" {
+		version += ":" + page
+	}
+	if render != "" {
+		version += ":render=" + render
+	}
+	if audience != "" {
+		version += ":audience=" + audience
+	}
+
+	return strongETag(version)
+}
+
+// authorETag tells responses to different authors apart, they have
+// reactions of their own, and responses to the same author apart by
+// readState, when they last read or bookmarked anything.
+func authorETag(etag string, authorId int32, readState time.Time) string {
+	return strongETag(fmt.Sprintf("%s:author=%d:read=%d", etag, authorId, readState.UnixNano()))
+}
+
+func strongETag(version string) string {
+	sum := sha256.Sum256([]byte(version))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the caching headers and reports whether the copy the
+// client already has is still current, in which case it answers with 304.
+// Responses vary by Authorization and by the headers in vary.
+func (h *NewsHandler) notModified(ctx *gin.Context, etag string, lastModified time.Time, vary ...string) bool {
+	varyHeader := strings.Join(append([]string{"Authorization"}, vary...), ", ")
+	cacheControl := h.cacheControl
+	if author, ok := auth.AuthorFromContext(ctx); ok {
+		readState, err := h.readStateService.GetUpdatedAt(ctx)
+		if err != nil {
+			// without the read state there is nothing to validate against
+			fmt.Printf("can't get read state: [%v]\n", err)
+			ctx.Header("Cache-Control", privateCacheControl)
+			ctx.Header("Vary", varyHeader)
+			return false
+		}
+
+		etag = authorETag(etag, author.ID, readState)
+		cacheControl = privateCacheControl
+		if readState.After(lastModified) {
+			lastModified = readState
+		}
+	}
+
+	ctx.Header("Cache-Control", cacheControl)
+	ctx.Header("Vary", varyHeader)
+	ctx.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		ctx.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if !isFresh(ctx.Request, etag, lastModified) {
+		return false
+	}
+
+	ctx.Status(http.StatusNotModified)
+	return true
+}
+
+func isFresh(r *http.Request, etag string, lastModified time.Time) bool {
+	// If-Modified-Since is ignored when If-None-Match is present, see RFC 9110 13.1.3
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	// the header only has second precision
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
@@ -0,0 +1,262 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestConditionalGet(t *testing.T) {
+	newsTag := newsETag(core.News{
+		ID:        1,
+		UpdatedAt: pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+	}, time.Time{}, "")
+	renderedTag := newsETag(core.News{
+		ID:        1,
+		UpdatedAt: pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+	}, time.Time{}, "html")
+	activity := newsUpdatedAt.Add(time.Minute)
+	activeTag := newsETag(core.News{
+		ID:        1,
+		UpdatedAt: pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+	}, activity, "")
+	stats := core.GetNewsStatsRow{
+		Count:         1,
+		LastUpdatedAt: pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+	}
+	listTag := newsListETag(stats, "", "", "")
+	pageTag := newsListETag(stats, "1:", "", "")
+	renderedListTag := newsListETag(stats, "", "html", "")
+	iosListTag := newsListETag(stats, "", "", targeting.Client{Platform: targeting.PlatformIOS}.Key())
+	readStateUpdatedAt := newsUpdatedAt.Add(time.Hour)
+
+	testTable := []struct {
+		Name                 string
+		Path                 string
+		Headers              map[string]string
+		ErrStatsShouldReturn error
+		ReadStateUpdatedAt   time.Time
+		ErrReadStateReturn   error
+		Activity             time.Time
+		ExpectedETag         string
+		ExpectedLastModified time.Time
+		ExpectedStatusCode   int
+	}{
+		{
+			Name:                 "Error list stats db internal",
+			Path:                 "/posts",
+			ErrStatsShouldReturn: pkg.ErrDbInternal,
+			ExpectedStatusCode:   http.StatusInternalServerError,
+		},
+		{
+			Name:               "Ok news without validators",
+			Path:               "/posts/1",
+			ExpectedETag:       newsTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok news for author",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"Authorization": "Bearer " + authToken},
+			ExpectedETag:       authorETag(newsTag, 1, time.Time{}),
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok news of other author is stale",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"Authorization": "Bearer " + authToken, "If-None-Match": newsTag},
+			ExpectedETag:       authorETag(newsTag, 1, time.Time{}),
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok news for author not modified",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"Authorization": "Bearer " + authToken, "If-None-Match": authorETag(newsTag, 1, time.Time{})},
+			ExpectedETag:       authorETag(newsTag, 1, time.Time{}),
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			Name:                 "Ok news for author read since is stale",
+			Path:                 "/posts/1",
+			Headers:              map[string]string{"Authorization": "Bearer " + authToken, "If-None-Match": authorETag(newsTag, 1, time.Time{})},
+			ReadStateUpdatedAt:   readStateUpdatedAt,
+			ExpectedETag:         authorETag(newsTag, 1, readStateUpdatedAt),
+			ExpectedLastModified: readStateUpdatedAt,
+			ExpectedStatusCode:   http.StatusOK,
+		},
+		{
+			Name:                 "Ok news for author read since modified since",
+			Path:                 "/posts/1",
+			Headers:              map[string]string{"Authorization": "Bearer " + authToken, "If-Modified-Since": newsUpdatedAt.Format(http.TimeFormat)},
+			ReadStateUpdatedAt:   readStateUpdatedAt,
+			ExpectedETag:         authorETag(newsTag, 1, readStateUpdatedAt),
+			ExpectedLastModified: readStateUpdatedAt,
+			ExpectedStatusCode:   http.StatusOK,
+		},
+		{
+			Name:               "Ok news for author without read state",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"Authorization": "Bearer " + authToken, "If-None-Match": authorETag(newsTag, 1, time.Time{})},
+			ErrReadStateReturn: pkg.ErrDbInternal,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Not modified news etag",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"If-None-Match": `"other", ` + newsTag},
+			ExpectedETag:       newsTag,
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			Name:               "Ok news stale etag",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"If-None-Match": `"other"`},
+			ExpectedETag:       newsTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Not modified news since",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"If-Modified-Since": newsUpdatedAt.Format(http.TimeFormat)},
+			ExpectedETag:       newsTag,
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			Name:                 "Ok news stale etag after activity",
+			Path:                 "/posts/1",
+			Headers:              map[string]string{"If-None-Match": newsTag},
+			Activity:             activity,
+			ExpectedETag:         activeTag,
+			ExpectedLastModified: activity,
+			ExpectedStatusCode:   http.StatusOK,
+		},
+		{
+			Name:                 "Ok news modified since before activity",
+			Path:                 "/posts/1",
+			Headers:              map[string]string{"If-Modified-Since": newsUpdatedAt.Format(http.TimeFormat)},
+			Activity:             activity,
+			ExpectedETag:         activeTag,
+			ExpectedLastModified: activity,
+			ExpectedStatusCode:   http.StatusOK,
+		},
+		{
+			Name:               "Ok news modified since",
+			Path:               "/posts/1",
+			Headers:            map[string]string{"If-Modified-Since": newsUpdatedAt.Add(-time.Hour).Format(http.TimeFormat)},
+			ExpectedETag:       newsTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name: "Ok etag takes precedence over date",
+			Path: "/posts/1",
+			Headers: map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": newsUpdatedAt.Format(http.TimeFormat),
+			},
+			ExpectedETag:       newsTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok rendered news with news etag",
+			Path:               "/posts/1?render=html",
+			Headers:            map[string]string{"If-None-Match": newsTag},
+			ExpectedETag:       renderedTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Not modified rendered news etag",
+			Path:               "/posts/1?render=html",
+			Headers:            map[string]string{"If-None-Match": renderedTag},
+			ExpectedETag:       renderedTag,
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			Name:               "Not modified list etag",
+			Path:               "/posts",
+			Headers:            map[string]string{"If-None-Match": listTag},
+			ExpectedETag:       listTag,
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			Name:               "Ok list stale etag",
+			Path:               "/posts",
+			Headers:            map[string]string{"If-None-Match": newsTag},
+			ExpectedETag:       listTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok page with list etag",
+			Path:               "/posts?first=1",
+			Headers:            map[string]string{"If-None-Match": listTag},
+			ExpectedETag:       pageTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Not modified page etag",
+			Path:               "/posts?first=1",
+			Headers:            map[string]string{"If-None-Match": pageTag},
+			ExpectedETag:       pageTag,
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+		{
+			Name:               "Ok rendered list with list etag",
+			Path:               "/posts?render=html",
+			Headers:            map[string]string{"If-None-Match": listTag},
+			ExpectedETag:       renderedListTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok list for client with list etag",
+			Path:               "/posts",
+			Headers:            map[string]string{"X-App-Platform": "ios", "If-None-Match": listTag},
+			ExpectedETag:       iosListTag,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Not modified list for client in query",
+			Path:               "/posts?platform=ios",
+			Headers:            map[string]string{"If-None-Match": iosListTag},
+			ExpectedETag:       iosListTag,
+			ExpectedStatusCode: http.StatusNotModified,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsStatsToReturn = testCase.ErrStatsShouldReturn
+			readStateServiceInstance.UpdatedAt = testCase.ReadStateUpdatedAt
+			readStateServiceInstance.ErrGetUpdatedAtToReturn = testCase.ErrReadStateReturn
+			newsServiceInstance.Activity = testCase.Activity
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081"+testCase.Path, nil)
+			for key, value := range testCase.Headers {
+				r.Header.Set(key, value)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, resp.Header.Get("ETag"), testCase.ExpectedETag)
+			if testCase.ExpectedETag != "" {
+				cacheControl := DefaultCacheControl
+				if testCase.Headers["Authorization"] != "" {
+					cacheControl = privateCacheControl
+				}
+				assert.Equal(t, resp.Header.Get("Cache-Control"), cacheControl)
+				vary := strings.Join(append([]string{"Authorization"}, clientContextHeaders...), ", ")
+				assert.Equal(t, resp.Header.Get("Vary"), vary)
+				lastModified := newsUpdatedAt
+				if !testCase.ExpectedLastModified.IsZero() {
+					lastModified = testCase.ExpectedLastModified
+				}
+				assert.Equal(t, resp.Header.Get("Last-Modified"), lastModified.Format(http.TimeFormat))
+			}
+		})
+	}
+}
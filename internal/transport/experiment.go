@@ -0,0 +1,405 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ExperimentHandler struct {
+	experimentService experimentService
+}
+
+func NewExperimentHandler(experimentService experimentService) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentService: experimentService,
+	}
+}
+
+type experimentService interface {
+	AddVariant(ctx context.Context, params core.AddNewsVariantParams) (int32, error)
+	DeleteVariant(ctx context.Context, newsId int32, variantId int32) error
+	GetExperiment(ctx context.Context, newsId int32) (service.Experiment, error)
+	StartExperiment(ctx context.Context, newsId int32) error
+	StopExperiment(ctx context.Context, newsId int32, winnerId int32) (core.NewsVariant, error)
+	RecordExposure(ctx context.Context, newsId int32, variantId int32) error
+	RecordClick(ctx context.Context, newsId int32, variantId int32) error
+	ApplyVariants(ctx context.Context, news []core.News) ([]core.News, map[int32]int32, error)
+}
+
+func variantData(variant core.NewsVariant) response.VariantData {
+	return response.VariantData{
+		Id:        int(variant.ID),
+		Title:     variant.Title,
+		Content:   variant.Content,
+		Weight:    int(variant.Weight),
+		Exposures: int(variant.Exposures),
+		Clicks:    int(variant.Clicks),
+	}
+}
+
+func (h *ExperimentHandler) GetExperiment(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	experiment, err := h.experimentService.GetExperiment(ctx, int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	data := response.ExperimentData{
+		Running:   experiment.Running,
+		StartedAt: timePtr(experiment.StartedAt),
+		StoppedAt: timePtr(experiment.StoppedAt),
+		WinnerId:  int(experiment.WinnerID),
+		Variants:  []response.VariantData{},
+	}
+	for _, variant := range experiment.Variants {
+		data.Variants = append(data.Variants, variantData(variant))
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: data,
+	})
+}
+
+func (h *ExperimentHandler) AddVariant(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.AddVariantPayload
+	err = ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	id, err := h.experimentService.AddVariant(ctx, core.AddNewsVariantParams{
+		NewsID:  int32(uriPayload.Id),
+		Title:   pl.Title,
+		Content: pl.Content,
+		Weight:  int32(pl.Weight),
+	})
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		// the experiment runs
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: response.AddVariantData{
+			Id: int(id),
+		},
+	})
+}
+
+func (h *ExperimentHandler) DeleteVariant(ctx *gin.Context) {
+	h.variant(ctx, h.experimentService.DeleteVariant)
+}
+
+// RecordExposure and RecordClick are told by clients, which know when the
+// title was shown and when the news were opened.
+func (h *ExperimentHandler) RecordExposure(ctx *gin.Context) {
+	h.variant(ctx, h.experimentService.RecordExposure)
+}
+
+func (h *ExperimentHandler) RecordClick(ctx *gin.Context) {
+	h.variant(ctx, h.experimentService.RecordClick)
+}
+
+func (h *ExperimentHandler) variant(ctx *gin.Context, do func(ctx context.Context, newsId int32, variantId int32) error) {
+	var uriPayload payload.VariantUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = do(ctx, int32(uriPayload.Id), int32(uriPayload.VariantId))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		// variants of experiments that don't run aren't counted
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		// variants of running experiments aren't deleted
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+func (h *ExperimentHandler) StartExperiment(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.experimentService.StartExperiment(ctx, int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrEntityAlreadyExists) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, response.Response{
+				Code:  response.EntityAlreadyExists,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		// there are too few variants
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+// StopExperiment responds with the variant promoted into the news.
+func (h *ExperimentHandler) StopExperiment(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.StopExperimentPayload
+	// the winner is optional, so is the body
+	if ctx.Request.ContentLength != 0 {
+		err = ctx.ShouldBindJSON(&pl)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+			})
+			return
+		}
+	}
+
+	winner, err := h.experimentService.StopExperiment(ctx, int32(uriPayload.Id), int32(pl.WinnerId))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		// there's no experiment running
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		// the title of the winner is taken by other news
+		if errors.Is(err, pkg.ErrEntityAlreadyExists) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, response.Response{
+				Code:  response.EntityAlreadyExists,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: variantData(winner),
+	})
+}
@@ -0,0 +1,334 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type experimentServiceMock struct {
+	// Applied are the variants readers are assigned, by news
+	Applied     map[int32]core.NewsVariant
+	Added       []core.AddNewsVariantParams
+	Calls       []string
+	ErrToReturn error
+}
+
+func (m *experimentServiceMock) call(format string, args ...any) error {
+	if m.ErrToReturn != nil {
+		return m.ErrToReturn
+	}
+	m.Calls = append(m.Calls, fmt.Sprintf(format, args...))
+	return nil
+}
+
+func (m *experimentServiceMock) AddVariant(ctx context.Context, params core.AddNewsVariantParams) (int32, error) {
+	if m.ErrToReturn != nil {
+		return 0, m.ErrToReturn
+	}
+	m.Added = append(m.Added, params)
+	return int32(len(m.Added)), nil
+}
+
+func (m *experimentServiceMock) DeleteVariant(ctx context.Context, newsId int32, variantId int32) error {
+	return m.call("delete %d %d", newsId, variantId)
+}
+
+func (m *experimentServiceMock) GetExperiment(ctx context.Context, newsId int32) (service.Experiment, error) {
+	if m.ErrToReturn != nil {
+		return service.Experiment{}, m.ErrToReturn
+	}
+	return service.Experiment{
+		Running:   true,
+		StartedAt: pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+		Variants: []core.NewsVariant{
+			{ID: 1, NewsID: newsId, Title: "first title", Content: "first content", Weight: 1, Exposures: 10, Clicks: 2},
+			{ID: 2, NewsID: newsId, Title: "second title", Content: "second content", Weight: 1, Exposures: 10, Clicks: 1},
+		},
+	}, nil
+}
+
+func (m *experimentServiceMock) StartExperiment(ctx context.Context, newsId int32) error {
+	return m.call("start %d", newsId)
+}
+
+func (m *experimentServiceMock) StopExperiment(ctx context.Context, newsId int32, winnerId int32) (core.NewsVariant, error) {
+	err := m.call("stop %d %d", newsId, winnerId)
+	if err != nil {
+		return core.NewsVariant{}, err
+	}
+	return core.NewsVariant{ID: max(winnerId, 1), NewsID: newsId, Title: "first title", Content: "first content", Weight: 1}, nil
+}
+
+func (m *experimentServiceMock) RecordExposure(ctx context.Context, newsId int32, variantId int32) error {
+	return m.call("exposure %d %d", newsId, variantId)
+}
+
+func (m *experimentServiceMock) RecordClick(ctx context.Context, newsId int32, variantId int32) error {
+	return m.call("click %d %d", newsId, variantId)
+}
+
+func (m *experimentServiceMock) ApplyVariants(ctx context.Context, news []core.News) ([]core.News, map[int32]int32, error) {
+	applied := make([]core.News, 0, len(news))
+	variantIds := map[int32]int32{}
+	for _, n := range news {
+		if variant, ok := m.Applied[n.ID]; ok {
+			n.Title = pgtype.Text{String: variant.Title, Valid: true}
+			n.Content = pgtype.Text{String: variant.Content, Valid: true}
+			variantIds[n.ID] = variant.ID
+		}
+		applied = append(applied, n)
+	}
+	return applied, variantIds, nil
+}
+
+func TestExperimentEndpoints(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Method                   string
+		Path                     string
+		Body                     string
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedCalls            []string
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok get",
+			Method:             http.MethodGet,
+			Path:               "/posts/1/experiment",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok add variant",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/variants",
+			Body:               `{"title":"other title","content":"other content","weight":2}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Variant without weight",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/variants",
+			Body:               `{"title":"other title","content":"other content"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Ok delete variant",
+			Method:             http.MethodDelete,
+			Path:               "/posts/1/variants/2",
+			ExpectedCalls:      []string{"delete 1 2"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok start",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/experiment/start",
+			ExpectedCalls:      []string{"start 1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Start running",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/experiment/start",
+			ErrorServiceShouldReturn: pkg.ErrEntityAlreadyExists,
+			ExpectedStatusCode:       http.StatusConflict,
+		},
+		{
+			Name:                     "Start with a single variant",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/experiment/start",
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedStatusCode:       http.StatusBadRequest,
+		},
+		{
+			Name:               "Ok stop with the best variant",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/experiment/stop",
+			ExpectedCalls:      []string{"stop 1 0"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok stop with a winner",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/experiment/stop",
+			Body:               `{"winner_id":2}`,
+			ExpectedCalls:      []string{"stop 1 2"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Stop not running",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/experiment/stop",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:               "Ok exposure",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/variants/2/exposures",
+			ExpectedCalls:      []string{"exposure 1 2"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok click",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/variants/2/clicks",
+			ExpectedCalls:      []string{"click 1 2"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Click on the variant of another reader",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/variants/2/clicks",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:               "Invalid variant id",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/variants/x/clicks",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Unauthorized",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/variants/2/clicks",
+			WithoutToken:       true,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Forbidden",
+			Method:                   http.MethodGet,
+			Path:                     "/posts/1/experiment",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "Db internal",
+			Method:                   http.MethodDelete,
+			Path:                     "/posts/1/variants/2",
+			ErrorServiceShouldReturn: errors.New("some unexpected error"),
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			experimentServiceInstance.ErrToReturn = testCase.ErrorServiceShouldReturn
+			experimentServiceInstance.Calls = nil
+
+			r, _ := http.NewRequest(testCase.Method, "http://localhost:8081"+testCase.Path, bytes.NewBufferString(testCase.Body))
+			if testCase.Body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, experimentServiceInstance.Calls, testCase.ExpectedCalls)
+		})
+	}
+}
+
+func TestGetExperiment(t *testing.T) {
+	experimentServiceInstance.ErrToReturn = nil
+
+	r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/posts/1/experiment", nil)
+	r.Header.Set("Authorization", "Bearer "+authToken)
+	resp, _ := http.DefaultClient.Do(r)
+
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var respResult struct {
+		Code int                     `json:"code"`
+		Data response.ExperimentData `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respResult)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, respResult.Data.Running, true)
+	assert.Equal(t, respResult.Data.StoppedAt == nil, true)
+	assert.Equal(t, len(respResult.Data.Variants), 2)
+	assert.Equal(t, respResult.Data.Variants[0].Clicks, 2)
+}
+
+func TestNewsVariant(t *testing.T) {
+	testTable := []struct {
+		Name              string
+		WithToken         bool
+		ExpectedTitle     string
+		ExpectedVariantId int
+	}{
+		{
+			Name:              "Ok variant for reader",
+			WithToken:         true,
+			ExpectedTitle:     "variant title",
+			ExpectedVariantId: 7,
+		},
+		{
+			Name:          "Ok news anonymous",
+			ExpectedTitle: "some title",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+			newsServiceInstance.ErrGetNewsStatsToReturn = nil
+			newsServiceInstance.ErrGetAllNewsToReturn = nil
+			newsServiceInstance.AllNewsToReturn = nil
+			mediaServiceInstance.ErrGetNewsMediaToReturn = nil
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+			readStateServiceInstance.ErrGetUpdatedAtToReturn = nil
+			experimentServiceInstance.Applied = nil
+			// the mock has no readers, variants are applied to the ones with tokens
+			if testCase.WithToken {
+				experimentServiceInstance.Applied = map[int32]core.NewsVariant{
+					1: {ID: 7, NewsID: 1, Title: "variant title", Content: "variant content"},
+				}
+			}
+
+			for _, path := range []string{"/posts/1", "/posts"} {
+				r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081"+path, nil)
+				if testCase.WithToken {
+					r.Header.Set("Authorization", "Bearer "+authToken)
+				}
+				resp, _ := http.DefaultClient.Do(r)
+				assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+				var data response.NewsData
+				if path == "/posts" {
+					var respResult struct {
+						Data []response.NewsData `json:"data"`
+					}
+					err := json.NewDecoder(resp.Body).Decode(&respResult)
+					assert.Equal(t, err, nil)
+					data = respResult.Data[0]
+				} else {
+					var respResult struct {
+						Data response.NewsData `json:"data"`
+					}
+					err := json.NewDecoder(resp.Body).Decode(&respResult)
+					assert.Equal(t, err, nil)
+					data = respResult.Data
+				}
+				assert.Equal(t, data.Title, testCase.ExpectedTitle)
+				assert.Equal(t, data.VariantId, testCase.ExpectedVariantId)
+			}
+			experimentServiceInstance.Applied = nil
+		})
+	}
+}
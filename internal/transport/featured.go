@@ -0,0 +1,250 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type featuredService interface {
+	GetFeaturedNews(ctx context.Context) ([]core.News, error)
+	FeatureNews(ctx context.Context, id int32, position int) error
+	UnfeatureNews(ctx context.Context, id int32) error
+}
+
+// lastPosition puts featured news after the others.
+const lastPosition = -1
+
+func (h *NewsHandler) PinNews(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.PinNewsPayload
+	// news are pinned for good without a body
+	if ctx.Request.ContentLength != 0 {
+		err = ctx.ShouldBindJSON(&pl)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+			})
+			return
+		}
+	}
+
+	var until pgtype.Timestamp
+	if pl.PinnedUntil != nil {
+		until = pgtype.Timestamp{Time: pl.PinnedUntil.UTC(), Valid: true}
+	}
+
+	err = h.newsService.PinNews(ctx, int32(uriPayload.Id), until)
+	if err != nil {
+		h.abortFeatured(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+func (h *NewsHandler) UnpinNews(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.newsService.UnpinNews(ctx, int32(uriPayload.Id))
+	if err != nil {
+		h.abortFeatured(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+// GetFeaturedNews isn't conditional, ordering the featured news changes no
+// news.
+func (h *NewsHandler) GetFeaturedNews(ctx *gin.Context) {
+	var queryPayload payload.FeaturedNewsQueryPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	client, targeted, err := requestClient(ctx, queryPayload.ClientContextPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+	if targeted {
+		ctx.Request = ctx.Request.WithContext(targeting.WithClient(ctx.Request.Context(), client))
+	}
+
+	news, err := h.featuredService.GetFeaturedNews(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	resultData, err := h.newsListData(ctx, news, queryPayload.Render)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+// FeatureNews adds news to the featured ones, or moves them when they're
+// featured already.
+func (h *NewsHandler) FeatureNews(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.FeatureNewsPayload
+	// news go last without a body
+	if ctx.Request.ContentLength != 0 {
+		err = ctx.ShouldBindJSON(&pl)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+			})
+			return
+		}
+	}
+
+	position := lastPosition
+	if pl.Position != nil {
+		position = *pl.Position
+	}
+
+	err = h.featuredService.FeatureNews(ctx, int32(uriPayload.Id), position)
+	if err != nil {
+		h.abortFeatured(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+func (h *NewsHandler) UnfeatureNews(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.featuredService.UnfeatureNews(ctx, int32(uriPayload.Id))
+	if err != nil {
+		h.abortFeatured(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+// abortFeatured answers the errors of pinning and featuring news, which are
+// all the same.
+func (h *NewsHandler) abortFeatured(ctx *gin.Context, err error) {
+	if errors.Is(err, pkg.ErrUnauthorized) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+			Code:  response.Unauthorized,
+			Error: pkg.ErrUnauthorized.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, pkg.ErrForbidden) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+			Code:  response.Forbidden,
+			Error: pkg.ErrForbidden.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, pkg.ErrNotFound) {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+			Code:  response.NotFound,
+			Error: pkg.ErrNotFound.Error(),
+		})
+		return
+	}
+
+	// another editor moved news to the same place, trying again works
+	if errors.Is(err, pkg.ErrEntityAlreadyExists) {
+		ctx.AbortWithStatusJSON(http.StatusConflict, response.Response{
+			Code:  response.EntityAlreadyExists,
+			Error: pkg.ErrEntityAlreadyExists.Error(),
+		})
+		return
+	}
+
+	if errors.Is(err, pkg.ErrInvalidPayload) {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+		Code:  response.InternalError,
+		Error: pkg.ErrDbInternal.Error(),
+	})
+}
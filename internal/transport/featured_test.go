@@ -0,0 +1,291 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type featuredServiceMock struct {
+	// News are the featured news, in order
+	News        []core.News
+	Calls       []string
+	ErrToReturn error
+}
+
+func (m *featuredServiceMock) GetFeaturedNews(ctx context.Context) ([]core.News, error) {
+	if m.ErrToReturn != nil {
+		return nil, m.ErrToReturn
+	}
+	return append([]core.News{}, m.News...), nil
+}
+
+func (m *featuredServiceMock) FeatureNews(ctx context.Context, id int32, position int) error {
+	if m.ErrToReturn != nil {
+		return m.ErrToReturn
+	}
+	m.Calls = append(m.Calls, fmt.Sprintf("feature %d %d", id, position))
+	return nil
+}
+
+func (m *featuredServiceMock) UnfeatureNews(ctx context.Context, id int32) error {
+	if m.ErrToReturn != nil {
+		return m.ErrToReturn
+	}
+	m.Calls = append(m.Calls, fmt.Sprintf("unfeature %d", id))
+	return nil
+}
+
+func TestFeaturedEndpoints(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Method                   string
+		Path                     string
+		Body                     string
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedCalls            []string
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok pin",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/pin",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok pin until",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/pin",
+			Body:               `{"pinned_until":"2030-01-01T12:00:00+02:00"}`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Pin until not a time",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/pin",
+			Body:               `{"pinned_until":"tomorrow"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Pin until the past",
+			Method:                   http.MethodPut,
+			Path:                     "/posts/1/pin",
+			Body:                     `{"pinned_until":"2020-01-01T12:00:00Z"}`,
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedStatusCode:       http.StatusBadRequest,
+		},
+		{
+			Name:               "Ok unpin",
+			Method:             http.MethodDelete,
+			Path:               "/posts/1/pin",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok feature last",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/featured",
+			ExpectedCalls:      []string{"feature 1 -1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok feature first",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/featured",
+			Body:               `{"position":0}`,
+			ExpectedCalls:      []string{"feature 1 0"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Negative position",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/featured",
+			Body:               `{"position":-1}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Feature at a place taken meanwhile",
+			Method:                   http.MethodPut,
+			Path:                     "/posts/1/featured",
+			ErrorServiceShouldReturn: pkg.ErrEntityAlreadyExists,
+			ExpectedStatusCode:       http.StatusConflict,
+		},
+		{
+			Name:               "Ok unfeature",
+			Method:             http.MethodDelete,
+			Path:               "/posts/1/featured",
+			ExpectedCalls:      []string{"unfeature 1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Unfeature not featured",
+			Method:                   http.MethodDelete,
+			Path:                     "/posts/1/featured",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:               "Invalid id",
+			Method:             http.MethodPut,
+			Path:               "/posts/x/pin",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Unauthorized",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/featured",
+			WithoutToken:       true,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Forbidden",
+			Method:                   http.MethodPut,
+			Path:                     "/posts/1/pin",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "Db internal",
+			Method:                   http.MethodDelete,
+			Path:                     "/posts/1/featured",
+			ErrorServiceShouldReturn: errors.New("some unexpected error"),
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrPinNewsToReturn = testCase.ErrorServiceShouldReturn
+			featuredServiceInstance.ErrToReturn = testCase.ErrorServiceShouldReturn
+			featuredServiceInstance.Calls = nil
+
+			r, _ := http.NewRequest(testCase.Method, "http://localhost:8081"+testCase.Path, bytes.NewBufferString(testCase.Body))
+			if testCase.Body != "" {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, featuredServiceInstance.Calls, testCase.ExpectedCalls)
+		})
+	}
+}
+
+func TestPinNewsUntil(t *testing.T) {
+	newsServiceInstance.ErrPinNewsToReturn = nil
+
+	r, _ := http.NewRequest(http.MethodPut, "http://localhost:8081/posts/1/pin", bytes.NewBufferString(`{"pinned_until":"2030-01-01T12:00:00+02:00"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer "+authToken)
+	resp, _ := http.DefaultClient.Do(r)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	// TIMESTAMP columns are UTC
+	assert.Equal(t, newsServiceInstance.PinnedUntil, pgtype.Timestamp{Time: time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC), Valid: true})
+}
+
+func TestGetFeaturedNews(t *testing.T) {
+	reactionServiceInstance.ErrGetReactionsReturn = nil
+	readStateServiceInstance.ErrGetUpdatedAtToReturn = nil
+	featuredServiceInstance.ErrToReturn = nil
+	featuredServiceInstance.News = []core.News{
+		{ID: 2, Title: pgtype.Text{String: "second title", Valid: true}, ContentFormat: "plain", Status: "published"},
+		{ID: 1, Title: pgtype.Text{String: "first title", Valid: true}, ContentFormat: "plain", Status: "published"},
+	}
+	defer func() { featuredServiceInstance.News = nil }()
+
+	resp, _ := http.Get("http://localhost:8081/posts/featured?platform=ios")
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var respResult struct {
+		Data []response.NewsData `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respResult)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(respResult.Data), 2)
+	// in the order of the featured list, not by id
+	assert.Equal(t, respResult.Data[0].Id, 2)
+	assert.Equal(t, respResult.Data[1].Id, 1)
+
+	resp, _ = http.Get("http://localhost:8081/posts/featured?platform=unknown")
+	assert.Equal(t, resp.StatusCode, http.StatusBadRequest)
+}
+
+func TestPinnedNewsFirst(t *testing.T) {
+	newsServiceInstance.ErrGetNewsStatsToReturn = nil
+	newsServiceInstance.ErrGetAllNewsToReturn = nil
+	reactionServiceInstance.ErrGetReactionsReturn = nil
+	readStateServiceInstance.ErrGetUpdatedAtToReturn = nil
+	pinnedAt := pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true}
+	newsServiceInstance.AllNewsToReturn = []core.News{
+		{ID: 1, ContentFormat: "plain", Status: "published"},
+		// the pin ran out
+		{ID: 2, ContentFormat: "plain", Status: "published", PinnedAt: pinnedAt, PinnedUntil: pgtype.Timestamp{Time: time.Now().Add(-time.Minute), Valid: true}},
+		{ID: 3, ContentFormat: "plain", Status: "published", PinnedAt: pinnedAt},
+		{ID: 4, ContentFormat: "plain", Status: "published", PinnedAt: pinnedAt, PinnedUntil: pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true}},
+	}
+	defer func() { newsServiceInstance.AllNewsToReturn = nil }()
+
+	list := func(query string) ([]response.NewsData, string) {
+		resp, _ := http.Get("http://localhost:8081/posts" + query)
+		assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+		var respResult struct {
+			Data []response.NewsData `json:"data"`
+		}
+		err := json.NewDecoder(resp.Body).Decode(&respResult)
+		assert.Equal(t, err, nil)
+		return respResult.Data, resp.Header.Get("Link")
+	}
+	ids := func(data []response.NewsData) []int {
+		result := []int{}
+		for _, d := range data {
+			result = append(result, d.Id)
+		}
+		return result
+	}
+
+	data, _ := list("")
+	assert.Equal(t, ids(data), []int{3, 4, 1, 2})
+	assert.Equal(t, data[0].Pinned, true)
+	assert.Equal(t, data[0].PinnedUntil == nil, true)
+	assert.Equal(t, data[1].PinnedUntil != nil, true)
+	assert.Equal(t, data[3].Pinned, false)
+
+	// pages follow the same order, across the pinned and the others
+	data, link := list("?first=2")
+	assert.Equal(t, ids(data), []int{3, 4})
+	assert.Equal(t, link, `</posts?after=`+encodeNewsCursor(newsCursor{pinned: true, id: 4})+`&first=2>; rel="next"`)
+
+	data, link = list("?first=2&after=" + encodeNewsCursor(newsCursor{pinned: true, id: 4}))
+	assert.Equal(t, ids(data), []int{1, 2})
+	assert.Equal(t, link, "")
+
+	data, _ = list("?first=2&after=" + encodeNewsCursor(newsCursor{pinned: true, id: 3}))
+	assert.Equal(t, ids(data), []int{4, 1})
+}
+
+func TestNewsCursor(t *testing.T) {
+	for _, cursor := range []newsCursor{{id: 5}, {pinned: true, id: 5}} {
+		decoded, err := decodeNewsCursor(encodeNewsCursor(cursor))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, decoded, cursor)
+	}
+
+	_, err := decodeNewsCursor(encodeCommentCursor(5))
+	assert.Equal(t, errors.Is(err, pkg.ErrInvalidPayload), true)
+}
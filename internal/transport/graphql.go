@@ -0,0 +1,583 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/dataloader"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type GraphqlHandler struct {
+	newsService   newsService
+	authorService authorService
+	schema        graphql.Schema
+}
+
+func NewGraphqlHandler(newsService newsService, authorService authorService) *GraphqlHandler {
+	h := &GraphqlHandler{
+		newsService:   newsService,
+		authorService: authorService,
+	}
+
+	schema, err := graphql.NewSchema(h.schemaConfig())
+	if err != nil {
+		// the schema is static, this only fails on a programming error
+		panic(err)
+	}
+	h.schema = schema
+
+	return h
+}
+
+type authorService interface {
+	GetAuthorsByIds(ctx context.Context, ids []int32) ([]core.Author, error)
+}
+
+// loaders batch the lookups made while resolving a single request.
+type loaders struct {
+	news    *dataloader.Loader[int32, core.News]
+	authors *dataloader.Loader[int32, core.Author]
+}
+
+type loadersKey struct{}
+
+func (h *GraphqlHandler) newLoaders() *loaders {
+	return &loaders{
+		news: dataloader.New(func(ctx context.Context, ids []int32) (map[int32]core.News, error) {
+			news, err := h.newsService.GetNewsByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byId := make(map[int32]core.News, len(news))
+			for _, n := range news {
+				byId[n.ID] = n
+			}
+			return byId, nil
+		}),
+		authors: dataloader.New(func(ctx context.Context, ids []int32) (map[int32]core.Author, error) {
+			authors, err := h.authorService.GetAuthorsByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byId := make(map[int32]core.Author, len(authors))
+			for _, a := range authors {
+				byId[a.ID] = a
+			}
+			return byId, nil
+		}),
+	}
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (h *GraphqlHandler) Query(ctx *gin.Context) {
+	var pl payload.GraphqlPayload
+	err := ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, graphqlRequestError(
+			fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err),
+		))
+		return
+	}
+
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(pl.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, graphqlRequestError(
+			fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err),
+		))
+		return
+	}
+
+	err = checkQueryLimits(document, pl.OperationName, pl.Variables)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, graphqlRequestError(err))
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  pl.Query,
+		VariableValues: pl.Variables,
+		OperationName:  pl.OperationName,
+		Context:        context.WithValue(ctx, loadersKey{}, h.newLoaders()),
+	})
+
+	// errors of single fields come along with the rest of the data
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (h *GraphqlHandler) schemaConfig() graphql.SchemaConfig {
+	authorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"id":   authorField(graphql.NewNonNull(graphql.Int), func(a core.Author) any { return a.ID }),
+			"name": authorField(graphql.NewNonNull(graphql.String), func(a core.Author) any { return a.Name }),
+		},
+	})
+
+	newsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "News",
+		Fields: graphql.Fields{
+			"id":                newsField(graphql.NewNonNull(graphql.Int), func(n core.News) any { return n.ID }),
+			"title":             newsField(graphql.NewNonNull(graphql.String), func(n core.News) any { return n.Title.String }),
+			"content":           newsField(graphql.NewNonNull(graphql.String), func(n core.News) any { return n.Content.String }),
+			"contentFormat":     newsField(graphql.NewNonNull(graphql.String), func(n core.News) any { return n.ContentFormat }),
+			"authorId":          newsField(graphql.Int, func(n core.News) any { return nullableInt(n.AuthorID) }),
+			"author":            &graphql.Field{Type: authorType, Resolve: h.resolveNewsAuthor},
+			"updatedBy":         newsField(graphql.Int, func(n core.News) any { return nullableInt(n.UpdatedBy) }),
+			"apiKeyId":          newsField(graphql.Int, func(n core.News) any { return nullableInt(n.ApiKeyID) }),
+			"updatedByApiKeyId": newsField(graphql.Int, func(n core.News) any { return nullableInt(n.UpdatedByApiKeyID) }),
+			"status":            newsField(graphql.NewNonNull(graphql.String), func(n core.News) any { return n.Status }),
+			"commentsCount":     newsField(graphql.NewNonNull(graphql.Int), func(n core.News) any { return n.CommentsCount }),
+			"createdAt":         newsField(graphql.DateTime, func(n core.News) any { return nullableTime(n.CreatedAt) }),
+			"updatedAt":         newsField(graphql.DateTime, func(n core.News) any { return nullableTime(n.UpdatedAt) }),
+			"publishedAt":       newsField(graphql.DateTime, func(n core.News) any { return nullableTime(n.PublishedAt) }),
+			"targeting":         newsField(graphql.String, func(n core.News) any { return nullableText(n.Targeting) }),
+		},
+	})
+
+	newsEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NewsEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(newsType)},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	newsConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NewsConnection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(newsEdgeType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	newsFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "NewsFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"status":   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"authorId": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"search":   &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Case insensitive match on the title."},
+		},
+	})
+
+	newsInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "NewsInput",
+		Description: "News are written as either content or blocks. Updates keep the content format and targeting news have unless they're set, an empty targeting shows news to everyone.",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":         &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"content":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"contentFormat": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Plain unless it's set, or markdown for the content derived from blocks."},
+			"blocks":        &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "A BlockDocument as JSON."},
+			"targeting":     &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "A rule on the clients news are shown to."},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"news": &graphql.Field{
+				Type: newsType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: h.resolveNews,
+			},
+			"newsList": &graphql.Field{
+				Type: graphql.NewNonNull(newsConnectionType),
+				Args: graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+					"filter": &graphql.ArgumentConfig{Type: newsFilterType},
+				},
+				Resolve: h.resolveNewsList,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createNews": &graphql.Field{
+				Type: graphql.NewNonNull(newsType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(newsInputType)},
+				},
+				Resolve: h.resolveCreateNews,
+			},
+			"updateNews": &graphql.Field{
+				Type: graphql.NewNonNull(newsType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(newsInputType)},
+				},
+				Resolve: h.resolveUpdateNews,
+			},
+			"deleteNews": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: h.resolveDeleteNews,
+			},
+		},
+	})
+
+	return graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	}
+}
+
+func (h *GraphqlHandler) resolveNews(p graphql.ResolveParams) (any, error) {
+	load := loadersFromContext(p.Context).news.Load(p.Context, int32(p.Args["id"].(int)))
+
+	return func() (any, error) {
+		news, err := load()
+		if err != nil {
+			// a missing news is null rather than an error, like a missing field
+			if errors.Is(err, pkg.ErrNotFound) {
+				return nil, nil
+			}
+			failThunk(p, err)
+		}
+
+		return news, nil
+	}, nil
+}
+
+// resolveNewsAuthor loads the authors of every news on the page in one
+// batch.
+func (h *GraphqlHandler) resolveNewsAuthor(p graphql.ResolveParams) (any, error) {
+	news := p.Source.(core.News)
+	if !news.AuthorID.Valid {
+		return nil, nil
+	}
+
+	load := loadersFromContext(p.Context).authors.Load(p.Context, news.AuthorID.Int32)
+
+	return func() (any, error) {
+		author, err := load()
+		if err != nil {
+			if errors.Is(err, pkg.ErrNotFound) {
+				return nil, nil
+			}
+			failThunk(p, err)
+		}
+
+		return author, nil
+	}, nil
+}
+
+// failThunk reports an error from a thunk. The executor drops the extensions
+// of errors thunks return, but passes located errors they panic with as they
+// are.
+func failThunk(p graphql.ResolveParams, err error) {
+	panic(graphql.NewLocatedErrorWithPath(
+		graphqlError(err),
+		graphql.FieldASTsToNodeASTs(p.Info.FieldASTs),
+		p.Info.Path.AsArray(),
+	))
+}
+
+func (h *GraphqlHandler) resolveNewsList(p graphql.ResolveParams) (any, error) {
+	first := p.Args["first"].(int)
+	if first < 0 || first > maxPageSize {
+		return nil, graphqlError(fmt.Errorf("%w: [first must be between 0 and %d]", pkg.ErrInvalidPayload, maxPageSize))
+	}
+
+	after := firstNewsPage
+	cursor, ok := p.Args["after"].(string)
+	if ok {
+		var err error
+		after, err = decodeNewsCursor(cursor)
+		if err != nil {
+			return nil, graphqlError(err)
+		}
+	}
+
+	news, err := h.newsService.GetAllNews(p.Context)
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+
+	filter, _ := p.Args["filter"].(map[string]any)
+	news = slices.DeleteFunc(news, func(n core.News) bool {
+		return !matchesNewsFilter(n, filter)
+	})
+	totalCount := len(news)
+
+	now := time.Now()
+	page, hasNextPage := newsPage(news, after, first, now)
+	edges := make([]map[string]any, 0, len(page))
+	for _, n := range page {
+		edges = append(edges, map[string]any{
+			"cursor": encodeNewsCursor(newsCursorOf(n, now)),
+			"node":   n,
+		})
+	}
+
+	var endCursor any
+	if len(edges) > 0 {
+		endCursor = edges[len(edges)-1]["cursor"]
+	}
+
+	return map[string]any{
+		"edges": edges,
+		"pageInfo": map[string]any{
+			"hasNextPage": hasNextPage,
+			"endCursor":   endCursor,
+		},
+		"totalCount": totalCount,
+	}, nil
+}
+
+func (h *GraphqlHandler) resolveCreateNews(p graphql.ResolveParams) (any, error) {
+	input := p.Args["input"].(map[string]any)
+	content, _ := input["content"].(string)
+	contentFormat, _ := input["contentFormat"].(string)
+	rule, _ := input["targeting"].(string)
+	pl := payload.AddNewsPayload{
+		Title:         input["title"].(string),
+		Content:       content,
+		ContentFormat: contentFormat,
+		Blocks:        inputBlocks(input),
+		Targeting:     rule,
+	}
+	err := binding.Validator.ValidateStruct(pl)
+	if err != nil {
+		return nil, graphqlError(fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err))
+	}
+
+	author, ok := auth.AuthorFromContext(p.Context)
+	id, err := h.newsService.AddNews(p.Context, core.AddNewsParams{
+		Title:         pgtype.Text{String: pl.Title, Valid: true},
+		Content:       pgtype.Text{String: pl.Content, Valid: true},
+		ContentFormat: pl.ContentFormat,
+		Blocks:        pl.Blocks,
+		Targeting:     pgtype.Text{String: pl.Targeting, Valid: pl.Targeting != ""},
+		AuthorID:      pgtype.Int4{Int32: author.ID, Valid: ok},
+	})
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+
+	news, err := loadersFromContext(p.Context).news.Load(p.Context, id)()
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+
+	return news, nil
+}
+
+func (h *GraphqlHandler) resolveUpdateNews(p graphql.ResolveParams) (any, error) {
+	id := int32(p.Args["id"].(int))
+	input := p.Args["input"].(map[string]any)
+	content, _ := input["content"].(string)
+	contentFormat, formatSet := input["contentFormat"].(string)
+	// the rule news have is kept unless it's set
+	rule, ruleSet := input["targeting"].(string)
+	pl := payload.UpdateNewsPayload{
+		Title:         input["title"].(string),
+		Content:       content,
+		ContentFormat: contentFormat,
+		Blocks:        inputBlocks(input),
+		Targeting:     rule,
+	}
+	err := binding.Validator.ValidateStruct(pl)
+	if err != nil {
+		return nil, graphqlError(fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err))
+	}
+
+	newsLoader := loadersFromContext(p.Context).news
+
+	// content is written in the format news have unless it's set, blocks
+	// get the format they default to
+	if !formatSet && pl.Blocks == nil {
+		news, err := newsLoader.Load(p.Context, id)()
+		if err != nil {
+			return nil, graphqlError(err)
+		}
+		pl.ContentFormat = news.ContentFormat
+	}
+
+	author, ok := auth.AuthorFromContext(p.Context)
+	err = h.newsService.UpdatNews(p.Context, core.UpdateNewsParams{
+		ID:            id,
+		Title:         pgtype.Text{String: pl.Title, Valid: true},
+		Content:       pgtype.Text{String: pl.Content, Valid: true},
+		ContentFormat: pl.ContentFormat,
+		Blocks:        pl.Blocks,
+		Targeting:     pgtype.Text{String: pl.Targeting, Valid: ruleSet},
+		UpdatedBy:     pgtype.Int4{Int32: author.ID, Valid: ok},
+	})
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+
+	newsLoader.Clear(id)
+	news, err := newsLoader.Load(p.Context, id)()
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+
+	return news, nil
+}
+
+// inputBlocks are missing rather than empty when NewsInput doesn't set them.
+func inputBlocks(input map[string]any) json.RawMessage {
+	document, _ := input["blocks"].(string)
+	if document == "" {
+		return nil
+	}
+	return json.RawMessage(document)
+}
+
+func (h *GraphqlHandler) resolveDeleteNews(p graphql.ResolveParams) (any, error) {
+	id := int32(p.Args["id"].(int))
+	err := h.newsService.DeleteNews(p.Context, id)
+	if err != nil {
+		return nil, graphqlError(err)
+	}
+
+	loadersFromContext(p.Context).news.Clear(id)
+	return id, nil
+}
+
+func newsField(fieldType graphql.Output, value func(core.News) any) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return value(p.Source.(core.News)), nil
+		},
+	}
+}
+
+func authorField(fieldType graphql.Output, value func(core.Author) any) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			return value(p.Source.(core.Author)), nil
+		},
+	}
+}
+
+func nullableInt(v pgtype.Int4) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.Int32
+}
+
+func nullableText(v pgtype.Text) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.String
+}
+
+func nullableTime(v pgtype.Timestamp) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.Time
+}
+
+func matchesNewsFilter(news core.News, filter map[string]any) bool {
+	status, ok := filter["status"].(string)
+	if ok && news.Status != status {
+		return false
+	}
+
+	authorId, ok := filter["authorId"].(int)
+	if ok && (!news.AuthorID.Valid || int(news.AuthorID.Int32) != authorId) {
+		return false
+	}
+
+	search, ok := filter["search"].(string)
+	if ok && !strings.Contains(strings.ToLower(news.Title.String), strings.ToLower(search)) {
+		return false
+	}
+
+	return true
+}
+
+// resolverError carries the same code the REST handlers answer with in the
+// error extensions.
+type resolverError struct {
+	message string
+	code    int
+}
+
+func (e resolverError) Error() string {
+	return e.message
+}
+
+func (e resolverError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func graphqlError(err error) error {
+	switch {
+	case errors.Is(err, pkg.ErrInvalidPayload):
+		return resolverError{message: err.Error(), code: response.InvalidPayload}
+	case errors.Is(err, pkg.ErrUnauthorized):
+		return resolverError{message: pkg.ErrUnauthorized.Error(), code: response.Unauthorized}
+	case errors.Is(err, pkg.ErrForbidden):
+		return resolverError{message: pkg.ErrForbidden.Error(), code: response.Forbidden}
+	case errors.Is(err, pkg.ErrNotFound):
+		return resolverError{message: pkg.ErrNotFound.Error(), code: response.NotFound}
+	case errors.Is(err, pkg.ErrEntityAlreadyDeleted):
+		return resolverError{message: pkg.ErrEntityAlreadyDeleted.Error(), code: response.NotFound}
+	case errors.Is(err, pkg.ErrEntityAlreadyExists):
+		return resolverError{message: pkg.ErrEntityAlreadyExists.Error(), code: response.EntityAlreadyExists}
+	default:
+		return resolverError{message: pkg.ErrDbInternal.Error(), code: response.InternalError}
+	}
+}
+
+// graphqlRequestError answers requests rejected before execution in the
+// shape GraphQL clients expect.
+func graphqlRequestError(err error) *graphql.Result {
+	resolverErr := graphqlError(err).(resolverError)
+
+	return &graphql.Result{
+		Errors: []gqlerrors.FormattedError{{
+			Message:    resolverErr.message,
+			Extensions: resolverErr.Extensions(),
+		}},
+	}
+}
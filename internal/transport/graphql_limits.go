@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	maxQueryDepth      = 10
+	maxQueryComplexity = 2500
+)
+
+// connectionFields are paginated, their selections are counted once per
+// node of the requested page.
+var connectionFields = map[string]bool{
+	"newsList": true,
+}
+
+// checkQueryLimits rejects operations nested deeper than maxQueryDepth or
+// costing more than maxQueryComplexity before they run. Every field costs
+// one, what's selected on a connection costs as many times as it returns
+// nodes.
+func checkQueryLimits(document *ast.Document, operationName string, variables map[string]any) error {
+	cost := queryCost{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+	}
+	for _, definition := range document.Definitions {
+		fragment, ok := definition.(*ast.FragmentDefinition)
+		if ok {
+			cost.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+
+		depth, complexity := cost.selectionSet(operation.SelectionSet, map[string]bool{})
+		if depth > maxQueryDepth {
+			return fmt.Errorf("%w: [query depth %d exceeds %d]", pkg.ErrInvalidPayload, depth, maxQueryDepth)
+		}
+		if complexity > maxQueryComplexity {
+			return fmt.Errorf("%w: [query complexity %d exceeds %d]", pkg.ErrInvalidPayload, complexity, maxQueryComplexity)
+		}
+	}
+
+	return nil
+}
+
+type queryCost struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+func (c queryCost) selectionSet(set *ast.SelectionSet, spread map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	var depth, complexity int
+	for _, selection := range set.Selections {
+		var selectionDepth, selectionComplexity int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			// introspection is bounded by the schema itself
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+
+			childDepth, childComplexity := c.selectionSet(selection.SelectionSet, spread)
+			selectionDepth = childDepth + 1
+			selectionComplexity = 1 + c.nodes(selection)*childComplexity
+		case *ast.InlineFragment:
+			selectionDepth, selectionComplexity = c.selectionSet(selection.SelectionSet, spread)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := c.fragments[name]
+			// cycles are reported by validation, they only have to terminate here
+			if !ok || spread[name] {
+				continue
+			}
+
+			spread[name] = true
+			selectionDepth, selectionComplexity = c.selectionSet(fragment.SelectionSet, spread)
+			delete(spread, name)
+		}
+
+		depth = max(depth, selectionDepth)
+		complexity += selectionComplexity
+	}
+
+	return depth, complexity
+}
+
+// nodes is how many times the selection of the field is resolved.
+func (c queryCost) nodes(field *ast.Field) int {
+	if !connectionFields[field.Name.Value] {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			first, err := strconv.Atoi(value.Value)
+			if err == nil {
+				return pageNodes(first)
+			}
+		case *ast.Variable:
+			// numbers decoded from JSON are floats
+			first, ok := c.variables[value.Name.Value].(float64)
+			if ok {
+				return pageNodes(int(min(first, maxQueryComplexity+1)))
+			}
+		}
+	}
+
+	return defaultPageSize
+}
+
+// pageNodes keeps absurd page sizes from overflowing the cost, they're over
+// the limit either way.
+func pageNodes(first int) int {
+	return min(max(first, 1), maxQueryComplexity+1)
+}
@@ -0,0 +1,379 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type GraphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code int `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type authorServiceMock struct {
+	// Calls are the ids of every batch of authors read
+	Calls       [][]int32
+	ErrToReturn error
+}
+
+func (m *authorServiceMock) GetAuthorsByIds(ctx context.Context, ids []int32) ([]core.Author, error) {
+	m.Calls = append(m.Calls, ids)
+	if m.ErrToReturn != nil {
+		return nil, m.ErrToReturn
+	}
+
+	var authors []core.Author
+	for _, id := range ids {
+		// the third author is gone
+		if id != 3 {
+			authors = append(authors, core.Author{ID: id, Name: fmt.Sprintf("author %d", id)})
+		}
+	}
+	return authors, nil
+}
+
+func TestGraphql(t *testing.T) {
+	testTable := []struct {
+		Name                   string
+		Query                  string
+		Variables              map[string]any
+		Authorization          string
+		ErrGetNewsByIdToReturn error
+		ErrAddNewsToReturn     error
+		ErrDeleteNewsToReturn  error
+		ExpectedStatusCode     int
+		ExpectedErrorCode      int
+		ExpectedData           string
+	}{
+		{
+			Name:               "News by id",
+			Query:              `{ news(id: 2) { id title content } }`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"news":{"content":"some content","id":2,"title":"some title"}}`,
+		},
+		{
+			Name:                   "News not found",
+			Query:                  `{ news(id: 2) { id } }`,
+			ErrGetNewsByIdToReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:     http.StatusOK,
+			ExpectedData:           `{"news":null}`,
+		},
+		{
+			Name:                   "News internal error",
+			Query:                  `{ news(id: 2) { id } }`,
+			ErrGetNewsByIdToReturn: pkg.ErrDbInternal,
+			ExpectedStatusCode:     http.StatusOK,
+			ExpectedErrorCode:      response.InternalError,
+			ExpectedData:           `{"news":null}`,
+		},
+		{
+			Name:               "News list",
+			Query:              `{ newsList(first: 1) { totalCount edges { cursor node { id } } pageInfo { hasNextPage endCursor } } }`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"newsList":{"edges":[{"cursor":"bmV3czox","node":{"id":1}}],"pageInfo":{"endCursor":"bmV3czox","hasNextPage":false},"totalCount":1}}`,
+		},
+		{
+			Name:               "News list filtered",
+			Query:              `{ newsList(filter: {status: "published"}) { totalCount edges { cursor } pageInfo { endCursor } } }`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"newsList":{"edges":[],"pageInfo":{"endCursor":null},"totalCount":0}}`,
+		},
+		{
+			Name:               "News list after last page",
+			Query:              `query Page($after: String) { newsList(after: $after) { edges { cursor } pageInfo { hasNextPage } } }`,
+			Variables:          map[string]any{"after": "bmV3czox"},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"newsList":{"edges":[],"pageInfo":{"hasNextPage":false}}}`,
+		},
+		{
+			Name:               "Invalid cursor",
+			Query:              `{ newsList(after: "invalid") { totalCount } }`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedErrorCode:  response.InvalidPayload,
+			ExpectedData:       `null`,
+		},
+		{
+			Name:               "Create news",
+			Query:              `mutation { createNews(input: {title: "some title", content: "some content"}) { id title } }`,
+			Authorization:      "Bearer " + authToken,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"createNews":{"id":1,"title":"some title"}}`,
+		},
+		{
+			Name:               "Create news validation error",
+			Query:              `mutation { createNews(input: {title: "f", content: "some content"}) { id } }`,
+			Authorization:      "Bearer " + authToken,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedErrorCode:  response.InvalidPayload,
+			ExpectedData:       `null`,
+		},
+		{
+			Name:               "Create news anonymously",
+			Query:              `mutation { createNews(input: {title: "some title", content: "some content"}) { id } }`,
+			ErrAddNewsToReturn: pkg.ErrUnauthorized,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedErrorCode:  response.Unauthorized,
+			ExpectedData:       `null`,
+		},
+		{
+			Name:               "Invalid token",
+			Query:              `mutation { deleteNews(id: 1) }`,
+			Authorization:      "Bearer invalid",
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "Delete news forbidden",
+			Query:                 `mutation { deleteNews(id: 1) }`,
+			Authorization:         "Bearer " + authToken,
+			ErrDeleteNewsToReturn: pkg.ErrForbidden,
+			ExpectedStatusCode:    http.StatusOK,
+			ExpectedErrorCode:     response.Forbidden,
+			ExpectedData:          `null`,
+		},
+		{
+			Name:               "Delete news",
+			Query:              `mutation { deleteNews(id: 1) }`,
+			Authorization:      "Bearer " + authToken,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedData:       `{"deleteNews":1}`,
+		},
+		{
+			Name:               "Syntax error",
+			Query:              `{ news(`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedErrorCode:  response.InvalidPayload,
+			ExpectedData:       `null`,
+		},
+		{
+			Name:               "Too deep",
+			Query:              "{" + strings.Repeat(" a {", maxQueryDepth+1) + " id" + strings.Repeat(" }", maxQueryDepth+1) + " }",
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedErrorCode:  response.InvalidPayload,
+			ExpectedData:       `null`,
+		},
+		{
+			Name:               "Too complex",
+			Query:              `{ newsList(first: 1000) { edges { node { id title content } } } }`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedErrorCode:  response.InvalidPayload,
+			ExpectedData:       `null`,
+		},
+		{
+			Name:               "Too complex with variables",
+			Query:              `query Page($first: Int) { newsList(first: $first) { edges { node { id title content } } } }`,
+			Variables:          map[string]any{"first": 500},
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedErrorCode:  response.InvalidPayload,
+			ExpectedData:       `null`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = testCase.ErrGetNewsByIdToReturn
+			newsServiceInstance.ErrAddNewsToReturn = testCase.ErrAddNewsToReturn
+			newsServiceInstance.ErrDeleteNewsToReturn = testCase.ErrDeleteNewsToReturn
+			defer func() {
+				newsServiceInstance.ErrGetNewsByIdToReturn = nil
+				newsServiceInstance.ErrAddNewsToReturn = nil
+				newsServiceInstance.ErrDeleteNewsToReturn = nil
+			}()
+
+			pl, _ := json.Marshal(payload.GraphqlPayload{
+				Query:     testCase.Query,
+				Variables: testCase.Variables,
+			})
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/graphql", bytes.NewBuffer(pl))
+			if testCase.Authorization != "" {
+				r.Header.Set("Authorization", testCase.Authorization)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			if resp.StatusCode == http.StatusUnauthorized {
+				return
+			}
+
+			var respResult GraphqlResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, string(respResult.Data), testCase.ExpectedData)
+			if testCase.ExpectedErrorCode == 0 {
+				assert.Equal(t, len(respResult.Errors), 0)
+				return
+			}
+
+			assert.Equal(t, len(respResult.Errors), 1)
+			assert.Equal(t, respResult.Errors[0].Extensions.Code, testCase.ExpectedErrorCode)
+		})
+	}
+}
+
+func TestGraphqlUpdateNewsContentFormat(t *testing.T) {
+	testTable := []struct {
+		Name                  string
+		Query                 string
+		ExpectedContentFormat string
+		ExpectedBlocks        []byte
+	}{
+		{
+			Name:                  "Kept when missing",
+			Query:                 `mutation { updateNews(id: 2, input: {title: "some title", content: "some *content*"}) { id } }`,
+			ExpectedContentFormat: "markdown",
+		},
+		{
+			Name:                  "Set",
+			Query:                 `mutation { updateNews(id: 2, input: {title: "some title", content: "some content", contentFormat: "plain"}) { id } }`,
+			ExpectedContentFormat: "plain",
+		},
+		{
+			Name:           "Blocks",
+			Query:          `mutation { updateNews(id: 2, input: {title: "some title", blocks: "{\"version\": 1, \"blocks\": []}"}) { id } }`,
+			ExpectedBlocks: []byte(`{"version": 1, "blocks": []}`),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			pl, _ := json.Marshal(payload.GraphqlPayload{Query: testCase.Query})
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/graphql", bytes.NewBuffer(pl))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			var respResult GraphqlResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(respResult.Errors), 0)
+			assert.Equal(t, newsServiceInstance.Updated.ContentFormat, testCase.ExpectedContentFormat)
+			assert.Equal(t, []byte(newsServiceInstance.Updated.Blocks), testCase.ExpectedBlocks)
+		})
+	}
+}
+
+func TestGraphqlUpdateNewsTargeting(t *testing.T) {
+	testTable := []struct {
+		Name              string
+		Query             string
+		ExpectedTargeting pgtype.Text
+	}{
+		{
+			Name:  "Kept when missing",
+			Query: `mutation { updateNews(id: 2, input: {title: "some title", content: "some content"}) { id } }`,
+		},
+		{
+			Name:              "Set",
+			Query:             `mutation { updateNews(id: 2, input: {title: "some title", content: "some content", targeting: "platform == \"ios\""}) { id } }`,
+			ExpectedTargeting: pgtype.Text{String: `platform == "ios"`, Valid: true},
+		},
+		{
+			Name:              "Cleared",
+			Query:             `mutation { updateNews(id: 2, input: {title: "some title", content: "some content", targeting: ""}) { id } }`,
+			ExpectedTargeting: pgtype.Text{Valid: true},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			pl, _ := json.Marshal(payload.GraphqlPayload{Query: testCase.Query})
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/graphql", bytes.NewBuffer(pl))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			var respResult GraphqlResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(respResult.Errors), 0)
+			assert.Equal(t, newsServiceInstance.Updated.Targeting, testCase.ExpectedTargeting)
+		})
+	}
+}
+
+func TestGraphqlBatchesLookups(t *testing.T) {
+	newsServiceInstance.AllNewsToReturn = []core.News{
+		{ID: 1, AuthorID: pgtype.Int4{Int32: 1, Valid: true}},
+		{ID: 2, AuthorID: pgtype.Int4{Int32: 2, Valid: true}},
+		{ID: 3, AuthorID: pgtype.Int4{Int32: 1, Valid: true}},
+		{ID: 4, AuthorID: pgtype.Int4{Int32: 3, Valid: true}},
+		{ID: 5},
+	}
+	defer func() { newsServiceInstance.AllNewsToReturn = nil }()
+
+	testTable := []struct {
+		Name                  string
+		Query                 string
+		ErrAuthorsToReturn    error
+		ExpectedNewsBatches   int
+		ExpectedAuthorBatches [][]int32
+		ExpectedErrorCode     int
+		ExpectedData          string
+	}{
+		{
+			Name:                  "Authors",
+			Query:                 `{ newsList { edges { node { id author { id name } } } } }`,
+			ExpectedAuthorBatches: [][]int32{{1, 2, 3}},
+			ExpectedData:          `{"newsList":{"edges":[{"node":{"author":{"id":1,"name":"author 1"},"id":1}},{"node":{"author":{"id":2,"name":"author 2"},"id":2}},{"node":{"author":{"id":1,"name":"author 1"},"id":3}},{"node":{"author":null,"id":4}},{"node":{"author":null,"id":5}}]}}`,
+		},
+		{
+			Name:                  "Authors internal error",
+			Query:                 `{ newsList(first: 1) { edges { node { id author { id } } } } }`,
+			ErrAuthorsToReturn:    pkg.ErrDbInternal,
+			ExpectedAuthorBatches: [][]int32{{1}},
+			ExpectedErrorCode:     response.InternalError,
+			ExpectedData:          `{"newsList":{"edges":[{"node":{"author":null,"id":1}}]}}`,
+		},
+		{
+			Name:                "News",
+			Query:               `{ first: news(id: 1) { id } second: news(id: 2) { id } again: news(id: 1) { id } }`,
+			ExpectedNewsBatches: 1,
+			ExpectedData:        `{"again":{"id":1},"first":{"id":1},"second":{"id":2}}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.GetNewsByIdsCalls = 0
+			authorServiceInstance.Calls = nil
+			authorServiceInstance.ErrToReturn = testCase.ErrAuthorsToReturn
+
+			pl, _ := json.Marshal(payload.GraphqlPayload{Query: testCase.Query})
+			resp, err := http.Post("http://localhost:8081/graphql", "application/json", bytes.NewBuffer(pl))
+			assert.Equal(t, err, nil)
+
+			var respResult GraphqlResponse
+			err = json.NewDecoder(resp.Body).Decode(&respResult)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, string(respResult.Data), testCase.ExpectedData)
+			assert.Equal(t, newsServiceInstance.GetNewsByIdsCalls, testCase.ExpectedNewsBatches)
+			assert.Equal(t, authorServiceInstance.Calls, testCase.ExpectedAuthorBatches)
+
+			if testCase.ExpectedErrorCode == 0 {
+				assert.Equal(t, len(respResult.Errors), 0)
+				return
+			}
+			assert.Equal(t, len(respResult.Errors), 1)
+			assert.Equal(t, respResult.Errors[0].Extensions.Code, testCase.ExpectedErrorCode)
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/media"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is room for the form around the file.
+const multipartOverhead = 1 << 20
+
+type MediaHandler struct {
+	mediaService mediaService
+}
+
+func NewMediaHandler(mediaService mediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+	}
+}
+
+type mediaService interface {
+	UploadMedia(ctx context.Context, newsId int32, role string, data []byte) (core.Media, error)
+	GetNewsMedia(ctx context.Context, newsId int32) ([]core.Media, error)
+	DeleteMedia(ctx context.Context, newsId int32, mediaId int32) error
+	URL(key string) string
+}
+
+// mediaData has the urls files are downloaded from.
+func mediaData(m core.Media, url func(key string) string) (response.MediaData, error) {
+	variants, err := service.MediaVariants(m)
+	if err != nil {
+		return response.MediaData{}, err
+	}
+
+	data := response.MediaData{
+		Id:          int(m.ID),
+		Role:        m.Role,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       int(m.Width.Int32),
+		Height:      int(m.Height.Int32),
+		Url:         url(m.StorageKey),
+		Variants:    []response.MediaVariantData{},
+	}
+	for _, variant := range variants {
+		data.Variants = append(data.Variants, response.MediaVariantData{
+			Name:   variant.Name,
+			Format: variant.Format,
+			Width:  variant.Width,
+			Height: variant.Height,
+			Url:    url(variant.Key),
+		})
+	}
+
+	return data, nil
+}
+
+func (h *MediaHandler) UploadMedia(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, media.MaxSize+multipartOverhead)
+
+	var pl payload.UploadMediaPayload
+	err = ctx.ShouldBind(&pl)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, response.Response{
+				Code:  response.PayloadTooLarge,
+				Error: pkg.ErrPayloadTooLarge.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	if pl.File.Size > media.MaxSize {
+		ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, response.Response{
+			Code:  response.PayloadTooLarge,
+			Error: pkg.ErrPayloadTooLarge.Error(),
+		})
+		return
+	}
+
+	file, err := pl.File.Open()
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	m, err := h.mediaService.UploadMedia(ctx, int32(uriPayload.Id), pl.Role, data)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrPayloadTooLarge) {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, response.Response{
+				Code:  response.PayloadTooLarge,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		// the news already have a cover
+		if errors.Is(err, pkg.ErrEntityAlreadyExists) {
+			ctx.AbortWithStatusJSON(http.StatusConflict, response.Response{
+				Code:  response.EntityAlreadyExists,
+				Error: pkg.ErrEntityAlreadyExists.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	mData, err := mediaData(m, h.mediaService.URL)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: mData,
+	})
+}
+
+func (h *MediaHandler) DeleteMedia(ctx *gin.Context) {
+	var uriPayload payload.MediaUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.mediaService.DeleteMedia(ctx, int32(uriPayload.Id), int32(uriPayload.MediaId))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
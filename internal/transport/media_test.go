@@ -0,0 +1,263 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var someMedia = core.Media{
+	ID:          1,
+	NewsID:      1,
+	Role:        "cover",
+	ContentType: "image/png",
+	Size:        1024,
+	Width:       pgtype.Int4{Int32: 400, Valid: true},
+	Height:      pgtype.Int4{Int32: 300, Valid: true},
+	StorageKey:  "news/1/abc/original.png",
+	Variants:    []byte(`[{"name":"thumbnail","format":"webp","width":300,"height":300,"key":"news/1/abc/thumbnail.webp"}]`),
+}
+
+var someMediaData = response.MediaData{
+	Id:          1,
+	Role:        "cover",
+	ContentType: "image/png",
+	Size:        1024,
+	Width:       400,
+	Height:      300,
+	Url:         "https://cdn.example.com/news/1/abc/original.png",
+	Variants: []response.MediaVariantData{
+		{
+			Name:   "thumbnail",
+			Format: "webp",
+			Width:  300,
+			Height: 300,
+			Url:    "https://cdn.example.com/news/1/abc/thumbnail.webp",
+		},
+	},
+}
+
+type mediaServiceMock struct {
+	Role                    string
+	ErrUploadMediaToReturn  error
+	ErrDeleteMediaToReturn  error
+	ErrGetNewsMediaToReturn error
+}
+
+func (m *mediaServiceMock) UploadMedia(ctx context.Context, newsId int32, role string, data []byte) (core.Media, error) {
+	if m.ErrUploadMediaToReturn != nil {
+		return core.Media{}, m.ErrUploadMediaToReturn
+	}
+	m.Role = role
+
+	return someMedia, nil
+}
+
+func (m *mediaServiceMock) GetNewsMedia(ctx context.Context, newsId int32) ([]core.Media, error) {
+	if m.ErrGetNewsMediaToReturn != nil {
+		return nil, m.ErrGetNewsMediaToReturn
+	}
+
+	return []core.Media{someMedia}, nil
+}
+
+func (m *mediaServiceMock) DeleteMedia(ctx context.Context, newsId int32, mediaId int32) error {
+	return m.ErrDeleteMediaToReturn
+}
+
+func (m *mediaServiceMock) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+type UploadMediaResponse struct {
+	Code int                `json:"code"`
+	Data response.MediaData `json:"data"`
+}
+
+func TestUploadMedia(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		Role                     string
+		WithoutFile              bool
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedResult           UploadMediaResponse
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:     "Ok",
+			UriParam: "1",
+			Role:     "cover",
+			ExpectedResult: UploadMediaResponse{
+				Code: response.Ok,
+				Data: someMediaData,
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Unknown role",
+			UriParam:           "1",
+			Role:               "background",
+			ExpectedResult:     UploadMediaResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Without file",
+			UriParam:           "1",
+			WithoutFile:        true,
+			ExpectedResult:     UploadMediaResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Without token",
+			UriParam:           "1",
+			WithoutToken:       true,
+			ExpectedResult:     UploadMediaResponse{Code: response.Unauthorized},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Unsupported type",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedResult:           UploadMediaResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode:       http.StatusBadRequest,
+		},
+		{
+			Name:                     "Too large",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrPayloadTooLarge,
+			ExpectedResult:           UploadMediaResponse{Code: response.PayloadTooLarge},
+			ExpectedStatusCode:       http.StatusRequestEntityTooLarge,
+		},
+		{
+			Name:                     "Second cover",
+			UriParam:                 "1",
+			Role:                     "cover",
+			ErrorServiceShouldReturn: pkg.ErrEntityAlreadyExists,
+			ExpectedResult:           UploadMediaResponse{Code: response.EntityAlreadyExists},
+			ExpectedStatusCode:       http.StatusConflict,
+		},
+		{
+			Name:                     "News not found",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedResult:           UploadMediaResponse{Code: response.NotFound},
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Forbidden",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult:           UploadMediaResponse{Code: response.Forbidden},
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			mediaServiceInstance.ErrUploadMediaToReturn = testCase.ErrorServiceShouldReturn
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			if testCase.Role != "" {
+				writer.WriteField("role", testCase.Role)
+			}
+			if !testCase.WithoutFile {
+				file, _ := writer.CreateFormFile("file", "cover.png")
+				file.Write([]byte("some image"))
+			}
+			writer.Close()
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/posts/"+testCase.UriParam+"/media", &body)
+			r.Header.Set("Content-Type", writer.FormDataContentType())
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult UploadMediaResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult, testCase.ExpectedResult)
+			if testCase.ExpectedStatusCode == http.StatusOK {
+				assert.Equal(t, mediaServiceInstance.Role, testCase.Role)
+			}
+		})
+	}
+}
+
+func TestDeleteMedia(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			UriParam:           "1/media/1",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid uri param",
+			UriParam:           "1/media/abc",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Not found",
+			UriParam:                 "1/media/2",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Forbidden",
+			UriParam:                 "1/media/1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			mediaServiceInstance.ErrDeleteMediaToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodDelete, "http://localhost:8081/posts/"+testCase.UriParam, nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+		})
+	}
+}
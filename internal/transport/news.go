@@ -5,31 +5,120 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/anton-uvarenko/promova_test/internal/core"
 	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/content"
 	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
 	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/anton-uvarenko/promova_test/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const (
+	renderCacheSize = 1000
+	// rendered content is cached by its hash, it never goes stale
+	renderCacheTTL = time.Hour
+)
+
 type NewsHandler struct {
-	newsService newsService
+	newsService       newsService
+	mediaService      mediaService
+	reactionService   reactionService
+	viewService       viewService
+	readStateService  readStateService
+	experimentService experimentService
+	featuredService   featuredService
+	cacheControl      string
+	renderer          *content.Renderer
 }
 
-func NewNewsHandler(newsService newsService) *NewsHandler {
+// NewNewsHandler falls back to DefaultCacheControl when cacheControl is empty.
+func NewNewsHandler(
+	newsService newsService,
+	mediaService mediaService,
+	reactionService reactionService,
+	viewService viewService,
+	readStateService readStateService,
+	experimentService experimentService,
+	featuredService featuredService,
+	cacheControl string,
+) *NewsHandler {
+	if cacheControl == "" {
+		cacheControl = DefaultCacheControl
+	}
+
 	return &NewsHandler{
-		newsService: newsService,
+		newsService:       newsService,
+		mediaService:      mediaService,
+		reactionService:   reactionService,
+		viewService:       viewService,
+		readStateService:  readStateService,
+		experimentService: experimentService,
+		featuredService:   featuredService,
+		cacheControl:      cacheControl,
+		renderer:          content.NewRenderer(renderCacheSize, renderCacheTTL),
+	}
+}
+
+// pinned tells whether news are pinned at now, pins that ran out are left
+// in place until news are pinned or unpinned again.
+func pinned(news core.News, now time.Time) bool {
+	return news.PinnedAt.Valid && (!news.PinnedUntil.Valid || news.PinnedUntil.Time.After(now))
+}
+
+// newsData renders the content of news when render is html.
+func (h *NewsHandler) newsData(news core.News, render string) (response.NewsData, error) {
+	data := response.NewsData{
+		Id:                int(news.ID),
+		Title:             news.Title.String,
+		Content:           news.Content.String,
+		ContentFormat:     news.ContentFormat,
+		Blocks:            news.Blocks,
+		AuthorId:          int(news.AuthorID.Int32),
+		UpdatedBy:         int(news.UpdatedBy.Int32),
+		ApiKeyId:          int(news.ApiKeyID.Int32),
+		UpdatedByApiKeyId: int(news.UpdatedByApiKeyID.Int32),
+		Status:            news.Status,
+		CommentsCount:     int(news.CommentsCount),
+		Targeting:         news.Targeting.String,
+	}
+	if pinned(news, time.Now()) {
+		data.Pinned = true
+		data.PinnedUntil = timePtr(news.PinnedUntil)
 	}
+
+	if render == content.FormatHTML {
+		var err error
+		data.ContentHtml, err = h.renderer.HTML(news.ContentFormat, news.Content.String)
+		if err != nil {
+			return response.NewsData{}, err
+		}
+	}
+
+	return data, nil
 }
 
 type newsService interface {
 	AddNews(ctx context.Context, params core.AddNewsParams) (int32, error)
 	UpdatNews(ctx context.Context, params core.UpdateNewsParams) error
 	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error)
 	GetAllNews(ctx context.Context) ([]core.News, error)
+	VisibleNews(ctx context.Context, news []core.News) []core.News
+	GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error)
+	GetNewsActivity(ctx context.Context, id int32) (time.Time, error)
 	DeleteNews(ctx context.Context, id int32) error
+	PublishNews(ctx context.Context, id int32) error
+	PinNews(ctx context.Context, id int32, until pgtype.Timestamp) error
+	UnpinNews(ctx context.Context, id int32) error
+	DryRunTargeting(ctx context.Context, id int32, clients []targeting.Client) ([]bool, error)
 }
 
 func (h *NewsHandler) AddNews(ctx *gin.Context) {
@@ -43,11 +132,32 @@ func (h *NewsHandler) AddNews(ctx *gin.Context) {
 		return
 	}
 
+	author, ok := auth.AuthorFromContext(ctx)
 	id, err := h.newsService.AddNews(ctx, core.AddNewsParams{
-		Title:   pgtype.Text{String: pl.Title, Valid: true},
-		Content: pgtype.Text{String: pl.Content, Valid: true},
+		Title:         pgtype.Text{String: pl.Title, Valid: true},
+		Content:       pgtype.Text{String: pl.Content, Valid: true},
+		ContentFormat: pl.ContentFormat,
+		Blocks:        pl.Blocks,
+		Targeting:     pgtype.Text{String: pl.Targeting, Valid: pl.Targeting != ""},
+		AuthorID:      pgtype.Int4{Int32: author.ID, Valid: ok},
 	})
 	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, pkg.ErrEntityAlreadyExists) {
 			ctx.AbortWithStatusJSON(http.StatusConflict, response.Response{
 				Code:  response.EntityAlreadyExists,
@@ -56,6 +166,14 @@ func (h *NewsHandler) AddNews(ctx *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
 			Code:  response.InternalError,
 			Error: pkg.ErrDbInternal.Error(),
@@ -92,12 +210,45 @@ func (h *NewsHandler) UpdateNews(ctx *gin.Context) {
 		return
 	}
 
-	err = h.newsService.UpdatNews(ctx, core.UpdateNewsParams{
-		ID:      int32(uriPayload.Id),
-		Title:   pgtype.Text{String: pl.Title, Valid: true},
-		Content: pgtype.Text{String: pl.Content, Valid: true},
-	})
+	// content is written in the format news have unless it's set, blocks
+	// get the format they default to
+	if pl.ContentFormat == "" && pl.Blocks == nil {
+		var news core.News
+		news, err = h.newsService.GetNewsById(ctx, int32(uriPayload.Id))
+		pl.ContentFormat = news.ContentFormat
+	}
+
+	// news that can't be looked up are answered like a failed update
+	if err == nil {
+		author, ok := auth.AuthorFromContext(ctx)
+		err = h.newsService.UpdatNews(ctx, core.UpdateNewsParams{
+			ID:            int32(uriPayload.Id),
+			Title:         pgtype.Text{String: pl.Title, Valid: true},
+			Content:       pgtype.Text{String: pl.Content, Valid: true},
+			ContentFormat: pl.ContentFormat,
+			Blocks:        pl.Blocks,
+			// a put replaces the news, so a rule left out is cleared
+			Targeting: pgtype.Text{String: pl.Targeting, Valid: true},
+			UpdatedBy: pgtype.Int4{Int32: author.ID, Valid: ok},
+		})
+	}
 	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, pkg.ErrNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
 				Code:  response.NotFound,
@@ -114,6 +265,14 @@ func (h *NewsHandler) UpdateNews(ctx *gin.Context) {
 			return
 		}
 
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
 		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
 			Code:  response.InternalError,
 			Error: pkg.ErrDbInternal.Error(),
@@ -137,6 +296,28 @@ func (h *NewsHandler) GetNewsById(ctx *gin.Context) {
 		return
 	}
 
+	var queryPayload payload.NewsByIdQueryPayload
+	err = ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	client, targeted, err := requestClient(ctx, queryPayload.ClientContextPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+	if targeted {
+		ctx.Request = ctx.Request.WithContext(targeting.WithClient(ctx.Request.Context(), client))
+	}
+
 	news, err := h.newsService.GetNewsById(ctx, int32(uriPayload.Id))
 	if err != nil {
 		if errors.Is(err, pkg.ErrNotFound) {
@@ -154,17 +335,162 @@ func (h *NewsHandler) GetNewsById(ctx *gin.Context) {
 		return
 	}
 
+	// a failed count isn't worth failing the read for
+	err = h.viewService.RecordView(ctx, news.ID, viewerKey(ctx))
+	if err != nil {
+		fmt.Printf("can't record view: [%v]\n", err)
+	}
+
+	activity, err := h.newsService.GetNewsActivity(ctx, news.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+	lastModified := news.UpdatedAt.Time
+	if activity.After(lastModified) {
+		lastModified = activity
+	}
+
+	// news outside the client's audience aren't found, so the answer varies
+	// by the client as well
+	if h.notModified(ctx, newsETag(news, activity, queryPayload.Render), lastModified, clientContextHeaders...) {
+		return
+	}
+
+	// starting and stopping experiments updates the news, so the variants
+	// are fresh as long as the news are
+	applied, variantIds, err := h.experimentService.ApplyVariants(ctx, []core.News{news})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+	news = applied[0]
+
+	data, err := h.newsData(news, queryPayload.Render)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: err.Error(),
+		})
+		return
+	}
+	data.VariantId = int(variantIds[news.ID])
+
+	reactions, err := h.reactionService.GetReactions(ctx, []int32{news.ID})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+	data.Reactions = reactions[news.ID].Counts
+	data.MyReactions = reactions[news.ID].Mine
+
+	readStates, err := h.readStateService.GetReadStates(ctx, []int32{news.ID})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+	setReadState(ctx, &data, readStates[news.ID])
+
+	newsMedia, err := h.mediaService.GetNewsMedia(ctx, news.ID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+	for _, m := range newsMedia {
+		mData, err := mediaData(m, h.mediaService.URL)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+				Code:  response.InternalError,
+				Error: err.Error(),
+			})
+			return
+		}
+		data.Media = append(data.Media, mData)
+	}
+
 	ctx.JSON(http.StatusOK, response.Response{
 		Code: response.Ok,
-		Data: response.NewsData{
-			Id:      int(news.ID),
-			Title:   news.Title.String,
-			Content: news.Content.String,
-		},
+		Data: data,
 	})
 }
 
 func (h *NewsHandler) GetAllNews(ctx *gin.Context) {
+	var queryPayload payload.NewsListQueryPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	paged := queryPayload.First != 0 || queryPayload.After != ""
+	first := queryPayload.First
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	after := firstNewsPage
+	if queryPayload.After != "" {
+		after, err = decodeNewsCursor(queryPayload.After)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+	}
+
+	var page string
+	if paged {
+		page = fmt.Sprintf("%d:%s", first, queryPayload.After)
+	}
+
+	client, targeted, err := requestClient(ctx, queryPayload.ClientContextPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	var audience string
+	if targeted {
+		audience = client.Key()
+		ctx.Request = ctx.Request.WithContext(targeting.WithClient(ctx.Request.Context(), client))
+	}
+
+	stats, err := h.newsService.GetNewsStats(ctx)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	if h.notModified(ctx, newsListETag(stats, page, queryPayload.Render, audience), stats.LastUpdatedAt.Time, clientContextHeaders...) {
+		return
+	}
+
 	news, err := h.newsService.GetAllNews(ctx)
 	if err != nil {
 		if errors.Is(err, pkg.ErrNotFound) {
@@ -182,13 +508,209 @@ func (h *NewsHandler) GetAllNews(ctx *gin.Context) {
 		return
 	}
 
+	now := time.Now()
+	if paged {
+		var hasNextPage bool
+		news, hasNextPage = newsPage(news, after, first, now)
+		if hasNextPage {
+			next := url.Values{
+				"first": {strconv.Itoa(first)},
+				"after": {encodeNewsCursor(newsCursorOf(news[len(news)-1], now))},
+			}
+			// the next page is listed for the same client
+			for _, key := range []string{"locale", "platform", "app_version", "tier", "learning_language"} {
+				if value := ctx.Query(key); value != "" {
+					next.Set(key, value)
+				}
+			}
+			ctx.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, ctx.Request.URL.Path, next.Encode()))
+		}
+	} else {
+		sortNews(news, now)
+	}
+
+	resultData, err := h.newsListData(ctx, news, queryPayload.Render)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+// GetPopularNews isn't conditional, the ranking changes as time passes
+// without any news changing.
+func (h *NewsHandler) GetPopularNews(ctx *gin.Context) {
+	var queryPayload payload.PopularNewsQueryPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	window := queryPayload.Window
+	if window == "" {
+		window = defaultPopularWindow
+	}
+	first := queryPayload.First
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	news, err := h.viewService.GetPopularNews(ctx, window, first)
+	if err != nil {
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	// the rules of targeted news are for the ones who may edit them
+	news = h.newsService.VisibleNews(ctx, news)
+	resultData, err := h.newsListData(ctx, news, queryPayload.Render)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+// newsListData is newsData of every news along with their reactions and
+// read state, with the variants of running experiments applied.
+func (h *NewsHandler) newsListData(ctx context.Context, news []core.News, render string) ([]response.NewsData, error) {
+	news, variantIds, err := h.experimentService.ApplyVariants(ctx, news)
+	if err != nil {
+		return nil, pkg.ErrDbInternal
+	}
+
+	newsIds := []int32{}
+	for _, v := range news {
+		newsIds = append(newsIds, v.ID)
+	}
+	reactions, err := h.reactionService.GetReactions(ctx, newsIds)
+	if err != nil {
+		return nil, pkg.ErrDbInternal
+	}
+	readStates, err := h.readStateService.GetReadStates(ctx, newsIds)
+	if err != nil {
+		return nil, pkg.ErrDbInternal
+	}
+
 	resultData := []response.NewsData{}
 	for _, v := range news {
-		resultData = append(resultData, response.NewsData{
-			Id:      int(v.ID),
-			Title:   v.Title.String,
-			Content: v.Content.String,
+		data, err := h.newsData(v, render)
+		if err != nil {
+			return nil, err
+		}
+		data.Reactions = reactions[v.ID].Counts
+		data.MyReactions = reactions[v.ID].Mine
+		data.VariantId = int(variantIds[v.ID])
+		setReadState(ctx, &data, readStates[v.ID])
+		resultData = append(resultData, data)
+	}
+
+	return resultData, nil
+}
+
+// setReadState sets the read state of news for authenticated authors, for
+// the others there is none.
+func setReadState(ctx context.Context, data *response.NewsData, state service.ReadState) {
+	if _, ok := auth.AuthorFromContext(ctx); !ok {
+		return
+	}
+
+	data.IsRead = &state.IsRead
+	data.IsBookmarked = &state.IsBookmarked
+}
+
+// GetBookmarks isn't conditional either, it's the author's own list.
+func (h *NewsHandler) GetBookmarks(ctx *gin.Context) {
+	var queryPayload payload.BookmarksQueryPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
 		})
+		return
+	}
+
+	first := queryPayload.First
+	if first == 0 {
+		first = defaultPageSize
+	}
+
+	var before int32
+	if queryPayload.After != "" {
+		before, err = decodeBookmarkCursor(queryPayload.After)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+	}
+
+	news, hasNextPage, err := h.readStateService.GetBookmarks(ctx, before, first)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	if hasNextPage {
+		next := url.Values{
+			"first": {strconv.Itoa(first)},
+			"after": {encodeBookmarkCursor(news[len(news)-1].ID)},
+		}
+		ctx.Header("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, ctx.Request.URL.Path, next.Encode()))
+	}
+
+	// the cursor is taken before filtering, so pages don't shift
+	news = h.newsService.VisibleNews(ctx, news)
+	resultData, err := h.newsListData(ctx, news, queryPayload.Render)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: err.Error(),
+		})
+		return
 	}
 
 	ctx.JSON(http.StatusOK, response.Response{
@@ -210,6 +732,22 @@ func (h *NewsHandler) DeleteNews(ctx *gin.Context) {
 
 	err = h.newsService.DeleteNews(ctx, int32(uriPayload.Id))
 	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
 		if errors.Is(err, pkg.ErrEntityAlreadyDeleted) {
 			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
 				Code:  response.NotFound,
@@ -229,3 +767,52 @@ func (h *NewsHandler) DeleteNews(ctx *gin.Context) {
 		Code: response.Ok,
 	})
 }
+
+func (h *NewsHandler) PublishNews(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.newsService.PublishNews(ctx, int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
@@ -0,0 +1,193 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/events"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval keeps idle streams from being closed by proxies.
+const heartbeatInterval = 15 * time.Second
+
+type NewsEventHandler struct {
+	newsEventBroker newsEventBroker
+	newsFilter      newsFilter
+}
+
+func NewNewsEventHandler(newsEventBroker newsEventBroker, newsFilter newsFilter) *NewsEventHandler {
+	return &NewsEventHandler{
+		newsEventBroker: newsEventBroker,
+		newsFilter:      newsFilter,
+	}
+}
+
+type newsEventBroker interface {
+	Subscribe() (<-chan events.Event, func())
+	EventsAfter(ctx context.Context, id int64) ([]events.Event, error)
+}
+
+type newsFilter interface {
+	VisibleNews(ctx context.Context, news []core.News) []core.News
+}
+
+// visible reports whether the subscriber may read the news of the event.
+// Deletes are always sent, they carry nothing but the id.
+func (h *NewsEventHandler) visible(ctx *gin.Context, event events.Event) bool {
+	if event.Type == events.TypeDeleted {
+		return true
+	}
+
+	return len(h.newsFilter.VisibleNews(ctx, []core.News{event.News})) > 0
+}
+
+func (h *NewsEventHandler) StreamNewsEvents(ctx *gin.Context) {
+	var queryPayload payload.ClientContextPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	client, targeted, err := requestClient(ctx, queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+	if targeted {
+		ctx.Request = ctx.Request.WithContext(targeting.WithClient(ctx.Request.Context(), client))
+	}
+
+	var lastEventId int64
+	resume := ctx.GetHeader("Last-Event-ID")
+	if resume != "" {
+		id, err := strconv.ParseInt(resume, 10, 64)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: fmt.Errorf("%w: [invalid Last-Event-ID]", pkg.ErrInvalidPayload).Error(),
+			})
+			return
+		}
+		lastEventId = id
+	}
+
+	// subscribe before replaying, so events recorded meanwhile aren't missed
+	subscription, unsubscribe := h.newsEventBroker.Subscribe()
+	defer unsubscribe()
+
+	var missed []events.Event
+	if resume != "" {
+		missed, err = h.newsEventBroker.EventsAfter(ctx, lastEventId)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+				Code:  response.InternalError,
+				Error: pkg.ErrDbInternal.Error(),
+			})
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	for len(missed) > 0 {
+		for _, event := range missed {
+			if h.visible(ctx, event) {
+				err := writeNewsEvent(ctx, event)
+				if err != nil {
+					return
+				}
+			}
+			lastEventId = event.ID
+		}
+
+		var err error
+		missed, err = h.newsEventBroker.EventsAfter(ctx, lastEventId)
+		if err != nil {
+			// the client reconnects and resumes from the last event it got
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-subscription:
+			if !ok {
+				// dropped for falling behind, the client resumes from the log
+				return
+			}
+
+			// already sent while replaying
+			if event.ID <= lastEventId {
+				continue
+			}
+
+			if h.visible(ctx, event) {
+				err := writeNewsEvent(ctx, event)
+				if err != nil {
+					return
+				}
+			}
+			lastEventId = event.ID
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+func writeNewsEvent(ctx *gin.Context, event events.Event) error {
+	data, err := json.Marshal(response.NewsData{
+		Id:                int(event.News.ID),
+		Title:             event.News.Title.String,
+		Content:           event.News.Content.String,
+		ContentFormat:     event.News.ContentFormat,
+		Blocks:            event.News.Blocks,
+		AuthorId:          int(event.News.AuthorID.Int32),
+		UpdatedBy:         int(event.News.UpdatedBy.Int32),
+		ApiKeyId:          int(event.News.ApiKeyID.Int32),
+		UpdatedByApiKeyId: int(event.News.UpdatedByApiKeyID.Int32),
+		Status:            event.News.Status,
+		Targeting:         event.News.Targeting.String,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	if err != nil {
+		return err
+	}
+
+	ctx.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/events"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type newsEventBrokerMock struct {
+	Log                    []events.Event
+	Published              []events.Event
+	ErrEventsAfterToReturn error
+}
+
+// Subscribe hands out the published events and closes the stream right
+// after, as if the subscriber fell behind.
+func (m *newsEventBrokerMock) Subscribe() (<-chan events.Event, func()) {
+	subscription := make(chan events.Event, len(m.Published))
+	for _, event := range m.Published {
+		subscription <- event
+	}
+	close(subscription)
+
+	return subscription, func() {}
+}
+
+func (m *newsEventBrokerMock) EventsAfter(ctx context.Context, id int64) ([]events.Event, error) {
+	if m.ErrEventsAfterToReturn != nil {
+		return nil, m.ErrEventsAfterToReturn
+	}
+
+	result := []events.Event{}
+	for _, event := range m.Log {
+		if event.ID > id {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func newsEvent(id int64, eventType string) events.Event {
+	return events.Event{
+		ID:   id,
+		Type: eventType,
+		News: core.News{
+			ID:    1,
+			Title: pgtype.Text{String: "some title", Valid: true},
+		},
+	}
+}
+
+func draftNewsEvent(id int64, eventType string) events.Event {
+	event := newsEvent(id, eventType)
+	event.News.Status = "draft"
+	return event
+}
+
+func TestStreamNewsEvents(t *testing.T) {
+	log := []events.Event{
+		newsEvent(1, events.TypeCreated),
+		newsEvent(2, events.TypeUpdated),
+		newsEvent(3, events.TypePublished),
+	}
+
+	testTable := []struct {
+		Name                    string
+		LastEventId             string
+		Published               []events.Event
+		ErrorBrokerShouldReturn error
+		ExpectedIds             []string
+		ExpectedStatusCode      int
+	}{
+		{
+			Name:               "Ok live events",
+			Published:          []events.Event{newsEvent(4, events.TypeDeleted)},
+			ExpectedIds:        []string{"4"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok resume skips replayed events",
+			LastEventId:        "1",
+			Published:          []events.Event{newsEvent(3, events.TypePublished), newsEvent(4, events.TypeDeleted)},
+			ExpectedIds:        []string{"2", "3", "4"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok hidden news are skipped",
+			LastEventId:        "2",
+			Published:          []events.Event{draftNewsEvent(4, events.TypeUpdated), newsEvent(5, events.TypeDeleted)},
+			ExpectedIds:        []string{"3", "5"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid last event id",
+			LastEventId:        "latest",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                    "Error db internal",
+			LastEventId:             "1",
+			ErrorBrokerShouldReturn: pkg.ErrDbInternal,
+			ExpectedStatusCode:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsEventBrokerInstance.Log = log
+			newsEventBrokerInstance.Published = testCase.Published
+			newsEventBrokerInstance.ErrEventsAfterToReturn = testCase.ErrorBrokerShouldReturn
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/posts/events", nil)
+			if testCase.LastEventId != "" {
+				r.Header.Set("Last-Event-ID", testCase.LastEventId)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+			body, _ := io.ReadAll(resp.Body)
+			ids := []string{}
+			for _, line := range strings.Split(string(body), "\n") {
+				if id, ok := strings.CutPrefix(line, "id: "); ok {
+					ids = append(ids, id)
+				}
+			}
+			assert.Equal(t, ids, testCase.ExpectedIds)
+			assert.Equal(t, strings.Contains(string(body), `data: {"id":1,"title":"some title","content":"","content_format":"","status":"","comments_count":0}`), true)
+		})
+	}
+}
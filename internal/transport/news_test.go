@@ -4,27 +4,56 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/anton-uvarenko/promova_test/internal/authz"
 	"github.com/anton-uvarenko/promova_test/internal/core"
 	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/openapi"
 	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/ratelimit"
 	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
 	"github.com/anton-uvarenko/promova_test/internal/pkg/server"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
 	"github.com/go-playground/assert/v2"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type newsServiceMock struct {
-	ErrAddNewsToReturn     error
-	ErrUpdateNewsToReturn  error
-	ErrGetNewsByIdToReturn error
-	ErrGetAllNewsToReturn  error
-	ErrDeleteNewsToReturn  error
+	ErrAddNewsToReturn      error
+	ErrUpdateNewsToReturn   error
+	ErrGetNewsByIdToReturn  error
+	ErrGetAllNewsToReturn   error
+	ErrGetNewsStatsToReturn error
+	ErrDeleteNewsToReturn   error
+	ErrPublishNewsToReturn  error
+	ErrDryRunToReturn       error
+	ErrPinNewsToReturn      error
+
+	// PinnedUntil is what news were last pinned until
+	PinnedUntil pgtype.Timestamp
+	// Updated is what news were last updated with
+	Updated core.UpdateNewsParams
+
+	// AllNewsToReturn replaces the news GetAllNews returns when set
+	AllNewsToReturn []core.News
+	// Activity is when what's served along with news last changed
+	Activity time.Time
+	// Client is the client news were last asked for, nil for none
+	Client *targeting.Client
+	// GetNewsByIdsCalls is how many batches of news were read
+	GetNewsByIdsCalls int
 }
 
+var newsUpdatedAt = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
 func (m *newsServiceMock) AddNews(ctx context.Context, params core.AddNewsParams) (int32, error) {
 	if m.ErrAddNewsToReturn != nil {
 		return 0, m.ErrAddNewsToReturn
@@ -33,40 +62,101 @@ func (m *newsServiceMock) AddNews(ctx context.Context, params core.AddNewsParams
 }
 
 func (m *newsServiceMock) UpdatNews(ctx context.Context, params core.UpdateNewsParams) error {
-	if m.ErrAddNewsToReturn != nil {
+	if m.ErrUpdateNewsToReturn != nil {
 		return m.ErrUpdateNewsToReturn
 	}
 
+	m.Updated = params
 	return nil
 }
 
 func (m *newsServiceMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	m.Client = nil
+	if client, ok := targeting.ClientFromContext(ctx); ok {
+		m.Client = &client
+	}
 	if m.ErrGetNewsByIdToReturn != nil {
 		return core.News{}, m.ErrGetNewsByIdToReturn
 	}
 
 	return core.News{
-		ID:        id,
-		Title:     pgtype.Text{String: "some title", Valid: true},
-		Content:   pgtype.Text{String: "some content", Valid: true},
-		CreatedAt: pgtype.Timestamp{},
+		ID:            id,
+		Title:         pgtype.Text{String: "some title", Valid: true},
+		Content:       pgtype.Text{String: "some content", Valid: true},
+		ContentFormat: "markdown",
+		Blocks:        []byte(`{"version":1,"blocks":[{"type":"paragraph","text":"some content"}]}`),
+		Status:        "draft",
+		CreatedAt:     pgtype.Timestamp{},
+		UpdatedAt:     pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
 	}, nil
 }
 
+// GetNewsByIds leaves out the news GetNewsById doesn't find.
+func (m *newsServiceMock) GetNewsByIds(ctx context.Context, ids []int32) ([]core.News, error) {
+	m.GetNewsByIdsCalls++
+	var news []core.News
+	for _, id := range ids {
+		n, err := m.GetNewsById(ctx, id)
+		if err != nil {
+			if errors.Is(err, pkg.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		news = append(news, n)
+	}
+	return news, nil
+}
+
 func (m *newsServiceMock) GetAllNews(ctx context.Context) ([]core.News, error) {
+	m.Client = nil
+	if client, ok := targeting.ClientFromContext(ctx); ok {
+		m.Client = &client
+	}
 	if m.ErrGetAllNewsToReturn != nil {
 		return nil, m.ErrGetAllNewsToReturn
 	}
+	if m.AllNewsToReturn != nil {
+		return append([]core.News{}, m.AllNewsToReturn...), nil
+	}
 
 	return []core.News{
 		{
-			ID:      1,
-			Title:   pgtype.Text{String: "some title", Valid: true},
-			Content: pgtype.Text{String: "some content", Valid: true},
+			ID:            1,
+			Title:         pgtype.Text{String: "some title", Valid: true},
+			Content:       pgtype.Text{String: "some content", Valid: true},
+			ContentFormat: "plain",
+			Status:        "draft",
 		},
 	}, nil
 }
 
+// VisibleNews hides drafts, the way they're hidden from anonymous readers.
+func (m *newsServiceMock) VisibleNews(ctx context.Context, news []core.News) []core.News {
+	visible := []core.News{}
+	for _, n := range news {
+		if n.Status != "draft" {
+			visible = append(visible, n)
+		}
+	}
+	return visible
+}
+
+func (m *newsServiceMock) GetNewsStats(ctx context.Context) (core.GetNewsStatsRow, error) {
+	if m.ErrGetNewsStatsToReturn != nil {
+		return core.GetNewsStatsRow{}, m.ErrGetNewsStatsToReturn
+	}
+
+	return core.GetNewsStatsRow{
+		Count:         1,
+		LastUpdatedAt: pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+	}, nil
+}
+
+func (m *newsServiceMock) GetNewsActivity(ctx context.Context, id int32) (time.Time, error) {
+	return m.Activity, nil
+}
+
 func (m *newsServiceMock) DeleteNews(ctx context.Context, id int32) error {
 	if m.ErrDeleteNewsToReturn != nil {
 		return m.ErrDeleteNewsToReturn
@@ -75,30 +165,154 @@ func (m *newsServiceMock) DeleteNews(ctx context.Context, id int32) error {
 	return nil
 }
 
+func (m *newsServiceMock) PublishNews(ctx context.Context, id int32) error {
+	if m.ErrPublishNewsToReturn != nil {
+		return m.ErrPublishNewsToReturn
+	}
+
+	return nil
+}
+
+func (m *newsServiceMock) PinNews(ctx context.Context, id int32, until pgtype.Timestamp) error {
+	if m.ErrPinNewsToReturn != nil {
+		return m.ErrPinNewsToReturn
+	}
+
+	m.PinnedUntil = until
+	return nil
+}
+
+func (m *newsServiceMock) UnpinNews(ctx context.Context, id int32) error {
+	if m.ErrPinNewsToReturn != nil {
+		return m.ErrPinNewsToReturn
+	}
+
+	return nil
+}
+
+// DryRunTargeting shows news to ios clients only.
+func (m *newsServiceMock) DryRunTargeting(ctx context.Context, id int32, clients []targeting.Client) ([]bool, error) {
+	if m.ErrDryRunToReturn != nil {
+		return nil, m.ErrDryRunToReturn
+	}
+
+	shown := make([]bool, 0, len(clients))
+	for _, client := range clients {
+		shown = append(shown, client.Platform == targeting.PlatformIOS)
+	}
+	return shown, nil
+}
+
 type AddNewsResponse struct {
 	Code int                  `json:"code"`
 	Data response.AddNewsData `json:"data"`
 }
 
 var (
-	httpServer          http.Server
-	newsServiceInstance *newsServiceMock
+	httpServer                http.Server
+	router                    http.Handler
+	newsServiceInstance       *newsServiceMock
+	newsEventBrokerInstance   *newsEventBrokerMock
+	apiKeyServiceInstance     *apiKeyServiceMock
+	webhookServiceInstance    *webhookServiceMock
+	syncServiceInstance       *syncServiceMock
+	mediaServiceInstance      *mediaServiceMock
+	commentServiceInstance    *commentServiceMock
+	reactionServiceInstance   *reactionServiceMock
+	viewServiceInstance       *viewServiceMock
+	readStateServiceInstance  *readStateServiceMock
+	experimentServiceInstance *experimentServiceMock
+	featuredServiceInstance   *featuredServiceMock
+	authorServiceInstance     *authorServiceMock
+	authToken                 string
 )
 
 func TestMain(m *testing.M) {
+	keyset := auth.NewKeyset("test", auth.NewHMACKey("test", []byte("test secret")))
+	authToken, _ = keyset.Sign(auth.Author{ID: 1, Name: "test author", Role: authz.RoleAdmin}, time.Hour)
+
 	newsServiceInstance = &newsServiceMock{}
-	handler := NewHandler(newsServiceInstance)
-	router := server.SetUpRoutes(handler.NewsHandler)
+	apiKeyServiceInstance = &apiKeyServiceMock{}
+	newsEventBrokerInstance = &newsEventBrokerMock{}
+	webhookServiceInstance = &webhookServiceMock{}
+	syncServiceInstance = &syncServiceMock{}
+	mediaServiceInstance = &mediaServiceMock{}
+	commentServiceInstance = &commentServiceMock{}
+	reactionServiceInstance = &reactionServiceMock{}
+	viewServiceInstance = &viewServiceMock{}
+	readStateServiceInstance = &readStateServiceMock{}
+	experimentServiceInstance = &experimentServiceMock{}
+	featuredServiceInstance = &featuredServiceMock{}
+	authorServiceInstance = &authorServiceMock{}
+	router = newRouter(keyset, ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.SystemClock{}, ratelimit.PerSecond(1000)))
 	httpServer := server.NewServer(router, "8081")
-	go httpServer.ListenAndServe()
+
+	// listen before running tests so the first request doesn't race the server start
+	listener, err := net.Listen("tcp", httpServer.Addr)
+	if err != nil {
+		panic(err)
+	}
+	go httpServer.Serve(listener)
 
 	m.Run()
 }
 
+// newRouter routes to the service mocks, tests limiting clients themselves
+// pass their own limiter.
+func newRouter(keyset *auth.Keyset, limiter *ratelimit.Limiter) http.Handler {
+	handler := NewHandler(
+		newsServiceInstance,
+		newsEventBrokerInstance,
+		apiKeyServiceInstance,
+		webhookServiceInstance,
+		syncServiceInstance,
+		mediaServiceInstance,
+		commentServiceInstance,
+		reactionServiceInstance,
+		viewServiceInstance,
+		readStateServiceInstance,
+		experimentServiceInstance,
+		featuredServiceInstance,
+		authorServiceInstance,
+		"",
+	)
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		panic(err)
+	}
+	router, err := server.SetUpRoutes(
+		handler.NewsHandler,
+		handler.NewsEventHandler,
+		handler.SyncHandler,
+		handler.ApiKeyHandler,
+		handler.WebhookHandler,
+		handler.GraphqlHandler,
+		handler.OpenapiHandler,
+		handler.MediaHandler,
+		nil,
+		handler.CommentHandler,
+		handler.ReactionHandler,
+		handler.ViewHandler,
+		handler.ReadStateHandler,
+		handler.ExperimentHandler,
+		auth.Middleware(keyset, apiKeyServiceInstance),
+		auth.OptionalMiddleware(keyset, apiKeyServiceInstance),
+		ratelimit.Middleware(limiter),
+		validator.Middleware(),
+		nil,
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return router
+}
+
 func TestAddNews(t *testing.T) {
 	testTable := []struct {
 		Name                     string
 		RequestPayload           any
+		WithoutToken             bool
 		ErrorServiceShouldReturn error
 		ExpectedResult           AddNewsResponse
 		ExpectedStatusCode       int
@@ -118,6 +332,18 @@ func TestAddNews(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusOK,
 		},
+		{
+			Name: "Unauthorized",
+			RequestPayload: payload.AddNewsPayload{
+				Title:   "some title",
+				Content: "some content",
+			},
+			WithoutToken: true,
+			ExpectedResult: AddNewsResponse{
+				Code: response.Unauthorized,
+			},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
 		{
 			Name:                     "Decode error",
 			RequestPayload:           "some string that fail decoding",
@@ -139,6 +365,69 @@ func TestAddNews(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			Name: "Unknown content format",
+			RequestPayload: payload.AddNewsPayload{
+				Title:         "some title",
+				Content:       "some content",
+				ContentFormat: "rtf",
+			},
+			ErrorServiceShouldReturn: nil,
+			ExpectedResult: AddNewsResponse{
+				Code: response.InvalidPayload,
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Ok blocks",
+			RequestPayload: payload.AddNewsPayload{
+				Title:  "some title",
+				Blocks: json.RawMessage(`{"version": 1, "blocks": [{"type": "lesson", "lesson_id": 42}]}`),
+			},
+			ErrorServiceShouldReturn: nil,
+			ExpectedResult: AddNewsResponse{
+				Code: response.Ok,
+				Data: response.AddNewsData{
+					Id: 1,
+				},
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name: "Neither content nor blocks",
+			RequestPayload: payload.AddNewsPayload{
+				Title: "some title",
+			},
+			ErrorServiceShouldReturn: nil,
+			ExpectedResult: AddNewsResponse{
+				Code: response.InvalidPayload,
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Invalid blocks",
+			RequestPayload: payload.AddNewsPayload{
+				Title:  "some title",
+				Blocks: json.RawMessage(`{"version": 1, "blocks": [{"type": "paragraph", "text": "some\n\ntext"}]}`),
+			},
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedResult: AddNewsResponse{
+				Code: response.InvalidPayload,
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Forbidden",
+			RequestPayload: payload.AddNewsPayload{
+				Title:   "some title",
+				Content: "some content",
+			},
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult: AddNewsResponse{
+				Code: response.Forbidden,
+			},
+			ExpectedStatusCode: http.StatusForbidden,
+		},
 		{
 			Name: "Entity already exist",
 			RequestPayload: payload.AddNewsPayload{
@@ -171,6 +460,9 @@ func TestAddNews(t *testing.T) {
 			pl, _ := json.Marshal(testCase.RequestPayload)
 
 			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/posts", bytes.NewBuffer(pl))
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
 			resp, _ := http.DefaultClient.Do(r)
 
 			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
@@ -198,6 +490,7 @@ func TestUpdateNews(t *testing.T) {
 		Name                     string
 		RequestPayload           any
 		UriParam                 string
+		WithoutToken             bool
 		ErrorServiceShouldReturn error
 		ExpectedResult           response.Response
 		ExpectedStatusCode       int
@@ -215,6 +508,19 @@ func TestUpdateNews(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusOK,
 		},
+		{
+			Name: "Unauthorized",
+			RequestPayload: payload.UpdateNewsPayload{
+				Title:   "some title",
+				Content: "some content",
+			},
+			UriParam:     "1",
+			WithoutToken: true,
+			ExpectedResult: response.Response{
+				Code: response.Unauthorized,
+			},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
 		{
 			Name:                     "Error decode payload",
 			RequestPayload:           "adsfadsf",
@@ -264,6 +570,19 @@ func TestUpdateNews(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusNotFound,
 		},
+		{
+			Name: "Error forbidden",
+			RequestPayload: payload.UpdateNewsPayload{
+				Title:   "some title",
+				Content: "some content",
+			},
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult: response.Response{
+				Code: response.Forbidden,
+			},
+			ExpectedStatusCode: http.StatusForbidden,
+		},
 		{
 			Name: "Error entity already exists",
 			RequestPayload: payload.UpdateNewsPayload{
@@ -298,6 +617,9 @@ func TestUpdateNews(t *testing.T) {
 			pl, _ := json.Marshal(testCase.RequestPayload)
 
 			r, _ := http.NewRequest(http.MethodPut, "http://localhost:8081/posts/"+testCase.UriParam, bytes.NewBuffer(pl))
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
 			resp, _ := http.DefaultClient.Do(r)
 
 			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
@@ -315,6 +637,60 @@ func TestUpdateNews(t *testing.T) {
 	}
 }
 
+func TestUpdateNewsContentFormat(t *testing.T) {
+	testTable := []struct {
+		Name                   string
+		RequestPayload         payload.UpdateNewsPayload
+		ErrGetNewsByIdToReturn error
+		ExpectedStatusCode     int
+		ExpectedContentFormat  string
+		ExpectedBlocks         []byte
+	}{
+		{
+			Name:                  "Kept when missing",
+			RequestPayload:        payload.UpdateNewsPayload{Title: "some title", Content: "some *content*"},
+			ExpectedStatusCode:    http.StatusOK,
+			ExpectedContentFormat: "markdown",
+		},
+		{
+			Name:                  "Set",
+			RequestPayload:        payload.UpdateNewsPayload{Title: "some title", Content: "some content", ContentFormat: "plain"},
+			ExpectedStatusCode:    http.StatusOK,
+			ExpectedContentFormat: "plain",
+		},
+		{
+			Name:               "Blocks",
+			RequestPayload:     payload.UpdateNewsPayload{Title: "some title", Blocks: []byte(`{"version":1,"blocks":[]}`)},
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBlocks:     []byte(`{"version":1,"blocks":[]}`),
+		},
+		{
+			Name:                   "News not found",
+			RequestPayload:         payload.UpdateNewsPayload{Title: "some title", Content: "some content"},
+			ErrGetNewsByIdToReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:     http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrUpdateNewsToReturn = nil
+			newsServiceInstance.ErrGetNewsByIdToReturn = testCase.ErrGetNewsByIdToReturn
+			newsServiceInstance.Updated = core.UpdateNewsParams{}
+			defer func() { newsServiceInstance.ErrGetNewsByIdToReturn = nil }()
+			pl, _ := json.Marshal(testCase.RequestPayload)
+
+			r, _ := http.NewRequest(http.MethodPut, "http://localhost:8081/posts/2", bytes.NewBuffer(pl))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, newsServiceInstance.Updated.ContentFormat, testCase.ExpectedContentFormat)
+			assert.Equal(t, []byte(newsServiceInstance.Updated.Blocks), testCase.ExpectedBlocks)
+		})
+	}
+}
+
 type GetNewsByIdResponse struct {
 	Code int               `json:"code"`
 	Data response.NewsData `json:"data"`
@@ -335,13 +711,43 @@ func TestGetNewsById(t *testing.T) {
 			ExpectedResult: GetNewsByIdResponse{
 				Code: response.Ok,
 				Data: response.NewsData{
-					Id:      1,
-					Title:   "some title",
-					Content: "some content",
+					Id:            1,
+					Title:         "some title",
+					Content:       "some content",
+					ContentFormat: "markdown",
+					Blocks:        json.RawMessage(`{"version":1,"blocks":[{"type":"paragraph","text":"some content"}]}`),
+					Media:         []response.MediaData{someMediaData},
+				},
+			},
+			ExpectedStatusCode: 200,
+		},
+		{
+			Name:                     "Ok rendered",
+			UriParam:                 "1?render=html",
+			ErrorServiceShouldReturn: nil,
+			ExpectedResult: GetNewsByIdResponse{
+				Code: response.Ok,
+				Data: response.NewsData{
+					Id:            1,
+					Title:         "some title",
+					Content:       "some content",
+					ContentFormat: "markdown",
+					ContentHtml:   "<p>some content</p>\n",
+					Blocks:        json.RawMessage(`{"version":1,"blocks":[{"type":"paragraph","text":"some content"}]}`),
+					Media:         []response.MediaData{someMediaData},
 				},
 			},
 			ExpectedStatusCode: 200,
 		},
+		{
+			Name:                     "Invalid render",
+			UriParam:                 "1?render=pdf",
+			ErrorServiceShouldReturn: nil,
+			ExpectedResult: GetNewsByIdResponse{
+				Code: response.InvalidPayload,
+			},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			Name:                     "Invalid uri param",
 			UriParam:                 "adsfasd",
@@ -392,6 +798,10 @@ func TestGetNewsById(t *testing.T) {
 			assert.Equal(t, respResult.Data.Id, testCase.ExpectedResult.Data.Id)
 			assert.Equal(t, respResult.Data.Title, testCase.ExpectedResult.Data.Title)
 			assert.Equal(t, respResult.Data.Content, testCase.ExpectedResult.Data.Content)
+			assert.Equal(t, respResult.Data.ContentFormat, testCase.ExpectedResult.Data.ContentFormat)
+			assert.Equal(t, respResult.Data.ContentHtml, testCase.ExpectedResult.Data.ContentHtml)
+			assert.Equal(t, string(respResult.Data.Blocks), string(testCase.ExpectedResult.Data.Blocks))
+			assert.Equal(t, respResult.Data.Media, testCase.ExpectedResult.Data.Media)
 		})
 	}
 }
@@ -468,10 +878,92 @@ func TestGetAllNews(t *testing.T) {
 	}
 }
 
+func TestGetAllNewsPaged(t *testing.T) {
+	allNews := []core.News{
+		{ID: 3, ContentFormat: "plain", Status: "draft"},
+		{ID: 1, ContentFormat: "plain", Status: "draft"},
+		{ID: 2, ContentFormat: "plain", Status: "published"},
+	}
+
+	testTable := []struct {
+		Name               string
+		Query              string
+		ExpectedIds        []int
+		ExpectedLink       string
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "Ok first page",
+			Query:              "first=2",
+			ExpectedIds:        []int{1, 2},
+			ExpectedLink:       `</posts?after=` + encodeNewsCursor(newsCursor{id: 2}) + `&first=2>; rel="next"`,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok last page",
+			Query:              "first=2&after=" + encodeNewsCursor(newsCursor{id: 2}),
+			ExpectedIds:        []int{3},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok default page size",
+			Query:              "after=" + encodeNewsCursor(newsCursor{id: 1}),
+			ExpectedIds:        []int{2, 3},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Error page too big",
+			Query:              "first=101",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Error invalid cursor",
+			Query:              "after=invalid",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	newsServiceInstance.ErrGetAllNewsToReturn = nil
+	newsServiceInstance.AllNewsToReturn = allNews
+	defer func() {
+		newsServiceInstance.AllNewsToReturn = nil
+	}()
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/posts?"+testCase.Query, nil)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, resp.Header.Get("Link"), testCase.ExpectedLink)
+
+			var respResult GetAllNewsResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				assert.Equal(t, respResult.Code, response.InvalidPayload)
+				return
+			}
+
+			ids := []int{}
+			for _, news := range respResult.Data {
+				ids = append(ids, news.Id)
+			}
+			assert.Equal(t, ids, testCase.ExpectedIds)
+		})
+	}
+}
+
 func TestDeleteNews(t *testing.T) {
 	testTable := []struct {
 		Name                     string
 		UriParam                 string
+		WithoutToken             bool
 		ErrorServiceShouldReturn error
 		ExpectedResult           response.Response
 		ExpectedStatusCode       int
@@ -485,6 +977,15 @@ func TestDeleteNews(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusOK,
 		},
+		{
+			Name:         "Unauthorized",
+			UriParam:     "1",
+			WithoutToken: true,
+			ExpectedResult: response.Response{
+				Code: response.Unauthorized,
+			},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
 		{
 			Name:                     "Err invalied payload",
 			UriParam:                 "adfasdf",
@@ -494,6 +995,13 @@ func TestDeleteNews(t *testing.T) {
 			},
 			ExpectedStatusCode: http.StatusBadRequest,
 		},
+		{
+			Name:                     "Err forbidden",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult:           response.Response{Code: response.Forbidden},
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
 		{
 			Name:                     "Err entity already deleted",
 			UriParam:                 "1",
@@ -517,6 +1025,9 @@ func TestDeleteNews(t *testing.T) {
 			newsServiceInstance.ErrDeleteNewsToReturn = testCase.ErrorServiceShouldReturn
 
 			r, _ := http.NewRequest(http.MethodDelete, "http://localhost:8081/posts/"+testCase.UriParam, nil)
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
 			resp, _ := http.DefaultClient.Do(r)
 
 			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
@@ -533,3 +1044,101 @@ func TestDeleteNews(t *testing.T) {
 		})
 	}
 }
+
+func TestPublishNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedResult           response.Response
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			UriParam:           "1",
+			ExpectedResult:     response.Response{Code: response.Ok},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Unauthorized",
+			UriParam:           "1",
+			WithoutToken:       true,
+			ExpectedResult:     response.Response{Code: response.Unauthorized},
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:               "Invalid uri param",
+			UriParam:           "abc",
+			ExpectedResult:     response.Response{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Forbidden",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult:           response.Response{Code: response.Forbidden},
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "Not found",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedResult:           response.Response{Code: response.NotFound},
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Internal error",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedResult:           response.Response{Code: response.InternalError},
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrPublishNewsToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/posts/"+testCase.UriParam+"/publish", nil)
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedResult.Code)
+		})
+	}
+}
+
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	newsServiceInstance.ErrGetNewsStatsToReturn = nil
+	newsServiceInstance.ErrGetAllNewsToReturn = nil
+	reactionServiceInstance.ErrGetReactionsReturn = nil
+	readStateServiceInstance.ErrGetUpdatedAtToReturn = nil
+	keyset := auth.NewKeyset("test", auth.NewHMACKey("test", []byte("test secret")))
+	limited := newRouter(keyset, ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.SystemClock{}, ratelimit.PerMinute(1)))
+
+	get := func(forwardedFor string) int {
+		r := httptest.NewRequest(http.MethodGet, "/posts", nil)
+		r.RemoteAddr = "10.0.0.1:1000"
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, get("203.0.113.1"), http.StatusOK)
+	// a new forwarded address is the same client, in the same bucket
+	assert.Equal(t, get("203.0.113.2"), http.StatusTooManyRequests)
+}
@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/openapi"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// swaggerInitializer replaces the one bundled with Swagger UI, which points
+// at the petstore example.
+const swaggerInitializer = `window.onload = function () {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout",
+  });
+};
+`
+
+type OpenapiHandler struct {
+	docs http.FileSystem
+}
+
+func NewOpenapiHandler() *OpenapiHandler {
+	return &OpenapiHandler{
+		docs: http.FS(swaggerFiles.FS),
+	}
+}
+
+func (h *OpenapiHandler) GetSpec(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json", openapi.Spec)
+}
+
+// GetDocs serves Swagger UI for the spec.
+func (h *OpenapiHandler) GetDocs(ctx *gin.Context) {
+	file := strings.TrimPrefix(ctx.Param("filepath"), "/")
+	switch file {
+	case "", "index.html":
+		// served directly, the file server redirects index.html to the directory
+		index, err := fs.ReadFile(swaggerFiles.FS, "index.html")
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	case "swagger-initializer.js":
+		ctx.Data(http.StatusOK, "text/javascript; charset=utf-8", []byte(swaggerInitializer))
+	default:
+		ctx.FileFromFS(file, h.docs)
+	}
+}
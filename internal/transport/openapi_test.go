@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+// TestOpenapiMatchesRoutes fails when /posts or /me routes are added to or
+// removed from server.SetUpRoutes without updating the spec.
+func TestOpenapiMatchesRoutes(t *testing.T) {
+	validator, err := openapi.NewValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []string
+	for _, route := range router.(*gin.Engine).Routes() {
+		if route.Path != "/posts" && !strings.HasPrefix(route.Path, "/posts/") && !strings.HasPrefix(route.Path, "/me/") {
+			continue
+		}
+		routes = append(routes, openapi.Operation(route.Method, route.Path))
+	}
+	slices.Sort(routes)
+
+	assert.Equal(t, routes, validator.Operations())
+}
+
+func TestOpenapiServesDocs(t *testing.T) {
+	testTable := []struct {
+		Name                string
+		Path                string
+		ExpectedContentType string
+		ExpectedBody        []byte
+	}{
+		{
+			Name:                "Spec",
+			Path:                "/openapi.json",
+			ExpectedContentType: "application/json",
+			ExpectedBody:        openapi.Spec,
+		},
+		{
+			Name:                "Swagger UI",
+			Path:                "/docs/",
+			ExpectedContentType: "text/html; charset=utf-8",
+			ExpectedBody:        []byte("swagger-initializer.js"),
+		},
+		{
+			Name:                "Swagger UI initializer",
+			Path:                "/docs/swagger-initializer.js",
+			ExpectedContentType: "text/javascript; charset=utf-8",
+			ExpectedBody:        []byte(`url: "/openapi.json"`),
+		},
+		{
+			Name:                "Swagger UI assets",
+			Path:                "/docs/swagger-ui.css",
+			ExpectedContentType: "text/css; charset=utf-8",
+			ExpectedBody:        []byte(".swagger-ui"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			resp, err := http.Get("http://localhost:8081" + testCase.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, resp.StatusCode, http.StatusOK)
+			assert.Equal(t, resp.Header.Get("Content-Type"), testCase.ExpectedContentType)
+			assert.Equal(t, bytes.Contains(body, testCase.ExpectedBody), true)
+		})
+	}
+}
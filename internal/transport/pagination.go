@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	newsCursorPrefix       = "news:"
+	pinnedNewsCursorPrefix = "pinned:"
+	commentCursorPrefix    = "comments:"
+	bookmarkCursorPrefix   = "bookmarks:"
+)
+
+// newsCursor is where a page of news ends. Pinned news come before the
+// others, both ordered by id, so the zero cursor of pinned news is before
+// all of them.
+type newsCursor struct {
+	pinned bool
+	id     int32
+}
+
+// firstNewsPage is the cursor of the first page.
+var firstNewsPage = newsCursor{pinned: true}
+
+func newsCursorOf(news core.News, now time.Time) newsCursor {
+	return newsCursor{pinned: pinned(news, now), id: news.ID}
+}
+
+func compareNewsCursors(a, b newsCursor) int {
+	if a.pinned != b.pinned {
+		if a.pinned {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Compare(a.id, b.id)
+}
+
+// sortNews orders news the way pages list them, pinned first. It sorts news
+// in place.
+func sortNews(news []core.News, now time.Time) {
+	slices.SortFunc(news, func(a, b core.News) int {
+		return compareNewsCursors(newsCursorOf(a, now), newsCursorOf(b, now))
+	})
+}
+
+// newsPage returns up to first news after the cursor, ordered by sortNews,
+// and whether there are more. It sorts news in place.
+func newsPage(news []core.News, after newsCursor, first int, now time.Time) ([]core.News, bool) {
+	sortNews(news, now)
+
+	start, _ := slices.BinarySearchFunc(news, after, func(n core.News, cursor newsCursor) int {
+		return compareNewsCursors(newsCursorOf(n, now), cursor)
+	})
+	if start < len(news) && newsCursorOf(news[start], now) == after {
+		start++
+	}
+	end := min(start+first, len(news))
+
+	return news[start:end], end < len(news)
+}
+
+// encodeNewsCursor keeps the cursors of news that aren't pinned what they
+// were before news could be pinned.
+func encodeNewsCursor(cursor newsCursor) string {
+	if cursor.pinned {
+		return encodeCursor(pinnedNewsCursorPrefix, cursor.id)
+	}
+	return encodeCursor(newsCursorPrefix, cursor.id)
+}
+
+func decodeNewsCursor(cursor string) (newsCursor, error) {
+	id, err := decodeCursor(pinnedNewsCursorPrefix, cursor)
+	if err == nil {
+		return newsCursor{pinned: true, id: id}, nil
+	}
+
+	id, err = decodeCursor(newsCursorPrefix, cursor)
+	if err != nil {
+		return newsCursor{}, err
+	}
+	return newsCursor{id: id}, nil
+}
+
+func encodeCommentCursor(id int32) string {
+	return encodeCursor(commentCursorPrefix, id)
+}
+
+func decodeCommentCursor(cursor string) (int32, error) {
+	return decodeCursor(commentCursorPrefix, cursor)
+}
+
+func encodeBookmarkCursor(id int32) string {
+	return encodeCursor(bookmarkCursorPrefix, id)
+}
+
+func decodeBookmarkCursor(cursor string) (int32, error) {
+	return decodeCursor(bookmarkCursorPrefix, cursor)
+}
+
+// encodeCursor keeps cursors opaque, prefix tells cursors of different
+// lists apart.
+func encodeCursor(prefix string, id int32) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(prefix + strconv.Itoa(int(id))))
+}
+
+func decodeCursor(prefix string, cursor string) (int32, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(decoded), prefix) {
+		return 0, fmt.Errorf("%w: [invalid cursor]", pkg.ErrInvalidPayload)
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(string(decoded), prefix), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: [invalid cursor]", pkg.ErrInvalidPayload)
+	}
+
+	return int32(id), nil
+}
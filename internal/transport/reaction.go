@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionHandler struct {
+	reactionService reactionService
+}
+
+func NewReactionHandler(reactionService reactionService) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: reactionService,
+	}
+}
+
+type reactionService interface {
+	AddReaction(ctx context.Context, newsId int32, kind string) error
+	RemoveReaction(ctx context.Context, newsId int32, kind string) error
+	GetReactions(ctx context.Context, newsIds []int32) (map[int32]service.NewsReactions, error)
+}
+
+func (h *ReactionHandler) AddReaction(ctx *gin.Context) {
+	h.react(ctx, h.reactionService.AddReaction)
+}
+
+func (h *ReactionHandler) RemoveReaction(ctx *gin.Context) {
+	h.react(ctx, h.reactionService.RemoveReaction)
+}
+
+func (h *ReactionHandler) react(ctx *gin.Context, react func(ctx context.Context, newsId int32, kind string) error) {
+	var uriPayload payload.ReactionUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = react(ctx, int32(uriPayload.Id), uriPayload.Kind)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		// the kind isn't allowed
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
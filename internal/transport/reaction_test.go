@@ -0,0 +1,199 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+type reactionServiceMock struct {
+	Kind                  string
+	ErrReactToReturn      error
+	ErrGetReactionsReturn error
+}
+
+func (m *reactionServiceMock) AddReaction(ctx context.Context, newsId int32, kind string) error {
+	m.Kind = kind
+	return m.ErrReactToReturn
+}
+
+func (m *reactionServiceMock) RemoveReaction(ctx context.Context, newsId int32, kind string) error {
+	m.Kind = kind
+	return m.ErrReactToReturn
+}
+
+// GetReactions likes every news, the author asking likes them too.
+func (m *reactionServiceMock) GetReactions(ctx context.Context, newsIds []int32) (map[int32]service.NewsReactions, error) {
+	if m.ErrGetReactionsReturn != nil {
+		return nil, m.ErrGetReactionsReturn
+	}
+
+	_, authenticated := auth.AuthorFromContext(ctx)
+	reactions := map[int32]service.NewsReactions{}
+	for _, id := range newsIds {
+		newsReactions := service.NewsReactions{Counts: map[string]int{"like": 2}}
+		if authenticated {
+			newsReactions.Mine = []string{"like"}
+		}
+		reactions[id] = newsReactions
+	}
+	return reactions, nil
+}
+
+func TestReact(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Method                   string
+		Kind                     string
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok add",
+			Method:             http.MethodPut,
+			Kind:               "like",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok remove",
+			Method:             http.MethodDelete,
+			Kind:               "clap",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Unknown kind",
+			Method:                   http.MethodPut,
+			Kind:                     "dislike",
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedCode:             response.InvalidPayload,
+			ExpectedStatusCode:       http.StatusBadRequest,
+		},
+		{
+			Name:               "Without token",
+			Method:             http.MethodPut,
+			Kind:               "like",
+			WithoutToken:       true,
+			ExpectedCode:       response.Unauthorized,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Draft",
+			Method:                   http.MethodPut,
+			Kind:                     "like",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "News not found",
+			Method:                   http.MethodDelete,
+			Kind:                     "like",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			reactionServiceInstance.ErrReactToReturn = testCase.ErrorServiceShouldReturn
+			reactionServiceInstance.Kind = ""
+
+			r, _ := http.NewRequest(testCase.Method, "http://localhost:8081/posts/1/reactions/"+testCase.Kind, nil)
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if testCase.ExpectedStatusCode == http.StatusOK {
+				assert.Equal(t, reactionServiceInstance.Kind, testCase.Kind)
+			}
+		})
+	}
+}
+
+func TestNewsReactions(t *testing.T) {
+	testTable := []struct {
+		Name                string
+		Path                string
+		WithToken           bool
+		ExpectedMyReactions []string
+	}{
+		{
+			Name: "Anonymous news",
+			Path: "/posts/1",
+		},
+		{
+			Name:                "Authenticated news",
+			Path:                "/posts/1",
+			WithToken:           true,
+			ExpectedMyReactions: []string{"like"},
+		},
+		{
+			Name:                "Authenticated list",
+			Path:                "/posts",
+			WithToken:           true,
+			ExpectedMyReactions: []string{"like"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+			newsServiceInstance.ErrGetAllNewsToReturn = nil
+			mediaServiceInstance.ErrGetNewsMediaToReturn = nil
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081"+testCase.Path, nil)
+			if testCase.WithToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+			var respResult struct {
+				Data json.RawMessage `json:"data"`
+			}
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			var news response.NewsData
+			if testCase.Path == "/posts" {
+				var list []response.NewsData
+				json.Unmarshal(respResult.Data, &list)
+				news = list[0]
+			} else {
+				json.Unmarshal(respResult.Data, &news)
+			}
+			assert.Equal(t, news.Reactions, map[string]int{"like": 2})
+			assert.Equal(t, news.MyReactions, testCase.ExpectedMyReactions)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReadStateHandler struct {
+	readStateService readStateService
+}
+
+func NewReadStateHandler(readStateService readStateService) *ReadStateHandler {
+	return &ReadStateHandler{
+		readStateService: readStateService,
+	}
+}
+
+type readStateService interface {
+	MarkRead(ctx context.Context, newsId int32) error
+	MarkAllRead(ctx context.Context) error
+	GetUnreadCount(ctx context.Context) (int, error)
+	AddBookmark(ctx context.Context, newsId int32) error
+	RemoveBookmark(ctx context.Context, newsId int32) error
+	GetBookmarks(ctx context.Context, before int32, first int) ([]core.News, bool, error)
+	GetReadStates(ctx context.Context, newsIds []int32) (map[int32]service.ReadState, error)
+	GetUpdatedAt(ctx context.Context) (time.Time, error)
+}
+
+func (h *ReadStateHandler) MarkRead(ctx *gin.Context) {
+	h.markNews(ctx, h.readStateService.MarkRead)
+}
+
+func (h *ReadStateHandler) AddBookmark(ctx *gin.Context) {
+	h.markNews(ctx, h.readStateService.AddBookmark)
+}
+
+func (h *ReadStateHandler) RemoveBookmark(ctx *gin.Context) {
+	h.markNews(ctx, h.readStateService.RemoveBookmark)
+}
+
+func (h *ReadStateHandler) markNews(ctx *gin.Context, mark func(ctx context.Context, newsId int32) error) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = mark(ctx, int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+// MarkAllRead marks everything published so far read, news published later
+// are unread until they're read.
+func (h *ReadStateHandler) MarkAllRead(ctx *gin.Context) {
+	err := h.readStateService.MarkAllRead(ctx)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+// GetUnreadCount counts the unread news the way they're listed for the
+// client.
+func (h *ReadStateHandler) GetUnreadCount(ctx *gin.Context) {
+	var queryPayload payload.ClientContextPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	client, targeted, err := requestClient(ctx, queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+	if targeted {
+		ctx.Request = ctx.Request.WithContext(targeting.WithClient(ctx.Request.Context(), client))
+	}
+
+	count, err := h.readStateService.GetUnreadCount(ctx)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: response.UnreadCountData{
+			Count: count,
+		},
+	})
+}
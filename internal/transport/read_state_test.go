@@ -0,0 +1,330 @@
+package transport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type readStateServiceMock struct {
+	Read                    map[int32]bool
+	Bookmarks               map[int32]bool
+	UpdatedAt               time.Time
+	Before                  int32
+	ErrMarkToReturn         error
+	ErrGetBookmarksToReturn error
+	ErrGetUpdatedAtToReturn error
+}
+
+func (m *readStateServiceMock) mark(marks *map[int32]bool, newsId int32, value bool) error {
+	if m.ErrMarkToReturn != nil {
+		return m.ErrMarkToReturn
+	}
+	if *marks == nil {
+		*marks = map[int32]bool{}
+	}
+	(*marks)[newsId] = value
+	return nil
+}
+
+func (m *readStateServiceMock) MarkRead(ctx context.Context, newsId int32) error {
+	return m.mark(&m.Read, newsId, true)
+}
+
+func (m *readStateServiceMock) MarkAllRead(ctx context.Context) error {
+	return m.mark(&m.Read, 0, true)
+}
+
+func (m *readStateServiceMock) GetUnreadCount(ctx context.Context) (int, error) {
+	if m.ErrMarkToReturn != nil {
+		return 0, m.ErrMarkToReturn
+	}
+	return 10 - len(m.Read), nil
+}
+
+func (m *readStateServiceMock) AddBookmark(ctx context.Context, newsId int32) error {
+	return m.mark(&m.Bookmarks, newsId, true)
+}
+
+func (m *readStateServiceMock) RemoveBookmark(ctx context.Context, newsId int32) error {
+	return m.mark(&m.Bookmarks, newsId, false)
+}
+
+func (m *readStateServiceMock) GetBookmarks(ctx context.Context, before int32, first int) ([]core.News, bool, error) {
+	m.Before = before
+	if m.ErrGetBookmarksToReturn != nil {
+		return nil, false, m.ErrGetBookmarksToReturn
+	}
+	return []core.News{
+		{ID: 3, Title: pgtype.Text{String: "some title", Valid: true}, ContentFormat: "plain", Status: "published"},
+	}, true, nil
+}
+
+func (m *readStateServiceMock) GetReadStates(ctx context.Context, newsIds []int32) (map[int32]service.ReadState, error) {
+	states := map[int32]service.ReadState{}
+	for _, id := range newsIds {
+		states[id] = service.ReadState{IsRead: m.Read[id], IsBookmarked: m.Bookmarks[id]}
+	}
+	return states, nil
+}
+
+func (m *readStateServiceMock) GetUpdatedAt(ctx context.Context) (time.Time, error) {
+	if m.ErrGetUpdatedAtToReturn != nil {
+		return time.Time{}, m.ErrGetUpdatedAtToReturn
+	}
+	return m.UpdatedAt, nil
+}
+
+func TestMarkNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Method                   string
+		Path                     string
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedRead             map[int32]bool
+		ExpectedBookmarks        map[int32]bool
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok read",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/read",
+			ExpectedRead:       map[int32]bool{1: true},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok read all",
+			Method:             http.MethodPost,
+			Path:               "/posts/read-all",
+			ExpectedRead:       map[int32]bool{0: true},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok bookmark",
+			Method:             http.MethodPut,
+			Path:               "/posts/1/bookmark",
+			ExpectedBookmarks:  map[int32]bool{1: true},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok remove bookmark",
+			Method:             http.MethodDelete,
+			Path:               "/posts/1/bookmark",
+			ExpectedBookmarks:  map[int32]bool{1: false},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Unauthorized",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/read",
+			WithoutToken:       true,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Bookmark draft",
+			Method:                   http.MethodPut,
+			Path:                     "/posts/1/bookmark",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "News not found",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/read",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Db internal",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/read-all",
+			ErrorServiceShouldReturn: errors.New("some unexpected error"),
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			readStateServiceInstance.ErrMarkToReturn = testCase.ErrorServiceShouldReturn
+			readStateServiceInstance.Read = nil
+			readStateServiceInstance.Bookmarks = nil
+
+			r, _ := http.NewRequest(testCase.Method, "http://localhost:8081"+testCase.Path, nil)
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, readStateServiceInstance.Read, testCase.ExpectedRead)
+			assert.Equal(t, readStateServiceInstance.Bookmarks, testCase.ExpectedBookmarks)
+		})
+	}
+}
+
+func TestGetUnreadCount(t *testing.T) {
+	readStateServiceInstance.ErrMarkToReturn = nil
+	readStateServiceInstance.Read = map[int32]bool{1: true, 2: true}
+
+	r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/me/unread-count", nil)
+	r.Header.Set("Authorization", "Bearer "+authToken)
+	resp, _ := http.DefaultClient.Do(r)
+
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+	var respResult struct {
+		Code int                      `json:"code"`
+		Data response.UnreadCountData `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respResult)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, respResult.Data.Count, 8)
+
+	resp, _ = http.Get("http://localhost:8081/me/unread-count")
+	assert.Equal(t, resp.StatusCode, http.StatusUnauthorized)
+}
+
+func TestGetBookmarks(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Query                    string
+		ErrorServiceShouldReturn error
+		ExpectedBefore           int32
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok first page",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok next page",
+			Query:              "?after=" + encodeBookmarkCursor(5),
+			ExpectedBefore:     5,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Cursor of other list",
+			Query:              "?after=" + encodeNewsCursor(newsCursor{id: 5}),
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Malformed cursor",
+			Query:              "?after=" + base64.RawURLEncoding.EncodeToString([]byte("bookmarks:x")),
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Db internal",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+			readStateServiceInstance.ErrGetBookmarksToReturn = testCase.ErrorServiceShouldReturn
+			readStateServiceInstance.Before = 0
+			readStateServiceInstance.Read = nil
+			readStateServiceInstance.Bookmarks = map[int32]bool{3: true}
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/me/bookmarks"+testCase.Query, nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, readStateServiceInstance.Before, testCase.ExpectedBefore)
+			if testCase.ExpectedStatusCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, resp.Header.Get("Link"), `</me/bookmarks?after=`+encodeBookmarkCursor(3)+`&first=20>; rel="next"`)
+
+			var respResult struct {
+				Code int                 `json:"code"`
+				Data []response.NewsData `json:"data"`
+			}
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(respResult.Data), 1)
+			assert.Equal(t, *respResult.Data[0].IsRead, false)
+			assert.Equal(t, *respResult.Data[0].IsBookmarked, true)
+		})
+	}
+}
+
+func TestNewsReadState(t *testing.T) {
+	testTable := []struct {
+		Name      string
+		Path      string
+		WithToken bool
+	}{
+		{
+			Name:      "Ok news for author",
+			Path:      "/posts/1",
+			WithToken: true,
+		},
+		{
+			Name: "Ok news anonymous",
+			Path: "/posts/1",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+			newsServiceInstance.ErrGetNewsStatsToReturn = nil
+			newsServiceInstance.ErrGetAllNewsToReturn = nil
+			newsServiceInstance.AllNewsToReturn = nil
+			mediaServiceInstance.ErrGetNewsMediaToReturn = nil
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+			readStateServiceInstance.ErrGetUpdatedAtToReturn = nil
+			readStateServiceInstance.Read = map[int32]bool{1: true}
+			readStateServiceInstance.Bookmarks = nil
+
+			for _, path := range []string{testCase.Path, "/posts"} {
+				r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081"+path, nil)
+				if testCase.WithToken {
+					r.Header.Set("Authorization", "Bearer "+authToken)
+				}
+				resp, _ := http.DefaultClient.Do(r)
+				assert.Equal(t, resp.StatusCode, http.StatusOK)
+
+				var data response.NewsData
+				if path == "/posts" {
+					var respResult struct {
+						Data []response.NewsData `json:"data"`
+					}
+					err := json.NewDecoder(resp.Body).Decode(&respResult)
+					assert.Equal(t, err, nil)
+					data = respResult.Data[0]
+				} else {
+					var respResult struct {
+						Data response.NewsData `json:"data"`
+					}
+					err := json.NewDecoder(resp.Body).Decode(&respResult)
+					assert.Equal(t, err, nil)
+					data = respResult.Data
+				}
+
+				if !testCase.WithToken {
+					assert.Equal(t, data.IsRead, (*bool)(nil))
+					assert.Equal(t, data.IsBookmarked, (*bool)(nil))
+					continue
+				}
+				assert.Equal(t, *data.IsRead, true)
+				assert.Equal(t, *data.IsBookmarked, false)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	newsv1 "github.com/anton-uvarenko/promova_test/internal/pb/news/v1"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewsServer serves news.v1.NewsService on top of the same service the REST
+// handlers use.
+type NewsServer struct {
+	newsv1.UnimplementedNewsServiceServer
+
+	newsService newsService
+}
+
+func NewNewsServer(newsService newsService) *NewsServer {
+	return &NewsServer{
+		newsService: newsService,
+	}
+}
+
+type newsService interface {
+	AddNews(ctx context.Context, params core.AddNewsParams) (int32, error)
+	UpdatNews(ctx context.Context, params core.UpdateNewsParams) error
+	GetNewsById(ctx context.Context, id int32) (core.News, error)
+	GetAllNews(ctx context.Context) ([]core.News, error)
+	DeleteNews(ctx context.Context, id int32) error
+}
+
+func (s *NewsServer) CreateNews(ctx context.Context, req *newsv1.CreateNewsRequest) (*newsv1.CreateNewsResponse, error) {
+	err := validateNews(req.GetTitle(), req.GetContent(), req.GetBlocks())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	author, ok := auth.AuthorFromContext(ctx)
+	id, err := s.newsService.AddNews(ctx, core.AddNewsParams{
+		Title:         pgtype.Text{String: req.GetTitle(), Valid: true},
+		Content:       pgtype.Text{String: req.GetContent(), Valid: true},
+		ContentFormat: req.GetContentFormat(),
+		Blocks:        blocks(req.GetBlocks()),
+		Targeting:     pgtype.Text{String: req.GetTargeting(), Valid: req.GetTargeting() != ""},
+		AuthorID:      pgtype.Int4{Int32: author.ID, Valid: ok},
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &newsv1.CreateNewsResponse{Id: id}, nil
+}
+
+func (s *NewsServer) UpdateNews(ctx context.Context, req *newsv1.UpdateNewsRequest) (*newsv1.UpdateNewsResponse, error) {
+	err := validateNews(req.GetTitle(), req.GetContent(), req.GetBlocks())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	// content is written in the format news have unless it's set, blocks
+	// get the format they default to
+	format := req.GetContentFormat()
+	if req.ContentFormat == nil && req.GetBlocks() == "" {
+		news, err := s.newsService.GetNewsById(ctx, req.GetId())
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		format = news.ContentFormat
+	}
+
+	author, ok := auth.AuthorFromContext(ctx)
+	err = s.newsService.UpdatNews(ctx, core.UpdateNewsParams{
+		ID:            req.GetId(),
+		Title:         pgtype.Text{String: req.GetTitle(), Valid: true},
+		Content:       pgtype.Text{String: req.GetContent(), Valid: true},
+		ContentFormat: format,
+		Blocks:        blocks(req.GetBlocks()),
+		Targeting:     pgtype.Text{String: req.GetTargeting(), Valid: req.Targeting != nil},
+		UpdatedBy:     pgtype.Int4{Int32: author.ID, Valid: ok},
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &newsv1.UpdateNewsResponse{}, nil
+}
+
+func (s *NewsServer) GetNews(ctx context.Context, req *newsv1.GetNewsRequest) (*newsv1.GetNewsResponse, error) {
+	news, err := s.newsService.GetNewsById(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &newsv1.GetNewsResponse{News: newsMessage(news)}, nil
+}
+
+func (s *NewsServer) ListNews(req *newsv1.ListNewsRequest, stream newsv1.NewsService_ListNewsServer) error {
+	news, err := s.newsService.GetAllNews(stream.Context())
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, v := range news {
+		err := stream.Send(&newsv1.ListNewsResponse{News: newsMessage(v)})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *NewsServer) DeleteNews(ctx context.Context, req *newsv1.DeleteNewsRequest) (*newsv1.DeleteNewsResponse, error) {
+	err := s.newsService.DeleteNews(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &newsv1.DeleteNewsResponse{}, nil
+}
+
+// validateNews applies the rules the REST payloads are bound with.
+func validateNews(title string, content string, blocks string) error {
+	length := utf8.RuneCountInString(title)
+	if length <= 2 || length >= 50 {
+		return fmt.Errorf("%w: [title must be between 3 and 49 characters]", pkg.ErrInvalidPayload)
+	}
+
+	if content == "" && blocks == "" {
+		return fmt.Errorf("%w: [content or blocks are required]", pkg.ErrInvalidPayload)
+	}
+
+	return nil
+}
+
+// blocks are missing rather than empty when they aren't set.
+func blocks(document string) []byte {
+	if document == "" {
+		return nil
+	}
+	return []byte(document)
+}
+
+func newsMessage(news core.News) *newsv1.News {
+	return &newsv1.News{
+		Id:            news.ID,
+		Title:         news.Title.String,
+		Content:       news.Content.String,
+		ContentFormat: news.ContentFormat,
+		Blocks:        string(news.Blocks),
+		Targeting:     news.Targeting.String,
+		AuthorId:      news.AuthorID.Int32,
+		UpdatedBy:     news.UpdatedBy.Int32,
+		Status:        news.Status,
+		CreatedAt:     timestamp(news.CreatedAt),
+		UpdatedAt:     timestamp(news.UpdatedAt),
+		PublishedAt:   timestamp(news.PublishedAt),
+	}
+}
+
+func timestamp(t pgtype.Timestamp) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+
+	return timestamppb.New(t.Time)
+}
@@ -0,0 +1,504 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/authz"
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	newsv1 "github.com/anton-uvarenko/promova_test/internal/pb/news/v1"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/server"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type newsServiceMock struct {
+	ErrAddNewsToReturn     error
+	ErrUpdateNewsToReturn  error
+	ErrGetNewsByIdToReturn error
+	ErrGetAllNewsToReturn  error
+	ErrDeleteNewsToReturn  error
+
+	// author is who the last write was made on behalf of
+	author pgtype.Int4
+	// updated is what news were last updated with
+	updated core.UpdateNewsParams
+}
+
+var newsUpdatedAt = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func (m *newsServiceMock) AddNews(ctx context.Context, params core.AddNewsParams) (int32, error) {
+	if m.ErrAddNewsToReturn != nil {
+		return 0, m.ErrAddNewsToReturn
+	}
+
+	m.author = params.AuthorID
+	return 1, nil
+}
+
+func (m *newsServiceMock) UpdatNews(ctx context.Context, params core.UpdateNewsParams) error {
+	if m.ErrUpdateNewsToReturn != nil {
+		return m.ErrUpdateNewsToReturn
+	}
+
+	m.author = params.UpdatedBy
+	m.updated = params
+	return nil
+}
+
+func (m *newsServiceMock) GetNewsById(ctx context.Context, id int32) (core.News, error) {
+	if m.ErrGetNewsByIdToReturn != nil {
+		return core.News{}, m.ErrGetNewsByIdToReturn
+	}
+
+	return core.News{
+		ID:            id,
+		Title:         pgtype.Text{String: "some title", Valid: true},
+		Content:       pgtype.Text{String: "some content", Valid: true},
+		ContentFormat: "markdown",
+		UpdatedAt:     pgtype.Timestamp{Time: newsUpdatedAt, Valid: true},
+		Status:        "draft",
+	}, nil
+}
+
+func (m *newsServiceMock) GetAllNews(ctx context.Context) ([]core.News, error) {
+	if m.ErrGetAllNewsToReturn != nil {
+		return nil, m.ErrGetAllNewsToReturn
+	}
+
+	return []core.News{
+		{
+			ID:      1,
+			Title:   pgtype.Text{String: "some title", Valid: true},
+			Content: pgtype.Text{String: "some content", Valid: true},
+		},
+		{
+			ID:      2,
+			Title:   pgtype.Text{String: "other title", Valid: true},
+			Content: pgtype.Text{String: "other content", Valid: true},
+		},
+	}, nil
+}
+
+func (m *newsServiceMock) DeleteNews(ctx context.Context, id int32) error {
+	if m.ErrDeleteNewsToReturn != nil {
+		return m.ErrDeleteNewsToReturn
+	}
+
+	return nil
+}
+
+type apiKeyServiceMock struct{}
+
+func (m *apiKeyServiceMock) Authenticate(ctx context.Context, key string) (auth.Author, error) {
+	return auth.Author{}, auth.ErrInvalidApiKey
+}
+
+var (
+	newsServiceInstance *newsServiceMock
+	conn                *grpc.ClientConn
+	authToken           string
+)
+
+func TestMain(m *testing.M) {
+	keyset := auth.NewKeyset("test", auth.NewHMACKey("test", []byte("test secret")))
+	authToken, _ = keyset.Sign(auth.Author{ID: 1, Name: "test author", Role: authz.RoleAdmin}, time.Hour)
+
+	newsServiceInstance = &newsServiceMock{}
+	grpcServer := server.NewGrpcServer(
+		NewNewsServer(newsServiceInstance),
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(keyset, &apiKeyServiceMock{})),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(keyset, &apiKeyServiceMock{})),
+	)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	var err error
+	conn, err = grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	m.Run()
+}
+
+func authorized(authorization string) context.Context {
+	if authorization == "" {
+		return context.Background()
+	}
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", authorization)
+}
+
+func TestCreateNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Request                  *newsv1.CreateNewsRequest
+		Authorization            string
+		ErrorServiceShouldReturn error
+		ExpectedId               int32
+		ExpectedCode             codes.Code
+	}{
+		{
+			Name:          "Ok",
+			Request:       &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			Authorization: "Bearer " + authToken,
+			ExpectedId:    1,
+			ExpectedCode:  codes.OK,
+		},
+		{
+			Name:                     "Anonymous",
+			Request:                  &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			ErrorServiceShouldReturn: pkg.ErrUnauthorized,
+			ExpectedCode:             codes.Unauthenticated,
+		},
+		{
+			Name:          "Invalid token",
+			Request:       &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			Authorization: "Bearer invalid",
+			ExpectedCode:  codes.Unauthenticated,
+		},
+		{
+			Name:          "Invalid api key",
+			Request:       &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			Authorization: "ApiKey nk_invalid",
+			ExpectedCode:  codes.Unauthenticated,
+		},
+		{
+			Name:          "Validation error",
+			Request:       &newsv1.CreateNewsRequest{Title: "f", Content: "some content"},
+			Authorization: "Bearer " + authToken,
+			ExpectedCode:  codes.InvalidArgument,
+		},
+		{
+			Name:          "Missing content",
+			Request:       &newsv1.CreateNewsRequest{Title: "some title"},
+			Authorization: "Bearer " + authToken,
+			ExpectedCode:  codes.InvalidArgument,
+		},
+		{
+			Name:                     "Forbidden",
+			Request:                  &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			Authorization:            "Bearer " + authToken,
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             codes.PermissionDenied,
+		},
+		{
+			Name:                     "Entity already exist",
+			Request:                  &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			Authorization:            "Bearer " + authToken,
+			ErrorServiceShouldReturn: pkg.ErrEntityAlreadyExists,
+			ExpectedCode:             codes.AlreadyExists,
+		},
+		{
+			Name:                     "Db Internal error",
+			Request:                  &newsv1.CreateNewsRequest{Title: "some title", Content: "some content"},
+			Authorization:            "Bearer " + authToken,
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedCode:             codes.Internal,
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrAddNewsToReturn = testCase.ErrorServiceShouldReturn
+
+			resp, err := client.CreateNews(authorized(testCase.Authorization), testCase.Request)
+
+			assert.Equal(t, testCase.ExpectedCode, status.Code(err))
+			if err == nil {
+				assert.Equal(t, testCase.ExpectedId, resp.GetId())
+				assert.Equal(t, pgtype.Int4{Int32: 1, Valid: true}, newsServiceInstance.author)
+			}
+		})
+	}
+}
+
+func TestUpdateNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Request                  *newsv1.UpdateNewsRequest
+		ErrorServiceShouldReturn error
+		ExpectedCode             codes.Code
+	}{
+		{
+			Name:         "Ok",
+			Request:      &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content"},
+			ExpectedCode: codes.OK,
+		},
+		{
+			Name:         "Validation error",
+			Request:      &newsv1.UpdateNewsRequest{Id: 1, Title: "some title"},
+			ExpectedCode: codes.InvalidArgument,
+		},
+		{
+			Name:                     "Not found",
+			Request:                  &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content"},
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             codes.NotFound,
+		},
+		{
+			Name:                     "Forbidden",
+			Request:                  &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content"},
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             codes.PermissionDenied,
+		},
+		{
+			Name:                     "Db Internal error",
+			Request:                  &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content"},
+			ErrorServiceShouldReturn: fmt.Errorf("%w: [%w]", pkg.ErrDbInternal, errors.New("connection reset")),
+			ExpectedCode:             codes.Internal,
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrUpdateNewsToReturn = testCase.ErrorServiceShouldReturn
+
+			_, err := client.UpdateNews(authorized("Bearer "+authToken), testCase.Request)
+
+			assert.Equal(t, testCase.ExpectedCode, status.Code(err))
+			if testCase.ExpectedCode == codes.Internal {
+				// the cause stays in the server logs
+				assert.Equal(t, pkg.ErrDbInternal.Error(), status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestUpdateNewsContentFormat(t *testing.T) {
+	testTable := []struct {
+		Name                  string
+		Request               *newsv1.UpdateNewsRequest
+		ExpectedContentFormat string
+		ExpectedBlocks        []byte
+	}{
+		{
+			Name:                  "Ok kept when missing",
+			Request:               &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some *content*"},
+			ExpectedContentFormat: "markdown",
+		},
+		{
+			Name:                  "Ok set",
+			Request:               &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content", ContentFormat: proto.String("plain")},
+			ExpectedContentFormat: "plain",
+		},
+		{
+			Name:           "Ok blocks",
+			Request:        &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Blocks: `{"version": 1, "blocks": []}`},
+			ExpectedBlocks: []byte(`{"version": 1, "blocks": []}`),
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrUpdateNewsToReturn = nil
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+
+			_, err := client.UpdateNews(authorized("Bearer "+authToken), testCase.Request)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, newsServiceInstance.updated.ContentFormat, testCase.ExpectedContentFormat)
+			assert.Equal(t, newsServiceInstance.updated.Blocks, testCase.ExpectedBlocks)
+		})
+	}
+}
+
+func TestUpdateNewsTargeting(t *testing.T) {
+	testTable := []struct {
+		Name              string
+		Request           *newsv1.UpdateNewsRequest
+		ExpectedTargeting pgtype.Text
+	}{
+		{
+			Name:    "Ok kept when missing",
+			Request: &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content"},
+		},
+		{
+			Name:              "Ok set",
+			Request:           &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content", Targeting: proto.String(`platform == "ios"`)},
+			ExpectedTargeting: pgtype.Text{String: `platform == "ios"`, Valid: true},
+		},
+		{
+			Name:              "Ok cleared",
+			Request:           &newsv1.UpdateNewsRequest{Id: 1, Title: "some title", Content: "some content", Targeting: proto.String("")},
+			ExpectedTargeting: pgtype.Text{Valid: true},
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrUpdateNewsToReturn = nil
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+
+			_, err := client.UpdateNews(authorized("Bearer "+authToken), testCase.Request)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, newsServiceInstance.updated.Targeting, testCase.ExpectedTargeting)
+		})
+	}
+}
+
+func TestGetNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		ErrorServiceShouldReturn error
+		ExpectedCode             codes.Code
+	}{
+		{
+			Name:         "Ok",
+			ExpectedCode: codes.OK,
+		},
+		{
+			Name:                     "Not found",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             codes.NotFound,
+		},
+		{
+			Name:                     "Db Internal error",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedCode:             codes.Internal,
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = testCase.ErrorServiceShouldReturn
+
+			resp, err := client.GetNews(context.Background(), &newsv1.GetNewsRequest{Id: 5})
+
+			assert.Equal(t, testCase.ExpectedCode, status.Code(err))
+			if err == nil {
+				assert.Equal(t, int32(5), resp.GetNews().GetId())
+				assert.Equal(t, "some title", resp.GetNews().GetTitle())
+				assert.Equal(t, "draft", resp.GetNews().GetStatus())
+				assert.Equal(t, newsUpdatedAt, resp.GetNews().GetUpdatedAt().AsTime())
+				assert.Equal(t, true, resp.GetNews().GetPublishedAt() == nil)
+			}
+		})
+	}
+}
+
+func TestListNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		ErrorServiceShouldReturn error
+		ExpectedIds              []int32
+		ExpectedCode             codes.Code
+	}{
+		{
+			Name:         "Ok",
+			ExpectedIds:  []int32{1, 2},
+			ExpectedCode: codes.OK,
+		},
+		{
+			Name:                     "Db Internal error",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedCode:             codes.Internal,
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetAllNewsToReturn = testCase.ErrorServiceShouldReturn
+
+			stream, err := client.ListNews(context.Background(), &newsv1.ListNewsRequest{})
+			assert.Equal(t, nil, err)
+
+			var ids []int32
+			for {
+				resp, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					assert.Equal(t, testCase.ExpectedCode, status.Code(err))
+					break
+				}
+				ids = append(ids, resp.GetNews().GetId())
+			}
+
+			assert.Equal(t, testCase.ExpectedIds, ids)
+		})
+	}
+}
+
+func TestDeleteNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		ErrorServiceShouldReturn error
+		ExpectedCode             codes.Code
+	}{
+		{
+			Name:         "Ok",
+			ExpectedCode: codes.OK,
+		},
+		{
+			Name:                     "Already deleted",
+			ErrorServiceShouldReturn: pkg.ErrEntityAlreadyDeleted,
+			ExpectedCode:             codes.NotFound,
+		},
+		{
+			Name:                     "Unauthorized",
+			ErrorServiceShouldReturn: pkg.ErrUnauthorized,
+			ExpectedCode:             codes.Unauthenticated,
+		},
+		{
+			Name:                     "Forbidden",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             codes.PermissionDenied,
+		},
+	}
+
+	client := newsv1.NewNewsServiceClient(conn)
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrDeleteNewsToReturn = testCase.ErrorServiceShouldReturn
+
+			_, err := client.DeleteNews(authorized("Bearer "+authToken), &newsv1.DeleteNewsRequest{Id: 1})
+
+			assert.Equal(t, testCase.ExpectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	client := healthpb.NewHealthClient(conn)
+
+	for _, service := range []string{"", newsv1.NewsService_ServiceDesc.ServiceName} {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+
+		assert.Equal(t, nil, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+}
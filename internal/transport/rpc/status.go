@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps service errors to the gRPC codes matching the HTTP statuses
+// the REST handlers answer with. Internal errors don't leak their cause.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, pkg.ErrInvalidPayload), errors.Is(err, pkg.ErrInvalidUriParameters):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, pkg.ErrNotFound):
+		return status.Error(codes.NotFound, pkg.ErrNotFound.Error())
+	case errors.Is(err, pkg.ErrEntityAlreadyDeleted):
+		return status.Error(codes.NotFound, pkg.ErrEntityAlreadyDeleted.Error())
+	case errors.Is(err, pkg.ErrEntityAlreadyExists):
+		return status.Error(codes.AlreadyExists, pkg.ErrEntityAlreadyExists.Error())
+	case errors.Is(err, pkg.ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, pkg.ErrUnauthorized.Error())
+	case errors.Is(err, pkg.ErrForbidden):
+		return status.Error(codes.PermissionDenied, pkg.ErrForbidden.Error())
+	case errors.Is(err, pkg.ErrTooManyRequests):
+		return status.Error(codes.ResourceExhausted, pkg.ErrTooManyRequests.Error())
+	default:
+		return status.Error(codes.Internal, pkg.ErrDbInternal.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	syncService syncService
+}
+
+func NewSyncHandler(syncService syncService) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+	}
+}
+
+type syncService interface {
+	GetNewsChanges(ctx context.Context, since string) (service.NewsChanges, error)
+}
+
+func (h *SyncHandler) GetNewsChanges(ctx *gin.Context) {
+	var queryPayload payload.NewsChangesQueryPayload
+	err := ctx.ShouldBindQuery(&queryPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	client, targeted, err := requestClient(ctx, queryPayload.ClientContextPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: err.Error(),
+		})
+		return
+	}
+	if targeted {
+		ctx.Request = ctx.Request.WithContext(targeting.WithClient(ctx.Request.Context(), client))
+	}
+
+	changes, err := h.syncService.GetNewsChanges(ctx, queryPayload.Since)
+	if err != nil {
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	resultData := response.NewsChangesData{
+		Upserted: []response.NewsData{},
+		Deleted:  []int{},
+		Token:    changes.Token,
+		HasMore:  changes.HasMore,
+	}
+	for _, v := range changes.Upserted {
+		resultData.Upserted = append(resultData.Upserted, response.NewsData{
+			Id:                int(v.ID),
+			Title:             v.Title.String,
+			Content:           v.Content.String,
+			ContentFormat:     v.ContentFormat,
+			Blocks:            v.Blocks,
+			AuthorId:          int(v.AuthorID.Int32),
+			UpdatedBy:         int(v.UpdatedBy.Int32),
+			ApiKeyId:          int(v.ApiKeyID.Int32),
+			UpdatedByApiKeyId: int(v.UpdatedByApiKeyID.Int32),
+			Status:            v.Status,
+			Targeting:         v.Targeting.String,
+		})
+	}
+	for _, id := range changes.Deleted {
+		resultData.Deleted = append(resultData.Deleted, int(id))
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/service"
+	"github.com/go-playground/assert/v2"
+)
+
+type syncServiceMock struct {
+	Since                     string
+	ErrGetNewsChangesToReturn error
+}
+
+func (m *syncServiceMock) GetNewsChanges(ctx context.Context, since string) (service.NewsChanges, error) {
+	m.Since = since
+	if m.ErrGetNewsChangesToReturn != nil {
+		return service.NewsChanges{}, m.ErrGetNewsChangesToReturn
+	}
+
+	return service.NewsChanges{
+		Upserted: []core.News{{ID: 1, ContentFormat: "plain", Status: "draft"}},
+		Deleted:  []int32{2},
+		Token:    "next",
+	}, nil
+}
+
+type GetNewsChangesResponse struct {
+	Code int                      `json:"code"`
+	Data response.NewsChangesData `json:"data"`
+}
+
+func TestGetNewsChanges(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Query                    string
+		ErrorServiceShouldReturn error
+		ExpectedSince            string
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok full sync",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok delta",
+			Query:              "?since=previous",
+			ExpectedSince:      "previous",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Invalid token",
+			Query:                    "?since=previous",
+			ErrorServiceShouldReturn: pkg.ErrInvalidPayload,
+			ExpectedSince:            "previous",
+			ExpectedCode:             response.InvalidPayload,
+			ExpectedStatusCode:       http.StatusBadRequest,
+		},
+		{
+			Name:                     "Internal error",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedCode:             response.InternalError,
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			syncServiceInstance.ErrGetNewsChangesToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/posts/changes"+testCase.Query, nil)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, syncServiceInstance.Since, testCase.ExpectedSince)
+
+			var respResult GetNewsChangesResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if resp.StatusCode == http.StatusOK {
+				assert.Equal(t, respResult.Data.Upserted[0].Id, 1)
+				assert.Equal(t, respResult.Data.Deleted, []int{2})
+				assert.Equal(t, respResult.Data.Token, "next")
+			}
+		})
+	}
+}
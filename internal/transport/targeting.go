@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/gin-gonic/gin"
+)
+
+// clientContextHeaders are the headers clients tell about themselves with,
+// lists vary by them.
+var clientContextHeaders = []string{
+	"X-App-Locale",
+	"X-App-Platform",
+	"X-App-Version",
+	"X-Subscription-Tier",
+	"X-Learning-Language",
+}
+
+// clientFromPayload is false when the payload tells nothing.
+func clientFromPayload(pl payload.ClientContextPayload) (targeting.Client, bool, error) {
+	if pl == (payload.ClientContextPayload{}) {
+		return targeting.Client{}, false, nil
+	}
+
+	version, err := targeting.ParseVersion(pl.AppVersion)
+	if err != nil {
+		return targeting.Client{}, false, fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err)
+	}
+
+	return targeting.Client{
+		Locale:           pl.Locale,
+		Platform:         pl.Platform,
+		AppVersion:       version,
+		Tier:             pl.Tier,
+		LearningLanguage: pl.LearningLanguage,
+	}, true, nil
+}
+
+// requestClient is the client of query, with the fields missing there taken
+// from headers.
+func requestClient(ctx *gin.Context, query payload.ClientContextPayload) (targeting.Client, bool, error) {
+	var header payload.ClientContextPayload
+	err := ctx.ShouldBindHeader(&header)
+	if err != nil {
+		return targeting.Client{}, false, fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err)
+	}
+
+	for _, field := range []struct{ query, header *string }{
+		{&query.Locale, &header.Locale},
+		{&query.Platform, &header.Platform},
+		{&query.AppVersion, &header.AppVersion},
+		{&query.Tier, &header.Tier},
+		{&query.LearningLanguage, &header.LearningLanguage},
+	} {
+		if *field.query == "" {
+			*field.query = *field.header
+		}
+	}
+
+	return clientFromPayload(query)
+}
+
+func clientContextData(pl payload.ClientContextPayload) response.ClientContextData {
+	return response.ClientContextData{
+		Locale:           pl.Locale,
+		Platform:         pl.Platform,
+		AppVersion:       pl.AppVersion,
+		Tier:             pl.Tier,
+		LearningLanguage: pl.LearningLanguage,
+	}
+}
+
+// DryRunTargeting tells which of the contexts in the body the news would be
+// listed for, as they're targeted now.
+func (h *NewsHandler) DryRunTargeting(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	var pl payload.TargetingDryRunPayload
+	err = ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	clients := make([]targeting.Client, 0, len(pl.Contexts))
+	for _, clientContext := range pl.Contexts {
+		client, _, err := clientFromPayload(clientContext)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+		clients = append(clients, client)
+	}
+
+	shown, err := h.newsService.DryRunTargeting(ctx, int32(uriPayload.Id), clients)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	data := response.TargetingDryRunData{
+		Visible: []response.ClientContextData{},
+		Hidden:  []response.ClientContextData{},
+	}
+	for i, clientContext := range pl.Contexts {
+		if shown[i] {
+			data.Visible = append(data.Visible, clientContextData(clientContext))
+		} else {
+			data.Hidden = append(data.Hidden, clientContextData(clientContext))
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: data,
+	})
+}
@@ -0,0 +1,242 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/targeting"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestGetAllNewsForClient(t *testing.T) {
+	testTable := []struct {
+		Name               string
+		Query              string
+		Headers            map[string]string
+		ExpectedClient     *targeting.Client
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "Ok without client",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name: "Ok client from headers",
+			Headers: map[string]string{
+				"X-App-Locale":        "en-US",
+				"X-App-Platform":      "android",
+				"X-App-Version":       "3.2.1",
+				"X-Subscription-Tier": "premium",
+				"X-Learning-Language": "es",
+			},
+			ExpectedClient: &targeting.Client{
+				Locale:           "en-US",
+				Platform:         targeting.PlatformAndroid,
+				AppVersion:       targeting.Version{3, 2, 1},
+				Tier:             "premium",
+				LearningLanguage: "es",
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok query over headers",
+			Query:              "?platform=web&tier=free",
+			Headers:            map[string]string{"X-App-Platform": "ios", "X-App-Locale": "uk"},
+			ExpectedClient:     &targeting.Client{Locale: "uk", Platform: targeting.PlatformWeb, Tier: "free"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Unknown platform",
+			Query:              "?platform=windows",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Unknown platform in header",
+			Headers:            map[string]string{"X-App-Platform": "windows"},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Invalid app version",
+			Query:              "?app_version=3.x",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsStatsToReturn = nil
+			newsServiceInstance.ErrGetAllNewsToReturn = nil
+			newsServiceInstance.AllNewsToReturn = nil
+			newsServiceInstance.Client = nil
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/posts"+testCase.Query, nil)
+			for key, value := range testCase.Headers {
+				r.Header.Set(key, value)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			if testCase.ExpectedClient == nil {
+				assert.Equal(t, newsServiceInstance.Client, (*targeting.Client)(nil))
+				return
+			}
+			assert.Equal(t, newsServiceInstance.Client.Key(), testCase.ExpectedClient.Key())
+		})
+	}
+}
+
+func TestGetNewsByIdForClient(t *testing.T) {
+	testTable := []struct {
+		Name               string
+		Query              string
+		Headers            map[string]string
+		ExpectedClient     *targeting.Client
+		ExpectedStatusCode int
+	}{
+		{
+			Name:               "Ok without client",
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok query over headers",
+			Query:              "?platform=web",
+			Headers:            map[string]string{"X-App-Platform": "ios", "X-App-Locale": "uk"},
+			ExpectedClient:     &targeting.Client{Locale: "uk", Platform: targeting.PlatformWeb},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Unknown platform",
+			Query:              "?platform=windows",
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+			newsServiceInstance.Client = nil
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/posts/1"+testCase.Query, nil)
+			for key, value := range testCase.Headers {
+				r.Header.Set(key, value)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			if testCase.ExpectedClient == nil {
+				assert.Equal(t, newsServiceInstance.Client, (*targeting.Client)(nil))
+				return
+			}
+			assert.Equal(t, newsServiceInstance.Client.Key(), testCase.ExpectedClient.Key())
+		})
+	}
+}
+
+func TestGetAllNewsForClientNextPage(t *testing.T) {
+	newsServiceInstance.ErrGetNewsStatsToReturn = nil
+	newsServiceInstance.ErrGetAllNewsToReturn = nil
+	newsServiceInstance.AllNewsToReturn = []core.News{
+		{ID: 1, Title: pgtype.Text{String: "some title", Valid: true}, ContentFormat: "plain", Status: "published"},
+		{ID: 2, Title: pgtype.Text{String: "other title", Valid: true}, ContentFormat: "plain", Status: "published"},
+	}
+	reactionServiceInstance.ErrGetReactionsReturn = nil
+
+	resp, _ := http.Get("http://localhost:8081/posts?first=1&platform=ios&after=" + encodeNewsCursor(newsCursor{id: 0}))
+
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, resp.Header.Get("Link"), `</posts?after=`+encodeNewsCursor(newsCursor{id: 1})+`&first=1&platform=ios>; rel="next"`)
+}
+
+func TestDryRunTargeting(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Body                     string
+		WithoutToken             bool
+		ErrorServiceShouldReturn error
+		ExpectedData             response.TargetingDryRunData
+		ExpectedStatusCode       int
+	}{
+		{
+			Name: "Ok",
+			Body: `{"contexts": [{"platform": "ios", "app_version": "3.2"}, {"platform": "web"}, {}]}`,
+			ExpectedData: response.TargetingDryRunData{
+				Visible: []response.ClientContextData{{Platform: "ios", AppVersion: "3.2"}},
+				Hidden:  []response.ClientContextData{{Platform: "web"}, {}},
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "No contexts",
+			Body:               `{"contexts": []}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Unknown platform",
+			Body:               `{"contexts": [{"platform": "windows"}]}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Invalid app version",
+			Body:               `{"contexts": [{"app_version": "v3"}]}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Unauthorized",
+			Body:               `{"contexts": [{}]}`,
+			WithoutToken:       true,
+			ExpectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                     "Forbidden",
+			Body:                     `{"contexts": [{}]}`,
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name:                     "News not found",
+			Body:                     `{"contexts": [{}]}`,
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Db internal",
+			Body:                     `{"contexts": [{}]}`,
+			ErrorServiceShouldReturn: errors.New("some unexpected error"),
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrDryRunToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/posts/1/targeting/dry-run", bytes.NewBufferString(testCase.Body))
+			r.Header.Set("Content-Type", "application/json")
+			if !testCase.WithoutToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			if testCase.ExpectedStatusCode != http.StatusOK {
+				return
+			}
+
+			var respResult struct {
+				Code int                          `json:"code"`
+				Data response.TargetingDryRunData `json:"data"`
+			}
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, respResult.Data, testCase.ExpectedData)
+		})
+	}
+}
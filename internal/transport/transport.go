@@ -1,11 +1,50 @@
 package transport
 
 type Handler struct {
-	NewsHandler *NewsHandler
+	NewsHandler       *NewsHandler
+	NewsEventHandler  *NewsEventHandler
+	ApiKeyHandler     *ApiKeyHandler
+	WebhookHandler    *WebhookHandler
+	SyncHandler       *SyncHandler
+	GraphqlHandler    *GraphqlHandler
+	OpenapiHandler    *OpenapiHandler
+	MediaHandler      *MediaHandler
+	CommentHandler    *CommentHandler
+	ReactionHandler   *ReactionHandler
+	ViewHandler       *ViewHandler
+	ReadStateHandler  *ReadStateHandler
+	ExperimentHandler *ExperimentHandler
 }
 
-func NewHandler(newsService newsService) *Handler {
+func NewHandler(
+	newsService newsService,
+	newsEventBroker newsEventBroker,
+	apiKeyService apiKeyService,
+	webhookService webhookService,
+	syncService syncService,
+	mediaService mediaService,
+	commentService commentService,
+	reactionService reactionService,
+	viewService viewService,
+	readStateService readStateService,
+	experimentService experimentService,
+	featuredService featuredService,
+	authorService authorService,
+	cacheControl string,
+) *Handler {
 	return &Handler{
-		NewsHandler: NewNewsHandler(newsService),
+		NewsHandler:       NewNewsHandler(newsService, mediaService, reactionService, viewService, readStateService, experimentService, featuredService, cacheControl),
+		NewsEventHandler:  NewNewsEventHandler(newsEventBroker, newsService),
+		ApiKeyHandler:     NewApiKeyHandler(apiKeyService),
+		WebhookHandler:    NewWebhookHandler(webhookService),
+		SyncHandler:       NewSyncHandler(syncService),
+		GraphqlHandler:    NewGraphqlHandler(newsService, authorService),
+		OpenapiHandler:    NewOpenapiHandler(),
+		MediaHandler:      NewMediaHandler(mediaService),
+		CommentHandler:    NewCommentHandler(commentService),
+		ReactionHandler:   NewReactionHandler(reactionService),
+		ViewHandler:       NewViewHandler(viewService),
+		ReadStateHandler:  NewReadStateHandler(readStateService),
+		ExperimentHandler: NewExperimentHandler(experimentService),
 	}
 }
@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/auth"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPopularWindow is what popular news are ranked over unless the query
+// says otherwise.
+const defaultPopularWindow = "24h"
+
+type ViewHandler struct {
+	viewService viewService
+}
+
+func NewViewHandler(viewService viewService) *ViewHandler {
+	return &ViewHandler{
+		viewService: viewService,
+	}
+}
+
+type viewService interface {
+	RecordView(ctx context.Context, newsId int32, client string) error
+	GetPopularNews(ctx context.Context, window string, first int) ([]core.News, error)
+}
+
+// viewerKey tells viewers apart, by who they are when they are
+// authenticated and by address otherwise.
+func viewerKey(ctx *gin.Context) string {
+	author, ok := auth.AuthorFromContext(ctx)
+	if ok {
+		return fmt.Sprintf("author:%d", author.ID)
+	}
+
+	return "ip:" + ctx.ClientIP()
+}
+
+// RecordView is for clients showing news they didn't get from the api
+// right before, e.g. from their cache. Getting news counts a view already.
+func (h *ViewHandler) RecordView(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.viewService.RecordView(ctx, int32(uriPayload.Id), viewerKey(ctx))
+	if err != nil {
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
@@ -0,0 +1,214 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type viewServiceMock struct {
+	Viewers                   []string
+	Window                    string
+	First                     int
+	ErrRecordViewToReturn     error
+	ErrGetPopularNewsToReturn error
+}
+
+func (m *viewServiceMock) RecordView(ctx context.Context, newsId int32, client string) error {
+	if m.ErrRecordViewToReturn != nil {
+		return m.ErrRecordViewToReturn
+	}
+	m.Viewers = append(m.Viewers, client)
+	return nil
+}
+
+func (m *viewServiceMock) GetPopularNews(ctx context.Context, window string, first int) ([]core.News, error) {
+	m.Window = window
+	m.First = first
+	if m.ErrGetPopularNewsToReturn != nil {
+		return nil, m.ErrGetPopularNewsToReturn
+	}
+	return []core.News{
+		{ID: 2, Title: pgtype.Text{String: "some title", Valid: true}, ContentFormat: "plain", Status: "published"},
+		{ID: 1, Title: pgtype.Text{String: "some title", Valid: true}, ContentFormat: "plain", Status: "published"},
+	}, nil
+}
+
+func TestRecordView(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Method                   string
+		Path                     string
+		WithToken                bool
+		ForwardedFor             string
+		ErrorServiceShouldReturn error
+		ExpectedViewers          []string
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok anonymous",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/views",
+			ExpectedViewers:    []string{"ip:127.0.0.1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:   "Ok forwarded by an untrusted proxy",
+			Method: http.MethodPost,
+			Path:   "/posts/1/views",
+			// anyone can set the header, it mustn't make another viewer
+			ForwardedFor:       "203.0.113.7",
+			ExpectedViewers:    []string{"ip:127.0.0.1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok authenticated",
+			Method:             http.MethodPost,
+			Path:               "/posts/1/views",
+			WithToken:          true,
+			ExpectedViewers:    []string{"author:1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok counted by get",
+			Method:             http.MethodGet,
+			Path:               "/posts/1",
+			WithToken:          true,
+			ExpectedViewers:    []string{"author:1"},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:                     "Ok get when counting fails",
+			Method:                   http.MethodGet,
+			Path:                     "/posts/1",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedStatusCode:       http.StatusOK,
+		},
+		{
+			Name:                     "News not found",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/views",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Db internal",
+			Method:                   http.MethodPost,
+			Path:                     "/posts/1/views",
+			ErrorServiceShouldReturn: errors.New("some unexpected error"),
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			newsServiceInstance.ErrGetNewsByIdToReturn = nil
+			mediaServiceInstance.ErrGetNewsMediaToReturn = nil
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+			viewServiceInstance.ErrRecordViewToReturn = testCase.ErrorServiceShouldReturn
+			viewServiceInstance.Viewers = nil
+
+			r, _ := http.NewRequest(testCase.Method, "http://localhost:8081"+testCase.Path, nil)
+			if testCase.WithToken {
+				r.Header.Set("Authorization", "Bearer "+authToken)
+			}
+			if testCase.ForwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", testCase.ForwardedFor)
+			}
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+			assert.Equal(t, viewServiceInstance.Viewers, testCase.ExpectedViewers)
+		})
+	}
+}
+
+func TestGetPopularNews(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Query                    string
+		ErrorServiceShouldReturn error
+		ExpectedWindow           string
+		ExpectedFirst            int
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok default window",
+			ExpectedWindow:     "24h",
+			ExpectedFirst:      defaultPageSize,
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Ok week",
+			Query:              "?window=7d&first=5",
+			ExpectedWindow:     "7d",
+			ExpectedFirst:      5,
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Unknown window",
+			Query:              "?window=1y",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:               "Too many",
+			Query:              "?first=101",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Db internal",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedWindow:           "24h",
+			ExpectedFirst:            defaultPageSize,
+			ExpectedCode:             response.InternalError,
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			reactionServiceInstance.ErrGetReactionsReturn = nil
+			viewServiceInstance.ErrGetPopularNewsToReturn = testCase.ErrorServiceShouldReturn
+			viewServiceInstance.Window = ""
+			viewServiceInstance.First = 0
+
+			resp, _ := http.Get("http://localhost:8081/posts/popular" + testCase.Query)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult struct {
+				Code int                 `json:"code"`
+				Data []response.NewsData `json:"data"`
+			}
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			assert.Equal(t, viewServiceInstance.Window, testCase.ExpectedWindow)
+			assert.Equal(t, viewServiceInstance.First, testCase.ExpectedFirst)
+			if testCase.ExpectedStatusCode == http.StatusOK {
+				// in the order of the ranking
+				assert.Equal(t, len(respResult.Data), 2)
+				assert.Equal(t, respResult.Data[0].Id, 2)
+				assert.Equal(t, respResult.Data[1].Id, 1)
+			}
+		})
+	}
+}
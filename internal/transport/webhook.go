@@ -0,0 +1,243 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/outbox"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService webhookService
+}
+
+func NewWebhookHandler(webhookService webhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+type webhookService interface {
+	AddWebhook(ctx context.Context, url string, events []string) (core.Webhook, error)
+	GetAllWebhooks(ctx context.Context) ([]core.Webhook, error)
+	GetWebhookDeliveries(ctx context.Context, webhookId int32) ([]core.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, webhookId int32, deliveryId int64) error
+}
+
+func (h *WebhookHandler) AddWebhook(ctx *gin.Context) {
+	var pl payload.AddWebhookPayload
+	err := ctx.ShouldBindJSON(&pl)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: fmt.Errorf("%w: [%w]", pkg.ErrInvalidPayload, err).Error(),
+		})
+		return
+	}
+
+	webhook, err := h.webhookService.AddWebhook(ctx, pl.Url, pl.Events)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrInvalidPayload) {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+				Code:  response.InvalidPayload,
+				Error: err.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: response.AddWebhookData{
+			WebhookData: webhookData(webhook),
+			Secret:      webhook.Secret,
+		},
+	})
+}
+
+func (h *WebhookHandler) GetAllWebhooks(ctx *gin.Context) {
+	webhooks, err := h.webhookService.GetAllWebhooks(ctx)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	resultData := []response.WebhookData{}
+	for _, v := range webhooks {
+		resultData = append(resultData, webhookData(v))
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+func (h *WebhookHandler) GetWebhookDeliveries(ctx *gin.Context) {
+	var uriPayload payload.IdUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	deliveries, err := h.webhookService.GetWebhookDeliveries(ctx, int32(uriPayload.Id))
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	resultData := []response.WebhookDeliveryData{}
+	for _, v := range deliveries {
+		delivery := response.WebhookDeliveryData{
+			Id:             v.ID,
+			WebhookId:      int(v.WebhookID),
+			EventId:        v.OutboxID,
+			Status:         v.Status,
+			Attempts:       int(v.Attempts),
+			LastError:      v.LastError.String,
+			ResponseStatus: int(v.ResponseStatus.Int32),
+			CreatedAt:      v.CreatedAt.Time,
+			DeliveredAt:    timePtr(v.DeliveredAt),
+		}
+		if v.Status == outbox.StatusPending {
+			delivery.NextAttemptAt = timePtr(v.NextAttemptAt)
+		}
+
+		resultData = append(resultData, delivery)
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+		Data: resultData,
+	})
+}
+
+func (h *WebhookHandler) ReplayWebhookDelivery(ctx *gin.Context) {
+	var uriPayload payload.WebhookDeliveryUriPayload
+	err := ctx.ShouldBindUri(&uriPayload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, response.Response{
+			Code:  response.InvalidPayload,
+			Error: pkg.ErrInvalidUriParameters.Error(),
+		})
+		return
+	}
+
+	err = h.webhookService.ReplayWebhookDelivery(ctx, int32(uriPayload.Id), uriPayload.DeliveryId)
+	if err != nil {
+		if errors.Is(err, pkg.ErrUnauthorized) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.Response{
+				Code:  response.Unauthorized,
+				Error: pkg.ErrUnauthorized.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrForbidden) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Response{
+				Code:  response.Forbidden,
+				Error: pkg.ErrForbidden.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, pkg.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, response.Response{
+				Code:  response.NotFound,
+				Error: pkg.ErrNotFound.Error(),
+			})
+			return
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, response.Response{
+			Code:  response.InternalError,
+			Error: pkg.ErrDbInternal.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response.Response{
+		Code: response.Ok,
+	})
+}
+
+func webhookData(webhook core.Webhook) response.WebhookData {
+	return response.WebhookData{
+		Id:        int(webhook.ID),
+		Url:       webhook.Url,
+		Events:    webhook.Events,
+		CreatedBy: int(webhook.CreatedBy),
+		CreatedAt: webhook.CreatedAt.Time,
+	}
+}
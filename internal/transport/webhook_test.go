@@ -0,0 +1,294 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/anton-uvarenko/promova_test/internal/pkg"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/payload"
+	"github.com/anton-uvarenko/promova_test/internal/pkg/response"
+	"github.com/go-playground/assert/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type webhookServiceMock struct {
+	ErrAddWebhookToReturn            error
+	ErrGetAllWebhooksToReturn        error
+	ErrGetWebhookDeliveriesToReturn  error
+	ErrReplayWebhookDeliveryToReturn error
+}
+
+func (m *webhookServiceMock) AddWebhook(ctx context.Context, url string, events []string) (core.Webhook, error) {
+	if m.ErrAddWebhookToReturn != nil {
+		return core.Webhook{}, m.ErrAddWebhookToReturn
+	}
+
+	return core.Webhook{
+		ID:     1,
+		Url:    url,
+		Secret: "whsec_test",
+		Events: events,
+	}, nil
+}
+
+func (m *webhookServiceMock) GetAllWebhooks(ctx context.Context) ([]core.Webhook, error) {
+	if m.ErrGetAllWebhooksToReturn != nil {
+		return nil, m.ErrGetAllWebhooksToReturn
+	}
+
+	return []core.Webhook{
+		{
+			ID:     1,
+			Url:    "https://partner.example/hooks",
+			Secret: "whsec_test",
+			Events: []string{"news.published"},
+		},
+	}, nil
+}
+
+func (m *webhookServiceMock) GetWebhookDeliveries(ctx context.Context, webhookId int32) ([]core.WebhookDelivery, error) {
+	if m.ErrGetWebhookDeliveriesToReturn != nil {
+		return nil, m.ErrGetWebhookDeliveriesToReturn
+	}
+
+	return []core.WebhookDelivery{
+		{
+			ID:             7,
+			WebhookID:      webhookId,
+			OutboxID:       3,
+			Status:         "dead",
+			Attempts:       8,
+			LastError:      pgtype.Text{String: "unexpected status 500", Valid: true},
+			ResponseStatus: pgtype.Int4{Int32: 500, Valid: true},
+		},
+	}, nil
+}
+
+func (m *webhookServiceMock) ReplayWebhookDelivery(ctx context.Context, webhookId int32, deliveryId int64) error {
+	if m.ErrReplayWebhookDeliveryToReturn != nil {
+		return m.ErrReplayWebhookDeliveryToReturn
+	}
+
+	return nil
+}
+
+type AddWebhookResponse struct {
+	Code int                     `json:"code"`
+	Data response.AddWebhookData `json:"data"`
+}
+
+func TestAddWebhook(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		RequestPayload           any
+		ErrorServiceShouldReturn error
+		ExpectedResult           AddWebhookResponse
+		ExpectedStatusCode       int
+	}{
+		{
+			Name: "Ok",
+			RequestPayload: payload.AddWebhookPayload{
+				Url:    "https://partner.example/hooks",
+				Events: []string{"news.published"},
+			},
+			ExpectedResult: AddWebhookResponse{
+				Code: response.Ok,
+				Data: response.AddWebhookData{
+					WebhookData: response.WebhookData{Id: 1},
+					Secret:      "whsec_test",
+				},
+			},
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name: "Validation error invalid url",
+			RequestPayload: payload.AddWebhookPayload{
+				Url:    "partner",
+				Events: []string{"news.published"},
+			},
+			ExpectedResult:     AddWebhookResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Validation error unknown event",
+			RequestPayload: payload.AddWebhookPayload{
+				Url:    "https://partner.example/hooks",
+				Events: []string{"news.read"},
+			},
+			ExpectedResult:     AddWebhookResponse{Code: response.InvalidPayload},
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "Forbidden",
+			RequestPayload: payload.AddWebhookPayload{
+				Url:    "https://partner.example/hooks",
+				Events: []string{"news.published"},
+			},
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedResult:           AddWebhookResponse{Code: response.Forbidden},
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+		{
+			Name: "Internal error",
+			RequestPayload: payload.AddWebhookPayload{
+				Url:    "https://partner.example/hooks",
+				Events: []string{"news.published"},
+			},
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedResult:           AddWebhookResponse{Code: response.InternalError},
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			webhookServiceInstance.ErrAddWebhookToReturn = testCase.ErrorServiceShouldReturn
+			pl, _ := json.Marshal(testCase.RequestPayload)
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081/webhooks", bytes.NewBuffer(pl))
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult AddWebhookResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedResult.Code)
+			assert.Equal(t, respResult.Data.Id, testCase.ExpectedResult.Data.Id)
+			assert.Equal(t, respResult.Data.Secret, testCase.ExpectedResult.Data.Secret)
+		})
+	}
+}
+
+type GetWebhookDeliveriesResponse struct {
+	Code int                            `json:"code"`
+	Data []response.WebhookDeliveryData `json:"data"`
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		UriParam                 string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			UriParam:           "1",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid uri param",
+			UriParam:           "abc",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Forbidden",
+			UriParam:                 "1",
+			ErrorServiceShouldReturn: pkg.ErrForbidden,
+			ExpectedCode:             response.Forbidden,
+			ExpectedStatusCode:       http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			webhookServiceInstance.ErrGetWebhookDeliveriesToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodGet, "http://localhost:8081/webhooks/"+testCase.UriParam+"/deliveries", nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult GetWebhookDeliveriesResponse
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+			if resp.StatusCode == http.StatusOK {
+				assert.Equal(t, respResult.Data[0].Id, int64(7))
+				assert.Equal(t, respResult.Data[0].EventId, int64(3))
+				assert.Equal(t, respResult.Data[0].Status, "dead")
+				assert.Equal(t, respResult.Data[0].ResponseStatus, 500)
+				assert.Equal(t, respResult.Data[0].NextAttemptAt == nil, true)
+			}
+		})
+	}
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	testTable := []struct {
+		Name                     string
+		Path                     string
+		ErrorServiceShouldReturn error
+		ExpectedCode             int
+		ExpectedStatusCode       int
+	}{
+		{
+			Name:               "Ok",
+			Path:               "/webhooks/1/deliveries/7/replay",
+			ExpectedCode:       response.Ok,
+			ExpectedStatusCode: http.StatusOK,
+		},
+		{
+			Name:               "Invalid uri param",
+			Path:               "/webhooks/1/deliveries/abc/replay",
+			ExpectedCode:       response.InvalidPayload,
+			ExpectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                     "Not found",
+			Path:                     "/webhooks/1/deliveries/7/replay",
+			ErrorServiceShouldReturn: pkg.ErrNotFound,
+			ExpectedCode:             response.NotFound,
+			ExpectedStatusCode:       http.StatusNotFound,
+		},
+		{
+			Name:                     "Internal error",
+			Path:                     "/webhooks/1/deliveries/7/replay",
+			ErrorServiceShouldReturn: pkg.ErrDbInternal,
+			ExpectedCode:             response.InternalError,
+			ExpectedStatusCode:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			webhookServiceInstance.ErrReplayWebhookDeliveryToReturn = testCase.ErrorServiceShouldReturn
+
+			r, _ := http.NewRequest(http.MethodPost, "http://localhost:8081"+testCase.Path, nil)
+			r.Header.Set("Authorization", "Bearer "+authToken)
+			resp, _ := http.DefaultClient.Do(r)
+
+			assert.Equal(t, resp.StatusCode, testCase.ExpectedStatusCode)
+
+			var respResult response.Response
+			err := json.NewDecoder(resp.Body).Decode(&respResult)
+			if err != nil {
+				t.Error(err)
+				t.Fail()
+				return
+			}
+
+			assert.Equal(t, respResult.Code, testCase.ExpectedCode)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// maxPending is how many hourly counts make Run flush before the
+	// interval is up
+	maxPending = 1000
+	// flushTimeout bounds the last flush, which runs after ctx is done
+	flushTimeout = 10 * time.Second
+)
+
+type viewRepo interface {
+	AddNewsViews(ctx context.Context, arg core.AddNewsViewsParams) error
+}
+
+type seenKey struct {
+	newsId int32
+	client string
+}
+
+type hourKey struct {
+	newsId int32
+	hour   time.Time
+}
+
+// Recorder counts views in memory and adds them to the database in batches,
+// so reading news doesn't wait for a write. A client viewing the same news
+// again within the window isn't counted again. Counts are kept per replica,
+// only the database has all of them.
+type Recorder struct {
+	viewRepo viewRepo
+	window   time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	seen      map[seenKey]time.Time
+	pending   map[hourKey]int32
+	lastSweep time.Time
+	full      chan struct{}
+}
+
+func NewRecorder(viewRepo viewRepo, window time.Duration) *Recorder {
+	return &Recorder{
+		viewRepo: viewRepo,
+		window:   window,
+		now:      time.Now,
+		seen:     map[seenKey]time.Time{},
+		pending:  map[hourKey]int32{},
+		full:     make(chan struct{}, 1),
+	}
+}
+
+// Record counts a view of the news by client, returning whether it was
+// counted.
+func (r *Recorder) Record(newsId int32, client string) bool {
+	now := r.now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(now)
+
+	key := seenKey{newsId: newsId, client: client}
+	last, ok := r.seen[key]
+	if ok && now.Sub(last) < r.window {
+		return false
+	}
+	r.seen[key] = now
+
+	r.pending[hourKey{newsId: newsId, hour: now.Truncate(time.Hour)}]++
+	if len(r.pending) >= maxPending {
+		select {
+		case r.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+// sweep forgets views older than the window, counting them again is what
+// happens to them anyway.
+func (r *Recorder) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < time.Minute {
+		return
+	}
+	r.lastSweep = now
+
+	for key, last := range r.seen {
+		if now.Sub(last) >= r.window {
+			delete(r.seen, key)
+		}
+	}
+}
+
+// Flush adds the views counted since the last flush to the database. Views
+// that fail to be added are kept for the next flush.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = map[hourKey]int32{}
+	r.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	params := core.AddNewsViewsParams{}
+	for key, views := range pending {
+		params.NewsIds = append(params.NewsIds, key.newsId)
+		params.Hours = append(params.Hours, pgtype.Timestamp{Time: key.hour, Valid: true})
+		params.Views = append(params.Views, views)
+	}
+
+	err := r.viewRepo.AddNewsViews(ctx, params)
+	if err != nil {
+		r.mu.Lock()
+		for key, views := range pending {
+			r.pending[key] += views
+		}
+		r.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Run flushes every interval, or sooner when a lot of views pile up, until
+// ctx is done. The views counted by then are flushed before it returns.
+func (r *Recorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+			defer cancel()
+
+			err := r.Flush(flushCtx)
+			if err != nil {
+				fmt.Printf("can't flush views: [%v]\n", err)
+			}
+			return
+		case <-ticker.C:
+		case <-r.full:
+		}
+
+		err := r.Flush(ctx)
+		if err != nil {
+			fmt.Printf("can't flush views: [%v]\n", err)
+		}
+	}
+}
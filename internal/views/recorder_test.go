@@ -0,0 +1,148 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anton-uvarenko/promova_test/internal/core"
+	"github.com/go-playground/assert/v2"
+)
+
+type ViewRepoMock struct {
+	mu        sync.Mutex
+	Err       error
+	Flushes   int
+	Views     map[hourKey]int32
+	Flushed   chan struct{}
+	BatchSize int
+}
+
+func (m *ViewRepoMock) AddNewsViews(ctx context.Context, arg core.AddNewsViewsParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Flushes++
+	if m.Err != nil {
+		return m.Err
+	}
+
+	m.BatchSize = len(arg.NewsIds)
+	for i := range arg.NewsIds {
+		m.Views[hourKey{newsId: arg.NewsIds[i], hour: arg.Hours[i].Time}] += arg.Views[i]
+	}
+	if m.Flushed != nil {
+		m.Flushed <- struct{}{}
+	}
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	hour := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		Name          string
+		Views         []seenKey
+		Elapsed       time.Duration
+		ExpectedViews map[hourKey]int32
+	}{
+		{
+			Name:  "Counted once within window",
+			Views: []seenKey{{newsId: 1, client: "ip:1"}, {newsId: 1, client: "ip:1"}},
+			ExpectedViews: map[hourKey]int32{
+				{newsId: 1, hour: hour}: 1,
+			},
+		},
+		{
+			Name:  "Counted per client and news",
+			Views: []seenKey{{newsId: 1, client: "ip:1"}, {newsId: 1, client: "user:1"}, {newsId: 2, client: "ip:1"}},
+			ExpectedViews: map[hourKey]int32{
+				{newsId: 1, hour: hour}: 2,
+				{newsId: 2, hour: hour}: 1,
+			},
+		},
+		{
+			Name:    "Counted again after window",
+			Views:   []seenKey{{newsId: 1, client: "ip:1"}, {newsId: 1, client: "ip:1"}},
+			Elapsed: 31 * time.Minute,
+			ExpectedViews: map[hourKey]int32{
+				{newsId: 1, hour: hour}:                1,
+				{newsId: 1, hour: hour.Add(time.Hour)}: 1,
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.Name, func(t *testing.T) {
+			repo := &ViewRepoMock{Views: map[hourKey]int32{}}
+			recorder := NewRecorder(repo, 30*time.Minute)
+
+			clock := now
+			recorder.now = func() time.Time { return clock }
+			for _, view := range testCase.Views {
+				recorder.Record(view.newsId, view.client)
+				clock = clock.Add(testCase.Elapsed)
+			}
+
+			err := recorder.Flush(context.Background())
+			assert.Equal(t, err, nil)
+			assert.Equal(t, repo.Views, testCase.ExpectedViews)
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	repo := &ViewRepoMock{Views: map[hourKey]int32{}, Err: errors.New("connection refused")}
+	recorder := NewRecorder(repo, time.Minute)
+
+	recorder.Record(1, "ip:1")
+	err := recorder.Flush(context.Background())
+	assert.NotEqual(t, err, nil)
+
+	// the views of the failed flush go with the next one
+	repo.Err = nil
+	recorder.Record(1, "ip:2")
+	err = recorder.Flush(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(repo.Views), 1)
+	for _, views := range repo.Views {
+		assert.Equal(t, views, int32(2))
+	}
+
+	// nothing to flush
+	err = recorder.Flush(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, repo.Flushes, 2)
+}
+
+func TestRun(t *testing.T) {
+	repo := &ViewRepoMock{Views: map[hourKey]int32{}, Flushed: make(chan struct{}, 2)}
+	recorder := NewRecorder(repo, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		recorder.Run(ctx, time.Hour)
+		close(done)
+	}()
+
+	// a full buffer is flushed without waiting for the interval
+	for i := range maxPending {
+		recorder.Record(int32(i), "ip:1")
+	}
+	select {
+	case <-repo.Flushed:
+	case <-time.After(time.Second):
+		t.Fatal("full buffer wasn't flushed")
+	}
+	assert.Equal(t, repo.BatchSize, maxPending)
+
+	// and what's left is flushed on the way out
+	recorder.Record(1, "ip:2")
+	cancel()
+	<-done
+	assert.Equal(t, repo.BatchSize, 1)
+}
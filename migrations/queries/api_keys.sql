@@ -0,0 +1,42 @@
+-- name: AddApiKey :one
+INSERT INTO api_keys (
+  name,
+  prefix,
+  secret_hash,
+  scopes,
+  created_by,
+  created_at,
+  expires_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  NOW(),
+  $6
+)
+RETURNING *;
+
+-- name: GetApiKeyByPrefix :one
+SELECT * FROM api_keys
+WHERE prefix = $1;
+
+-- name: GetAllApiKeys :many
+SELECT * FROM api_keys
+ORDER BY id;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_keys
+SET
+  revoked_at = NOW()
+WHERE
+  id = $1 AND revoked_at IS NULL;
+
+-- name: TouchApiKey :exec
+-- last_used_at is only refreshed once a minute to avoid a write per request
+UPDATE api_keys
+SET
+  last_used_at = NOW()
+WHERE
+  id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
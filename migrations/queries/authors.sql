@@ -0,0 +1,21 @@
+-- name: AddAuthor :one
+INSERT INTO authors (
+  name,
+  email,
+  role,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  NOW()
+)
+RETURNING id;
+
+-- name: GetAuthorById :one
+SELECT * FROM authors
+WHERE id = $1;
+
+-- name: GetAuthorsByIds :many
+SELECT * FROM authors
+WHERE id = ANY(@ids::int[]);
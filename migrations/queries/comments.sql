@@ -0,0 +1,65 @@
+-- name: AddComment :one
+INSERT INTO comments (
+  news_id,
+  parent_id,
+  author_id,
+  content,
+  status,
+  moderation_reason,
+  created_at,
+  updated_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  NOW(),
+  NOW()
+)
+RETURNING *;
+
+-- name: GetCommentById :one
+SELECT * FROM comments
+WHERE id = $1;
+
+-- name: GetNewsComments :many
+SELECT * FROM comments
+WHERE news_id = $1 AND status = 'approved' AND id > @after
+ORDER BY id
+LIMIT @max_comments;
+
+-- name: GetPendingComments :many
+SELECT * FROM comments
+WHERE status = 'pending' AND id > @after
+ORDER BY id
+LIMIT @max_comments;
+
+-- name: UpdateComment :one
+UPDATE comments
+SET
+  content = $2,
+  status = $3,
+  moderation_reason = $4,
+  moderated_by = NULL,
+  moderated_at = NULL,
+  updated_at = NOW()
+WHERE
+  id = $1
+RETURNING *;
+
+-- name: ModerateComment :one
+UPDATE comments
+SET
+  status = $2,
+  moderation_reason = $3,
+  moderated_by = $4,
+  moderated_at = NOW()
+WHERE
+  id = $1
+RETURNING *;
+
+-- name: DeleteComment :exec
+DELETE FROM comments
+WHERE id = $1;
@@ -0,0 +1,21 @@
+-- name: GetFeaturedNews :many
+SELECT * FROM featured_news
+ORDER BY position;
+
+-- name: FeatureNews :exec
+-- featuring news again moves them
+INSERT INTO featured_news (
+  news_id,
+  position,
+  featured_at
+) VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT (news_id) DO UPDATE
+SET position = EXCLUDED.position;
+
+-- name: UnfeatureNews :execrows
+DELETE FROM featured_news
+WHERE news_id = $1;
@@ -0,0 +1,62 @@
+-- name: AddMedia :one
+-- marks the news active so their ETag changes with the media
+WITH touched AS (
+  INSERT INTO news_activity (
+    news_id,
+    updated_at
+  ) VALUES (
+    $1,
+    NOW()
+  )
+  ON CONFLICT (news_id) DO UPDATE
+  SET
+    updated_at = EXCLUDED.updated_at
+)
+INSERT INTO media (
+  news_id,
+  role,
+  content_type,
+  size,
+  width,
+  height,
+  storage_key,
+  variants,
+  uploaded_by,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
+  $8,
+  $9,
+  NOW()
+)
+RETURNING *;
+
+-- name: GetNewsMedia :many
+SELECT * FROM media
+WHERE news_id = $1
+ORDER BY id;
+
+-- name: GetMediaById :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: DeleteMedia :exec
+WITH touched AS (
+  INSERT INTO news_activity (
+    news_id,
+    updated_at
+  )
+  SELECT news_id, NOW() FROM media
+  WHERE media.id = $1
+  ON CONFLICT (news_id) DO UPDATE
+  SET
+    updated_at = EXCLUDED.updated_at
+)
+DELETE FROM media
+WHERE id = $1;
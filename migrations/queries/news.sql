@@ -2,11 +2,21 @@
 INSERT INTO news (
   title,
   content,
+  content_format,
+  blocks,
+  author_id,
+  targeting,
+  api_key_id,
   created_at,
   updated_at
 ) VALUES (
   $1,
   $2,
+  $3,
+  $4,
+  $5,
+  $6,
+  $7,
   NOW(),
   NOW()
 )
@@ -17,6 +27,38 @@ UPDATE news
 SET 
   title = $2,
   content = $3,
+  content_format = $4,
+  blocks = $5,
+  updated_by = $6,
+  targeting = $7,
+  updated_by_api_key_id = $8,
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: PublishNews :exec
+UPDATE news
+SET
+  status = 'published',
+  published_at = NOW(),
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: PinNews :exec
+UPDATE news
+SET
+  pinned_at = NOW(),
+  pinned_until = $2,
+  updated_at = NOW()
+WHERE
+  id = $1;
+
+-- name: UnpinNews :exec
+UPDATE news
+SET
+  pinned_at = NULL,
+  pinned_until = NULL,
   updated_at = NOW()
 WHERE
   id = $1;
@@ -28,7 +70,41 @@ WHERE id = $1;
 -- name: GetAllNews :many
 SELECT * FROM news;
 
+-- name: GetNewsActivity :one
+-- is null for news nothing was served along with yet
+SELECT MAX(updated_at)::timestamp AS updated_at FROM news_activity
+WHERE news_id = $1;
+
 -- name: GetNewsById :one
 SELECT * FROM news
 WHERE id = $1;
 
+-- name: GetNewsByIds :many
+SELECT * FROM news
+WHERE id = ANY(@ids::int[]);
+
+-- name: GetNewsStats :one
+SELECT
+  COUNT(*)::int AS count,
+  -- pins running out change the order of the list as well
+  GREATEST(
+    MAX(updated_at),
+    MAX(pinned_until) FILTER (WHERE pinned_until <= NOW()),
+    -- so do media, comments and reactions, served along with news
+    (SELECT MAX(updated_at) FROM news_activity)
+  )::timestamp AS last_updated_at
+FROM news;
+
+-- name: GetNewsWithoutBlocks :many
+SELECT id, content, content_format FROM news
+WHERE blocks IS NULL AND content_format <> 'html'
+ORDER BY id
+LIMIT $1;
+
+-- name: SetNewsBlocks :exec
+UPDATE news
+SET blocks = $2
+WHERE id = $1 AND blocks IS NULL;
+
+-- name: NotifyNewsChanged :exec
+SELECT pg_notify('news_changed', @payload::text);
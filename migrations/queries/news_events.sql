@@ -0,0 +1,19 @@
+-- name: GetNewsEventsAfter :many
+SELECT * FROM news_events
+WHERE id > @id
+ORDER BY id
+LIMIT @max_events;
+
+-- name: GetNewsChanges :many
+-- the latest event of every news changed in the window after @since
+WITH changes AS (
+  SELECT * FROM news_events
+  WHERE id > @since
+  ORDER BY id
+  LIMIT @max_events
+)
+SELECT DISTINCT ON (news_id) * FROM changes
+ORDER BY news_id, id DESC;
+
+-- name: GetLastNewsEventId :one
+SELECT COALESCE(MAX(id), 0)::bigint FROM news_events;
@@ -0,0 +1,99 @@
+-- name: AddNewsVariant :one
+INSERT INTO news_variants (
+  news_id,
+  title,
+  content,
+  weight,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  NOW()
+)
+RETURNING id;
+
+-- name: DeleteNewsVariant :execrows
+DELETE FROM news_variants
+WHERE id = $1 AND news_id = $2;
+
+-- name: GetNewsVariants :many
+SELECT * FROM news_variants
+WHERE news_id = $1
+ORDER BY id;
+
+-- name: GetRunningVariants :many
+-- variants of the news with a running experiment, in the order traffic is
+-- split in
+SELECT news_variants.* FROM news_variants
+JOIN news_experiments ON news_experiments.news_id = news_variants.news_id
+WHERE news_variants.news_id = ANY(@news_ids::int[]) AND news_experiments.stopped_at IS NULL
+ORDER BY news_variants.news_id, news_variants.id;
+
+-- name: GetNewsExperiment :one
+SELECT * FROM news_experiments
+WHERE news_id = $1;
+
+-- name: StartNewsExperiment :exec
+-- starts over with the counts of the variants at 0, and the readers who
+-- counted them, and touches the news so their ETag changes with the variants
+-- readers get
+WITH reset AS (
+  UPDATE news_variants
+  SET
+    exposures = 0,
+    clicks = 0
+  WHERE news_id = $1
+), forgotten AS (
+  DELETE FROM news_variant_readers
+  WHERE variant_id IN (SELECT id FROM news_variants WHERE news_id = $1)
+), touched AS (
+  UPDATE news
+  SET
+    updated_at = NOW()
+  WHERE id = $1
+)
+INSERT INTO news_experiments (
+  news_id,
+  started_at
+) VALUES (
+  $1,
+  NOW()
+)
+ON CONFLICT (news_id) DO UPDATE
+SET
+  started_at = NOW(),
+  stopped_at = NULL,
+  winner_id = NULL;
+
+-- name: StopNewsExperiment :execrows
+UPDATE news_experiments
+SET
+  stopped_at = NOW(),
+  winner_id = $2
+WHERE news_id = $1 AND stopped_at IS NULL;
+
+-- name: CountNewsVariant :execrows
+-- counts only variants of a running experiment, and every reader once
+WITH counted AS (
+  INSERT INTO news_variant_readers (
+    variant_id,
+    author_id,
+    kind
+  )
+  SELECT news_variants.id, @author_id::int, @kind::text
+  FROM news_variants
+  JOIN news_experiments ON news_experiments.news_id = news_variants.news_id
+  WHERE
+    news_variants.id = @id
+    AND news_variants.news_id = @news_id
+    AND news_experiments.stopped_at IS NULL
+  ON CONFLICT DO NOTHING
+  RETURNING variant_id
+)
+UPDATE news_variants
+SET
+  exposures = exposures + CASE WHEN @kind = 'exposure' THEN 1 ELSE 0 END,
+  clicks = clicks + CASE WHEN @kind = 'click' THEN 1 ELSE 0 END
+WHERE id IN (SELECT variant_id FROM counted);
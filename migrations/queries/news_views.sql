@@ -0,0 +1,30 @@
+-- name: AddNewsViews :exec
+-- adds a batch of hourly counts, counts of news deleted since their views
+-- were recorded are dropped
+INSERT INTO news_views AS v (
+  news_id,
+  hour,
+  views
+)
+SELECT b.news_id, b.hour, b.views
+FROM unnest(@news_ids::int[], @hours::timestamp[], @views::int[]) AS b (news_id, hour, views)
+WHERE EXISTS (SELECT 1 FROM news WHERE news.id = b.news_id)
+ON CONFLICT (news_id, hour) DO UPDATE
+SET
+  views = v.views + EXCLUDED.views;
+
+-- name: GetPopularNews :many
+-- ranks published news by views since @since, each hour of views weighing
+-- half as much as the one @half_life seconds later
+SELECT news.* FROM news
+JOIN (
+  SELECT
+    news_id,
+    SUM(views * POWER(0.5, EXTRACT(EPOCH FROM (@now::timestamp - hour)) / @half_life::float8)) AS score
+  FROM news_views
+  WHERE hour >= @since::timestamp
+  GROUP BY news_id
+) scores ON scores.news_id = news.id
+WHERE news.status = 'published'
+ORDER BY scores.score DESC, news.id DESC
+LIMIT @max_news;
@@ -0,0 +1,34 @@
+-- name: TakeRateLimitToken :one
+-- refills the bucket for the time passed since the last request and takes a
+-- token if there is one, in a single statement so replicas can't race
+INSERT INTO rate_limits AS r (
+  key,
+  tokens,
+  allowed,
+  updated_at,
+  full_at
+) VALUES (
+  @key,
+  @burst::float8 - 1,
+  TRUE,
+  @now,
+  @now + make_interval(secs => @burst::float8 / @rate::float8)
+)
+ON CONFLICT (key) DO UPDATE
+SET
+  allowed = LEAST(@burst::float8, r.tokens + GREATEST(EXTRACT(EPOCH FROM (@now - r.updated_at)), 0) * @rate::float8) >= 1,
+  tokens = LEAST(@burst::float8, r.tokens + GREATEST(EXTRACT(EPOCH FROM (@now - r.updated_at)), 0) * @rate::float8)
+    - CASE
+      WHEN LEAST(@burst::float8, r.tokens + GREATEST(EXTRACT(EPOCH FROM (@now - r.updated_at)), 0) * @rate::float8) >= 1 THEN 1
+      ELSE 0
+    END,
+  updated_at = GREATEST(r.updated_at, @now),
+  -- an empty bucket takes burst / rate seconds to refill
+  full_at = GREATEST(r.updated_at, @now) + make_interval(secs => @burst::float8 / @rate::float8)
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleRateLimits :exec
+-- drops buckets that have refilled completely, they are indistinguishable
+-- from buckets that were never used
+DELETE FROM rate_limits
+WHERE full_at <= @now;
@@ -0,0 +1,70 @@
+-- name: AddReaction :execrows
+-- counts the reaction in the same statement, so in the same transaction,
+-- and marks the news active so their ETag changes with the counts.
+-- Reactions the author already has are left as they are.
+WITH added AS (
+  INSERT INTO reactions (
+    news_id,
+    author_id,
+    kind,
+    created_at
+  ) VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+  )
+  ON CONFLICT DO NOTHING
+  RETURNING news_id, kind
+), touched AS (
+  INSERT INTO news_activity (
+    news_id,
+    updated_at
+  )
+  SELECT news_id, NOW() FROM added
+  ON CONFLICT (news_id) DO UPDATE
+  SET
+    updated_at = EXCLUDED.updated_at
+)
+INSERT INTO reaction_counts (
+  news_id,
+  kind,
+  count
+)
+SELECT news_id, kind, 1 FROM added
+ON CONFLICT (news_id, kind) DO UPDATE
+SET
+  count = reaction_counts.count + 1;
+
+-- name: RemoveReaction :execrows
+-- uncounts the reaction the way AddReaction counts it
+WITH removed AS (
+  DELETE FROM reactions
+  WHERE news_id = $1 AND author_id = $2 AND kind = $3
+  RETURNING news_id, kind
+), touched AS (
+  INSERT INTO news_activity (
+    news_id,
+    updated_at
+  )
+  SELECT news_id, NOW() FROM removed
+  ON CONFLICT (news_id) DO UPDATE
+  SET
+    updated_at = EXCLUDED.updated_at
+)
+UPDATE reaction_counts
+SET
+  count = reaction_counts.count - 1
+FROM removed
+WHERE
+  reaction_counts.news_id = removed.news_id AND reaction_counts.kind = removed.kind;
+
+-- name: GetReactionCounts :many
+SELECT * FROM reaction_counts
+WHERE news_id = ANY(@news_ids::int[]) AND count > 0
+ORDER BY news_id, kind;
+
+-- name: GetAuthorReactions :many
+SELECT * FROM reactions
+WHERE author_id = @author_id AND news_id = ANY(@news_ids::int[])
+ORDER BY news_id, kind;
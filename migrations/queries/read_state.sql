@@ -0,0 +1,131 @@
+-- name: MarkNewsRead :exec
+-- only reads of news published after the watermark are kept, the ones
+-- before it are read already
+WITH marker AS (
+  INSERT INTO read_markers (
+    author_id,
+    updated_at
+  ) VALUES (
+    @author_id,
+    NOW()
+  )
+  ON CONFLICT (author_id) DO UPDATE
+  SET
+    updated_at = NOW()
+  RETURNING read_up_to
+)
+INSERT INTO news_reads (
+  author_id,
+  news_id
+)
+SELECT @author_id, news.id FROM news, marker
+WHERE
+  news.id = @news_id
+  AND news.status = 'published'
+  AND news.published_at > COALESCE(marker.read_up_to, '-infinity'::timestamp)
+ON CONFLICT DO NOTHING;
+
+-- name: MarkAllNewsRead :exec
+-- moves the watermark to now, by the clock news are published by, and
+-- drops the reads it covers
+WITH marker AS (
+  INSERT INTO read_markers (
+    author_id,
+    read_up_to,
+    updated_at
+  ) VALUES (
+    @author_id,
+    NOW(),
+    NOW()
+  )
+  ON CONFLICT (author_id) DO UPDATE
+  SET
+    read_up_to = GREATEST(read_markers.read_up_to, EXCLUDED.read_up_to),
+    updated_at = NOW()
+  RETURNING read_up_to
+)
+DELETE FROM news_reads
+USING news, marker
+WHERE
+  news_reads.author_id = @author_id
+  AND news.id = news_reads.news_id
+  AND news.published_at <= marker.read_up_to;
+
+-- name: GetUnreadNewsIds :many
+-- are published news the reader hasn't read, whether the reader is shown
+-- them is up to the service
+SELECT news.id FROM news
+WHERE
+  news.status = 'published'
+  AND news.published_at > COALESCE(
+    (SELECT read_up_to FROM read_markers WHERE author_id = @author_id),
+    '-infinity'::timestamp
+  )
+  AND NOT EXISTS (
+    SELECT 1 FROM news_reads
+    WHERE news_reads.author_id = @author_id AND news_reads.news_id = news.id
+  )
+ORDER BY news.id;
+
+-- name: GetReadStateUpdatedAt :one
+SELECT updated_at FROM read_markers
+WHERE author_id = $1;
+
+-- name: GetNewsReadState :many
+SELECT
+  news.id AS news_id,
+  COALESCE(news.published_at <= read_markers.read_up_to, FALSE)
+    OR EXISTS (
+      SELECT 1 FROM news_reads
+      WHERE news_reads.author_id = @author_id AND news_reads.news_id = news.id
+    ) AS is_read,
+  EXISTS (
+    SELECT 1 FROM bookmarks
+    WHERE bookmarks.author_id = @author_id AND bookmarks.news_id = news.id
+  ) AS is_bookmarked
+FROM news
+LEFT JOIN read_markers ON read_markers.author_id = @author_id
+WHERE news.id = ANY(@news_ids::int[])
+ORDER BY news.id;
+
+-- name: AddBookmark :execrows
+WITH touched AS (
+  INSERT INTO read_markers (
+    author_id,
+    updated_at
+  ) VALUES (
+    $1,
+    NOW()
+  )
+  ON CONFLICT (author_id) DO UPDATE
+  SET
+    updated_at = NOW()
+)
+INSERT INTO bookmarks (
+  author_id,
+  news_id,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveBookmark :execrows
+WITH touched AS (
+  UPDATE read_markers
+  SET
+    updated_at = NOW()
+  WHERE author_id = $1
+)
+DELETE FROM bookmarks
+WHERE author_id = $1 AND news_id = $2;
+
+-- name: GetBookmarkedNews :many
+-- newest news first, @before is the id of the last news of the previous page
+SELECT news.* FROM news
+JOIN bookmarks ON bookmarks.news_id = news.id
+WHERE bookmarks.author_id = @author_id AND (@before::int = 0 OR news.id < @before)
+ORDER BY news.id DESC
+LIMIT @max_news;
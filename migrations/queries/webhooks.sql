@@ -0,0 +1,115 @@
+-- name: AddOutboxEvent :exec
+INSERT INTO outbox (
+  type,
+  payload,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  NOW()
+);
+
+-- name: FanOutOutbox :execrows
+-- turns pending outbox events into a delivery per subscribed webhook, rows
+-- locked by another replica are left for it. Deliveries are due at the now
+-- of the dispatcher, which is what they're claimed against
+WITH events AS (
+  UPDATE outbox
+  SET
+    dispatched_at = NOW()
+  WHERE id IN (
+    SELECT id FROM outbox
+    WHERE dispatched_at IS NULL
+    ORDER BY id
+    LIMIT @max_events
+    FOR UPDATE SKIP LOCKED
+  )
+  RETURNING id, type
+)
+INSERT INTO webhook_deliveries (
+  webhook_id,
+  outbox_id,
+  status,
+  attempts,
+  next_attempt_at,
+  created_at
+)
+SELECT w.id, e.id, 'pending', 0, @now::timestamp, NOW()
+FROM events e
+JOIN webhooks w ON e.type = ANY(w.events);
+
+-- name: ClaimWebhookDeliveries :many
+-- pushes next_attempt_at past the lease so other replicas don't pick the
+-- same deliveries, a dispatcher dying mid delivery retries after it
+UPDATE webhook_deliveries d
+SET
+  next_attempt_at = @lease_until
+FROM webhooks w, outbox o
+WHERE d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= @now
+    ORDER BY next_attempt_at
+    LIMIT @max_deliveries
+    FOR UPDATE SKIP LOCKED
+  )
+  AND w.id = d.webhook_id
+  AND o.id = d.outbox_id
+RETURNING d.id, d.attempts, w.url, w.secret, o.id AS event_id, o.type, o.payload;
+
+-- name: MarkWebhookDeliveryDelivered :exec
+UPDATE webhook_deliveries
+SET
+  status = 'delivered',
+  attempts = attempts + 1,
+  response_status = @response_status,
+  last_error = NULL,
+  delivered_at = @delivered_at
+WHERE
+  id = @id;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET
+  status = @status,
+  attempts = attempts + 1,
+  response_status = @response_status,
+  last_error = @last_error,
+  next_attempt_at = @next_attempt_at
+WHERE
+  id = @id;
+
+-- name: AddWebhook :one
+INSERT INTO webhooks (
+  url,
+  secret,
+  events,
+  created_by,
+  created_at
+) VALUES (
+  $1,
+  $2,
+  $3,
+  $4,
+  NOW()
+)
+RETURNING *;
+
+-- name: GetAllWebhooks :many
+SELECT * FROM webhooks
+ORDER BY id;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2;
+
+-- name: ReplayWebhookDelivery :execrows
+UPDATE webhook_deliveries
+SET
+  status = 'pending',
+  attempts = 0,
+  last_error = NULL,
+  next_attempt_at = @now
+WHERE
+  id = @id AND webhook_id = @webhook_id;
@@ -0,0 +1,5 @@
+ALTER TABLE news
+  DROP COLUMN updated_by,
+  DROP COLUMN author_id;
+
+DROP TABLE authors;
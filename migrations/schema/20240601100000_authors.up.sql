@@ -0,0 +1,10 @@
+CREATE TABLE authors (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) UNIQUE,
+  created_at TIMESTAMP
+);
+
+ALTER TABLE news
+  ADD COLUMN author_id INTEGER REFERENCES authors (id),
+  ADD COLUMN updated_by INTEGER REFERENCES authors (id);
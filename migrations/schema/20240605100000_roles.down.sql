@@ -0,0 +1,6 @@
+ALTER TABLE news
+  DROP COLUMN published_at,
+  DROP COLUMN status;
+
+ALTER TABLE authors
+  DROP COLUMN role;
@@ -0,0 +1,9 @@
+ALTER TABLE authors
+  ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'author';
+
+ALTER TABLE news
+  ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'draft',
+  ADD COLUMN published_at TIMESTAMP;
+
+-- news created before roles existed were already public
+UPDATE news SET status = 'published', published_at = updated_at;
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  prefix VARCHAR(16) NOT NULL UNIQUE,
+  secret_hash BYTEA NOT NULL,
+  scopes TEXT[] NOT NULL,
+  created_by INTEGER NOT NULL REFERENCES authors (id),
+  created_at TIMESTAMP NOT NULL,
+  expires_at TIMESTAMP,
+  last_used_at TIMESTAMP,
+  revoked_at TIMESTAMP
+);
@@ -0,0 +1,6 @@
+CREATE TABLE rate_limits (
+  key VARCHAR(255) PRIMARY KEY,
+  tokens DOUBLE PRECISION NOT NULL,
+  allowed BOOLEAN NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
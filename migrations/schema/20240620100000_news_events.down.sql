@@ -0,0 +1,5 @@
+DROP TRIGGER news_events ON news;
+
+DROP FUNCTION record_news_event();
+
+DROP TABLE news_events;
@@ -0,0 +1,47 @@
+CREATE TABLE news_events (
+  id BIGSERIAL PRIMARY KEY,
+  news_id INT NOT NULL,
+  type VARCHAR(16) NOT NULL,
+  news JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL
+);
+
+-- events are written by the database itself so every change to news ends up
+-- in the log, in the same transaction as the change
+CREATE FUNCTION record_news_event() RETURNS TRIGGER AS $$
+DECLARE
+  event_type VARCHAR(16);
+  event_news news;
+  event_id BIGINT;
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    event_type := 'created';
+    event_news := NEW;
+  ELSIF TG_OP = 'DELETE' THEN
+    event_type := 'deleted';
+    event_news := OLD;
+  ELSIF NEW.status = 'published' AND OLD.status <> 'published' THEN
+    event_type := 'published';
+    event_news := NEW;
+  ELSE
+    event_type := 'updated';
+    event_news := NEW;
+  END IF;
+
+  -- serialises writers until commit, so ids become visible in order and
+  -- subscribers resuming after an id can't skip one committed later
+  PERFORM pg_advisory_xact_lock(hashtext('news_events'));
+
+  INSERT INTO news_events (news_id, type, news, created_at)
+  VALUES (event_news.id, event_type, to_jsonb(event_news), NOW())
+  RETURNING id INTO event_id;
+
+  PERFORM pg_notify('news_events', event_id::text);
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER news_events
+AFTER INSERT OR UPDATE OR DELETE ON news
+FOR EACH ROW EXECUTE FUNCTION record_news_event();
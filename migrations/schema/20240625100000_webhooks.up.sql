@@ -0,0 +1,34 @@
+CREATE TABLE outbox (
+  id BIGSERIAL PRIMARY KEY,
+  type VARCHAR(32) NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  dispatched_at TIMESTAMP
+);
+
+CREATE INDEX outbox_pending ON outbox (id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE webhooks (
+  id SERIAL PRIMARY KEY,
+  url TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  events TEXT[] NOT NULL,
+  created_by INTEGER NOT NULL REFERENCES authors (id),
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+  outbox_id BIGINT NOT NULL REFERENCES outbox (id),
+  status VARCHAR(16) NOT NULL,
+  attempts INTEGER NOT NULL,
+  next_attempt_at TIMESTAMP NOT NULL,
+  last_error TEXT,
+  response_status INTEGER,
+  created_at TIMESTAMP NOT NULL,
+  delivered_at TIMESTAMP
+);
+
+CREATE INDEX webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
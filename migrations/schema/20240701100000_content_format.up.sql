@@ -0,0 +1,4 @@
+-- how content is written, markdown is rendered to html when it's read and
+-- html is sanitised before it's stored
+ALTER TABLE news
+  ADD COLUMN content_format VARCHAR(16) NOT NULL DEFAULT 'plain';
@@ -0,0 +1,5 @@
+-- content as a document of blocks, see internal/pkg/blocks. Content is kept
+-- in step for clients that don't read blocks, news written before blocks
+-- get them from cmd/blocks.
+ALTER TABLE news
+  ADD COLUMN blocks JSONB;
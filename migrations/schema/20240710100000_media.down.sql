@@ -0,0 +1,3 @@
+DROP TABLE news_activity;
+
+DROP TABLE media;
@@ -0,0 +1,24 @@
+-- files of deleted news are left in storage, rows only keep track of them
+CREATE TABLE media (
+  id SERIAL PRIMARY KEY,
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  role VARCHAR(16) NOT NULL,
+  content_type VARCHAR(64) NOT NULL,
+  size BIGINT NOT NULL,
+  width INTEGER,
+  height INTEGER,
+  storage_key TEXT NOT NULL,
+  variants JSONB NOT NULL,
+  uploaded_by INTEGER NOT NULL REFERENCES authors (id),
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX media_news ON media (news_id, id);
+CREATE UNIQUE INDEX media_cover ON media (news_id) WHERE role = 'cover';
+
+-- when what's served along with news last changed, kept apart from news so
+-- uploads don't make news events. ETags of news go by both.
+CREATE TABLE news_activity (
+  news_id INTEGER PRIMARY KEY REFERENCES news (id) ON DELETE CASCADE,
+  updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,16 @@
+DROP TRIGGER news_events_updated ON news;
+
+DROP TRIGGER news_events ON news;
+
+CREATE TRIGGER news_events
+AFTER INSERT OR UPDATE OR DELETE ON news
+FOR EACH ROW EXECUTE FUNCTION record_news_event();
+
+DROP TRIGGER count_news_comments ON comments;
+
+DROP FUNCTION count_news_comments();
+
+ALTER TABLE news
+  DROP COLUMN comments_count;
+
+DROP TABLE comments;
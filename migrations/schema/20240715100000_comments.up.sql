@@ -0,0 +1,77 @@
+-- comments are pending until a moderator approves them, replies go along
+-- with the comments they reply to
+CREATE TABLE comments (
+  id SERIAL PRIMARY KEY,
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  parent_id INTEGER REFERENCES comments (id) ON DELETE CASCADE,
+  author_id INTEGER NOT NULL REFERENCES authors (id),
+  content TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL,
+  moderation_reason TEXT,
+  moderated_by INTEGER REFERENCES authors (id),
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  moderated_at TIMESTAMP
+);
+
+CREATE INDEX comments_approved ON comments (news_id, id) WHERE status = 'approved';
+CREATE INDEX comments_pending ON comments (id) WHERE status = 'pending';
+
+ALTER TABLE news
+  ADD COLUMN comments_count INTEGER NOT NULL DEFAULT 0;
+
+-- keeps the count of approved comments on news, cascaded deletes of replies
+-- included, and marks the news active so their ETag changes with it
+CREATE FUNCTION count_news_comments() RETURNS TRIGGER AS $$
+DECLARE
+  delta INTEGER := 0;
+  comment_news_id INTEGER;
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    comment_news_id := OLD.news_id;
+    IF OLD.status = 'approved' THEN
+      delta := delta - 1;
+    END IF;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    comment_news_id := NEW.news_id;
+    IF NEW.status = 'approved' THEN
+      delta := delta + 1;
+    END IF;
+  END IF;
+
+  IF delta <> 0 THEN
+    UPDATE news
+    SET
+      comments_count = comments_count + delta
+    WHERE id = comment_news_id;
+
+    INSERT INTO news_activity (news_id, updated_at)
+    VALUES (comment_news_id, NOW())
+    ON CONFLICT (news_id) DO UPDATE
+    SET
+      updated_at = EXCLUDED.updated_at;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER count_news_comments
+AFTER INSERT OR UPDATE OR DELETE ON comments
+FOR EACH ROW EXECUTE FUNCTION count_news_comments();
+
+-- counting comments isn't a change of news, it doesn't make news events. A
+-- WHEN clause can't look at rows of inserts and deletes, so updates get a
+-- trigger of their own.
+DROP TRIGGER news_events ON news;
+
+CREATE TRIGGER news_events
+AFTER INSERT OR DELETE ON news
+FOR EACH ROW EXECUTE FUNCTION record_news_event();
+
+CREATE TRIGGER news_events_updated
+AFTER UPDATE ON news
+FOR EACH ROW
+WHEN ((to_jsonb(OLD) - 'comments_count') IS DISTINCT FROM (to_jsonb(NEW) - 'comments_count'))
+EXECUTE FUNCTION record_news_event();
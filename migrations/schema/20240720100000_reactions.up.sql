@@ -0,0 +1,18 @@
+-- a reaction of each kind per author, counted in reaction_counts along with
+-- the writes so reads don't count rows
+CREATE TABLE reactions (
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+  kind VARCHAR(16) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (news_id, author_id, kind)
+);
+
+CREATE INDEX reactions_author ON reactions (author_id, news_id);
+
+CREATE TABLE reaction_counts (
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  kind VARCHAR(16) NOT NULL,
+  count INTEGER NOT NULL CHECK (count >= 0),
+  PRIMARY KEY (news_id, kind)
+);
@@ -0,0 +1,10 @@
+-- views counted per news and hour, the app buffers views and adds them up
+-- here in batches
+CREATE TABLE news_views (
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  hour TIMESTAMP NOT NULL,
+  views INTEGER NOT NULL CHECK (views > 0),
+  PRIMARY KEY (news_id, hour)
+);
+
+CREATE INDEX news_views_hour ON news_views (hour);
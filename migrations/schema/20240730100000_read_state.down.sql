@@ -0,0 +1,4 @@
+DROP INDEX news_published_at;
+DROP TABLE bookmarks;
+DROP TABLE news_reads;
+DROP TABLE read_markers;
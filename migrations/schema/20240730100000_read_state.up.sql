@@ -0,0 +1,26 @@
+-- a reader has read published news up to read_up_to, news published since
+-- are unread unless they are in news_reads. Marking all read moves the
+-- watermark and drops the reads below it, so most readers take a single
+-- row. updated_at changes with anything the reader reads or bookmarks, it
+-- versions what responses to the reader carry.
+CREATE TABLE read_markers (
+  author_id INTEGER PRIMARY KEY REFERENCES authors (id) ON DELETE CASCADE,
+  read_up_to TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE news_reads (
+  author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  PRIMARY KEY (author_id, news_id)
+);
+
+CREATE TABLE bookmarks (
+  author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  PRIMARY KEY (author_id, news_id)
+);
+
+-- unread counts only look at news published after the watermark
+CREATE INDEX news_published_at ON news (published_at) WHERE status = 'published';
@@ -0,0 +1,2 @@
+ALTER TABLE news
+  DROP COLUMN targeting;
@@ -0,0 +1,4 @@
+-- who news are shown to, see internal/pkg/targeting. News without a rule
+-- are shown to everyone.
+ALTER TABLE news
+  ADD COLUMN targeting TEXT;
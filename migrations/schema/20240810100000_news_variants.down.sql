@@ -0,0 +1,2 @@
+DROP TABLE news_experiments;
+DROP TABLE news_variants;
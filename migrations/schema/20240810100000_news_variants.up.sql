@@ -0,0 +1,24 @@
+-- alternate titles and content of news for headline experiments. While the
+-- experiment of the news runs readers are split between its variants by
+-- weight, clients count what they showed and what was opened.
+CREATE TABLE news_variants (
+  id SERIAL PRIMARY KEY,
+  news_id INTEGER NOT NULL REFERENCES news (id) ON DELETE CASCADE,
+  title TEXT NOT NULL,
+  content TEXT NOT NULL,
+  weight INTEGER NOT NULL CHECK (weight > 0),
+  exposures INTEGER NOT NULL DEFAULT 0,
+  clicks INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX news_variants_news_id ON news_variants (news_id);
+
+-- an experiment runs until stopped_at, winner_id is the variant promoted
+-- into the news when it stopped
+CREATE TABLE news_experiments (
+  news_id INTEGER PRIMARY KEY REFERENCES news (id) ON DELETE CASCADE,
+  started_at TIMESTAMP NOT NULL,
+  stopped_at TIMESTAMP,
+  winner_id INTEGER REFERENCES news_variants (id) ON DELETE SET NULL
+);
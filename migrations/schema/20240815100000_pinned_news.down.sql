@@ -0,0 +1,5 @@
+DROP TABLE featured_news;
+
+ALTER TABLE news
+  DROP COLUMN pinned_until,
+  DROP COLUMN pinned_at;
@@ -0,0 +1,13 @@
+-- pinned news are listed before the others until pinned_until, or until
+-- they're unpinned when it's NULL
+ALTER TABLE news
+  ADD COLUMN pinned_at TIMESTAMP,
+  ADD COLUMN pinned_until TIMESTAMP;
+
+-- featured news are ordered by position, keys of internal/pkg/rank compared
+-- byte by byte, so that moving news writes their own row only
+CREATE TABLE featured_news (
+  news_id INTEGER PRIMARY KEY REFERENCES news (id) ON DELETE CASCADE,
+  position TEXT COLLATE "C" NOT NULL UNIQUE,
+  featured_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,10 @@
+-- full_at is when the bucket has refilled completely at the latest, from
+-- then on the row is the same as no row and can be deleted
+ALTER TABLE rate_limits ADD COLUMN full_at TIMESTAMP;
+
+-- an hour is the longest period of the limits
+UPDATE rate_limits SET full_at = updated_at + INTERVAL '1 hour';
+
+ALTER TABLE rate_limits ALTER COLUMN full_at SET NOT NULL;
+
+CREATE INDEX rate_limits_full_at ON rate_limits (full_at);
@@ -0,0 +1 @@
+DROP TABLE news_variant_readers;
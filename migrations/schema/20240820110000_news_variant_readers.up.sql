@@ -0,0 +1,8 @@
+-- readers who counted a variant, each reader counts an exposure and a click
+-- of the variant they're assigned once per experiment
+CREATE TABLE news_variant_readers (
+  variant_id INTEGER NOT NULL REFERENCES news_variants (id) ON DELETE CASCADE,
+  author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+  kind VARCHAR(16) NOT NULL CHECK (kind IN ('exposure', 'click')),
+  PRIMARY KEY (variant_id, author_id, kind)
+);
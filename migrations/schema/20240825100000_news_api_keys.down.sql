@@ -0,0 +1,3 @@
+ALTER TABLE news
+  DROP COLUMN updated_by_api_key_id,
+  DROP COLUMN api_key_id;
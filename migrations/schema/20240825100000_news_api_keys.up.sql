@@ -0,0 +1,5 @@
+-- news written with an api key record the key, author_id and updated_by
+-- are the admin who created it
+ALTER TABLE news
+  ADD COLUMN api_key_id INTEGER REFERENCES api_keys (id) ON DELETE SET NULL,
+  ADD COLUMN updated_by_api_key_id INTEGER REFERENCES api_keys (id) ON DELETE SET NULL;